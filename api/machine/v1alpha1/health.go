@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HealthCheckType is the kind of probe used to determine the health of a
+// machine.
+type HealthCheckType string
+
+const (
+	// HealthCheckTCP probes the machine by connecting to a TCP port.
+	HealthCheckTCP = HealthCheckType("tcp")
+
+	// HealthCheckHTTP probes the machine by performing an HTTP GET request and
+	// expects a 2xx or 3xx status code.
+	HealthCheckHTTP = HealthCheckType("http")
+
+	// HealthCheckLog probes the machine by matching a regular expression
+	// against its console log.
+	HealthCheckLog = HealthCheckType("log")
+)
+
+// Defaults of health checks which are applied to unset values.
+const (
+	DefaultHealthCheckInterval = 30 * time.Second
+	DefaultHealthCheckTimeout  = 30 * time.Second
+	DefaultHealthCheckRetries  = 3
+)
+
+// MachineHealthCheck describes how the health of a machine is determined.
+type MachineHealthCheck struct {
+	// Type of the probe.
+	Type HealthCheckType `json:"type"`
+
+	// Port of the machine which is probed by TCP and HTTP health checks.  If the
+	// port is published, the probe is made against the host; otherwise, the
+	// probe is made against the machine's IP address.
+	Port int32 `json:"port,omitempty"`
+
+	// Path which is requested by HTTP health checks.
+	Path string `json:"path,omitempty"`
+
+	// Pattern is the regular expression which is matched against the console
+	// log by log health checks.
+	Pattern string `json:"pattern,omitempty"`
+
+	// Interval between two consecutive probes.
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// Timeout of a single probe.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Retries is the number of consecutive failed probes after which the
+	// machine is considered unhealthy.
+	Retries int `json:"retries,omitempty"`
+
+	// StartPeriod is the duration after the machine has started during which
+	// failed probes are not counted towards the number of retries.
+	StartPeriod time.Duration `json:"startPeriod,omitempty"`
+}
+
+// ParseHealthCheckTarget parses the string representation of the target of a
+// health check of the provided type, i.e. "<port>" for TCP, "<port>[/path]"
+// for HTTP and a regular expression for log health checks.
+func ParseHealthCheckTarget(typ HealthCheckType, target string) (*MachineHealthCheck, error) {
+	check := MachineHealthCheck{
+		Type: typ,
+	}
+
+	switch typ {
+	case HealthCheckTCP, HealthCheckHTTP:
+		port, path, hasPath := strings.Cut(target, "/")
+		if hasPath && typ == HealthCheckTCP {
+			return nil, fmt.Errorf("tcp health check does not accept a path: %s", target)
+		}
+
+		p, err := strconv.ParseInt(port, 10, 32)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid health check port: %s", port)
+		}
+
+		check.Port = int32(p)
+
+		if typ == HealthCheckHTTP {
+			check.Path = "/" + path
+		}
+
+	case HealthCheckLog:
+		if _, err := regexp.Compile(target); err != nil {
+			return nil, fmt.Errorf("invalid health check pattern: %w", err)
+		}
+
+		check.Pattern = target
+
+	default:
+		return nil, fmt.Errorf("unknown health check type: %s", typ)
+	}
+
+	return &check, nil
+}
+
+// WithDefaults returns the health check with unset values populated with their
+// defaults.
+func (check MachineHealthCheck) WithDefaults() MachineHealthCheck {
+	if check.Interval <= 0 {
+		check.Interval = DefaultHealthCheckInterval
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultHealthCheckTimeout
+	}
+	if check.Retries <= 0 {
+		check.Retries = DefaultHealthCheckRetries
+	}
+
+	return check
+}
+
+// String implements fmt.Stringer and outputs the health check in
+// human-readable format.
+func (check MachineHealthCheck) String() string {
+	switch check.Type {
+	case HealthCheckTCP:
+		return fmt.Sprintf("tcp:%d", check.Port)
+	case HealthCheckHTTP:
+		return fmt.Sprintf("http:%d%s", check.Port, check.Path)
+	case HealthCheckLog:
+		return fmt.Sprintf("log:%s", check.Pattern)
+	}
+
+	return string(check.Type)
+}
+
+// HealthStatus is the health of a machine as determined by its health check.
+type HealthStatus string
+
+const (
+	// HealthStatusNone indicates that the machine has no health check.
+	HealthStatusNone = HealthStatus("")
+
+	// HealthStatusStarting indicates that the machine has not yet passed its
+	// health check since it was started.
+	HealthStatusStarting = HealthStatus("starting")
+
+	// HealthStatusHealthy indicates that the last probe of the machine passed.
+	HealthStatusHealthy = HealthStatus("healthy")
+
+	// HealthStatusUnhealthy indicates that the machine has failed its health
+	// check the configured number of consecutive times.
+	HealthStatusUnhealthy = HealthStatus("unhealthy")
+)
+
+// String implements fmt.Stringer
+func (status HealthStatus) String() string {
+	return string(status)
+}
+
+// MachineHealth is the most recently observed health of a machine.
+type MachineHealth struct {
+	// Status of the machine's health.
+	Status HealthStatus `json:"status,omitempty"`
+
+	// FailingStreak is the number of consecutive failed probes.
+	FailingStreak int `json:"failingStreak,omitempty"`
+
+	// LastProbe is the time of the most recent probe.
+	LastProbe time.Time `json:"lastProbe,omitempty"`
+
+	// LastError is the error of the most recent failed probe.
+	LastError string `json:"lastError,omitempty"`
+}
@@ -93,6 +93,13 @@ type MachineSpec struct {
 
 	// Emulation indicates whether to use VMM emulation.
 	Emulation bool `json:"emulation,omitempty"`
+
+	// RestartPolicy determines whether the machine is restarted by its
+	// supervisor once it has exited.
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+
+	// HealthCheck determines how the health of the machine is probed.
+	HealthCheck *MachineHealthCheck `json:"healthCheck,omitempty"`
 }
 
 // MachineState indicates the state of the machine.
@@ -144,6 +151,26 @@ type MachineStatus struct {
 	// LogFile is the in-host path to the log file of the machine.
 	LogFile string `json:"logFile,omitempty"`
 
+	// ConsoleSocket is the in-host path to the Unix socket which is connected
+	// to the serial console of the machine, if supported by the platform.
+	ConsoleSocket string `json:"consoleSocket,omitempty"`
+
+	// RestartCount is the number of times the machine has been restarted by its
+	// supervisor according to its restart policy.
+	RestartCount int `json:"restartCount,omitempty"`
+
+	// StoppedByUser indicates whether the machine was explicitly stopped, in
+	// which case it is not restarted according to its restart policy.
+	StoppedByUser bool `json:"stoppedByUser,omitempty"`
+
+	// Health is the most recently observed health of the machine according to
+	// its health check.
+	Health MachineHealth `json:"health,omitempty"`
+
+	// Stats is the most recent sample of the resource usage of the machine,
+	// which is only populated whilst the machine is running.
+	Stats *MachineStats `json:"stats,omitempty"`
+
 	// PlatformConfig is platform-specific attributes which are populated by the
 	// underlying machine service implementation.
 	PlatformConfig interface{} `json:"platformConfig,omitempty"`
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RestartPolicyName is the name of a policy which determines whether a machine
+// is restarted once it has exited.
+type RestartPolicyName string
+
+const (
+	// RestartPolicyNo never restarts the machine.
+	RestartPolicyNo = RestartPolicyName("no")
+
+	// RestartPolicyOnFailure restarts the machine only if it exited with a
+	// non-zero exit code or has failed, optionally up to a maximum number of
+	// times.
+	RestartPolicyOnFailure = RestartPolicyName("on-failure")
+
+	// RestartPolicyAlways restarts the machine whenever it exits.  A machine
+	// which has been stopped explicitly is only restarted again once its
+	// supervisor is (re-)started.
+	RestartPolicyAlways = RestartPolicyName("always")
+
+	// RestartPolicyUnlessStopped restarts the machine whenever it exits, unless
+	// it has been stopped explicitly.
+	RestartPolicyUnlessStopped = RestartPolicyName("unless-stopped")
+)
+
+// RestartPolicyNames returns the list of all supported restart policies.
+func RestartPolicyNames() []string {
+	return []string{
+		string(RestartPolicyNo),
+		string(RestartPolicyOnFailure),
+		string(RestartPolicyAlways),
+		string(RestartPolicyUnlessStopped),
+	}
+}
+
+// RestartPolicy describes whether and how often a machine is restarted once it
+// has exited.
+type RestartPolicy struct {
+	// Name of the restart policy.
+	Name RestartPolicyName `json:"name,omitempty"`
+
+	// MaximumRetryCount is the number of times a machine is restarted before
+	// giving up.  This is only applicable to the "on-failure" policy and zero
+	// indicates that there is no limit.
+	MaximumRetryCount int `json:"maximumRetryCount,omitempty"`
+}
+
+// ParseRestartPolicy parses a string representation of a RestartPolicy and
+// returns the instantiated structure.  The input follows the traditional
+// "docker-like" syntax often used in a CLI-context with the `--restart` flag,
+// e.g. "on-failure:3".
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	name, count, hasCount := strings.Cut(s, ":")
+
+	policy := RestartPolicy{
+		Name: RestartPolicyName(name),
+	}
+
+	switch policy.Name {
+	case "":
+		policy.Name = RestartPolicyNo
+	case RestartPolicyNo, RestartPolicyAlways, RestartPolicyUnlessStopped:
+	case RestartPolicyOnFailure:
+		if !hasCount {
+			break
+		}
+
+		retries, err := strconv.Atoi(count)
+		if err != nil || retries < 0 {
+			return RestartPolicy{}, fmt.Errorf("invalid maximum retry count: %s", count)
+		}
+
+		policy.MaximumRetryCount = retries
+
+		return policy, nil
+	default:
+		return RestartPolicy{}, fmt.Errorf("unknown restart policy: %s: expected one of %s", name, strings.Join(RestartPolicyNames(), ", "))
+	}
+
+	if hasCount {
+		return RestartPolicy{}, fmt.Errorf("maximum retry count cannot be used with restart policy: %s", policy.Name)
+	}
+
+	return policy, nil
+}
+
+// String implements fmt.Stringer and outputs the RestartPolicy in the same
+// format as accepted by ParseRestartPolicy.
+func (policy RestartPolicy) String() string {
+	if policy.Name == "" {
+		return string(RestartPolicyNo)
+	}
+
+	if policy.Name == RestartPolicyOnFailure && policy.MaximumRetryCount > 0 {
+		return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+	}
+
+	return string(policy.Name)
+}
+
+// ShouldRestart returns whether a machine with the provided status, which has
+// just exited, should be restarted according to the policy.
+func (policy RestartPolicy) ShouldRestart(status MachineStatus) bool {
+	if status.StoppedByUser {
+		return false
+	}
+
+	switch policy.Name {
+	case RestartPolicyAlways, RestartPolicyUnlessStopped:
+		return true
+
+	case RestartPolicyOnFailure:
+		if policy.MaximumRetryCount > 0 && status.RestartCount >= policy.MaximumRetryCount {
+			return false
+		}
+
+		switch status.State {
+		case MachineStateFailed, MachineStateErrored:
+			return true
+		}
+
+		return status.ExitCode > 0
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RestartPolicy
+		wantErr bool
+	}{
+		{in: "", want: RestartPolicy{Name: RestartPolicyNo}},
+		{in: "no", want: RestartPolicy{Name: RestartPolicyNo}},
+		{in: "always", want: RestartPolicy{Name: RestartPolicyAlways}},
+		{in: "unless-stopped", want: RestartPolicy{Name: RestartPolicyUnlessStopped}},
+		{in: "on-failure", want: RestartPolicy{Name: RestartPolicyOnFailure}},
+		{in: "on-failure:3", want: RestartPolicy{Name: RestartPolicyOnFailure, MaximumRetryCount: 3}},
+		{in: "on-failure:-1", wantErr: true},
+		{in: "on-failure:x", wantErr: true},
+		{in: "always:3", wantErr: true},
+		{in: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRestartPolicy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRestartPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRestartPolicy() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && tt.in != "" && got.String() != tt.in {
+				t.Errorf("String() = %s, want %s", got.String(), tt.in)
+			}
+		})
+	}
+}
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RestartPolicy
+		status MachineStatus
+		want   bool
+	}{
+		{
+			name:   "no",
+			policy: RestartPolicy{Name: RestartPolicyNo},
+			status: MachineStatus{State: MachineStateExited, ExitCode: 1},
+			want:   false,
+		},
+		{
+			name:   "always after success",
+			policy: RestartPolicy{Name: RestartPolicyAlways},
+			status: MachineStatus{State: MachineStateExited},
+			want:   true,
+		},
+		{
+			name:   "unless-stopped after stop",
+			policy: RestartPolicy{Name: RestartPolicyUnlessStopped},
+			status: MachineStatus{State: MachineStateExited, StoppedByUser: true},
+			want:   false,
+		},
+		{
+			name:   "on-failure after success",
+			policy: RestartPolicy{Name: RestartPolicyOnFailure},
+			status: MachineStatus{State: MachineStateExited},
+			want:   false,
+		},
+		{
+			name:   "on-failure after failure",
+			policy: RestartPolicy{Name: RestartPolicyOnFailure},
+			status: MachineStatus{State: MachineStateExited, ExitCode: 1},
+			want:   true,
+		},
+		{
+			name:   "on-failure after error",
+			policy: RestartPolicy{Name: RestartPolicyOnFailure},
+			status: MachineStatus{State: MachineStateErrored},
+			want:   true,
+		},
+		{
+			name:   "on-failure with retries exhausted",
+			policy: RestartPolicy{Name: RestartPolicyOnFailure, MaximumRetryCount: 2},
+			status: MachineStatus{State: MachineStateExited, ExitCode: 1, RestartCount: 2},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.ShouldRestart(tt.status); got != tt.want {
+				t.Errorf("ShouldRestart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
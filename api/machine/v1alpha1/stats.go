@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import "time"
+
+// MachineStats is a sample of the resource usage of a running machine.
+// Counters are cumulative since the machine was started such that rates can be
+// derived from two consecutive samples.
+type MachineStats struct {
+	// CollectedAt is the time at which the sample was taken.
+	CollectedAt time.Time `json:"collectedAt"`
+
+	// CPUTime is the total user and system CPU time consumed by the process of
+	// the virtual machine monitor.
+	CPUTime time.Duration `json:"cpuTime"`
+
+	// MemoryUsage is the resident set size of the process of the virtual
+	// machine monitor in bytes.
+	MemoryUsage uint64 `json:"memoryUsage"`
+
+	// MemoryLimit is the memory available to the guest in bytes, which
+	// reflects the size of the balloon if the machine has one.
+	MemoryLimit uint64 `json:"memoryLimit"`
+
+	// Block device statistics.
+	BlockReadBytes  uint64 `json:"blockReadBytes"`
+	BlockWriteBytes uint64 `json:"blockWriteBytes"`
+	BlockReadOps    uint64 `json:"blockReadOps"`
+	BlockWriteOps   uint64 `json:"blockWriteOps"`
+
+	// Network statistics from the perspective of the guest, summed over all of
+	// its interfaces.
+	NetRxBytes   uint64 `json:"netRxBytes"`
+	NetRxPackets uint64 `json:"netRxPackets"`
+	NetRxDropped uint64 `json:"netRxDropped"`
+	NetTxBytes   uint64 `json:"netTxBytes"`
+	NetTxPackets uint64 `json:"netTxPackets"`
+	NetTxDropped uint64 `json:"netTxDropped"`
+}
+
+// CPUPercentage returns the CPU usage of the machine as a percentage of a
+// single host CPU between the provided previous sample and this one.  Without
+// a previous sample, the average since the provided start time is returned.
+func (stats MachineStats) CPUPercentage(previous *MachineStats, startedAt time.Time) float64 {
+	cpuTime := stats.CPUTime
+	elapsed := stats.CollectedAt.Sub(startedAt)
+
+	if previous != nil {
+		cpuTime -= previous.CPUTime
+		elapsed = stats.CollectedAt.Sub(previous.CollectedAt)
+	}
+
+	if elapsed <= 0 || cpuTime < 0 {
+		return 0
+	}
+
+	return float64(cpuTime) / float64(elapsed) * 100
+}
+
+// MemoryPercentage returns the memory usage of the machine as a percentage of
+// its memory limit.
+func (stats MachineStats) MemoryPercentage() float64 {
+	if stats.MemoryLimit == 0 {
+		return 0
+	}
+
+	return float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+}
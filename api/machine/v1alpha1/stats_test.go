@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMachineStatsCPUPercentage(t *testing.T) {
+	startedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		stats    MachineStats
+		previous *MachineStats
+		want     float64
+	}{
+		{
+			name:  "average since start",
+			stats: MachineStats{CollectedAt: startedAt.Add(10 * time.Second), CPUTime: 5 * time.Second},
+			want:  50,
+		},
+		{
+			name:     "between samples",
+			stats:    MachineStats{CollectedAt: startedAt.Add(12 * time.Second), CPUTime: 6 * time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(10 * time.Second), CPUTime: 5 * time.Second},
+			want:     50,
+		},
+		{
+			name:     "multiple host CPUs",
+			stats:    MachineStats{CollectedAt: startedAt.Add(2 * time.Second), CPUTime: 4 * time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: 2 * time.Second},
+			want:     200,
+		},
+		{
+			name:     "idle",
+			stats:    MachineStats{CollectedAt: startedAt.Add(2 * time.Second), CPUTime: time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: time.Second},
+			want:     0,
+		},
+		{
+			name:     "zero elapsed time",
+			stats:    MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: 2 * time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: time.Second},
+			want:     0,
+		},
+		{
+			name:  "sampled at start",
+			stats: MachineStats{CollectedAt: startedAt, CPUTime: time.Second},
+			want:  0,
+		},
+		{
+			name:     "counter reset",
+			stats:    MachineStats{CollectedAt: startedAt.Add(2 * time.Second), CPUTime: time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: 5 * time.Second},
+			want:     0,
+		},
+		{
+			name:     "previous sample is newer",
+			stats:    MachineStats{CollectedAt: startedAt.Add(time.Second), CPUTime: 2 * time.Second},
+			previous: &MachineStats{CollectedAt: startedAt.Add(2 * time.Second), CPUTime: time.Second},
+			want:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.CPUPercentage(tt.previous, startedAt); got != tt.want {
+				t.Errorf("CPUPercentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineStatsMemoryPercentage(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats MachineStats
+		want  float64
+	}{
+		{
+			name:  "quarter",
+			stats: MachineStats{MemoryUsage: 16 << 20, MemoryLimit: 64 << 20},
+			want:  25,
+		},
+		{
+			name:  "unused",
+			stats: MachineStats{MemoryUsage: 0, MemoryLimit: 64 << 20},
+			want:  0,
+		},
+		{
+			name:  "exceeding the limit",
+			stats: MachineStats{MemoryUsage: 96 << 20, MemoryLimit: 64 << 20},
+			want:  150,
+		},
+		{
+			name:  "zero limit",
+			stats: MachineStats{MemoryUsage: 16 << 20},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.MemoryPercentage(); got != tt.want {
+				t.Errorf("MemoryPercentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -28,6 +28,9 @@ type NetworkInterfaceSpec struct {
 	// IPv4 address in CIDR notation, which includes the subnet.
 	CIDR string
 
+	// IPv6 address in CIDR notation, which includes the subnet.
+	CIDR6 string `json:"cidr6,omitempty"`
+
 	// Gateway IPv4 address.
 	Gateway string
 
@@ -43,8 +46,17 @@ type NetworkInterfaceSpec struct {
 	// Domain/Search suffix for IPv4 address.
 	Domain string
 
+	// Aliases are additional names under which the interface is resolvable by
+	// other machines on the network, e.g. the name of its machine or of the
+	// compose service it belongs to.
+	Aliases []string `json:"aliases,omitempty"`
+
 	// Hardware address of a machine interface.
 	MacAddress string `json:"mac,omitempty"`
+
+	// LinkDown indicates whether the link of the interface should be reported
+	// as down to the machine.
+	LinkDown bool `json:"linkDown,omitempty"`
 }
 
 // NetworkInterfaceTemplateSpec describes the data a network interface should
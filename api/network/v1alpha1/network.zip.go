@@ -6,9 +6,11 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	zip "api.zip"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type (
@@ -27,6 +29,11 @@ type NetworkSpec struct {
 	// Interface name of this network.
 	IfName string `json:"ifName,omitempty"`
 
+	// Parent is the name of the host interface whose layer 2 segment the
+	// network is attached to, for drivers which do not create an interface of
+	// their own, e.g. macvtap.
+	Parent string `json:"parent,omitempty"`
+
 	// The gateway IP address of the network.
 	Gateway string `json:"gateway,omitempty"`
 
@@ -34,8 +41,26 @@ type NetworkSpec struct {
 	// range.
 	Netmask string `json:"netmask,omitempty"`
 
+	// IPv6 is the IPv6 address of the gateway of the network together with the
+	// prefix length of its subnet in CIDR notation, e.g. fd00::1/64.  Networks
+	// without it are IPv4-only.
+	IPv6 string `json:"ipv6,omitempty"`
+
+	// Pools holds the subnets of the address pools from which the subnets of
+	// the network were allocated, if any.
+	Pools []string `json:"pools,omitempty"`
+
 	// Network interfaces associated with this network.
 	Interfaces []NetworkInterfaceTemplateSpec `json:"interfaces,omitempty"`
+
+	// DHCP indicates whether the addresses of the network's interfaces are
+	// additionally leased to machines by a DHCPv4 server on the network.
+	DHCP bool `json:"dhcp,omitempty"`
+
+	// DisableNAT indicates that traffic leaving the network is not masqueraded
+	// behind the address of the host, such that machines on the network cannot
+	// reach the outside world unless it is routed otherwise.
+	DisableNAT bool `json:"disableNat,omitempty"`
 }
 
 // NetworkTemplateSpec describes the data a network should have when created
@@ -67,6 +92,16 @@ type NetworkStatus struct {
 	// State is the current state of the network.
 	State NetworkState `json:"state"`
 
+	// Carrier indicates whether the interface of the network, or its parent
+	// interface, has a carrier, i.e. whether traffic can currently flow across
+	// it.  A bridge only has a carrier whilst at least one of its ports is up.
+	Carrier bool `json:"carrier"`
+
+	// Interfaces holds the state of each of the host interfaces which are
+	// attached to the network, e.g. the tap devices of its machines, keyed by
+	// their name.
+	Interfaces map[string]NetworkInterfaceState `json:"interfaces,omitempty"`
+
 	// Statistics
 	Collisions        uint64 `json:"collisions"`
 	Multicast         uint64 `json:"multicast"`
@@ -92,11 +127,35 @@ type NetworkStatus struct {
 	TxPackets         uint64 `json:"txPackets"`
 	TxWindowErrors    uint64 `json:"txWindowErrors"`
 
+	// DHCPLeases are the addresses which are currently leased to machines by
+	// the DHCPv4 server of the network.
+	DHCPLeases []NetworkDHCPLease `json:"dhcpLeases,omitempty"`
+
 	// DriverConfig is driver-specific attributes which are populated by the
 	// underlying network implementation.
 	DriverConfig interface{} `json:"driverConfig,omitempty"`
 }
 
+// NetworkDHCPLease is an address which has been leased to the interface of a
+// machine by the DHCPv4 server of the network.
+type NetworkDHCPLease struct {
+	// InterfaceUID is the UID of the network interface the address is reserved
+	// for.
+	InterfaceUID types.UID `json:"interfaceUID"`
+
+	// Hardware address of the machine interface holding the lease.
+	MacAddress string `json:"mac"`
+
+	// IPv4 address which has been leased.
+	IP string `json:"ip"`
+
+	// Hostname which was provided to the machine.
+	Hostname string `json:"hostname,omitempty"`
+
+	// ExpiresAt is the time at which the lease expires unless it is renewed.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
 // NetworkService is the interface of available methods which can be performed
 // by an implementing network driver.
 type NetworkService interface {
@@ -107,6 +166,7 @@ type NetworkService interface {
 	Delete(context.Context, *Network) (*Network, error)
 	Get(context.Context, *Network) (*Network, error)
 	List(context.Context, *NetworkList) (*NetworkList, error)
+	Watch(context.Context, *Network) (chan *Network, chan error, error)
 }
 
 // NetworkServiceHandler provides a Zip API Object Framework service for the
@@ -119,6 +179,7 @@ type NetworkServiceHandler struct {
 	delete zip.MethodStrategy[*Network, *Network]
 	get    zip.MethodStrategy[*Network, *Network]
 	list   zip.MethodStrategy[*NetworkList, *NetworkList]
+	watch  zip.StreamStrategy[*Network, *Network]
 }
 
 // Create implements NetworkService
@@ -156,6 +217,11 @@ func (client *NetworkServiceHandler) List(ctx context.Context, req *NetworkList)
 	return client.list.Do(ctx, req)
 }
 
+// Watch implements NetworkService
+func (client *NetworkServiceHandler) Watch(ctx context.Context, req *Network) (chan *Network, chan error, error) {
+	return client.watch.Channel(ctx, req)
+}
+
 // NewNetworkServiceHandler returns a service based on an inline API
 // client which essentially wraps the specific call, enabling pre- and post-
 // call hooks.  This is useful for wrapping the command with decorators, for
@@ -198,6 +264,11 @@ func NewNetworkServiceHandler(ctx context.Context, impl NetworkService, opts ...
 		return nil, err
 	}
 
+	watch, err := zip.NewStreamClient(ctx, impl.Watch, opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	return &NetworkServiceHandler{
 		create,
 		start,
@@ -206,5 +277,6 @@ func NewNetworkServiceHandler(ctx context.Context, impl NetworkService, opts ...
 		delete,
 		get,
 		list,
+		watch,
 	}, nil
 }
@@ -40,9 +40,21 @@ type VolumeSpec struct {
 	// Mark whether the volume is readonly.
 	ReadOnly bool `json:"readOnly,omitempty"`
 
+	// Options are additional driver-specific mount options of the volume, each
+	// in the form key[=value].
+	Options []string `json:"options,omitempty"`
+
 	// Managed is a flag that indicates whether the volume is managed
 	// by kraftkit or not.
 	Managed bool `json:"managed,omitempty"`
+
+	// Size of the volume in bytes, for drivers which allocate storage of a
+	// fixed size.
+	Size int64 `json:"size,omitempty"`
+
+	// Filesystem the volume is formatted with, for drivers which expose a
+	// block device to the machine.
+	Filesystem string `json:"filesystem,omitempty"`
 }
 
 // VolumeTemplateSpec describes the data a volume should have when created
@@ -82,6 +94,23 @@ type VolumeStatus struct {
 	// DriverConfig is driver-specific attributes which are populated by the
 	// underlying volume implementation.
 	DriverConfig interface{} `json:"driverConfig,omitempty"`
+
+	// Snapshots are the point-in-time copies of the contents of the volume, for
+	// drivers which support them.
+	Snapshots []VolumeSnapshot `json:"snapshots,omitempty"`
+}
+
+// VolumeSnapshot is a point-in-time copy of the contents of a volume.
+type VolumeSnapshot struct {
+	// Name of the snapshot, which is unique amongst the snapshots of the
+	// volume.
+	Name string `json:"name"`
+
+	// Source is the location of the copy of the contents of the volume.
+	Source string `json:"source"`
+
+	// CreatedAt is the time at which the snapshot was taken.
+	CreatedAt metav1.Time `json:"createdAt"`
 }
 
 // VolumeService is the interface of available methods which can be performed
@@ -92,6 +121,7 @@ type VolumeService interface {
 	Get(context.Context, *Volume) (*Volume, error)
 	List(context.Context, *VolumeList) (*VolumeList, error)
 	Update(context.Context, *Volume) (*Volume, error)
+	Watch(context.Context, *Volume) (chan *Volume, chan error, error)
 }
 
 // VolumeServiceHandler provides a Zip API Object Framework service for the
@@ -102,6 +132,7 @@ type VolumeServiceHandler struct {
 	get    zip.MethodStrategy[*Volume, *Volume]
 	list   zip.MethodStrategy[*VolumeList, *VolumeList]
 	update zip.MethodStrategy[*Volume, *Volume]
+	watch  zip.StreamStrategy[*Volume, *Volume]
 }
 
 // Create implements VolumeService
@@ -129,6 +160,11 @@ func (client *VolumeServiceHandler) Update(ctx context.Context, req *Volume) (*V
 	return client.update.Do(ctx, req)
 }
 
+// Watch implements VolumeService
+func (client *VolumeServiceHandler) Watch(ctx context.Context, req *Volume) (chan *Volume, chan error, error) {
+	return client.watch.Channel(ctx, req)
+}
+
 // NewVolumeServiceHandler returns a service based on an inline API
 // client which essentially wraps the specific call, enabling pre- and post-
 // call hooks.  This is useful for wrapping the command with decorators, for
@@ -161,11 +197,17 @@ func NewVolumeServiceHandler(ctx context.Context, impl VolumeService, opts ...zi
 		return nil, err
 	}
 
+	watch, err := zip.NewStreamClient(ctx, impl.Watch, opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	return &VolumeServiceHandler{
 		create,
 		delete,
 		get,
 		list,
 		update,
+		watch,
 	}, nil
 }
@@ -213,6 +213,6 @@ func TarDirWriter(ctx context.Context, root, prefix string, tw *tar.Writer, opts
 
 		dst = filepath.ToSlash(filepath.Join(prefix, dst))
 
-		return TarFileWriter(ctx, filepath.Join(root, path), dst, tw, opts...)
+		return TarFileWriter(ctx, path, dst, tw, opts...)
 	})
 }
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarDirWriterUntar(t *testing.T) {
+	src := t.TempDir()
+
+	files := map[string]string{
+		"a.txt":         "a",
+		"dir/b.txt":     "b",
+		"dir/sub/c.txt": "c",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tarball := func() []byte {
+		var buf bytes.Buffer
+
+		tw := tar.NewWriter(&buf)
+		if err := TarDirWriter(context.Background(), src, "", tw, WithStripTimes(true)); err != nil {
+			t.Fatal("TarDirWriter:", err)
+		}
+
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	first := tarball()
+	if !bytes.Equal(first, tarball()) {
+		t.Error("expected tarballs of the same directory to be identical")
+	}
+
+	dst := t.TempDir()
+	if err := Untar(bytes.NewReader(first), dst); err != nil {
+		t.Fatal("Untar:", err)
+	}
+
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Errorf("expected %s to be extracted: %v", name, err)
+		} else if string(got) != content {
+			t.Errorf("expected %s to contain %q, got %q", name, content, got)
+		}
+	}
+}
+
+func TestUntarIllegalPath(t *testing.T) {
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "../escaped.txt",
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := Untar(&buf, dst); err == nil {
+		t.Fatal("expected Untar to reject a path outside of the destination")
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dst), "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("expected no file to be written outside of the destination")
+	}
+}
@@ -71,6 +71,11 @@ func Untar(src io.Reader, dst string, opts ...UnarchiveOption) error {
 			path = filepath.Join(dst, header.Name)
 		}
 
+		// Prevent entries such as "../file" from being written outside of dst.
+		if rel, err := filepath.Rel(dst, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("illegal path in archive: %s", header.Name)
+		}
+
 		info := header.FileInfo()
 
 		switch header.Typeflag {
@@ -81,7 +86,7 @@ func Untar(src io.Reader, dst string, opts ...UnarchiveOption) error {
 
 		case tar.TypeReg:
 			// Create parent path if it does not exist
-			if err := os.MkdirAll(filepath.Dir(path), info.Mode()); err != nil {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 				return fmt.Errorf("could not create directory: %v", err)
 			}
 
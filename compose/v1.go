@@ -6,12 +6,11 @@ package compose
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	composev1 "kraftkit.sh/api/compose/v1"
-	"kraftkit.sh/config"
+	"kraftkit.sh/daemon/client"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
 	"kraftkit.sh/machine/volume"
@@ -30,12 +29,11 @@ type v1Compose struct {
 }
 
 func NewComposeProjectV1(ctx context.Context, opts ...any) (composev1.ComposeService, error) {
-	embeddedStore, err := store.NewEmbeddedStore[composev1.ComposeSpec, composev1.ComposeStatus](
-		filepath.Join(
-			config.G[config.KraftKit](ctx).RuntimeDir,
-			"composev1",
-		),
-	)
+	if client.Available(ctx) {
+		return client.NewComposeV1Service(ctx)
+	}
+
+	runtimeStore, err := store.NewRuntimeStore[composev1.ComposeSpec, composev1.ComposeStatus](ctx, "composev1")
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +58,7 @@ func NewComposeProjectV1(ctx context.Context, opts ...any) (composev1.ComposeSer
 	return composev1.NewComposeServiceHandler(
 		ctx,
 		service,
-		zip.WithStore[composev1.ComposeSpec, composev1.ComposeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+		zip.WithStore[composev1.ComposeSpec, composev1.ComposeStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 	)
 }
 
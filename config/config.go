@@ -14,6 +14,13 @@ type AuthConfig struct {
 	VerifySSL bool   `yaml:"verify_ssl" env:"KRAFTKIT_AUTH_%s_VERIFY_SSL" long:"auth-%s-verify-ssl" default:"true"`
 }
 
+// NetworkPool is a range of addresses from which the subnets of local machine
+// networks are allocated.
+type NetworkPool struct {
+	Subnet string `yaml:"subnet"`
+	Size   int    `yaml:"size"`
+}
+
 type KraftKit struct {
 	NoPrompt       bool   `yaml:"no_prompt" env:"KRAFTKIT_NO_PROMPT" long:"no-prompt" usage:"Do not prompt for user interaction" default:"false"`
 	NoParallel     bool   `yaml:"no_parallel" env:"KRAFTKIT_NO_PARALLEL" long:"no-parallel" usage:"Do not run internal tasks in parallel" default:"false"`
@@ -25,6 +32,8 @@ type KraftKit struct {
 	GitProtocol    string `yaml:"git_protocol" env:"KRAFTKIT_GIT_PROTOCOL" long:"git-protocol" usage:"Preferred Git protocol to use" default:"https"`
 	Pager          string `yaml:"pager,omitempty" env:"KRAFTKIT_PAGER" long:"pager" usage:"System pager to pipe output to" default:"cat"`
 	Qemu           string `yaml:"qemu,omitempty" env:"KRAFTKIT_QEMU" long:"qemu" usage:"Path to QEMU executable" default:""`
+	Virtiofsd      string `yaml:"virtiofsd,omitempty" env:"KRAFTKIT_VIRTIOFSD" long:"virtiofsd" usage:"Path to virtiofsd executable" default:""`
+	VolumeDriver   string `yaml:"volume_driver,omitempty" env:"KRAFTKIT_VOLUME_DRIVER" long:"volume-driver" usage:"Default driver of volumes which share a host directory. Choice of: [9pfs, virtiofs]" default:""`
 	HTTPUnixSocket string `yaml:"http_unix_socket,omitempty" env:"KRAFTKIT_HTTP_UNIX_SOCKET" long:"http-unix-sock" usage:"When making HTTP(S) connections, pipe requests via this shared socket"`
 	RuntimeDir     string `yaml:"runtime_dir" env:"KRAFTKIT_RUNTIME_DIR" long:"runtime-dir" usage:"Directory for placing runtime files (e.g. pidfiles)"`
 	DefaultPlat    string `yaml:"default_plat" env:"KRAFTKIT_DEFAULT_PLAT" usage:"The default platform to use when invoking platform-specific code" noattribute:"true"`
@@ -32,6 +41,9 @@ type KraftKit struct {
 	ContainerdAddr string `yaml:"containerd_addr,omitempty" env:"KRAFTKIT_CONTAINERD_ADDR" long:"containerd-addr" usage:"Address of containerd daemon socket" default:""`
 	EventsPidFile  string `yaml:"events_pidfile" env:"KRAFTKIT_EVENTS_PIDFILE" long:"events-pid-file" usage:"Events process ID used when running multiple unikernels"`
 	BuildKitHost   string `yaml:"buildkit_host" env:"KRAFTKIT_BUILDKIT_HOST" long:"buildkit-host" usage:"Path to the buildkit host" default:""`
+	DaemonSocket   string `yaml:"daemon_socket,omitempty" env:"KRAFTKIT_DAEMON_SOCKET" long:"daemon-socket" usage:"Path to the Unix socket of the local KraftKit daemon"`
+	NoDaemon       bool   `yaml:"no_daemon" env:"KRAFTKIT_NO_DAEMON" long:"no-daemon" usage:"Do not use the local KraftKit daemon even if it is running" default:"false"`
+	Store          string `yaml:"store" env:"KRAFTKIT_STORE" long:"store" usage:"Backend used to persist machines, networks and volumes. Choice of: [badger, json]" default:"badger"`
 
 	Paths struct {
 		Plugins   string `yaml:"plugins,omitempty" env:"KRAFTKIT_PATHS_PLUGINS" long:"plugins-dir" usage:"Path to KraftKit plugin directory"`
@@ -51,6 +63,10 @@ type KraftKit struct {
 		Manifests []string `yaml:"manifests" env:"KRAFTKIT_UNIKRAFT_MANIFESTS" long:"with-manifest" usage:"Paths to package or component manifests"`
 	} `yaml:"unikraft"`
 
+	Network struct {
+		Pools []NetworkPool `yaml:"pools,omitempty" env:"KRAFTKIT_NETWORK_POOLS" noattribute:"true"`
+	} `yaml:"network,omitempty"`
+
 	Auth map[string]AuthConfig `yaml:"auth,omitempty" noattribute:"true"`
 
 	Aliases map[string]map[string]string `yaml:"aliases" noattribute:"true"`
@@ -88,6 +104,18 @@ var configDetails = []ConfigDetail{
 		Key:         "pager",
 		Description: "the terminal pager program to send standard output to",
 	},
+	{
+		Key:         "store",
+		Description: "the backend used to persist local machines, networks and volumes",
+		AllowedValues: []string{
+			"badger",
+			"json",
+		},
+	},
+	{
+		Key:         "network.pools",
+		Description: "the address ranges from which the subnets of new networks are allocated",
+	},
 	{
 		Key:         "log.level",
 		Description: "Set the logging verbosity",
@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package client provides implementations of KraftKit's machine, network,
+// volume and compose services which forward all calls to a running KraftKit
+// daemon via its Unix socket.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kraftkit.sh/config"
+)
+
+const (
+	// APIVersion is the version of the daemon's HTTP API which prefixes all
+	// paths.
+	APIVersion = "v1"
+
+	// DefaultSocketName is the name of the daemon's socket within the runtime
+	// directory if no explicit path has been configured.
+	DefaultSocketName = "kraftd.sock"
+
+	// PingPath is the path which is used to determine whether the daemon is
+	// running.  It is not versioned such that clients can always reach it.
+	PingPath = "/_ping"
+
+	// pingTimeout is the maximum duration to wait for the daemon to respond
+	// before it is considered to be unavailable.
+	pingTimeout = 500 * time.Millisecond
+)
+
+// Ping is the response of the daemon to a request at PingPath.
+type Ping struct {
+	// APIVersion is the version of the HTTP API served by the daemon.
+	APIVersion string `json:"apiVersion"`
+
+	// Version is the version of KraftKit the daemon was built from.
+	Version string `json:"version"`
+}
+
+// Error is the body of any unsuccessful response of the daemon.
+type Error struct {
+	Message string `json:"message"`
+}
+
+// StreamEvent is a single newline-delimited entry of a streamed response of
+// the daemon.  Exactly one of its fields is set.
+type StreamEvent[T any] struct {
+	Object T      `json:"object,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Socket returns the path to the daemon's Unix socket based on the provided
+// context's configuration.
+func Socket(ctx context.Context) string {
+	if socket := config.G[config.KraftKit](ctx).DaemonSocket; socket != "" {
+		return socket
+	}
+
+	return filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, DefaultSocketName)
+}
+
+// Available returns whether a daemon is running and responding at the
+// configured socket and whether it should be used by the CLI.
+func Available(ctx context.Context) bool {
+	if config.G[config.KraftKit](ctx).NoDaemon {
+		return false
+	}
+
+	socket := Socket(ctx)
+	if _, err := os.Stat(socket); err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if _, err := newClient(socket).ping(ctx); err != nil {
+		return false
+	}
+
+	return true
+}
+
+// client performs requests against the daemon's HTTP API.
+type client struct {
+	http *http.Client
+}
+
+// newClient returns a client which connects to the daemon at the provided
+// Unix socket.
+func newClient(socket string) *client {
+	return &client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// endpoint returns the URL of the provided path of the daemon's versioned API.
+func endpoint(path string, query url.Values) string {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     "kraftd",
+		Path:     "/" + APIVersion + path,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// ping the daemon and return its response.
+func (c *client) ping(ctx context.Context) (*Ping, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://kraftd"+PingPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from daemon: %s", resp.Status)
+	}
+
+	var ping Ping
+	if err := json.NewDecoder(resp.Body).Decode(&ping); err != nil {
+		return nil, fmt.Errorf("could not decode response from daemon: %w", err)
+	}
+
+	if ping.APIVersion != APIVersion {
+		return nil, fmt.Errorf("unsupported daemon API version: %s", ping.APIVersion)
+	}
+
+	return &ping, nil
+}
+
+// post sends the provided object to the path of the daemon's API and returns
+// the successful response.
+func (c *client) post(ctx context.Context, path string, query url.Values, in any) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint(path, query), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to daemon: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var e Error
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Message == "" {
+			return nil, fmt.Errorf("unexpected response from daemon: %s", resp.Status)
+		}
+
+		return nil, errors.New(e.Message)
+	}
+
+	return resp, nil
+}
+
+// call performs a unary request against the daemon's API.
+func call[In, Out any](ctx context.Context, c *client, path string, query url.Values, in *In) (*Out, error) {
+	resp, err := c.post(ctx, path, query, in)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var out Out
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("could not decode response from daemon: %w", err)
+	}
+
+	if err := decodeConfigs(&out); err != nil {
+		return nil, fmt.Errorf("could not decode response from daemon: %w", err)
+	}
+
+	return &out, nil
+}
+
+// stream performs a streaming request against the daemon's API and returns
+// channels which receive each entry of the stream.  The stream ends when the
+// provided context is cancelled or when the daemon closes it, in which case
+// io.EOF is sent as error.
+func stream[In, Out any](ctx context.Context, c *client, path string, query url.Values, in *In) (chan Out, chan error, error) {
+	resp, err := c.post(ctx, path, query, in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan Out)
+	errs := make(chan error)
+
+	go func() {
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				var event StreamEvent[Out]
+				if err := json.Unmarshal(line, &event); err != nil {
+					select {
+					case errs <- fmt.Errorf("could not decode event from daemon: %w", err):
+					case <-ctx.Done():
+					}
+					return
+				}
+
+				if err := decodeConfigs(event.Object); err != nil {
+					select {
+					case errs <- fmt.Errorf("could not decode event from daemon: %w", err):
+					case <-ctx.Done():
+					}
+					return
+				}
+
+				if event.Error != "" {
+					select {
+					case errs <- errors.New(event.Error):
+					case <-ctx.Done():
+					}
+					return
+				}
+
+				select {
+				case events <- event.Object:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// The daemon closes the stream once the underlying stream has ended,
+			// which is signalled to the caller identically to local services.
+			if err != nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package client
+
+import (
+	"context"
+	"net/url"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+)
+
+// ComposePath is the path of the daemon's API which serves the compose service.
+const ComposePath = "/compose"
+
+type composeV1Client struct {
+	client *client
+	query  url.Values
+}
+
+// NewComposeV1Service returns a composev1.ComposeService which forwards all
+// calls to the daemon.
+func NewComposeV1Service(ctx context.Context) (composev1.ComposeService, error) {
+	return &composeV1Client{
+		client: newClient(Socket(ctx)),
+		query:  url.Values{},
+	}, nil
+}
+
+// Create implements kraftkit.sh/api/compose/v1.ComposeService
+func (service *composeV1Client) Create(ctx context.Context, project *composev1.Compose) (*composev1.Compose, error) {
+	return call[composev1.Compose, composev1.Compose](ctx, service.client, ComposePath+"/create", service.query, project)
+}
+
+// Delete implements kraftkit.sh/api/compose/v1.ComposeService
+func (service *composeV1Client) Delete(ctx context.Context, project *composev1.Compose) (*composev1.Compose, error) {
+	return call[composev1.Compose, composev1.Compose](ctx, service.client, ComposePath+"/delete", service.query, project)
+}
+
+// Get implements kraftkit.sh/api/compose/v1.ComposeService
+func (service *composeV1Client) Get(ctx context.Context, project *composev1.Compose) (*composev1.Compose, error) {
+	return call[composev1.Compose, composev1.Compose](ctx, service.client, ComposePath+"/get", service.query, project)
+}
+
+// List implements kraftkit.sh/api/compose/v1.ComposeService
+func (service *composeV1Client) List(ctx context.Context, projects *composev1.ComposeList) (*composev1.ComposeList, error) {
+	return call[composev1.ComposeList, composev1.ComposeList](ctx, service.client, ComposePath+"/list", service.query, projects)
+}
+
+// Update implements kraftkit.sh/api/compose/v1.ComposeService
+func (service *composeV1Client) Update(ctx context.Context, project *composev1.Compose) (*composev1.Compose, error) {
+	return call[composev1.Compose, composev1.Compose](ctx, service.client, ComposePath+"/update", service.query, project)
+}
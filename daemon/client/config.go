@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package client
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// Config is the representation of the platform- or driver-specific
+// configuration of an object exchanged with the daemon.  The configuration is
+// held by fields of interface types which cannot be restored from JSON, such
+// that it is transported in its gob encoding, which identifies its concrete
+// type by the name it has been registered with, as is the case when the
+// object is persisted in the store.
+type Config struct {
+	// Type is the name of the concrete type of the configuration.  It is
+	// provided for inspection only.
+	Type string `json:"type"`
+
+	// Data is the gob encoding of the configuration.
+	Data []byte `json:"data"`
+}
+
+// configsOf returns references to the platform- or driver-specific
+// configuration of the provided object, or of each item of the provided list.
+func configsOf(obj any) []*any {
+	var configs []*any
+
+	switch obj := obj.(type) {
+	case *machinev1alpha1.Machine:
+		configs = append(configs, &obj.Status.PlatformConfig)
+	case *machinev1alpha1.MachineList:
+		for i := range obj.Items {
+			configs = append(configs, &obj.Items[i].Status.PlatformConfig)
+		}
+	case *networkv1alpha1.Network:
+		configs = append(configs, &obj.Status.DriverConfig)
+	case *networkv1alpha1.NetworkList:
+		for i := range obj.Items {
+			configs = append(configs, &obj.Items[i].Status.DriverConfig)
+		}
+	case *volumev1alpha1.Volume:
+		configs = append(configs, &obj.Status.DriverConfig)
+	case *volumev1alpha1.VolumeList:
+		for i := range obj.Items {
+			configs = append(configs, &obj.Items[i].Status.DriverConfig)
+		}
+	}
+
+	return configs
+}
+
+// shallowCopy returns a copy of the provided object such that its
+// configuration, or that of each item of the provided list, can be replaced
+// without modifying the original.
+func shallowCopy(obj any) any {
+	switch obj := obj.(type) {
+	case *machinev1alpha1.Machine:
+		cpy := *obj
+		return &cpy
+	case *machinev1alpha1.MachineList:
+		cpy := *obj
+		cpy.Items = slices.Clone(obj.Items)
+		return &cpy
+	case *networkv1alpha1.Network:
+		cpy := *obj
+		return &cpy
+	case *networkv1alpha1.NetworkList:
+		cpy := *obj
+		cpy.Items = slices.Clone(obj.Items)
+		return &cpy
+	case *volumev1alpha1.Volume:
+		cpy := *obj
+		return &cpy
+	case *volumev1alpha1.VolumeList:
+		cpy := *obj
+		cpy.Items = slices.Clone(obj.Items)
+		return &cpy
+	default:
+		return obj
+	}
+}
+
+// EncodeConfigs returns a copy of the provided object whose platform- or
+// driver-specific configuration, or that of each item of the provided list, is
+// replaced by its Config such that it survives the transport via JSON.
+func EncodeConfigs[T any](obj T) (T, error) {
+	cpy, ok := shallowCopy(obj).(T)
+	if !ok {
+		return obj, nil
+	}
+
+	for _, config := range configsOf(cpy) {
+		if *config == nil {
+			continue
+		}
+
+		data := bytes.Buffer{}
+		if err := gob.NewEncoder(&data).Encode(config); err != nil {
+			return obj, fmt.Errorf("could not encode %T: %w", *config, err)
+		}
+
+		*config = Config{
+			Type: fmt.Sprintf("%T", *config),
+			Data: data.Bytes(),
+		}
+	}
+
+	return cpy, nil
+}
+
+// decodeConfigs restores the platform- or driver-specific configuration of the
+// provided object, or of each item of the provided list, from its Config as
+// received from the daemon.
+func decodeConfigs(obj any) error {
+	for _, config := range configsOf(obj) {
+		if *config == nil {
+			continue
+		}
+
+		// The configuration is decoded from JSON as a generic map.
+		b, err := json.Marshal(*config)
+		if err != nil {
+			return fmt.Errorf("could not decode configuration: %w", err)
+		}
+
+		var encoded Config
+		if err := json.Unmarshal(b, &encoded); err != nil {
+			return fmt.Errorf("could not decode configuration: %w", err)
+		}
+
+		var decoded any
+		if err := gob.NewDecoder(bytes.NewReader(encoded.Data)).Decode(&decoded); err != nil {
+			return fmt.Errorf("could not decode %s: %w", encoded.Type, err)
+		}
+
+		*config = decoded
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package client
+
+import (
+	"context"
+	"net/url"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/stats"
+)
+
+const (
+	// MachinesPath is the path of the daemon's API which serves the machine
+	// service.
+	MachinesPath = "/machines"
+
+	// SamplingParam is the query parameter which requests the daemon to sample
+	// the resource usage of running machines whenever they are retrieved.
+	SamplingParam = "stats"
+)
+
+type machineV1alpha1Client struct {
+	client *client
+	query  url.Values
+}
+
+// NewMachineV1alpha1Service returns a machinev1alpha1.MachineService which
+// forwards all calls to the daemon.  If the provided platform is empty, the
+// daemon iterates over all of its supported platforms.
+func NewMachineV1alpha1Service(ctx context.Context, platform string) (machinev1alpha1.MachineService, error) {
+	query := url.Values{}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+
+	return &machineV1alpha1Client{
+		client: newClient(Socket(ctx)),
+		query:  query,
+	}, nil
+}
+
+// values returns the query of requests made with the provided context.
+func (service *machineV1alpha1Client) values(ctx context.Context) url.Values {
+	if !stats.Sampling(ctx) {
+		return service.query
+	}
+
+	query := url.Values{}
+	for key, values := range service.query {
+		query[key] = values
+	}
+
+	query.Set(SamplingParam, "true")
+
+	return query
+}
+
+// Create implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/create", service.query, machine)
+}
+
+// Start implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Start(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/start", service.query, machine)
+}
+
+// Pause implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Pause(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/pause", service.query, machine)
+}
+
+// Stop implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Stop(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/stop", service.query, machine)
+}
+
+// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/update", service.query, machine)
+}
+
+// Delete implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Delete(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/delete", service.query, machine)
+}
+
+// Get implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Get(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[machinev1alpha1.Machine, machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/get", service.values(ctx), machine)
+}
+
+// List implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) List(ctx context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
+	return call[machinev1alpha1.MachineList, machinev1alpha1.MachineList](ctx, service.client, MachinesPath+"/list", service.values(ctx), machines)
+}
+
+// Watch implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Watch(ctx context.Context, machine *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
+	return stream[machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, service.client, MachinesPath+"/watch", service.query, machine)
+}
+
+// Logs implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (service *machineV1alpha1Client) Logs(ctx context.Context, machine *machinev1alpha1.Machine) (chan string, chan error, error) {
+	return stream[machinev1alpha1.Machine, string](ctx, service.client, MachinesPath+"/logs", service.query, machine)
+}
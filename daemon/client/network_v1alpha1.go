@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package client
+
+import (
+	"context"
+	"net/url"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// NetworksPath is the path of the daemon's API which serves the network service.
+const NetworksPath = "/networks"
+
+type networkV1alpha1Client struct {
+	client *client
+	query  url.Values
+}
+
+// NewNetworkV1alpha1Service returns a networkv1alpha1.NetworkService which
+// forwards all calls to the daemon.  If the provided driver is empty, the
+// daemon iterates over all of its supported drivers.
+func NewNetworkV1alpha1Service(ctx context.Context, driver string) (networkv1alpha1.NetworkService, error) {
+	query := url.Values{}
+	if driver != "" {
+		query.Set("driver", driver)
+	}
+
+	return &networkV1alpha1Client{
+		client: newClient(Socket(ctx)),
+		query:  query,
+	}, nil
+}
+
+// Create implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Create(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/create", service.query, network)
+}
+
+// Start implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Start(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/start", service.query, network)
+}
+
+// Stop implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Stop(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/stop", service.query, network)
+}
+
+// Update implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Update(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/update", service.query, network)
+}
+
+// Delete implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Delete(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/delete", service.query, network)
+}
+
+// Get implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Get(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[networkv1alpha1.Network, networkv1alpha1.Network](ctx, service.client, NetworksPath+"/get", service.query, network)
+}
+
+// List implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) List(ctx context.Context, networks *networkv1alpha1.NetworkList) (*networkv1alpha1.NetworkList, error) {
+	return call[networkv1alpha1.NetworkList, networkv1alpha1.NetworkList](ctx, service.client, NetworksPath+"/list", service.query, networks)
+}
+
+// Watch implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (service *networkV1alpha1Client) Watch(ctx context.Context, network *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
+	return stream[networkv1alpha1.Network, *networkv1alpha1.Network](ctx, service.client, NetworksPath+"/watch", service.query, network)
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package client
+
+import (
+	"context"
+	"net/url"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// VolumesPath is the path of the daemon's API which serves the volume service.
+const VolumesPath = "/volumes"
+
+type volumeV1alpha1Client struct {
+	client *client
+	query  url.Values
+}
+
+// NewVolumeV1alpha1Service returns a volumev1alpha1.VolumeService which
+// forwards all calls to the daemon.  If the provided driver is empty, the
+// daemon iterates over all of its supported drivers.
+func NewVolumeV1alpha1Service(ctx context.Context, driver string) (volumev1alpha1.VolumeService, error) {
+	query := url.Values{}
+	if driver != "" {
+		query.Set("driver", driver)
+	}
+
+	return &volumeV1alpha1Client{
+		client: newClient(Socket(ctx)),
+		query:  query,
+	}, nil
+}
+
+// Create implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) Create(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[volumev1alpha1.Volume, volumev1alpha1.Volume](ctx, service.client, VolumesPath+"/create", service.query, volume)
+}
+
+// Delete implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) Delete(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[volumev1alpha1.Volume, volumev1alpha1.Volume](ctx, service.client, VolumesPath+"/delete", service.query, volume)
+}
+
+// Get implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) Get(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[volumev1alpha1.Volume, volumev1alpha1.Volume](ctx, service.client, VolumesPath+"/get", service.query, volume)
+}
+
+// List implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) List(ctx context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	return call[volumev1alpha1.VolumeList, volumev1alpha1.VolumeList](ctx, service.client, VolumesPath+"/list", service.query, volumes)
+}
+
+// Update implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) Update(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[volumev1alpha1.Volume, volumev1alpha1.Volume](ctx, service.client, VolumesPath+"/update", service.query, volume)
+}
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (service *volumeV1alpha1Client) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	return stream[volumev1alpha1.Volume, *volumev1alpha1.Volume](ctx, service.client, VolumesPath+"/watch", service.query, volume)
+}
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package daemon hosts KraftKit's machine, network, volume and compose
+// services in a long-running process and exposes them via a versioned
+// JSON/HTTP API on a Unix socket.  The API is consumed by the implementations
+// in the kraftkit.sh/daemon/client package.  The daemon also supervises the
+// machines it hosts, restarting them according to their restart policy and
+// monitoring their health.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/config"
+	"kraftkit.sh/daemon/client"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/supervisor"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/store"
+)
+
+// shutdownTimeout is the maximum duration to wait for in-flight requests to
+// complete when the daemon is stopped.
+const shutdownTimeout = 5 * time.Second
+
+// Daemon serves KraftKit's services via a Unix socket.
+type Daemon struct {
+	socket string
+
+	mu       sync.Mutex
+	machines map[string]machinev1alpha1.MachineService
+	networks map[string]networkv1alpha1.NetworkService
+	volumes  map[string]volumev1alpha1.VolumeService
+	compose  composev1.ComposeService
+}
+
+// NewDaemon prepares a daemon which serves at the provided Unix socket.  If no
+// socket is provided, the configured socket is used.  Services are
+// instantiated on first use unless provided via options.
+func NewDaemon(ctx context.Context, opts ...DaemonOption) (*Daemon, error) {
+	daemon := Daemon{
+		socket:   client.Socket(ctx),
+		machines: map[string]machinev1alpha1.MachineService{},
+		networks: map[string]networkv1alpha1.NetworkService{},
+		volumes:  map[string]volumev1alpha1.VolumeService{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(&daemon); err != nil {
+			return nil, err
+		}
+	}
+
+	return &daemon, nil
+}
+
+// Socket returns the path of the Unix socket the daemon serves at.
+func (daemon *Daemon) Socket() string {
+	return daemon.socket
+}
+
+// Serve the daemon's API until the provided context is cancelled.
+func (daemon *Daemon) Serve(ctx context.Context) error {
+	// The services hosted by the daemon must always be instantiated in-process
+	// rather than being forwarded back to the daemon itself.
+	config.G[config.KraftKit](ctx).NoDaemon = true
+
+	if err := os.MkdirAll(filepath.Dir(daemon.socket), 0o755); err != nil {
+		return fmt.Errorf("could not prepare socket directory: %w", err)
+	}
+
+	// Determine whether another daemon is already serving at the socket or
+	// whether it is stale and can be removed.
+	if conn, err := net.DialTimeout("unix", daemon.socket, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("daemon is already running at %s", daemon.socket)
+	} else if err := os.Remove(daemon.socket); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", daemon.socket)
+	if err != nil {
+		return fmt.Errorf("could not listen at %s: %w", daemon.socket, err)
+	}
+
+	defer os.Remove(daemon.socket)
+
+	// Only allow the owner and their group to access the daemon.
+	if err := os.Chmod(daemon.socket, 0o660); err != nil {
+		listener.Close()
+		return fmt.Errorf("could not set socket permissions: %w", err)
+	}
+
+	// Supervise the machines hosted by the daemon for as long as it serves.
+	ctx, cancel := context.WithCancel(ctx)
+	supervised := make(chan struct{})
+
+	go func() {
+		defer close(supervised)
+		daemon.supervise(ctx)
+	}()
+
+	defer func() {
+		cancel()
+		<-supervised
+	}()
+
+	server := &http.Server{
+		Handler: daemon.Handler(),
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(sctx); err != nil {
+			log.G(ctx).Debugf("could not gracefully shut down daemon: %v", err)
+		}
+	}()
+
+	log.G(ctx).WithField("socket", daemon.socket).Info("daemon listening")
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// supervise restarts the machines hosted by the daemon according to their
+// restart policy and monitors their health until the context is cancelled.
+func (daemon *Daemon) supervise(ctx context.Context) {
+	controller, err := daemon.machineService(ctx, "")
+	if err != nil {
+		log.G(ctx).Warnf("could not supervise machines: %v", err)
+		return
+	}
+
+	machineStore, err := store.NewRuntimeStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](ctx, "machinev1alpha1")
+	if err != nil {
+		log.G(ctx).Warnf("could not supervise machines: %v", err)
+		return
+	}
+
+	if err := supervisor.Supervise(ctx, controller, machineStore); err != nil {
+		log.G(ctx).Warnf("could not supervise machines: %v", err)
+	}
+}
+
+// machineService returns the machine service of the provided platform,
+// or the iterator over all platforms if unset.
+func (daemon *Daemon) machineService(ctx context.Context, name string) (machinev1alpha1.MachineService, error) {
+	daemon.mu.Lock()
+	defer daemon.mu.Unlock()
+
+	if service, ok := daemon.machines[name]; ok {
+		return service, nil
+	}
+
+	var service machinev1alpha1.MachineService
+	var err error
+
+	if name == "" {
+		service, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		platform, ok := mplatform.PlatformsByName()[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown platform driver: %s", name)
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return nil, fmt.Errorf("unsupported platform driver: %s", name)
+		}
+
+		service, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	daemon.machines[name] = service
+
+	return service, nil
+}
+
+// networkService returns the network service of the provided driver, or the
+// iterator over all drivers if unset.
+func (daemon *Daemon) networkService(ctx context.Context, driver string) (networkv1alpha1.NetworkService, error) {
+	daemon.mu.Lock()
+	defer daemon.mu.Unlock()
+
+	if service, ok := daemon.networks[driver]; ok {
+		return service, nil
+	}
+
+	var service networkv1alpha1.NetworkService
+	var err error
+
+	if driver == "" {
+		service, err = network.NewNetworkV1alpha1ServiceIterator(ctx)
+	} else {
+		strategy, ok := network.Strategies()[driver]
+		if !ok {
+			return nil, fmt.Errorf("unsupported network driver strategy: %s", driver)
+		}
+
+		service, err = strategy.NewNetworkV1alpha1(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	daemon.networks[driver] = service
+
+	return service, nil
+}
+
+// volumeService returns the volume service of the provided driver, or the
+// iterator over all drivers if unset.
+func (daemon *Daemon) volumeService(ctx context.Context, driver string) (volumev1alpha1.VolumeService, error) {
+	daemon.mu.Lock()
+	defer daemon.mu.Unlock()
+
+	if service, ok := daemon.volumes[driver]; ok {
+		return service, nil
+	}
+
+	var service volumev1alpha1.VolumeService
+	var err error
+
+	if driver == "" {
+		service, err = volume.NewVolumeV1alpha1ServiceIterator(ctx)
+	} else {
+		strategy, ok := volume.Strategies()[driver]
+		if !ok {
+			return nil, fmt.Errorf("unsupported volume driver strategy: %s", driver)
+		}
+
+		service, err = strategy.NewVolumeV1alpha1(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	daemon.volumes[driver] = service
+
+	return service, nil
+}
+
+// composeService returns the compose service.
+func (daemon *Daemon) composeService(ctx context.Context) (composev1.ComposeService, error) {
+	daemon.mu.Lock()
+	defer daemon.mu.Unlock()
+
+	if daemon.compose != nil {
+		return daemon.compose, nil
+	}
+
+	service, err := compose.NewComposeProjectV1(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	daemon.compose = service
+
+	return service, nil
+}
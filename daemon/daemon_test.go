@@ -0,0 +1,426 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon_test
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/daemon"
+	"kraftkit.sh/daemon/client"
+	"kraftkit.sh/machine/qemu"
+	"kraftkit.sh/store"
+)
+
+// fakeMachineService records the platform-specific configuration it receives
+// and serves a fixed set of log lines.
+type fakeMachineService struct {
+	machinev1alpha1.MachineService
+	config any
+
+	mu       sync.Mutex
+	received any
+}
+
+func (service *fakeMachineService) Create(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	machine.UID = "1234"
+	machine.Status.State = machinev1alpha1.MachineStateCreated
+	return machine, nil
+}
+
+func (service *fakeMachineService) Start(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	service.mu.Lock()
+	service.received = machine.Status.PlatformConfig
+	service.mu.Unlock()
+
+	if machine.Name == "broken" {
+		return nil, fmt.Errorf("could not start machine")
+	}
+
+	machine.Status.State = machinev1alpha1.MachineStateRunning
+	return machine, nil
+}
+
+func (service *fakeMachineService) List(_ context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
+	machine := machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "1234",
+		},
+	}
+	machine.Status.PlatformConfig = service.config
+
+	machines.Items = []machinev1alpha1.Machine{machine}
+	return machines, nil
+}
+
+func (service *fakeMachineService) Logs(ctx context.Context, _ *machinev1alpha1.Machine) (chan string, chan error, error) {
+	logs := make(chan string)
+	errs := make(chan error)
+
+	go func() {
+		for _, line := range []string{"hello", "world"} {
+			select {
+			case logs <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case errs <- io.EOF:
+		case <-ctx.Done():
+		}
+	}()
+
+	return logs, errs, nil
+}
+
+// platformConfig is a platform-specific configuration which is not known to
+// JSON such that it must be restored to its concrete type.
+type platformConfig struct {
+	Value int
+}
+
+func init() {
+	gob.Register(platformConfig{})
+}
+
+// serveDaemon serves the provided machine service via a daemon for the
+// duration of the test and returns a context whose configuration refers to it.
+func serveDaemon(t *testing.T, service machinev1alpha1.MachineService) context.Context {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "kraftd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "kraftd.sock")
+
+	// The daemon and its clients are usually separate processes, each with
+	// their own configuration.
+	withConfig := func(ctx context.Context) context.Context {
+		cfgm, err := config.NewConfigManager(&config.KraftKit{
+			DaemonSocket: socket,
+			RuntimeDir:   dir,
+		})
+		if err != nil {
+			t.Fatal("NewConfigManager:", err)
+		}
+
+		return config.WithConfigManager(ctx, cfgm)
+	}
+
+	ctx, cancel := context.WithTimeout(withConfig(context.Background()), 10*time.Second)
+	t.Cleanup(cancel)
+
+	serveCtx, stop := context.WithCancel(withConfig(ctx))
+
+	d, err := daemon.NewDaemon(serveCtx,
+		daemon.WithMachineV1alpha1Service("qemu", service),
+		daemon.WithMachineV1alpha1Service("", service),
+	)
+	if err != nil {
+		t.Fatal("NewDaemon:", err)
+	}
+
+	served := make(chan error)
+	go func() {
+		served <- d.Serve(serveCtx)
+	}()
+
+	for i := 0; ; i++ {
+		if client.Available(ctx) {
+			break
+		} else if i > 50 {
+			t.Fatal("daemon did not become available")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Cleanup(func() {
+		stop()
+
+		if err := <-served; err != nil {
+			t.Fatal("Serve:", err)
+		}
+	})
+
+	return ctx
+}
+
+func TestDaemonMachineService(t *testing.T) {
+	service := &fakeMachineService{
+		config: platformConfig{Value: 42},
+	}
+
+	ctx := serveDaemon(t, service)
+
+	remote, err := client.NewMachineV1alpha1Service(ctx, "qemu")
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machine, err := remote.Create(ctx, &machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	})
+	if err != nil {
+		t.Fatal("Create:", err)
+	}
+	if machine.UID != "1234" || machine.Status.State != machinev1alpha1.MachineStateCreated {
+		t.Errorf("unexpected machine after create: %s %s", machine.UID, machine.Status.State)
+	}
+
+	machine, err = remote.Start(ctx, machine)
+	if err != nil {
+		t.Fatal("Start:", err)
+	}
+	if machine.Status.State != machinev1alpha1.MachineStateRunning {
+		t.Errorf("expected running machine, got %s", machine.Status.State)
+	}
+	service.mu.Lock()
+	if service.received != service.config {
+		t.Errorf("expected platform config to be rehydrated, got %#v", service.received)
+	}
+	service.mu.Unlock()
+	if machine.Status.PlatformConfig != service.config {
+		t.Errorf("expected platform config to be returned, got %#v", machine.Status.PlatformConfig)
+	}
+
+	if _, err := remote.Start(ctx, &machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "broken",
+		},
+	}); err == nil || err.Error() != "could not start machine" {
+		t.Errorf("expected error to be forwarded, got %v", err)
+	}
+
+	logs, errs, err := remote.Logs(ctx, machine)
+	if err != nil {
+		t.Fatal("Logs:", err)
+	}
+
+	var lines []string
+loop:
+	for {
+		select {
+		case line := <-logs:
+			lines = append(lines, line)
+		case err := <-errs:
+			if !errors.Is(err, io.EOF) {
+				t.Fatal("Logs:", err)
+			}
+			break loop
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for logs")
+		}
+	}
+
+	if len(lines) != 2 || lines[0] != "hello" || lines[1] != "world" {
+		t.Errorf("unexpected log lines: %v", lines)
+	}
+}
+
+func TestDaemonPlatformConfigRoundTrip(t *testing.T) {
+	expect := qemu.QemuConfig{
+		Name:   "test",
+		Kernel: "/tmp/kernel",
+		CharDevs: []qemu.QemuCharDev{
+			qemu.QemuCharDevSocketUnix{
+				Id:     "serial0",
+				Path:   "/tmp/serial.sock",
+				Server: true,
+			},
+		},
+		QMP: []qemu.QemuHostCharDev{
+			qemu.QemuHostCharDevUnix{
+				SocketDir: "/tmp",
+				Name:      "qemu_control",
+				Server:    true,
+				NoWait:    true,
+			},
+		},
+	}
+
+	ctx := serveDaemon(t, &fakeMachineService{
+		config: expect,
+	})
+
+	remote, err := client.NewMachineV1alpha1Service(ctx, "qemu")
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machines, err := remote.List(ctx, &machinev1alpha1.MachineList{})
+	if err != nil {
+		t.Fatal("List:", err)
+	}
+
+	if len(machines.Items) != 1 {
+		t.Fatalf("expected a single machine, got %d", len(machines.Items))
+	}
+
+	got, ok := machines.Items[0].Status.PlatformConfig.(qemu.QemuConfig)
+	if !ok {
+		t.Fatalf("expected QEMU platform config, got %T", machines.Items[0].Status.PlatformConfig)
+	}
+
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected platform config %#v, got %#v", expect, got)
+	}
+}
+
+// supervisedMachineService keeps the machines it starts in the machine store
+// and reports each start.
+type supervisedMachineService struct {
+	machinev1alpha1.MachineService
+	started chan string
+}
+
+// machineStore returns the machine store of the configuration of the provided
+// context.
+func machineStore(ctx context.Context) (zip.Store, error) {
+	return store.NewRuntimeStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](ctx, "machinev1alpha1")
+}
+
+func (service *supervisedMachineService) Create(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	machine.Status.State = machinev1alpha1.MachineStateCreated
+	return machine, nil
+}
+
+func (service *supervisedMachineService) Get(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	machines, err := machineStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var got machinev1alpha1.Machine
+	if err := machines.Get(ctx, machine.Name, storage.GetOptions{}, &got); err != nil {
+		return nil, err
+	}
+
+	return &got, nil
+}
+
+func (service *supervisedMachineService) Start(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	machines, err := machineStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started, err := store.Update[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](ctx, machines, machine.UID, func(existing *machinev1alpha1.Machine) error {
+		existing.Status.State = machinev1alpha1.MachineStateRunning
+		existing.Status.StartedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service.started <- machine.Name
+
+	return started, nil
+}
+
+func (service *supervisedMachineService) Watch(ctx context.Context, _ *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
+	return make(chan *machinev1alpha1.Machine), make(chan error), nil
+}
+
+func TestDaemonRestartsExitedMachine(t *testing.T) {
+	service := &supervisedMachineService{
+		started: make(chan string, 1),
+	}
+
+	ctx := serveDaemon(t, service)
+
+	remote, err := client.NewMachineV1alpha1Service(ctx, "qemu")
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	// Machines with a restart policy are accepted by the daemon.
+	if _, err := remote.Create(ctx, &machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "created",
+		},
+		Spec: machinev1alpha1.MachineSpec{
+			RestartPolicy: machinev1alpha1.RestartPolicy{
+				Name: machinev1alpha1.RestartPolicyAlways,
+			},
+		},
+	}); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	exited := &machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "exited",
+			UID:  "5678",
+		},
+		Spec: machinev1alpha1.MachineSpec{
+			RestartPolicy: machinev1alpha1.RestartPolicy{
+				Name: machinev1alpha1.RestartPolicyOnFailure,
+			},
+		},
+		Status: machinev1alpha1.MachineStatus{
+			State:     machinev1alpha1.MachineStateFailed,
+			ExitCode:  1,
+			StartedAt: time.Now().Add(-time.Minute),
+			ExitedAt:  time.Now(),
+		},
+	}
+
+	machines, err := machineStore(ctx)
+	if err != nil {
+		t.Fatal("NewRuntimeStore:", err)
+	}
+
+	if err := machines.Create(ctx, exited.Name, exited, exited, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	select {
+	case name := <-service.started:
+		if name != exited.Name {
+			t.Fatalf("expected machine %s to be restarted, got %s", exited.Name, name)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the machine to be restarted")
+	}
+
+	restarted, err := service.Get(ctx, exited)
+	if err != nil {
+		t.Fatal("Get:", err)
+	}
+
+	if restarted.Status.State != machinev1alpha1.MachineStateRunning {
+		t.Errorf("expected restarted machine to be running, got %s", restarted.Status.State)
+	}
+
+	if restarted.Status.RestartCount != 1 {
+		t.Errorf("expected restart count 1, got %d", restarted.Status.RestartCount)
+	}
+}
@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/daemon/client"
+	kitversion "kraftkit.sh/internal/version"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/stats"
+)
+
+// Handler returns the HTTP handler which serves the daemon's API.
+func (daemon *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	prefix := "/" + client.APIVersion
+
+	mux.HandleFunc("GET "+client.PingPath, daemon.handlePing)
+	mux.HandleFunc("POST "+prefix+client.MachinesPath+"/{method}", daemon.handleMachines)
+	mux.HandleFunc("POST "+prefix+client.NetworksPath+"/{method}", daemon.handleNetworks)
+	mux.HandleFunc("POST "+prefix+client.VolumesPath+"/{method}", daemon.handleVolumes)
+	mux.HandleFunc("POST "+prefix+client.ComposePath+"/{method}", daemon.handleCompose)
+
+	return mux
+}
+
+func (daemon *Daemon) handlePing(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, client.Ping{
+		APIVersion: client.APIVersion,
+		Version:    kitversion.Version(),
+	})
+}
+
+func (daemon *Daemon) handleMachines(w http.ResponseWriter, r *http.Request) {
+	service, err := daemon.machineService(r.Context(), r.URL.Query().Get("platform"))
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if r.URL.Query().Get(client.SamplingParam) == "true" {
+		r = r.WithContext(stats.WithSampling(r.Context()))
+	}
+
+	// Rehydrate the driver-specific configuration of the machine which does not
+	// survive the transport via JSON.
+	rehydrated := func(fn func(context.Context, *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error)) func(context.Context, *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+		return func(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+			return fn(ctx, rehydrateMachine(ctx, service, machine))
+		}
+	}
+
+	switch method := r.PathValue("method"); method {
+	case "create":
+		unary(w, r, service.Create)
+	case "start":
+		unary(w, r, rehydrated(service.Start))
+	case "pause":
+		unary(w, r, rehydrated(service.Pause))
+	case "stop":
+		unary(w, r, rehydrated(service.Stop))
+	case "update":
+		unary(w, r, rehydrated(service.Update))
+	case "delete":
+		unary(w, r, rehydrated(service.Delete))
+	case "get":
+		unary(w, r, rehydrated(service.Get))
+	case "list":
+		unary(w, r, service.List)
+	case "watch":
+		stream(w, r, func(ctx context.Context, machine *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
+			return service.Watch(ctx, rehydrateMachine(ctx, service, machine))
+		})
+	case "logs":
+		stream(w, r, func(ctx context.Context, machine *machinev1alpha1.Machine) (chan string, chan error, error) {
+			return service.Logs(ctx, rehydrateMachine(ctx, service, machine))
+		})
+	default:
+		fail(w, http.StatusNotFound, fmt.Errorf("unknown machine method: %s", method))
+	}
+}
+
+func (daemon *Daemon) handleNetworks(w http.ResponseWriter, r *http.Request) {
+	service, err := daemon.networkService(r.Context(), r.URL.Query().Get("driver"))
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rehydrated := func(fn func(context.Context, *networkv1alpha1.Network) (*networkv1alpha1.Network, error)) func(context.Context, *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+		return func(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+			return fn(ctx, rehydrateNetwork(ctx, service, network))
+		}
+	}
+
+	switch method := r.PathValue("method"); method {
+	case "create":
+		unary(w, r, service.Create)
+	case "start":
+		unary(w, r, rehydrated(service.Start))
+	case "stop":
+		unary(w, r, rehydrated(service.Stop))
+	case "update":
+		unary(w, r, rehydrated(service.Update))
+	case "delete":
+		unary(w, r, rehydrated(service.Delete))
+	case "get":
+		unary(w, r, rehydrated(service.Get))
+	case "list":
+		unary(w, r, service.List)
+	case "watch":
+		stream(w, r, func(ctx context.Context, network *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
+			return service.Watch(ctx, rehydrateNetwork(ctx, service, network))
+		})
+	default:
+		fail(w, http.StatusNotFound, fmt.Errorf("unknown network method: %s", method))
+	}
+}
+
+func (daemon *Daemon) handleVolumes(w http.ResponseWriter, r *http.Request) {
+	service, err := daemon.volumeService(r.Context(), r.URL.Query().Get("driver"))
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rehydrated := func(fn func(context.Context, *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error)) func(context.Context, *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+		return func(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+			return fn(ctx, rehydrateVolume(ctx, service, volume))
+		}
+	}
+
+	switch method := r.PathValue("method"); method {
+	case "create":
+		unary(w, r, service.Create)
+	case "delete":
+		unary(w, r, rehydrated(service.Delete))
+	case "get":
+		unary(w, r, rehydrated(service.Get))
+	case "list":
+		unary(w, r, service.List)
+	case "update":
+		unary(w, r, rehydrated(service.Update))
+	case "watch":
+		stream(w, r, func(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+			return service.Watch(ctx, rehydrateVolume(ctx, service, volume))
+		})
+	default:
+		fail(w, http.StatusNotFound, fmt.Errorf("unknown volume method: %s", method))
+	}
+}
+
+func (daemon *Daemon) handleCompose(w http.ResponseWriter, r *http.Request) {
+	service, err := daemon.composeService(r.Context())
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	switch method := r.PathValue("method"); method {
+	case "create":
+		unary(w, r, service.Create)
+	case "delete":
+		unary(w, r, service.Delete)
+	case "get":
+		unary(w, r, service.Get)
+	case "list":
+		unary(w, r, service.List)
+	case "update":
+		unary(w, r, service.Update)
+	default:
+		fail(w, http.StatusNotFound, fmt.Errorf("unknown compose method: %s", method))
+	}
+}
+
+// rehydrateMachine restores the platform-specific configuration of the
+// provided machine from the service's own record of it.
+func rehydrateMachine(ctx context.Context, service machinev1alpha1.MachineService, machine *machinev1alpha1.Machine) *machinev1alpha1.Machine {
+	machine.Status.PlatformConfig = nil
+
+	machines, err := service.List(ctx, &machinev1alpha1.MachineList{})
+	if err != nil {
+		log.G(ctx).Debugf("could not list machines: %v", err)
+		return machine
+	}
+
+	for _, existing := range machines.Items {
+		if (machine.UID != "" && existing.UID == machine.UID) || (machine.UID == "" && existing.Name == machine.Name) {
+			machine.Status.PlatformConfig = existing.Status.PlatformConfig
+			break
+		}
+	}
+
+	return machine
+}
+
+// rehydrateNetwork restores the driver-specific configuration of the provided
+// network from the service's own record of it.
+func rehydrateNetwork(ctx context.Context, service networkv1alpha1.NetworkService, network *networkv1alpha1.Network) *networkv1alpha1.Network {
+	network.Status.DriverConfig = nil
+
+	networks, err := service.List(ctx, &networkv1alpha1.NetworkList{})
+	if err != nil {
+		log.G(ctx).Debugf("could not list networks: %v", err)
+		return network
+	}
+
+	for _, existing := range networks.Items {
+		if (network.UID != "" && existing.UID == network.UID) || (network.UID == "" && existing.Name == network.Name) {
+			network.Status.DriverConfig = existing.Status.DriverConfig
+			break
+		}
+	}
+
+	return network
+}
+
+// rehydrateVolume restores the driver-specific configuration of the provided
+// volume from the service's own record of it.
+func rehydrateVolume(ctx context.Context, service volumev1alpha1.VolumeService, volume *volumev1alpha1.Volume) *volumev1alpha1.Volume {
+	volume.Status.DriverConfig = nil
+
+	volumes, err := service.List(ctx, &volumev1alpha1.VolumeList{})
+	if err != nil {
+		log.G(ctx).Debugf("could not list volumes: %v", err)
+		return volume
+	}
+
+	for _, existing := range volumes.Items {
+		if (volume.UID != "" && existing.UID == volume.UID) || (volume.UID == "" && existing.Name == volume.Name) {
+			volume.Status.DriverConfig = existing.Status.DriverConfig
+			break
+		}
+	}
+
+	return volume
+}
+
+// unary decodes the request into the input of the provided method, invokes
+// it and responds with its result.
+func unary[In, Out any](w http.ResponseWriter, r *http.Request, fn func(context.Context, *In) (*Out, error)) {
+	var in In
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("could not decode request: %w", err))
+		return
+	}
+
+	out, err := fn(r.Context(), &in)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	out, err = client.EncodeConfigs(out)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(w, http.StatusOK, out)
+}
+
+// stream decodes the request into the input of the provided method, invokes
+// it and responds with each of the entries it produces as newline-delimited
+// JSON until either the method's stream ends or the client disconnects.
+func stream[In, Out any](w http.ResponseWriter, r *http.Request, fn func(context.Context, *In) (chan Out, chan error, error)) {
+	var in In
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("could not decode request: %w", err))
+		return
+	}
+
+	ctx := r.Context()
+
+	events, errs, err := fn(ctx, &in)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	send := func(event client.StreamEvent[Out]) bool {
+		if err := encoder.Encode(event); err != nil {
+			return false
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		return true
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			event, err := client.EncodeConfigs(event)
+			if err != nil {
+				send(client.StreamEvent[Out]{Error: err.Error()})
+				return
+			}
+
+			if !send(client.StreamEvent[Out]{Object: event}) {
+				return
+			}
+
+		case err, ok := <-errs:
+			// The end of the stream is signalled to the client by closing it.
+			if !ok || errors.Is(err, io.EOF) {
+				return
+			}
+
+			send(client.StreamEvent[Out]{Error: err.Error()})
+			return
+		}
+	}
+}
+
+// respond with the provided object encoded as JSON.
+func respond(w http.ResponseWriter, status int, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+// fail responds with the provided error.
+func fail(w http.ResponseWriter, status int, err error) {
+	respond(w, status, client.Error{
+		Message: err.Error(),
+	})
+}
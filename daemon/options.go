@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	composev1 "kraftkit.sh/api/compose/v1"
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// DaemonOption is an option which configures the daemon.
+type DaemonOption func(*Daemon) error
+
+// WithSocket sets the path of the Unix socket the daemon serves at.
+func WithSocket(socket string) DaemonOption {
+	return func(daemon *Daemon) error {
+		daemon.socket = socket
+		return nil
+	}
+}
+
+// WithMachineV1alpha1Service sets the machine service which is used for the
+// provided platform, or for requests which do not specify a platform if empty.
+func WithMachineV1alpha1Service(platform string, service machinev1alpha1.MachineService) DaemonOption {
+	return func(daemon *Daemon) error {
+		daemon.machines[platform] = service
+		return nil
+	}
+}
+
+// WithNetworkV1alpha1Service sets the network service which is used for the
+// provided driver, or for requests which do not specify a driver if empty.
+func WithNetworkV1alpha1Service(driver string, service networkv1alpha1.NetworkService) DaemonOption {
+	return func(daemon *Daemon) error {
+		daemon.networks[driver] = service
+		return nil
+	}
+}
+
+// WithVolumeV1alpha1Service sets the volume service which is used for the
+// provided driver, or for requests which do not specify a driver if empty.
+func WithVolumeV1alpha1Service(driver string, service volumev1alpha1.VolumeService) DaemonOption {
+	return func(daemon *Daemon) error {
+		daemon.volumes[driver] = service
+		return nil
+	}
+}
+
+// WithComposeV1Service sets the compose service.
+func WithComposeV1Service(service composev1.ComposeService) DaemonOption {
+	return func(daemon *Daemon) error {
+		daemon.compose = service
+		return nil
+	}
+}
@@ -7,6 +7,7 @@ package exec
 import (
 	"fmt"
 	"io"
+	"os"
 )
 
 type ExecOptions struct {
@@ -18,6 +19,7 @@ type ExecOptions struct {
 	env       []string
 	callbacks []func(int)
 	detach    bool
+	files     []*os.File
 }
 
 type ExecOption func(eo *ExecOptions) error
@@ -112,6 +114,15 @@ func WithStderrCallback(stderrcb io.Writer) ExecOption {
 	}
 }
 
+// WithExtraFiles passes the provided open files to the process.  The first
+// file becomes file descriptor 3 of the process, the second 4 and so on.
+func WithExtraFiles(files ...*os.File) ExecOption {
+	return func(eo *ExecOptions) error {
+		eo.files = append(eo.files, files...)
+		return nil
+	}
+}
+
 func WithDetach(detach bool) ExecOption {
 	return func(eo *ExecOptions) error {
 		eo.detach = detach
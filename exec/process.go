@@ -107,6 +107,9 @@ func (e *Process) Start(ctx context.Context) error {
 	// Add any set environmental variables including the host's
 	e.cmd.Env = append(os.Environ(), e.opts.env...)
 
+	// Pass any additional open files
+	e.cmd.ExtraFiles = e.opts.files
+
 	log.G(ctx).Debug(e.Cmdline())
 
 	if e.opts.detach {
@@ -12,7 +12,13 @@ import (
 // New attempts to return the builder for a supplied path which
 // will allow the provided ...
 func New(ctx context.Context, path string, opts ...InitrdOption) (Initrd, error) {
-	if builder, err := NewFromOCIImage(ctx, path, opts...); err == nil {
+	// Images and tarballs on disk are preferred over remote image references,
+	// which most local paths would otherwise be mistaken for.
+	if builder, err := NewFromOCILayout(ctx, path, opts...); err == nil {
+		return builder, nil
+	} else if builder, err := NewFromTarball(ctx, path, opts...); err == nil {
+		return builder, nil
+	} else if builder, err := NewFromOCIImage(ctx, path, opts...); err == nil {
 		return builder, nil
 	} else if builder, err := NewFromDockerfile(ctx, path, opts...); err == nil {
 		return builder, nil
@@ -10,8 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/cavaliergopher/cpio"
 )
 
 type directory struct {
@@ -69,7 +67,11 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 
 	defer f.Close()
 
-	writer := cpio.NewWriter(f)
+	writer, err := newArchiveWriter(f, initrd.opts)
+	if err != nil {
+		return "", err
+	}
+
 	defer writer.Close()
 
 	if err := walkFiles(ctx, initrd.path, writer, &initrd.files); err != nil {
@@ -80,6 +82,8 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
 			return "", fmt.Errorf("could not compress files: %w", err)
 		}
+	} else if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %w", err)
 	}
 
 	return initrd.opts.output, nil
@@ -22,7 +22,6 @@ import (
 
 	sfile "github.com/anchore/stereoscope/pkg/file"
 	soci "github.com/anchore/stereoscope/pkg/image/oci"
-	"github.com/cavaliergopher/cpio"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session/filesync"
@@ -446,7 +445,11 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 
 	defer f.Close()
 
-	writer := cpio.NewWriter(f)
+	writer, err := newArchiveWriter(f, initrd.opts)
+	if err != nil {
+		return "", err
+	}
+
 	defer writer.Close()
 
 	if err := walkFiles(ctx, outputDir, writer, &initrd.files); err != nil {
@@ -457,6 +460,8 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
 			return "", fmt.Errorf("could not compress files: %w", err)
 		}
+	} else if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %w", err)
 	}
 
 	return initrd.opts.output, nil
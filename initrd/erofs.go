@@ -0,0 +1,360 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+const (
+	erofsMagic            = 0xE0F5E1E2
+	erofsSuperblockOffset = 1024
+	erofsBlockBits        = 12
+	erofsBlockSize        = 1 << erofsBlockBits
+	erofsInodeSize        = 64
+	erofsSlotSize         = 32
+	erofsDirentSize       = 12
+	erofsNameLen          = 255
+
+	erofsInodeExtended    = 1
+	erofsLayoutFlatPlain  = 0
+	erofsLayoutFlatInline = 2
+
+	erofsFileTypeReg     = 1
+	erofsFileTypeDir     = 2
+	erofsFileTypeSymlink = 7
+
+	// erofsNullAddr is the block address of inodes without data blocks.
+	erofsNullAddr = math.MaxUint32
+)
+
+// erofsWriter serializes a root filesystem as an uncompressed EROFS image.  The
+// data of files which span at least one block is written as it is received,
+// whereas that of smaller files is kept in memory and inlined with their inode
+// in the metadata area, which is written after all data once the writer is
+// closed.
+type erofsWriter struct {
+	f       *os.File
+	tree    *imageTree
+	blocks  int64
+	written int64
+	closed  bool
+}
+
+func newErofsWriter(f *os.File) *erofsWriter {
+	return &erofsWriter{
+		f:    f,
+		tree: newImageTree(),
+
+		// The first block holds the superblock.
+		blocks: 1,
+	}
+}
+
+// erofsBlocks returns the number of blocks which hold size bytes.
+func erofsBlocks(size int64) int64 {
+	return (size + erofsBlockSize - 1) / erofsBlockSize
+}
+
+// WriteHeader implements archiveWriter.
+func (w *erofsWriter) WriteHeader(hdr *cpio.Header) error {
+	if w.closed {
+		return cpio.ErrWriteAfterClose
+	}
+
+	node, err := w.tree.begin(hdr)
+	if err != nil {
+		return err
+	}
+
+	node.start = w.blocks
+	w.written = 0
+
+	if node.size >= erofsBlockSize {
+		w.blocks += erofsBlocks(node.size)
+	}
+
+	return nil
+}
+
+// Write implements archiveWriter.
+func (w *erofsWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, cpio.ErrWriteAfterClose
+	}
+
+	data, err := w.tree.consume(p)
+	if err != nil {
+		return 0, err
+	}
+
+	node := w.tree.cur
+	if node.size < erofsBlockSize {
+		node.data = append(node.data, data...)
+		return len(p), nil
+	}
+
+	if _, err := w.f.WriteAt(data, node.start*erofsBlockSize+w.written); err != nil {
+		return 0, err
+	}
+
+	w.written += int64(len(data))
+
+	return len(p), nil
+}
+
+// erofsDirent is an entry of a directory of an EROFS image.
+type erofsDirent struct {
+	name string
+	node *treeNode
+}
+
+// erofsInode describes how an entry of the root filesystem is laid out in an
+// EROFS image.
+type erofsInode struct {
+	node    *treeNode
+	nid     uint64
+	layout  uint16
+	size    int64
+	blkaddr uint32
+	dirents [][]erofsDirent
+
+	// content is the data of the entry which is written on close, either
+	// inlined with the inode or in the blocks starting at blkaddr.
+	content []byte
+}
+
+// Close implements archiveWriter.
+func (w *erofsWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if err := w.tree.end(); err != nil {
+		return err
+	}
+
+	var inodes []*erofsInode
+	byNode := map[*treeNode]*erofsInode{}
+	var mtime int64
+
+	// Directories, symbolic links and small files are stored inline with their
+	// inode unless they do not fit within a block alongside it.
+	if err := w.tree.root.walk(func(node *treeNode) error {
+		inode := &erofsInode{
+			node:    node,
+			layout:  erofsLayoutFlatPlain,
+			size:    node.size,
+			blkaddr: erofsNullAddr,
+		}
+
+		switch {
+		case node.isDir():
+			dirents, size, err := erofsDirents(node)
+			if err != nil {
+				return err
+			}
+
+			inode.dirents = dirents
+			inode.size = size
+		case node.isSymlink():
+			inode.content = []byte(node.target)
+			inode.size = int64(len(inode.content))
+		case node.size < erofsBlockSize:
+			inode.content = node.data
+		default:
+			inode.blkaddr = uint32(node.start)
+		}
+
+		if inode.blkaddr == erofsNullAddr && inode.size > 0 {
+			if inode.size <= erofsBlockSize-erofsInodeSize {
+				inode.layout = erofsLayoutFlatInline
+			} else {
+				inode.blkaddr = uint32(w.blocks)
+				w.blocks += erofsBlocks(inode.size)
+			}
+		}
+
+		mtime = max(mtime, node.mtime)
+		inodes = append(inodes, inode)
+		byNode[node] = inode
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if w.blocks > math.MaxUint32 {
+		return fmt.Errorf("filesystem too large")
+	}
+
+	// Lay out the metadata area which succeeds the data.  Inodes are aligned to
+	// slots and none crosses a block boundary along with its inline data.  The
+	// root directory comes first since its number has to fit 16 bits.
+	metaBlkaddr := w.blocks
+	var off int64
+	for _, inode := range inodes {
+		isize := int64(erofsInodeSize)
+		if inode.layout == erofsLayoutFlatInline {
+			isize += inode.size
+		}
+
+		if off%erofsBlockSize+isize > erofsBlockSize {
+			off = erofsBlocks(off) * erofsBlockSize
+		}
+
+		inode.nid = uint64(off / erofsSlotSize)
+		off += (isize + erofsSlotSize - 1) / erofsSlotSize * erofsSlotSize
+	}
+
+	meta := make([]byte, erofsBlocks(off)*erofsBlockSize)
+
+	for i, inode := range inodes {
+		node := inode.node
+
+		if node.isDir() {
+			inode.content = erofsDirContent(inode.dirents, byNode)
+		}
+
+		if inode.layout == erofsLayoutFlatPlain && len(inode.content) > 0 {
+			if _, err := w.f.WriteAt(inode.content, int64(inode.blkaddr)*erofsBlockSize); err != nil {
+				return err
+			}
+		}
+
+		nlink := uint32(1)
+		if node.isDir() {
+			nlink = uint32(2 + node.subdirs())
+		}
+
+		b := meta[inode.nid*erofsSlotSize:]
+		binary.LittleEndian.PutUint16(b[0:], erofsInodeExtended|inode.layout<<1)
+		binary.LittleEndian.PutUint16(b[4:], uint16(node.mode))
+		binary.LittleEndian.PutUint64(b[8:], uint64(inode.size))
+		binary.LittleEndian.PutUint32(b[16:], inode.blkaddr)
+		binary.LittleEndian.PutUint32(b[20:], uint32(i+1))
+		binary.LittleEndian.PutUint32(b[24:], node.uid)
+		binary.LittleEndian.PutUint32(b[28:], node.gid)
+		binary.LittleEndian.PutUint64(b[32:], uint64(node.mtime))
+		binary.LittleEndian.PutUint32(b[44:], nlink)
+
+		if inode.layout == erofsLayoutFlatInline {
+			copy(b[erofsInodeSize:], inode.content)
+		}
+	}
+
+	if _, err := w.f.WriteAt(meta, metaBlkaddr*erofsBlockSize); err != nil {
+		return err
+	}
+
+	blocks := metaBlkaddr + int64(len(meta))/erofsBlockSize
+	if blocks > math.MaxUint32 {
+		return fmt.Errorf("filesystem too large")
+	}
+
+	sb := make([]byte, 128)
+	binary.LittleEndian.PutUint32(sb[0:], erofsMagic)
+	sb[12] = erofsBlockBits
+	binary.LittleEndian.PutUint16(sb[14:], uint16(byNode[w.tree.root].nid))
+	binary.LittleEndian.PutUint64(sb[16:], uint64(len(inodes)))
+	binary.LittleEndian.PutUint64(sb[24:], uint64(mtime))
+	binary.LittleEndian.PutUint32(sb[36:], uint32(blocks))
+	binary.LittleEndian.PutUint32(sb[40:], uint32(metaBlkaddr))
+
+	if _, err := w.f.WriteAt(sb, erofsSuperblockOffset); err != nil {
+		return err
+	}
+
+	return w.f.Truncate(blocks * erofsBlockSize)
+}
+
+// erofsDirents returns the entries of a directory, including those referring
+// to itself and its parent, packed into blocks as well as the resulting size of
+// the directory.
+func erofsDirents(node *treeNode) ([][]erofsDirent, int64, error) {
+	parent := node.parent
+	if parent == nil {
+		parent = node
+	}
+
+	entries := []erofsDirent{
+		{name: ".", node: node},
+		{name: "..", node: parent},
+	}
+
+	for _, child := range node.children {
+		if len(child.name) > erofsNameLen {
+			return nil, 0, fmt.Errorf("name too long: %s", child.name)
+		}
+
+		entries = append(entries, erofsDirent{name: child.name, node: child})
+	}
+
+	// Entries are looked up by a binary search over all blocks.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	var blocks [][]erofsDirent
+	var used int64
+	for _, entry := range entries {
+		need := int64(erofsDirentSize + len(entry.name))
+		if len(blocks) == 0 || used+need > erofsBlockSize {
+			blocks = append(blocks, nil)
+			used = 0
+		}
+
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], entry)
+		used += need
+	}
+
+	return blocks, int64(len(blocks)-1)*erofsBlockSize + used, nil
+}
+
+// erofsDirContent serializes the blocks of a directory.  All but the last
+// block are padded such that the names of their last entries are terminated.
+func erofsDirContent(blocks [][]erofsDirent, byNode map[*treeNode]*erofsInode) []byte {
+	var content []byte
+
+	for i, block := range blocks {
+		start := len(content)
+		nameoff := len(block) * erofsDirentSize
+
+		for _, entry := range block {
+			var typ byte
+			switch {
+			case entry.node.isDir():
+				typ = erofsFileTypeDir
+			case entry.node.isSymlink():
+				typ = erofsFileTypeSymlink
+			default:
+				typ = erofsFileTypeReg
+			}
+
+			content = binary.LittleEndian.AppendUint64(content, byNode[entry.node].nid)
+			content = binary.LittleEndian.AppendUint16(content, uint16(nameoff))
+			content = append(content, typ, 0)
+			nameoff += len(entry.name)
+		}
+
+		for _, entry := range block {
+			content = append(content, entry.name...)
+		}
+
+		if i < len(blocks)-1 {
+			content = append(content, make([]byte, erofsBlockSize-(len(content)-start))...)
+		}
+	}
+
+	return content
+}
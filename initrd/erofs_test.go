@@ -0,0 +1,414 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kraftkit.sh/initrd"
+)
+
+// erofsEntry is a file, directory or symbolic link of a root filesystem as
+// found in its source directory or read back from its EROFS image.
+type erofsEntry struct {
+	mode    fs.FileMode
+	content string
+}
+
+// erofsSource populates a directory with a root filesystem which spans
+// multiple blocks: a file whose data is stored in blocks of its own, a file
+// which just fits inline with its inode and a directory whose entries and
+// inodes exceed a block.
+func erofsSource(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	files := map[string]struct {
+		perm    fs.FileMode
+		content string
+	}{
+		"entrypoint.sh":         {0o755, "#!/bin/sh\nexec /bin/app\n"},
+		"etc/app.conf":          {0o600, "key=value\n"},
+		"usr/lib/large.bin":     {0o644, strings.Repeat("0123456789abcdef", 1000)},
+		"usr/lib/inline.bin":    {0o644, strings.Repeat("x", 4096-64)},
+		"usr/share/empty":       {0o400, ""},
+		"var/lib/app/README.md": {0o644, "state\n"},
+	}
+
+	for i := 0; i < 300; i++ {
+		files[fmt.Sprintf("usr/share/data/entry-with-a-rather-long-name-%03d", i)] = struct {
+			perm    fs.FileMode
+			content string
+		}{0o644, fmt.Sprintf("%d\n", i)}
+	}
+
+	for name, file := range files {
+		path := filepath.Join(root, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(file.content), file.perm); err != nil {
+			t.Fatal(err)
+		}
+
+		// The permissions of created files are subject to the umask.
+		if err := os.Chmod(path, file.perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Chmod(filepath.Join(root, "var/lib/app"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for link, target := range map[string]string{
+		"usr/lib/large.so": "large.bin",
+		"bin/app":          "/entrypoint.sh",
+	} {
+		path := filepath.Join(root, link)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+// erofsSourceEntries returns the entries of the provided source directory.
+func erofsSourceEntries(t *testing.T, root string) map[string]erofsEntry {
+	t.Helper()
+
+	entries := map[string]erofsEntry{}
+
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := erofsEntry{mode: fi.Mode()}
+
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			entry.content, err = os.Readlink(path)
+		case fi.Mode().IsRegular():
+			var b []byte
+			b, err = os.ReadFile(path)
+			entry.content = string(b)
+		}
+		if err != nil {
+			return err
+		}
+
+		entries[filepath.ToSlash(name)] = entry
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return entries
+}
+
+// erofsImage reads uncompressed EROFS images independently of the writer, such
+// that images are checked against the on-disk format rather than against the
+// assumptions of the writer.
+type erofsImage struct {
+	b           []byte
+	blockSize   int64
+	metaBlkaddr int64
+}
+
+// erofsInode is an inode read back from an EROFS image.
+type erofsInode struct {
+	mode uint16
+	data []byte
+}
+
+func (img *erofsImage) inode(nid uint64) (*erofsInode, error) {
+	off := img.metaBlkaddr*img.blockSize + int64(nid)*32
+	if off+32 > int64(len(img.b)) {
+		return nil, fmt.Errorf("inode %d out of bounds", nid)
+	}
+
+	b := img.b[off:]
+	format := binary.LittleEndian.Uint16(b[0:])
+	layout := (format >> 1) & 0x7
+	xattrCount := int64(binary.LittleEndian.Uint16(b[2:]))
+
+	inode := &erofsInode{
+		mode: binary.LittleEndian.Uint16(b[4:]),
+	}
+
+	var isize, size int64
+	blkaddr := int64(binary.LittleEndian.Uint32(b[16:]))
+	if format&1 == 1 {
+		isize, size = 64, int64(binary.LittleEndian.Uint64(b[8:]))
+	} else {
+		isize, size = 32, int64(binary.LittleEndian.Uint32(b[8:]))
+	}
+
+	if xattrCount > 0 {
+		isize += 12 + (xattrCount-1)*4
+	}
+
+	switch layout {
+	case 0: // EROFS_INODE_FLAT_PLAIN
+		if size == 0 {
+			return inode, nil
+		}
+
+		start := blkaddr * img.blockSize
+		if start+size > int64(len(img.b)) {
+			return nil, fmt.Errorf("data of inode %d out of bounds", nid)
+		}
+
+		inode.data = img.b[start : start+size]
+
+	case 2: // EROFS_INODE_FLAT_INLINE
+		head := size / img.blockSize * img.blockSize
+		tail := size - head
+
+		if head > 0 {
+			start := blkaddr * img.blockSize
+			inode.data = append(inode.data, img.b[start:start+head]...)
+		}
+
+		if (off%img.blockSize)+isize+tail > img.blockSize {
+			return nil, fmt.Errorf("inline data of inode %d crosses a block boundary", nid)
+		}
+
+		inode.data = append(inode.data, img.b[off+isize:off+isize+tail]...)
+
+	default:
+		return nil, fmt.Errorf("unsupported layout %d of inode %d", layout, nid)
+	}
+
+	return inode, nil
+}
+
+// dirents returns the names of the entries of a directory in the order they
+// are stored in along with their inode numbers and file types.
+func (img *erofsImage) dirents(data []byte) ([]string, map[string]uint64, map[string]byte) {
+	var names []string
+	nids := map[string]uint64{}
+	types := map[string]byte{}
+
+	for start := int64(0); start < int64(len(data)); start += img.blockSize {
+		block := data[start:min(start+img.blockSize, int64(len(data)))]
+		count := int(binary.LittleEndian.Uint16(block[8:])) / 12
+
+		for i := 0; i < count; i++ {
+			dirent := block[i*12:]
+			nameoff := int(binary.LittleEndian.Uint16(dirent[8:]))
+
+			nameend := len(block)
+			if i < count-1 {
+				nameend = int(binary.LittleEndian.Uint16(block[(i+1)*12+8:]))
+			}
+
+			name := string(block[nameoff:nameend])
+			if i == count-1 {
+				name, _, _ = strings.Cut(name, "\x00")
+			}
+
+			names = append(names, name)
+			nids[name] = binary.LittleEndian.Uint64(dirent[0:])
+			types[name] = dirent[10]
+		}
+	}
+
+	return names, nids, types
+}
+
+// walk reads back all entries below the directory with the provided inode
+// number.
+func (img *erofsImage) walk(prefix string, nid, parent uint64, entries map[string]erofsEntry) error {
+	dir, err := img.inode(nid)
+	if err != nil {
+		return err
+	}
+
+	names, nids, types := img.dirents(dir.data)
+
+	// Entries are looked up by a binary search over all blocks.
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			return fmt.Errorf("directory '%s': entries are not sorted: '%s' precedes '%s'", prefix, names[i-1], names[i])
+		}
+	}
+
+	if nids["."] != nid || nids[".."] != parent {
+		return fmt.Errorf("directory '%s': unexpected '.' or '..'", prefix)
+	}
+
+	for _, name := range names {
+		if name == "." || name == ".." {
+			continue
+		}
+
+		path := strings.TrimPrefix(prefix+"/"+name, "/")
+
+		inode, err := img.inode(nids[name])
+		if err != nil {
+			return err
+		}
+
+		entry := erofsEntry{
+			mode: fs.FileMode(inode.mode & 0o777),
+		}
+
+		var typ byte
+		switch inode.mode & 0o170000 {
+		case 0o040000:
+			typ = 2 // EROFS_FT_DIR
+			entry.mode |= fs.ModeDir
+			if err := img.walk(path, nids[name], nid, entries); err != nil {
+				return err
+			}
+		case 0o120000:
+			typ = 7 // EROFS_FT_SYMLINK
+			entry.mode |= fs.ModeSymlink
+			entry.content = string(inode.data)
+		case 0o100000:
+			typ = 1 // EROFS_FT_REG_FILE
+			entry.content = string(inode.data)
+		default:
+			return fmt.Errorf("'%s': unexpected mode %o", path, inode.mode)
+		}
+
+		if types[name] != typ {
+			return fmt.Errorf("'%s': file type %d of entry does not match mode %o", path, types[name], inode.mode)
+		}
+
+		entries[path] = entry
+	}
+
+	return nil
+}
+
+// readErofs reads back all entries of the provided EROFS image.
+func readErofs(path string) (map[string]erofsEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := b[1024:]
+	if magic := binary.LittleEndian.Uint32(sb[0:]); magic != 0xE0F5E1E2 {
+		return nil, fmt.Errorf("unexpected magic %#x", magic)
+	}
+
+	img := &erofsImage{
+		b:           b,
+		blockSize:   1 << sb[12],
+		metaBlkaddr: int64(binary.LittleEndian.Uint32(sb[40:])),
+	}
+
+	if blocks := int64(binary.LittleEndian.Uint32(sb[36:])); blocks*img.blockSize != int64(len(b)) {
+		return nil, fmt.Errorf("image of %d bytes does not match %d blocks", len(b), blocks)
+	}
+
+	root := uint64(binary.LittleEndian.Uint16(sb[14:]))
+	entries := map[string]erofsEntry{}
+
+	if err := img.walk("", root, root, entries); err != nil {
+		return nil, err
+	}
+
+	if inodes := binary.LittleEndian.Uint64(sb[16:]); inodes != uint64(len(entries)+1) {
+		return nil, fmt.Errorf("expected %d inodes, superblock records %d", len(entries)+1, inodes)
+	}
+
+	return entries, nil
+}
+
+func TestOutputFormatErofs(t *testing.T) {
+	root := erofsSource(t)
+
+	ird, err := initrd.NewFromDirectory(context.Background(), root,
+		initrd.WithOutput(filepath.Join(t.TempDir(), "rootfs.erofs")),
+		initrd.WithOutputFormat(initrd.FormatErofs),
+	)
+	if err != nil {
+		t.Fatal("NewFromDirectory:", err)
+	}
+
+	irdPath, err := ird.Build(context.Background())
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+
+	t.Run("read back", func(t *testing.T) {
+		got, err := readErofs(irdPath)
+		if err != nil {
+			t.Fatal("Failed to read EROFS image:", err)
+		}
+
+		expect := erofsSourceEntries(t, root)
+
+		for name, entry := range expect {
+			if _, ok := got[name]; !ok {
+				t.Errorf("entry [%s]: missing", name)
+			} else if got[name].mode != entry.mode {
+				t.Errorf("entry [%s]: got mode %s, expected %s", name, got[name].mode, entry.mode)
+			} else if got[name].content != entry.content {
+				t.Errorf("entry [%s]: got %d bytes of content, expected %d", name, len(got[name].content), len(entry.content))
+			}
+		}
+
+		for name := range got {
+			if _, ok := expect[name]; !ok {
+				t.Errorf("entry [%s]: unexpected", name)
+			}
+		}
+	})
+
+	t.Run("fsck.erofs", func(t *testing.T) {
+		fsck, err := exec.LookPath("fsck.erofs")
+		if err != nil {
+			t.Skip("fsck.erofs not found")
+		}
+
+		extracted := filepath.Join(t.TempDir(), "rootfs")
+
+		if out, err := exec.Command(fsck, "--extract="+extracted, irdPath).CombinedOutput(); err != nil {
+			t.Fatalf("fsck.erofs: %v: %s", err, out)
+		}
+
+		got := erofsSourceEntries(t, extracted)
+
+		for name, entry := range erofsSourceEntries(t, root) {
+			if got[name] != entry {
+				t.Errorf("entry [%s]: extracted %+v, expected %+v", name, got[name], entry)
+			}
+		}
+	})
+}
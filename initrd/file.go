@@ -18,8 +18,9 @@ type file struct {
 	files []string
 }
 
-// NewFromFile accepts an input file which already represents a CPIO archive and
-// is provided as a mechanism for satisfying the Initrd interface.
+// NewFromFile accepts an input file which already represents a CPIO archive,
+// or an EROFS or squashfs image, and is provided as a mechanism for satisfying
+// the Initrd interface.
 func NewFromFile(_ context.Context, path string, opts ...InitrdOption) (Initrd, error) {
 	fi, err := os.Open(path)
 	if err != nil {
@@ -39,6 +40,11 @@ func NewFromFile(_ context.Context, path string, opts ...InitrdOption) (Initrd,
 		}
 	}
 
+	// The files of images are not listed since they are not extracted.
+	if format, err := DetectFormat(path); err == nil && format.IsImage() {
+		return &initrd, nil
+	}
+
 	reader := cpio.NewReader(fi)
 
 	// Iterate through the files in the archive.
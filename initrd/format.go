@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// Format is the format in which the root filesystem is serialized.
+type Format string
+
+const (
+	// FormatCpio serializes the root filesystem as a newc CPIO archive, which is
+	// extracted into the memory of the unikernel at boot.
+	FormatCpio = Format("cpio")
+
+	// FormatErofs serializes the root filesystem as an uncompressed EROFS image,
+	// which is attached to the unikernel as a read-only block device.
+	FormatErofs = Format("erofs")
+
+	// FormatSquashfs serializes the root filesystem as a gzip-compressed
+	// squashfs image, which is attached to the unikernel as a read-only block
+	// device.
+	FormatSquashfs = Format("squashfs")
+)
+
+// DefaultFormat is the format in which the root filesystem is serialized when
+// unspecified.
+const DefaultFormat = FormatCpio
+
+// Formats returns the list of supported formats.
+func Formats() []Format {
+	return []Format{
+		FormatCpio,
+		FormatErofs,
+		FormatSquashfs,
+	}
+}
+
+// String implements fmt.Stringer
+func (format Format) String() string {
+	return string(format)
+}
+
+// IsImage returns whether the format is that of a filesystem image which is
+// attached to the unikernel as a block device rather than extracted into its
+// memory.
+func (format Format) IsImage() bool {
+	return format == FormatErofs || format == FormatSquashfs
+}
+
+// ArchFileName returns the default filename used when creating or serializing
+// the root filesystem in the provided format for a specific architecture.
+func ArchFileName(format Format, arch string) string {
+	switch format {
+	case FormatErofs:
+		return fmt.Sprintf(DefaultErofsArchFileName, arch)
+	case FormatSquashfs:
+		return fmt.Sprintf(DefaultSquashfsArchFileName, arch)
+	default:
+		return fmt.Sprintf(DefaultInitramfsArchFileName, arch)
+	}
+}
+
+// DetectFormat returns the format of the root filesystem at the provided path.
+// Compressed CPIO archives are reported as FormatCpio.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	buf := make([]byte, erofsSuperblockOffset+4)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	buf = buf[:n]
+
+	switch {
+	case len(buf) >= 4 && binary.LittleEndian.Uint32(buf) == squashfsMagic:
+		return FormatSquashfs, nil
+	case len(buf) >= erofsSuperblockOffset+4 && binary.LittleEndian.Uint32(buf[erofsSuperblockOffset:]) == erofsMagic:
+		return FormatErofs, nil
+	case bytes.HasPrefix(buf, []byte("070701")),
+		bytes.HasPrefix(buf, []byte("070702")),
+		bytes.HasPrefix(buf, []byte{0x1f, 0x8b}):
+		return FormatCpio, nil
+	}
+
+	return "", fmt.Errorf("unrecognized root filesystem format: %s", path)
+}
+
+// archiveWriter serializes the entries of the root filesystem in the order in
+// which they are walked.  Each header is followed by the contents of the entry,
+// i.e. the data of regular files and the target of symbolic links.
+type archiveWriter interface {
+	WriteHeader(*cpio.Header) error
+	Write([]byte) (int, error)
+	Close() error
+}
+
+// newArchiveWriter returns the writer which serializes the root filesystem in
+// the format of the provided options to f.
+func newArchiveWriter(f *os.File, opts InitrdOptions) (archiveWriter, error) {
+	if opts.compress && opts.format.IsImage() {
+		return nil, fmt.Errorf("compression is not supported for %s root filesystems", opts.format)
+	}
+
+	switch opts.format {
+	case "", FormatCpio:
+		return cpio.NewWriter(f), nil
+	case FormatErofs:
+		return newErofsWriter(f), nil
+	case FormatSquashfs:
+		return newSquashfsWriter(f), nil
+	default:
+		return nil, fmt.Errorf("unsupported root filesystem format: %s", opts.format)
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sylabs/squashfs"
+
+	"kraftkit.sh/initrd"
+)
+
+func TestOutputFormat(t *testing.T) {
+	const rootDir = "testdata/rootfs"
+
+	ctx := context.Background()
+
+	for _, format := range initrd.Formats() {
+		t.Run(format.String(), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), initrd.ArchFileName(format, "x86_64"))
+
+			ird, err := initrd.NewFromDirectory(ctx, rootDir,
+				initrd.WithOutput(output),
+				initrd.WithOutputFormat(format),
+			)
+			if err != nil {
+				t.Fatal("NewFromDirectory:", err)
+			}
+
+			if _, err := ird.Build(ctx); err != nil {
+				t.Fatal("Build:", err)
+			}
+
+			detected, err := initrd.DetectFormat(output)
+			if err != nil {
+				t.Fatal("DetectFormat:", err)
+			}
+
+			if detected != format {
+				t.Errorf("expected format %s, got %s", format, detected)
+			}
+
+			if !format.IsImage() {
+				return
+			}
+
+			fi, err := os.Stat(output)
+			if err != nil {
+				t.Fatal("Stat:", err)
+			}
+
+			if fi.Size()%4096 != 0 {
+				t.Errorf("expected image to be padded to a multiple of 4096 bytes, got %d", fi.Size())
+			}
+		})
+	}
+}
+
+func TestOutputFormatSquashfs(t *testing.T) {
+	const rootDir = "testdata/rootfs"
+
+	ctx := context.Background()
+
+	ird, err := initrd.NewFromDirectory(ctx, rootDir,
+		initrd.WithOutput(filepath.Join(t.TempDir(), "rootfs.squashfs")),
+		initrd.WithOutputFormat(initrd.FormatSquashfs),
+	)
+	if err != nil {
+		t.Fatal("NewFromDirectory:", err)
+	}
+
+	irdPath, err := ird.Build(ctx)
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+
+	f, err := os.Open(irdPath)
+	if err != nil {
+		t.Fatal("Failed to open image:", err)
+	}
+
+	defer f.Close()
+
+	r, err := squashfs.NewReader(f)
+	if err != nil {
+		t.Fatal("Failed to read squashfs image:", err)
+	}
+
+	for _, name := range []string{
+		"entrypoint.sh",
+		"etc/app.conf",
+		"lib/libtest.so.1.0.0",
+	} {
+		expect, err := os.ReadFile(filepath.Join(rootDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := r.ReadFile(name)
+		if err != nil {
+			t.Errorf("file [%s]: %v", name, err)
+			continue
+		}
+
+		if string(got) != string(expect) {
+			t.Errorf("file [%s]: got contents %q, expected %q", name, got, expect)
+		}
+	}
+
+	entries, err := fs.ReadDir(r, "lib")
+	if err != nil {
+		t.Fatal("Failed to read directory:", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries in lib, got %d", len(entries))
+	}
+
+	link, err := r.Open("lib/libtest.so.1")
+	if err != nil {
+		t.Fatal("Failed to open symbolic link:", err)
+	}
+
+	if target := link.(*squashfs.File).SymlinkPath(); target != "libtest.so.1.0.0" {
+		t.Errorf("expected symbolic link to libtest.so.1.0.0, got %q", target)
+	}
+}
+
+func TestOutputFormatCompression(t *testing.T) {
+	ird, err := initrd.NewFromDirectory(context.Background(), "testdata/rootfs",
+		initrd.WithOutput(filepath.Join(t.TempDir(), "rootfs.erofs")),
+		initrd.WithOutputFormat(initrd.FormatErofs),
+		initrd.WithCompression(true),
+	)
+	if err != nil {
+		t.Fatal("NewFromDirectory:", err)
+	}
+
+	if _, err := ird.Build(context.Background()); err == nil {
+		t.Error("expected compressing an EROFS image to fail")
+	}
+}
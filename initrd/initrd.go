@@ -14,6 +14,14 @@ const (
 	// DefaultInitramfsArchFileName is the default filename used when creating
 	// or serializing a CPIO archive based on a specific architecture
 	DefaultInitramfsArchFileName = "initramfs-%s.cpio"
+
+	// DefaultErofsArchFileName is the default filename used when creating or
+	// serializing an EROFS image based on a specific architecture.
+	DefaultErofsArchFileName = "initramfs-%s.erofs"
+
+	// DefaultSquashfsArchFileName is the default filename used when creating or
+	// serializing a squashfs image based on a specific architecture.
+	DefaultSquashfsArchFileName = "initramfs-%s.squashfs"
 )
 
 // Initrd is an interface that is used to allow for different underlying
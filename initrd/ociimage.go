@@ -36,7 +36,7 @@ type ociimage struct {
 
 // NewFromOCIImage creates a new initrd from a remote container image.
 func NewFromOCIImage(ctx context.Context, path string, opts ...InitrdOption) (Initrd, error) {
-	if !strings.Contains("://", path) {
+	if !strings.Contains(path, "://") {
 		path = fmt.Sprintf("docker://%s", path)
 	}
 
@@ -156,7 +156,10 @@ func (initrd *ociimage) Build(ctx context.Context) (string, error) {
 		_ = f.Close()
 	}()
 
-	writer := cpio.NewWriter(f)
+	writer, err := newArchiveWriter(f, initrd.opts)
+	if err != nil {
+		return "", err
+	}
 
 	defer func() {
 		_ = writer.Close()
@@ -245,6 +248,8 @@ func (initrd *ociimage) Build(ctx context.Context) (string, error) {
 		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
 			return "", fmt.Errorf("could not compress files: %w", err)
 		}
+	} else if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %w", err)
 	}
 
 	return initrd.opts.output, nil
@@ -0,0 +1,354 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/compression"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// dockerArchiveManifestFile is the file of a tarball created by `docker save`
+	// which lists the images within it.
+	dockerArchiveManifestFile = "manifest.json"
+
+	// ociLayoutMaxSymlinks is the maximum number of symbolic links which are
+	// followed when opening a file of a tarball.
+	ociLayoutMaxSymlinks = 16
+)
+
+type ocilayout struct {
+	opts   InitrdOptions
+	path   string
+	open   func(name string) (io.ReadCloser, error)
+	layers []string
+	args   []string
+	env    []string
+	files  []string
+}
+
+// dockerArchiveManifest is an entry of the manifest.json file of a tarball
+// created by `docker save`.
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// NewFromOCILayout returns an instantiated Initrd interface which is able to
+// serialize a rootfs from an OCI image which is available locally, either as a
+// directory in the OCI image layout, as a tarball thereof or as a tarball
+// created by `docker save`.  No registry is contacted.
+func NewFromOCILayout(_ context.Context, path string, opts ...InitrdOption) (Initrd, error) {
+	initrd := ocilayout{
+		opts: InitrdOptions{},
+		path: path,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&initrd.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not check path: %w", err)
+	}
+
+	if fi.IsDir() {
+		initrd.open = func(name string) (io.ReadCloser, error) {
+			return os.Open(filepath.Join(path, filepath.FromSlash(name)))
+		}
+	} else if hasTarballFile(path, ocispec.ImageLayoutFile, dockerArchiveManifestFile) {
+		initrd.open = func(name string) (io.ReadCloser, error) {
+			return openTarballFile(path, name)
+		}
+	} else {
+		return nil, fmt.Errorf("supplied path is neither a directory nor a tarball: %s", path)
+	}
+
+	var config string
+	if err := initrd.readJSON(ocispec.ImageLayoutFile, &ocispec.ImageLayout{}); err == nil {
+		var index ocispec.Index
+		if err := initrd.readJSON(ocispec.ImageIndexFile, &index); err != nil {
+			return nil, fmt.Errorf("could not read image index: %w", err)
+		}
+
+		desc, err := initrd.selectManifest(index.Manifests)
+		if err != nil {
+			return nil, err
+		}
+
+		image, err := initrd.resolveManifest(desc)
+		if err != nil {
+			return nil, err
+		}
+
+		config = blobPath(image.Config)
+		for _, layer := range image.Layers {
+			initrd.layers = append(initrd.layers, blobPath(layer))
+		}
+	} else {
+		var manifests []dockerArchiveManifest
+		if err := initrd.readJSON(dockerArchiveManifestFile, &manifests); err != nil {
+			return nil, fmt.Errorf("supplied path is not an OCI image layout or docker archive: %s", path)
+		} else if len(manifests) == 0 {
+			return nil, fmt.Errorf("no images in docker archive: %s", path)
+		}
+
+		config = manifests[0].Config
+		initrd.layers = manifests[0].Layers
+	}
+
+	var image ocispec.Image
+	if err := initrd.readJSON(config, &image); err != nil {
+		return nil, fmt.Errorf("could not read image configuration: %w", err)
+	}
+
+	initrd.args = image.Config.Entrypoint
+	initrd.args = append(initrd.args, image.Config.Cmd...)
+	initrd.env = image.Config.Env
+
+	return &initrd, nil
+}
+
+// hasTarballFile returns whether the, possibly compressed, tarball at the
+// provided path contains any of the provided files.
+func hasTarballFile(archive string, names ...string) bool {
+	reader, err := openTarball(archive)
+	if err != nil {
+		return false
+	}
+
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return false
+		}
+
+		if slices.Contains(names, path.Clean(hdr.Name)) {
+			return true
+		}
+	}
+}
+
+// openTarballFile opens the regular file with the provided name within the
+// tarball at the provided path.  Symbolic links, as used by `docker save` to
+// deduplicate layers, are followed.
+func openTarballFile(archive, name string) (io.ReadCloser, error) {
+	name = path.Clean(name)
+
+	for i := 0; i < ociLayoutMaxSymlinks; i++ {
+		reader, err := openTarball(archive)
+		if err != nil {
+			return nil, err
+		}
+
+		var target string
+		tr := tar.NewReader(reader)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				reader.Close()
+				return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+			} else if err != nil {
+				reader.Close()
+				return nil, fmt.Errorf("could not read tar header: %w", err)
+			}
+
+			if path.Clean(hdr.Name) != name {
+				continue
+			}
+
+			if hdr.Typeflag == tar.TypeReg {
+				return &tarballReader{ReadCloser: io.NopCloser(tr), closer: reader}, nil
+			} else if hdr.Typeflag == tar.TypeSymlink {
+				target = path.Join(path.Dir(name), hdr.Linkname)
+				break
+			}
+
+			reader.Close()
+			return nil, fmt.Errorf("%s: not a regular file", name)
+		}
+
+		reader.Close()
+		name = target
+	}
+
+	return nil, fmt.Errorf("%s: too many levels of symbolic links", name)
+}
+
+// readJSON decodes the file with the provided name of the image into v.
+func (initrd *ocilayout) readJSON(name string, v any) error {
+	reader, err := initrd.open(name)
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	return json.NewDecoder(reader).Decode(v)
+}
+
+// blobPath returns the path of the blob described by the provided descriptor
+// within an OCI image layout.
+func blobPath(desc ocispec.Descriptor) string {
+	return path.Join(ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+}
+
+// selectManifest returns the descriptor of the manifest of an index which
+// matches the platform of the initrd.
+func (initrd *ocilayout) selectManifest(manifests []ocispec.Descriptor) (ocispec.Descriptor, error) {
+	arch := initrd.opts.arch
+	if arch == "x86_64" {
+		arch = "amd64"
+	}
+
+	for _, desc := range manifests {
+		if desc.Platform == nil {
+			return desc, nil
+		}
+
+		// Attestations are stored with an unknown platform.
+		if desc.Platform.OS != "linux" {
+			continue
+		}
+
+		if arch == "" || desc.Platform.Architecture == arch {
+			return desc, nil
+		}
+	}
+
+	return ocispec.Descriptor{}, fmt.Errorf("no image matching architecture '%s' in %s", initrd.opts.arch, initrd.path)
+}
+
+// resolveManifest returns the image manifest which is described by the
+// provided descriptor, selecting one from nested indexes.
+func (initrd *ocilayout) resolveManifest(desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest: %w", err)
+	}
+
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, manifest.DockerV2ListMediaType:
+		var index ocispec.Index
+		if err := initrd.readJSON(blobPath(desc), &index); err != nil {
+			return nil, fmt.Errorf("could not read image index: %w", err)
+		}
+
+		desc, err := initrd.selectManifest(index.Manifests)
+		if err != nil {
+			return nil, err
+		}
+
+		return initrd.resolveManifest(desc)
+
+	case ocispec.MediaTypeImageManifest, manifest.DockerV2Schema2MediaType:
+		var image ocispec.Manifest
+		if err := initrd.readJSON(blobPath(desc), &image); err != nil {
+			return nil, fmt.Errorf("could not read image manifest: %w", err)
+		}
+
+		return &image, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported manifest media type: %s", desc.MediaType)
+	}
+}
+
+// Build implements Initrd.
+func (initrd *ocilayout) Build(ctx context.Context) (string, error) {
+	if initrd.opts.output == "" {
+		fi, err := os.CreateTemp("", "")
+		if err != nil {
+			return "", fmt.Errorf("could not make temporary file: %w", err)
+		}
+
+		initrd.opts.output = fi.Name()
+		err = fi.Close()
+		if err != nil {
+			return "", fmt.Errorf("could not close temporary file: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("could not open initramfs file: %w", err)
+	}
+
+	defer f.Close()
+
+	writer, err := newArchiveWriter(f, initrd.opts)
+	if err != nil {
+		return "", err
+	}
+
+	defer writer.Close()
+
+	var layers []tarLayer
+	for _, name := range initrd.layers {
+		layers = append(layers, func() (io.ReadCloser, error) {
+			blob, err := initrd.open(name)
+			if err != nil {
+				return nil, fmt.Errorf("could not open layer %s: %w", name, err)
+			}
+
+			reader, _, err := compression.AutoDecompress(blob)
+			if err != nil {
+				blob.Close()
+				return nil, fmt.Errorf("could not decompress layer %s: %w", name, err)
+			}
+
+			return &tarballReader{ReadCloser: reader, closer: blob}, nil
+		})
+	}
+
+	if err := walkTarLayers(ctx, layers, true, writer, &initrd.files); err != nil {
+		return "", fmt.Errorf("could not walk image layers: %w", err)
+	}
+
+	if initrd.opts.compress {
+		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
+			return "", fmt.Errorf("could not compress files: %w", err)
+		}
+	} else if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %w", err)
+	}
+
+	return initrd.opts.output, nil
+}
+
+// Files implements Initrd.
+func (initrd *ocilayout) Files() []string {
+	return initrd.files
+}
+
+// Env implements Initrd.
+func (initrd *ocilayout) Env() []string {
+	return initrd.env
+}
+
+// Args implements Initrd.
+func (initrd *ocilayout) Args() []string {
+	return initrd.args
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/initrd"
+)
+
+// imageLayers are the layers of the image used to test the OCI layout builder,
+// the second of which removes entries of the first.
+var imageLayers = [][]tarEntry{
+	{
+		{hdr: &tar.Header{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{hdr: &tar.Header{Name: "etc/removed", Typeflag: tar.TypeReg, Mode: 0o644}, data: "removed\n"},
+		{hdr: &tar.Header{Name: "etc/kept", Typeflag: tar.TypeReg, Mode: 0o644}, data: "kept\n"},
+		{hdr: &tar.Header{Name: "opt/app/", Typeflag: tar.TypeDir, Mode: 0o750, Uid: 1000, Gid: 1000}},
+		{hdr: &tar.Header{Name: "opt/app/old", Typeflag: tar.TypeReg, Mode: 0o644}, data: "old\n"},
+	},
+	{
+		{hdr: &tar.Header{Name: "etc/.wh.removed", Typeflag: tar.TypeReg}},
+		{hdr: &tar.Header{Name: "opt/app/.wh..wh..opq", Typeflag: tar.TypeReg}},
+		{hdr: &tar.Header{Name: "opt/app/new", Typeflag: tar.TypeReg, Mode: 0o755, Uid: 1000, Gid: 1000}, data: "new\n"},
+		{hdr: &tar.Header{Name: "usr/bin/new", Typeflag: tar.TypeLink, Linkname: "opt/app/new"}},
+	},
+}
+
+func TestNewFromOCILayout(t *testing.T) {
+	ctx := context.Background()
+
+	layoutDir := t.TempDir()
+	blobsDir := filepath.Join(layoutDir, ocispec.ImageBlobsDir, "sha256")
+	if err := os.MkdirAll(blobsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// writeBlob adds the provided file to the blobs of the layout.
+	writeBlob := func(mediaType, src string) ocispec.Descriptor {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(data),
+			Size:      int64(len(data)),
+		}
+
+		if err := os.WriteFile(filepath.Join(blobsDir, desc.Digest.Encoded()), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return desc
+	}
+
+	// writeJSON adds the provided object to the blobs of the layout.
+	writeJSON := func(mediaType string, v any) ocispec.Descriptor {
+		src := filepath.Join(t.TempDir(), "blob.json")
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(src, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return writeBlob(mediaType, src)
+	}
+
+	var layers []ocispec.Descriptor
+	for i, entries := range imageLayers {
+		src := filepath.Join(t.TempDir(), "layer.tar.gz")
+		writeTarball(t, src, i%2 == 0, entries)
+		layers = append(layers, writeBlob(ocispec.MediaTypeImageLayerGzip, src))
+	}
+
+	config := writeJSON(ocispec.MediaTypeImageConfig, ocispec.Image{
+		Config: ocispec.ImageConfig{
+			Entrypoint: []string{"/opt/app/new"},
+			Cmd:        []string{"-v"},
+			Env:        []string{"PATH=/usr/bin"},
+		},
+	})
+
+	manifest := writeJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    layers,
+	})
+	manifest.Platform = &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+
+	// Attestations of the image are expected to be skipped.
+	attestation := manifest
+	attestation.Platform = &ocispec.Platform{OS: "unknown", Architecture: "unknown"}
+
+	index, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{attestation, manifest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(layoutDir, ocispec.ImageIndexFile), index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(layoutDir, ocispec.ImageLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := initrd.NewFromOCILayout(ctx, layoutDir, initrd.WithArchitecture("arm64")); err == nil {
+		t.Error("expected image without matching architecture to be rejected")
+	}
+
+	ird, err := initrd.New(ctx, layoutDir, initrd.WithArchitecture("x86_64"))
+	if err != nil {
+		t.Fatal("New:", err)
+	}
+
+	if args := ird.Args(); !slices.Equal(args, []string{"/opt/app/new", "-v"}) {
+		t.Errorf("unexpected arguments: %v", args)
+	}
+
+	if env := ird.Env(); !slices.Equal(env, []string{"PATH=/usr/bin"}) {
+		t.Errorf("unexpected environment: %v", env)
+	}
+
+	irdPath, err := ird.Build(ctx)
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Remove(irdPath); err != nil {
+			t.Fatal("Failed to remove initrd file:", err)
+		}
+	})
+
+	headers := readCPIO(t, irdPath)
+
+	checkHeaders(t, headers, map[string]cpio.Header{
+		"./etc": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./etc/kept": {
+			Mode: cpio.TypeReg | 0o644,
+			Size: 5,
+		},
+		"./opt": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./opt/app": {
+			Mode: cpio.TypeDir | 0o750,
+			Uid:  1000,
+			Guid: 1000,
+		},
+		"./opt/app/new": {
+			Mode:  cpio.TypeReg | 0o755,
+			Uid:   1000,
+			Guid:  1000,
+			Size:  4,
+			Links: 2,
+		},
+		"./usr": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./usr/bin": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./usr/bin/new": {
+			Mode:  cpio.TypeReg | 0o755,
+			Uid:   1000,
+			Guid:  1000,
+			Size:  4,
+			Links: 2,
+		},
+	})
+
+	if app, link := headers["./opt/app/new"], headers["./usr/bin/new"]; app.Inode != link.Inode {
+		t.Errorf("expected hard link to share inode %d, got %d", app.Inode, link.Inode)
+	}
+}
+
+func TestNewFromOCILayoutDockerArchive(t *testing.T) {
+	ctx := context.Background()
+
+	var entries []tarEntry
+	var layers []string
+	for i, layer := range imageLayers {
+		src := filepath.Join(t.TempDir(), "layer.tar")
+		writeTarball(t, src, false, layer)
+
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Newer versions of Docker link the legacy path of layers to blobs.
+		blob := "blobs/sha256/" + digest.FromBytes(data).Encoded()
+		name := fmt.Sprintf("layer%d/layer.tar", i)
+		entries = append(entries,
+			tarEntry{hdr: &tar.Header{Name: blob, Typeflag: tar.TypeReg, Mode: 0o644}, data: string(data)},
+			tarEntry{hdr: &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: "../" + blob}},
+		)
+		layers = append(layers, name)
+	}
+
+	config, err := json.Marshal(ocispec.Image{
+		Config: ocispec.ImageConfig{
+			Cmd: []string{"/opt/app/new"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := json.Marshal([]map[string]any{{
+		"Config":   "config.json",
+		"RepoTags": []string{"app:latest"},
+		"Layers":   layers,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries = append(entries,
+		tarEntry{hdr: &tar.Header{Name: "config.json", Typeflag: tar.TypeReg, Mode: 0o644}, data: string(config)},
+		tarEntry{hdr: &tar.Header{Name: "manifest.json", Typeflag: tar.TypeReg, Mode: 0o644}, data: string(manifest)},
+	)
+
+	archive := filepath.Join(t.TempDir(), "app.tar")
+	writeTarball(t, archive, false, entries)
+
+	ird, err := initrd.New(ctx, archive)
+	if err != nil {
+		t.Fatal("New:", err)
+	}
+
+	if args := ird.Args(); !slices.Equal(args, []string{"/opt/app/new"}) {
+		t.Errorf("unexpected arguments: %v", args)
+	}
+
+	irdPath, err := ird.Build(ctx)
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Remove(irdPath); err != nil {
+			t.Fatal("Failed to remove initrd file:", err)
+		}
+	})
+
+	files := ird.Files()
+	slices.Sort(files)
+
+	if expect := []string{"./etc/kept", "./opt/app/new", "./usr/bin/new"}; !slices.Equal(files, expect) {
+		t.Errorf("expected files %v, got %v", expect, files)
+	}
+}
@@ -4,8 +4,14 @@
 // You may not use this file except in compliance with the License.
 package initrd
 
+import (
+	"fmt"
+	"slices"
+)
+
 type InitrdOptions struct {
 	compress bool
+	format   Format
 	output   string
 	cacheDir string
 	arch     string
@@ -22,6 +28,19 @@ func WithCompression(compress bool) InitrdOption {
 	}
 }
 
+// WithOutputFormat sets the format in which the root filesystem is serialized.
+// By default, a CPIO archive is created.
+func WithOutputFormat(format Format) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		if len(format) > 0 && !slices.Contains(Formats(), format) {
+			return fmt.Errorf("unsupported root filesystem format: %s", format)
+		}
+
+		opts.format = format
+		return nil
+	}
+}
+
 // WithOutput sets the location of the output location of the resulting CPIO
 // archive file.
 func WithOutput(output string) InitrdOption {
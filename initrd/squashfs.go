@@ -0,0 +1,505 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+const (
+	squashfsMagic          = 0x73717368
+	squashfsSuperblockSize = 96
+	squashfsBlockLog       = 17
+	squashfsBlockSize      = 1 << squashfsBlockLog
+	squashfsMetadataSize   = 8192
+	squashfsCompressorGzip = 1
+
+	squashfsFlagNoFragments = 0x10
+	squashfsFlagNoXattrs    = 0x200
+
+	squashfsInvalidBlock    = math.MaxUint64
+	squashfsInvalidFragment = math.MaxUint32
+	squashfsInvalidXattr    = math.MaxUint32
+
+	squashfsDataUncompressed     = 1 << 24
+	squashfsMetadataUncompressed = 0x8000
+
+	squashfsTypeDir         = 1
+	squashfsTypeFile        = 2
+	squashfsTypeSymlink     = 3
+	squashfsTypeExtendedDir = 8
+	squashfsTypeExtendedReg = 9
+
+	// squashfsDirEntries is the maximum number of entries which follow a
+	// directory header.
+	squashfsDirEntries = 256
+
+	// squashfsPadding is the multiple to which the size of images is padded,
+	// such that they can be attached as block devices.
+	squashfsPadding = 4096
+)
+
+// squashfsWriter serializes a root filesystem as a squashfs 4.0 image whose
+// data and metadata blocks are compressed with gzip, should that reduce their
+// size.  The data of files is written as it is received, whereas the tables
+// describing the filesystem are written once the writer is closed.
+type squashfsWriter struct {
+	f      *os.File
+	tree   *imageTree
+	off    int64
+	block  []byte
+	zbuf   bytes.Buffer
+	zw     *zlib.Writer
+	closed bool
+}
+
+func newSquashfsWriter(f *os.File) *squashfsWriter {
+	w := &squashfsWriter{
+		f:     f,
+		tree:  newImageTree(),
+		off:   squashfsSuperblockSize,
+		block: make([]byte, 0, squashfsBlockSize),
+	}
+
+	w.zw = zlib.NewWriter(&w.zbuf)
+
+	return w
+}
+
+// compress returns the gzip-compressed data, or false if compressing it does
+// not reduce its size.
+func (w *squashfsWriter) compress(data []byte) ([]byte, bool, error) {
+	w.zbuf.Reset()
+	w.zw.Reset(&w.zbuf)
+
+	if _, err := w.zw.Write(data); err != nil {
+		return nil, false, err
+	}
+
+	if err := w.zw.Close(); err != nil {
+		return nil, false, err
+	}
+
+	if w.zbuf.Len() >= len(data) {
+		return data, false, nil
+	}
+
+	return w.zbuf.Bytes(), true, nil
+}
+
+// WriteHeader implements archiveWriter.
+func (w *squashfsWriter) WriteHeader(hdr *cpio.Header) error {
+	if w.closed {
+		return cpio.ErrWriteAfterClose
+	}
+
+	node, err := w.tree.begin(hdr)
+	if err != nil {
+		return err
+	}
+
+	node.start = w.off
+
+	return nil
+}
+
+// Write implements archiveWriter.
+func (w *squashfsWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, cpio.ErrWriteAfterClose
+	}
+
+	data, err := w.tree.consume(p)
+	if err != nil {
+		return 0, err
+	}
+
+	for len(data) > 0 {
+		n := min(squashfsBlockSize-len(w.block), len(data))
+		w.block = append(w.block, data[:n]...)
+		data = data[n:]
+
+		if len(w.block) == squashfsBlockSize {
+			if err := w.flushBlock(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// The last block of a file is written as-is since fragments are not used.
+	if w.tree.remaining == 0 && len(w.block) > 0 {
+		if err := w.flushBlock(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// flushBlock writes the pending data block of the current file.
+func (w *squashfsWriter) flushBlock() error {
+	data, compressed, err := w.compress(w.block)
+	if err != nil {
+		return fmt.Errorf("could not compress data block: %w", err)
+	}
+
+	size := uint32(len(data))
+	if !compressed {
+		size |= squashfsDataUncompressed
+	}
+
+	if _, err := w.f.WriteAt(data, w.off); err != nil {
+		return err
+	}
+
+	w.off += int64(len(data))
+	w.tree.cur.blocks = append(w.tree.cur.blocks, size)
+	w.block = w.block[:0]
+
+	return nil
+}
+
+// Close implements archiveWriter.
+func (w *squashfsWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if err := w.tree.end(); err != nil {
+		return err
+	}
+
+	// Number the inodes such that the entries of each directory are numbered
+	// consecutively, which keeps the differences between the numbers of the
+	// inodes referenced by a directory small.
+	count := uint32(1)
+	w.tree.root.ino = count
+	var mtime int64
+	_ = w.tree.root.walk(func(node *treeNode) error {
+		mtime = max(mtime, node.mtime)
+		for _, child := range node.sortedChildren() {
+			count++
+			child.ino = count
+		}
+		return nil
+	})
+
+	var ids []uint32
+	idIndex := map[uint32]uint16{}
+	id := func(v uint32) (uint16, error) {
+		if i, ok := idIndex[v]; ok {
+			return i, nil
+		}
+		if len(ids) >= math.MaxUint16 {
+			return 0, fmt.Errorf("too many distinct owners")
+		}
+		idIndex[v] = uint16(len(ids))
+		ids = append(ids, v)
+		return idIndex[v], nil
+	}
+
+	inodes := squashfsMetadataWriter{compress: w.compress}
+	dirs := squashfsMetadataWriter{compress: w.compress}
+
+	refs := map[*treeNode]uint64{}
+
+	var writeInode func(node *treeNode) error
+	writeInode = func(node *treeNode) error {
+		children := node.sortedChildren()
+		for _, child := range children {
+			if err := writeInode(child); err != nil {
+				return err
+			}
+		}
+
+		uidIdx, err := id(node.uid)
+		if err != nil {
+			return err
+		}
+
+		gidIdx, err := id(node.gid)
+		if err != nil {
+			return err
+		}
+
+		var typ uint16
+		var body []byte
+
+		switch {
+		case node.isDir():
+			listing, err := squashfsDirListing(children, refs)
+			if err != nil {
+				return fmt.Errorf("could not serialize directory %s: %w", node.name, err)
+			}
+
+			block, offset := dirs.position()
+			if err := dirs.write(listing); err != nil {
+				return err
+			}
+
+			parent := count + 1
+			if node.parent != nil {
+				parent = node.parent.ino
+			}
+
+			nlink := uint32(2 + node.subdirs())
+			size := len(listing) + 3
+
+			if size <= math.MaxUint16 {
+				typ = squashfsTypeDir
+				body = binary.LittleEndian.AppendUint32(body, uint32(block))
+				body = binary.LittleEndian.AppendUint32(body, nlink)
+				body = binary.LittleEndian.AppendUint16(body, uint16(size))
+				body = binary.LittleEndian.AppendUint16(body, offset)
+				body = binary.LittleEndian.AppendUint32(body, parent)
+			} else {
+				typ = squashfsTypeExtendedDir
+				body = binary.LittleEndian.AppendUint32(body, nlink)
+				body = binary.LittleEndian.AppendUint32(body, uint32(size))
+				body = binary.LittleEndian.AppendUint32(body, uint32(block))
+				body = binary.LittleEndian.AppendUint32(body, parent)
+				body = binary.LittleEndian.AppendUint16(body, 0) // index count
+				body = binary.LittleEndian.AppendUint16(body, offset)
+				body = binary.LittleEndian.AppendUint32(body, squashfsInvalidXattr)
+			}
+
+		case node.isSymlink():
+			typ = squashfsTypeSymlink
+			body = binary.LittleEndian.AppendUint32(body, 1)
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(node.target)))
+			body = append(body, node.target...)
+
+		default:
+			if node.start <= math.MaxUint32 && node.size <= math.MaxUint32 {
+				typ = squashfsTypeFile
+				body = binary.LittleEndian.AppendUint32(body, uint32(node.start))
+				body = binary.LittleEndian.AppendUint32(body, squashfsInvalidFragment)
+				body = binary.LittleEndian.AppendUint32(body, 0) // fragment offset
+				body = binary.LittleEndian.AppendUint32(body, uint32(node.size))
+			} else {
+				typ = squashfsTypeExtendedReg
+				body = binary.LittleEndian.AppendUint64(body, uint64(node.start))
+				body = binary.LittleEndian.AppendUint64(body, uint64(node.size))
+				body = binary.LittleEndian.AppendUint64(body, 0) // sparse bytes
+				body = binary.LittleEndian.AppendUint32(body, 1)
+				body = binary.LittleEndian.AppendUint32(body, squashfsInvalidFragment)
+				body = binary.LittleEndian.AppendUint32(body, 0) // fragment offset
+				body = binary.LittleEndian.AppendUint32(body, squashfsInvalidXattr)
+			}
+
+			for _, size := range node.blocks {
+				body = binary.LittleEndian.AppendUint32(body, size)
+			}
+		}
+
+		var inode []byte
+		inode = binary.LittleEndian.AppendUint16(inode, typ)
+		inode = binary.LittleEndian.AppendUint16(inode, uint16(node.mode&0o7777))
+		inode = binary.LittleEndian.AppendUint16(inode, uidIdx)
+		inode = binary.LittleEndian.AppendUint16(inode, gidIdx)
+		inode = binary.LittleEndian.AppendUint32(inode, uint32(min(node.mtime, math.MaxUint32)))
+		inode = binary.LittleEndian.AppendUint32(inode, node.ino)
+		inode = append(inode, body...)
+
+		block, offset := inodes.position()
+		refs[node] = block<<16 | uint64(offset)
+
+		return inodes.write(inode)
+	}
+
+	if err := writeInode(w.tree.root); err != nil {
+		return err
+	}
+
+	if err := inodes.flush(); err != nil {
+		return err
+	}
+
+	if err := dirs.flush(); err != nil {
+		return err
+	}
+
+	// The identifiers of owners are stored in metadata blocks which are located
+	// through an index of their offsets.
+	idBlocks := squashfsMetadataWriter{compress: w.compress}
+	for _, v := range ids {
+		if err := idBlocks.write(binary.LittleEndian.AppendUint32(nil, v)); err != nil {
+			return err
+		}
+	}
+
+	inodeTableStart := w.off
+	dirTableStart := inodeTableStart + int64(inodes.buf.Len())
+	idBlocksStart := dirTableStart + int64(dirs.buf.Len())
+
+	if err := idBlocks.flush(); err != nil {
+		return err
+	}
+
+	idTableStart := idBlocksStart + int64(idBlocks.buf.Len())
+
+	var idTable []byte
+	for _, start := range idBlocks.starts {
+		idTable = binary.LittleEndian.AppendUint64(idTable, uint64(idBlocksStart+start))
+	}
+
+	var tables []byte
+	tables = append(tables, inodes.buf.Bytes()...)
+	tables = append(tables, dirs.buf.Bytes()...)
+	tables = append(tables, idBlocks.buf.Bytes()...)
+	tables = append(tables, idTable...)
+
+	if _, err := w.f.WriteAt(tables, w.off); err != nil {
+		return err
+	}
+
+	bytesUsed := w.off + int64(len(tables))
+
+	var sb []byte
+	sb = binary.LittleEndian.AppendUint32(sb, squashfsMagic)
+	sb = binary.LittleEndian.AppendUint32(sb, count)
+	sb = binary.LittleEndian.AppendUint32(sb, uint32(min(mtime, math.MaxUint32)))
+	sb = binary.LittleEndian.AppendUint32(sb, squashfsBlockSize)
+	sb = binary.LittleEndian.AppendUint32(sb, 0) // fragment count
+	sb = binary.LittleEndian.AppendUint16(sb, squashfsCompressorGzip)
+	sb = binary.LittleEndian.AppendUint16(sb, squashfsBlockLog)
+	sb = binary.LittleEndian.AppendUint16(sb, squashfsFlagNoFragments|squashfsFlagNoXattrs)
+	sb = binary.LittleEndian.AppendUint16(sb, uint16(len(ids)))
+	sb = binary.LittleEndian.AppendUint16(sb, 4) // major version
+	sb = binary.LittleEndian.AppendUint16(sb, 0) // minor version
+	sb = binary.LittleEndian.AppendUint64(sb, refs[w.tree.root])
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(bytesUsed))
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(idTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, squashfsInvalidBlock) // xattr table
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(inodeTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(dirTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, squashfsInvalidBlock) // fragment table
+	sb = binary.LittleEndian.AppendUint64(sb, squashfsInvalidBlock) // export table
+
+	if _, err := w.f.WriteAt(sb, 0); err != nil {
+		return err
+	}
+
+	return w.f.Truncate((bytesUsed + squashfsPadding - 1) / squashfsPadding * squashfsPadding)
+}
+
+// squashfsDirListing serializes the entries of a directory, whose inodes are
+// referenced by refs, as a listing of the directory table.
+func squashfsDirListing(children []*treeNode, refs map[*treeNode]uint64) ([]byte, error) {
+	var listing []byte
+
+	for i := 0; i < len(children); {
+		block := uint32(refs[children[i]] >> 16)
+		base := children[i].ino
+
+		// Entries share a header as long as their inodes are located in the same
+		// metadata block and their numbers are close to each other.
+		n := 0
+		for i+n < len(children) && n < squashfsDirEntries {
+			child := children[i+n]
+			diff := int64(child.ino) - int64(base)
+			if uint32(refs[child]>>16) != block || diff < math.MinInt16 || diff > math.MaxInt16 {
+				break
+			}
+			n++
+		}
+
+		listing = binary.LittleEndian.AppendUint32(listing, uint32(n-1))
+		listing = binary.LittleEndian.AppendUint32(listing, block)
+		listing = binary.LittleEndian.AppendUint32(listing, base)
+
+		for _, child := range children[i : i+n] {
+			if len(child.name) > 256 {
+				return nil, fmt.Errorf("name too long: %s", child.name)
+			}
+
+			var typ uint16
+			switch {
+			case child.isDir():
+				typ = squashfsTypeDir
+			case child.isSymlink():
+				typ = squashfsTypeSymlink
+			default:
+				typ = squashfsTypeFile
+			}
+
+			listing = binary.LittleEndian.AppendUint16(listing, uint16(refs[child]&0xffff))
+			listing = binary.LittleEndian.AppendUint16(listing, uint16(int16(int64(child.ino)-int64(base))))
+			listing = binary.LittleEndian.AppendUint16(listing, typ)
+			listing = binary.LittleEndian.AppendUint16(listing, uint16(len(child.name)-1))
+			listing = append(listing, child.name...)
+		}
+
+		i += n
+	}
+
+	return listing, nil
+}
+
+// squashfsMetadataWriter serializes a table of a squashfs image as a sequence of
+// metadata blocks.
+type squashfsMetadataWriter struct {
+	compress func([]byte) ([]byte, bool, error)
+	buf      bytes.Buffer
+	pending  []byte
+
+	// starts are the offsets of the written blocks within the table.
+	starts []int64
+}
+
+// position returns the offset of the metadata block within the table and the
+// offset within the block at which the next write begins.
+func (m *squashfsMetadataWriter) position() (uint64, uint16) {
+	return uint64(m.buf.Len()), uint16(len(m.pending))
+}
+
+func (m *squashfsMetadataWriter) write(p []byte) error {
+	for len(p) > 0 {
+		n := min(squashfsMetadataSize-len(m.pending), len(p))
+		m.pending = append(m.pending, p[:n]...)
+		p = p[n:]
+
+		if len(m.pending) == squashfsMetadataSize {
+			if err := m.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// flush writes the pending metadata block, if any.
+func (m *squashfsMetadataWriter) flush() error {
+	if len(m.pending) == 0 {
+		return nil
+	}
+
+	data, compressed, err := m.compress(m.pending)
+	if err != nil {
+		return fmt.Errorf("could not compress metadata block: %w", err)
+	}
+
+	header := uint16(len(data))
+	if !compressed {
+		header |= squashfsMetadataUncompressed
+	}
+
+	m.starts = append(m.starts, int64(m.buf.Len()))
+	_ = binary.Write(&m.buf, binary.LittleEndian, header)
+	m.buf.Write(data)
+	m.pending = m.pending[:0]
+
+	return nil
+}
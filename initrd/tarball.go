@@ -0,0 +1,454 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/containers/image/v5/pkg/compression"
+
+	"kraftkit.sh/log"
+)
+
+const (
+	// whiteoutPrefix marks an entry of an image layer which removes the path
+	// without the prefix from the layers below it.
+	whiteoutPrefix = ".wh."
+
+	// whiteoutOpaque marks an entry of an image layer which removes the entries
+	// of its directory from the layers below it.
+	whiteoutOpaque = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+type tarball struct {
+	opts  InitrdOptions
+	path  string
+	files []string
+}
+
+// NewFromTarball returns an instantiated Initrd interface which is able to
+// serialize a rootfs from a tar archive, which is optionally compressed with
+// gzip, bzip2, xz or zstd.
+func NewFromTarball(_ context.Context, path string, opts ...InitrdOption) (Initrd, error) {
+	rootfs := tarball{
+		opts: InitrdOptions{},
+		path: path,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&rootfs.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not check path: %w", err)
+	} else if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("supplied path is not a file: %s", path)
+	}
+
+	if !isTarball(path) {
+		return nil, fmt.Errorf("supplied path is not a tar archive: %s", path)
+	}
+
+	return &rootfs, nil
+}
+
+// openTarball returns the decompressed contents of the tar archive at the
+// provided path.
+func openTarball(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, _, err := compression.AutoDecompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not decompress %s: %w", path, err)
+	}
+
+	return &tarballReader{ReadCloser: reader, closer: f}, nil
+}
+
+// tarballReader reads from a stream which is part of another one and closes
+// both.
+type tarballReader struct {
+	io.ReadCloser
+	closer io.Closer
+}
+
+// Close implements io.Closer.
+func (r *tarballReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.closer.Close())
+}
+
+// isTarball returns whether the file at the provided path is a, possibly
+// compressed, tar archive.
+func isTarball(path string) bool {
+	reader, err := openTarball(path)
+	if err != nil {
+		return false
+	}
+
+	defer reader.Close()
+
+	_, err = tar.NewReader(reader).Next()
+	return err == nil
+}
+
+// Build implements Initrd.
+func (initrd *tarball) Build(ctx context.Context) (string, error) {
+	if initrd.opts.output == "" {
+		fi, err := os.CreateTemp("", "")
+		if err != nil {
+			return "", fmt.Errorf("could not make temporary file: %w", err)
+		}
+
+		initrd.opts.output = fi.Name()
+		err = fi.Close()
+		if err != nil {
+			return "", fmt.Errorf("could not close temporary file: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("could not open initramfs file: %w", err)
+	}
+
+	defer f.Close()
+
+	writer, err := newArchiveWriter(f, initrd.opts)
+	if err != nil {
+		return "", err
+	}
+
+	defer writer.Close()
+
+	layers := []tarLayer{
+		func() (io.ReadCloser, error) {
+			return openTarball(initrd.path)
+		},
+	}
+
+	if err := walkTarLayers(ctx, layers, false, writer, &initrd.files); err != nil {
+		return "", fmt.Errorf("could not walk tarball: %w", err)
+	}
+
+	if initrd.opts.compress {
+		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
+			return "", fmt.Errorf("could not compress files: %w", err)
+		}
+	} else if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %w", err)
+	}
+
+	return initrd.opts.output, nil
+}
+
+// Files implements Initrd.
+func (initrd *tarball) Files() []string {
+	return initrd.files
+}
+
+// Env implements Initrd.
+func (initrd *tarball) Env() []string {
+	return nil
+}
+
+// Args implements Initrd.
+func (initrd *tarball) Args() []string {
+	return nil
+}
+
+// tarLayer opens the decompressed contents of a tar archive.  Since the
+// archive is walked more than once, it must be possible to open it repeatedly.
+type tarLayer func() (io.ReadCloser, error)
+
+// tarEntry identifies an entry of a tar archive by the index of the layer which
+// it is part of and its cleaned path.
+type tarEntry struct {
+	layer int
+	name  string
+}
+
+// tarWinner is the entry of the squashed layers which ends up at a path.
+type tarWinner struct {
+	layer int
+	index int
+	hdr   *tar.Header
+}
+
+// tarLinked is a regular file of a tar archive which is the target of hard
+// links, whose data is kept in memory to serialize them.
+type tarLinked struct {
+	hdr  *tar.Header
+	data []byte
+	ino  int64
+}
+
+// walkTarLayers squashes the provided tar archives, each applied on top of the
+// previous one, and serializes the result to the writer.  Entries of image
+// layers whose names are prefixed by whiteoutPrefix remove paths of the layers
+// below them if whiteouts is set.  Hard links are serialized as copies of their
+// target which share its inode number, similar to the directory builder.
+func walkTarLayers(ctx context.Context, layers []tarLayer, whiteouts bool, writer archiveWriter, files *[]string) error {
+	final := map[string]*tarWinner{}
+
+	// Determine which entry ends up at each path without reading any data.
+	for i, layer := range layers {
+		if err := readTarLayer(layer, func(index int, name string, hdr *tar.Header, _ io.Reader) error {
+			base := path.Base(name)
+			dir := path.Dir(name)
+
+			if whiteouts && base == whiteoutOpaque {
+				removeTarEntries(final, dir, i, false)
+				return nil
+			} else if whiteouts && strings.HasPrefix(base, whiteoutPrefix) {
+				removeTarEntries(final, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)), i, true)
+				return nil
+			}
+
+			// Entries which replace a directory hide its contents.
+			if existing, ok := final[name]; ok && existing.hdr.Typeflag == tar.TypeDir && hdr.Typeflag != tar.TypeDir {
+				removeTarEntries(final, name, i+1, false)
+			}
+
+			final[name] = &tarWinner{layer: i, index: index, hdr: hdr}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Count the names of the regular files which are hard linked, which are
+	// looked up within the layer of the link.
+	links := map[tarEntry]int{}
+	for _, winner := range final {
+		if winner.hdr.Typeflag == tar.TypeLink {
+			links[tarEntry{winner.layer, cleanTarName(winner.hdr.Linkname)}]++
+		}
+	}
+
+	emitted := map[string]bool{}
+	linked := map[tarEntry]*tarLinked{}
+	var ino int64
+
+	// writeDir serializes the directory at the provided path along with its
+	// parents unless already done.  Directories are serialized before any of
+	// their entries with the metadata of the entry which ends up at their path.
+	var writeDir func(name string) error
+	writeDir = func(name string) error {
+		if name == "/" || emitted[name] {
+			return nil
+		}
+
+		if err := writeDir(path.Dir(name)); err != nil {
+			return err
+		}
+
+		emitted[name] = true
+		ino++
+
+		header := &cpio.Header{
+			Name:  "." + name,
+			Mode:  cpio.TypeDir | 0o755,
+			Inode: ino,
+			Links: 2,
+		}
+
+		if winner, ok := final[name]; ok && winner.hdr.Typeflag == tar.TypeDir {
+			header.Mode = cpio.TypeDir | cpio.FileMode(winner.hdr.Mode&0o7777)
+			header.Uid = winner.hdr.Uid
+			header.Guid = winner.hdr.Gid
+			header.ModTime = winner.hdr.ModTime
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write CPIO header: %w", err)
+		}
+
+		return nil
+	}
+
+	for i, layer := range layers {
+		if err := readTarLayer(layer, func(index int, name string, hdr *tar.Header, reader io.Reader) error {
+			entry := tarEntry{i, name}
+
+			if hdr.Typeflag == tar.TypeDir {
+				if winner, ok := final[name]; ok && winner.hdr.Typeflag == tar.TypeDir {
+					return writeDir(name)
+				}
+
+				return nil
+			}
+
+			// Keep the data of hard linked files, since links may refer to files
+			// which do not end up in the root filesystem.
+			if _, ok := links[entry]; ok && hdr.Typeflag == tar.TypeReg {
+				data, err := io.ReadAll(reader)
+				if err != nil {
+					return fmt.Errorf("could not read file: %w", err)
+				}
+
+				ino++
+				linked[entry] = &tarLinked{hdr: hdr, data: data, ino: ino}
+				reader = bytes.NewReader(data)
+			}
+
+			winner, ok := final[name]
+			if !ok || winner.layer != i || winner.index != index {
+				return nil
+			}
+
+			internal := "." + name
+
+			header := &cpio.Header{
+				Name:    internal,
+				Mode:    cpio.FileMode(hdr.Mode & 0o7777),
+				Uid:     hdr.Uid,
+				Guid:    hdr.Gid,
+				ModTime: hdr.ModTime,
+				Links:   1,
+			}
+
+			switch hdr.Typeflag {
+			case tar.TypeReg:
+				header.Mode |= cpio.TypeReg
+				header.Size = hdr.Size
+
+				if n, ok := links[entry]; ok {
+					header.Inode = linked[entry].ino
+					header.Links += n
+				} else {
+					ino++
+					header.Inode = ino
+				}
+
+			case tar.TypeLink:
+				target := tarEntry{i, cleanTarName(hdr.Linkname)}
+				file, ok := linked[target]
+				if !ok {
+					return fmt.Errorf("could not find target %s of hard link %s", hdr.Linkname, hdr.Name)
+				}
+
+				// Hard links share the metadata of their target.
+				header.Mode = cpio.FileMode(file.hdr.Mode&0o7777) | cpio.TypeReg
+				header.Uid = file.hdr.Uid
+				header.Guid = file.hdr.Gid
+				header.ModTime = file.hdr.ModTime
+				header.Size = int64(len(file.data))
+				header.Inode = file.ino
+				header.Links += links[target]
+				reader = bytes.NewReader(file.data)
+
+			case tar.TypeSymlink:
+				header.Mode |= cpio.TypeSymlink
+				header.Linkname = hdr.Linkname
+				header.Size = int64(len(hdr.Linkname))
+				reader = strings.NewReader(hdr.Linkname)
+				ino++
+				header.Inode = ino
+
+			default:
+				log.G(ctx).Warnf("unsupported file: %s", hdr.Name)
+				return nil
+			}
+
+			if err := writeDir(path.Dir(name)); err != nil {
+				return err
+			}
+
+			*files = append(*files, internal)
+
+			log.G(ctx).
+				WithField("file", internal).
+				Trace("archiving")
+
+			if err := writer.WriteHeader(header); err != nil {
+				return fmt.Errorf("writing cpio header for %q: %w", internal, err)
+			}
+
+			if _, err := io.CopyN(writer, reader, header.Size); err != nil {
+				return fmt.Errorf("could not write CPIO data for %s: %w", internal, err)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readTarLayer calls fn for each entry of the provided layer along with its
+// index within the layer and cleaned path.  The root directory is skipped.
+func readTarLayer(layer tarLayer, fn func(index int, name string, hdr *tar.Header, reader io.Reader) error) error {
+	rc, err := layer()
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for index := 0; ; index++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not read tar header: %w", err)
+		}
+
+		name := cleanTarName(hdr.Name)
+		if name == "/" {
+			continue
+		}
+
+		if err := fn(index, name, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanTarName returns the absolute path of an entry of a tar archive.
+func cleanTarName(name string) string {
+	return path.Clean("/" + name)
+}
+
+// removeTarEntries removes the entries at and, unless only the contents of the
+// directory are removed, below the provided path which originate from layers
+// preceding the provided one.
+func removeTarEntries(final map[string]*tarWinner, name string, layer int, self bool) {
+	prefix := name + "/"
+	if name == "/" {
+		prefix = "/"
+	}
+
+	for entry, winner := range final {
+		if winner.layer >= layer {
+			continue
+		}
+
+		if (self && entry == name) || strings.HasPrefix(entry, prefix) {
+			delete(final, entry)
+		}
+	}
+}
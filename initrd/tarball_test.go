@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+
+	"kraftkit.sh/initrd"
+)
+
+func TestNewFromTarball(t *testing.T) {
+	ctx := context.Background()
+
+	tarPath := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	writeTarball(t, tarPath, true, []tarEntry{
+		{hdr: &tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{hdr: &tar.Header{Name: "./bin/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{hdr: &tar.Header{Name: "./bin/app", Typeflag: tar.TypeReg, Mode: 0o4755, Uid: 1000, Gid: 100}, data: "#!/bin/app\n"},
+		{hdr: &tar.Header{Name: "./bin/app-link", Typeflag: tar.TypeLink, Linkname: "./bin/app", Mode: 0o4755, Uid: 1000, Gid: 100}},
+		{hdr: &tar.Header{Name: "./bin/sh", Typeflag: tar.TypeSymlink, Linkname: "app", Mode: 0o777}},
+		{hdr: &tar.Header{Name: "./etc/hostname", Typeflag: tar.TypeReg, Mode: 0o644}, data: "unikraft\n"},
+	})
+
+	ird, err := initrd.New(ctx, tarPath)
+	if err != nil {
+		t.Fatal("New:", err)
+	}
+
+	irdPath, err := ird.Build(ctx)
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Remove(irdPath); err != nil {
+			t.Fatal("Failed to remove initrd file:", err)
+		}
+	})
+
+	const expectFiles = 4 // only regular and symlink files are indexed
+	if gotFiles := len(ird.Files()); gotFiles != expectFiles {
+		t.Errorf("Expected %d files in InitrdConfig, got %d: %v", expectFiles, gotFiles, ird.Files())
+	}
+
+	headers := readCPIO(t, irdPath)
+
+	expectHeaders := map[string]cpio.Header{
+		"./bin": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./bin/app": {
+			Mode:  cpio.TypeReg | 0o4755,
+			Uid:   1000,
+			Guid:  100,
+			Size:  11,
+			Links: 2,
+		},
+		"./bin/app-link": {
+			Mode:  cpio.TypeReg | 0o4755,
+			Uid:   1000,
+			Guid:  100,
+			Size:  11,
+			Links: 2,
+		},
+		"./bin/sh": {
+			Mode:     cpio.TypeSymlink | 0o777,
+			Linkname: "app",
+			Links:    1,
+		},
+		"./etc": {
+			Mode: cpio.TypeDir | 0o755,
+		},
+		"./etc/hostname": {
+			Mode:  cpio.TypeReg | 0o644,
+			Size:  9,
+			Links: 1,
+		},
+	}
+
+	checkHeaders(t, headers, expectHeaders)
+
+	if app, link := headers["./bin/app"], headers["./bin/app-link"]; app.Inode != link.Inode {
+		t.Errorf("expected hard link to share inode %d, got %d", app.Inode, link.Inode)
+	}
+}
+
+func TestNewFromTarballNotTar(t *testing.T) {
+	if _, err := initrd.NewFromTarball(context.Background(), "testdata/rootfs/etc/app.conf"); err == nil {
+		t.Error("expected plain file to be rejected")
+	}
+}
+
+// tarEntry is an entry of a tarball which is written by writeTarball.
+type tarEntry struct {
+	hdr  *tar.Header
+	data string
+}
+
+// writeTarball creates a tarball with the provided entries, optionally
+// compressed with gzip.
+func writeTarball(t *testing.T, path string, compress bool, entries []tarEntry) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	var w io.Writer = f
+	if compress {
+		gw := gzip.NewWriter(f)
+		defer gw.Close()
+		w = gw
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for _, entry := range entries {
+		entry.hdr.Size = int64(len(entry.data))
+		if err := tw.WriteHeader(entry.hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := io.WriteString(tw, entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readCPIO returns the headers of the CPIO archive at the provided path by
+// name.
+func readCPIO(t *testing.T, irdPath string) map[string]*cpio.Header {
+	t.Helper()
+
+	r := cpio.NewReader(openFile(t, irdPath))
+	headers := map[string]*cpio.Header{}
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("Failed to read next cpio header:", err)
+		}
+
+		if _, ok := headers[hdr.Name]; ok {
+			t.Errorf("file [%s]: duplicate entry", hdr.Name)
+		}
+
+		// Directories are expected to precede their entries.
+		if dir := "./" + path.Dir(strings.TrimPrefix(hdr.Name, "./")); dir != "./." {
+			if _, ok := headers[dir]; !ok {
+				t.Errorf("file [%s]: parent directory %s missing", hdr.Name, dir)
+			}
+		}
+
+		headers[hdr.Name] = hdr
+	}
+
+	return headers
+}
+
+// checkHeaders compares the headers of a CPIO archive with those expected.
+func checkHeaders(t *testing.T, headers map[string]*cpio.Header, expectHeaders map[string]cpio.Header) {
+	t.Helper()
+
+	for name := range headers {
+		if _, ok := expectHeaders[name]; !ok {
+			t.Error("Encountered unexpected file in cpio archive:", name)
+		}
+	}
+
+	for name, expectHdr := range expectHeaders {
+		hdr, ok := headers[name]
+		if !ok {
+			t.Errorf("file [%s]: missing from cpio archive", name)
+			continue
+		}
+
+		if hdr.Mode != expectHdr.Mode {
+			t.Errorf("file [%s]: got mode %s, expected %s", name, hdr.Mode, expectHdr.Mode)
+		}
+		if hdr.Linkname != expectHdr.Linkname {
+			t.Errorf("file [%s]: got linkname %q, expected %q", name, hdr.Linkname, expectHdr.Linkname)
+		}
+		if hdr.Size != expectHdr.Size {
+			t.Errorf("file [%s]: got size %d, expected %d", name, hdr.Size, expectHdr.Size)
+		}
+		if hdr.Uid != expectHdr.Uid || hdr.Guid != expectHdr.Guid {
+			t.Errorf("file [%s]: got owner %d:%d, expected %d:%d", name, hdr.Uid, hdr.Guid, expectHdr.Uid, expectHdr.Guid)
+		}
+		if expectHdr.Links != 0 && hdr.Links != expectHdr.Links {
+			t.Errorf("file [%s]: got %d links, expected %d", name, hdr.Links, expectHdr.Links)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// treeNode is an entry of a root filesystem which is serialized as an image.
+// Since the metadata of images is written after the data of their files, the
+// whole tree is kept in memory until the image is closed.
+type treeNode struct {
+	name     string
+	mode     cpio.FileMode
+	uid      uint32
+	gid      uint32
+	mtime    int64
+	size     int64
+	target   string
+	parent   *treeNode
+	children map[string]*treeNode
+
+	// ino is the number of the inode of the entry in the image.
+	ino uint32
+
+	// start is the offset in the image at which the data of a regular file
+	// begins, in bytes for squashfs and in blocks for EROFS.
+	start int64
+
+	// blocks are the on-disk sizes of the data blocks of a regular file in a
+	// squashfs image.
+	blocks []uint32
+
+	// data holds the contents of a regular file in an EROFS image which is small
+	// enough to be inlined with its inode.
+	data []byte
+}
+
+// isDir returns whether the entry is a directory.
+func (node *treeNode) isDir() bool {
+	return node.mode&cpio.ModeType == cpio.TypeDir
+}
+
+// isSymlink returns whether the entry is a symbolic link.
+func (node *treeNode) isSymlink() bool {
+	return node.mode&cpio.ModeType == cpio.TypeSymlink
+}
+
+// sortedChildren returns the entries of a directory ordered by name.
+func (node *treeNode) sortedChildren() []*treeNode {
+	children := make([]*treeNode, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].name < children[j].name
+	})
+
+	return children
+}
+
+// subdirs returns the number of directories within a directory.
+func (node *treeNode) subdirs() int {
+	n := 0
+	for _, child := range node.children {
+		if child.isDir() {
+			n++
+		}
+	}
+
+	return n
+}
+
+// walk visits the node and all of its descendants, each directory before its
+// entries and the entries of a directory in order.
+func (node *treeNode) walk(fn func(*treeNode) error) error {
+	if err := fn(node); err != nil {
+		return err
+	}
+
+	for _, child := range node.sortedChildren() {
+		if err := child.walk(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// imageTree is the in-memory representation of a root filesystem which is
+// serialized as an image.
+type imageTree struct {
+	root *treeNode
+
+	// cur is the entry whose contents are being written and remaining is the
+	// number of bytes of its contents which are yet to be written.
+	cur       *treeNode
+	remaining int64
+	link      []byte
+}
+
+func newImageTree() *imageTree {
+	return &imageTree{
+		root: &treeNode{
+			mode:     cpio.TypeDir | 0o755,
+			children: map[string]*treeNode{},
+		},
+	}
+}
+
+// begin adds the entry described by the provided header to the tree and
+// prepares for its contents to be written.
+func (tree *imageTree) begin(hdr *cpio.Header) (*treeNode, error) {
+	if tree.remaining > 0 {
+		return nil, fmt.Errorf("missed writing %d bytes of %s", tree.remaining, tree.cur.name)
+	}
+
+	node, err := tree.insert(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	tree.cur = node
+	tree.remaining = 0
+	tree.link = nil
+
+	if !node.isDir() {
+		tree.remaining = hdr.Size
+	}
+
+	return node, nil
+}
+
+// consume accounts for the provided contents of the current entry and returns
+// those which are data of a regular file.  The target of a symbolic link whose
+// header lacks one is taken from its contents.
+func (tree *imageTree) consume(p []byte) ([]byte, error) {
+	if int64(len(p)) > tree.remaining {
+		return nil, cpio.ErrWriteTooLong
+	}
+
+	tree.remaining -= int64(len(p))
+
+	if tree.cur.isSymlink() {
+		tree.link = append(tree.link, p...)
+		if tree.remaining == 0 && len(tree.cur.target) == 0 {
+			tree.cur.target = string(tree.link)
+		}
+
+		return nil, nil
+	}
+
+	return p, nil
+}
+
+// end returns an error if the contents of the current entry have not been
+// fully written.
+func (tree *imageTree) end() error {
+	if tree.remaining > 0 {
+		return fmt.Errorf("missed writing %d bytes of %s", tree.remaining, tree.cur.name)
+	}
+
+	return nil
+}
+
+// insert adds the entry described by the provided header to the tree.  Missing
+// parent directories are created and an existing entry at the same path is
+// replaced, except for directories whose entries are retained.
+func (tree *imageTree) insert(hdr *cpio.Header) (*treeNode, error) {
+	switch hdr.Mode & cpio.ModeType {
+	case cpio.TypeDir, cpio.TypeReg, cpio.TypeSymlink:
+	default:
+		return nil, fmt.Errorf("unsupported file type of %s: %o", hdr.Name, hdr.Mode&cpio.ModeType)
+	}
+
+	if hdr.Size < 0 {
+		return nil, fmt.Errorf("invalid size of %s: %d", hdr.Name, hdr.Size)
+	}
+
+	var mtime int64
+	if !hdr.ModTime.IsZero() {
+		mtime = max(hdr.ModTime.Unix(), 0)
+	}
+
+	node := &treeNode{
+		mode:   hdr.Mode & (cpio.ModeType | 0o7777),
+		uid:    uint32(hdr.Uid),
+		gid:    uint32(hdr.Guid),
+		mtime:  mtime,
+		target: hdr.Linkname,
+	}
+
+	if node.mode&cpio.ModeType == cpio.TypeReg {
+		node.size = hdr.Size
+	}
+
+	name := path.Clean("/" + hdr.Name)
+	if name == "/" {
+		if !node.isDir() {
+			return nil, fmt.Errorf("root of the filesystem is not a directory")
+		}
+
+		node.children = tree.root.children
+		for _, child := range node.children {
+			child.parent = node
+		}
+
+		tree.root = node
+		return node, nil
+	}
+
+	parent := tree.root
+	elems := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for _, elem := range elems[:len(elems)-1] {
+		child, ok := parent.children[elem]
+		if !ok {
+			child = &treeNode{
+				name:     elem,
+				mode:     cpio.TypeDir | 0o755,
+				parent:   parent,
+				children: map[string]*treeNode{},
+			}
+			parent.children[elem] = child
+		} else if !child.isDir() {
+			return nil, fmt.Errorf("parent of %s is not a directory", hdr.Name)
+		}
+
+		parent = child
+	}
+
+	node.name = elems[len(elems)-1]
+	node.parent = parent
+
+	if node.isDir() {
+		node.children = map[string]*treeNode{}
+		if existing, ok := parent.children[node.name]; ok && existing.isDir() {
+			node.children = existing.children
+			for _, child := range node.children {
+				child.parent = node
+			}
+		}
+	}
+
+	parent.children[node.name] = node
+
+	return node, nil
+}
@@ -18,7 +18,7 @@ import (
 	"kraftkit.sh/log"
 )
 
-func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files *[]string) error {
+func walkFiles(ctx context.Context, outputDir string, writer archiveWriter, files *[]string) error {
 	// Recursively walk the output directory on successful build and serialize to
 	// the output
 	return filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
@@ -103,7 +103,7 @@ func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files
 	})
 }
 
-func compressFiles(output string, writer *cpio.Writer, reader *os.File) error {
+func compressFiles(output string, writer archiveWriter, reader *os.File) error {
 	err := writer.Close()
 	if err != nil {
 		return fmt.Errorf("could not close CPIO writer: %w", err)
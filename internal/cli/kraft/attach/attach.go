@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// DefaultDetachKeys is the key sequence which detaches from the console of a
+// machine unless overridden.
+const DefaultDetachKeys = "ctrl-p,ctrl-q"
+
+type AttachOptions struct {
+	DetachKeys string `long:"detach-keys" usage:"Override the key sequence for detaching from the machine" default:"ctrl-p,ctrl-q"`
+	NoStdin    bool   `long:"no-stdin" usage:"Do not attach standard input"`
+	Platform   string `noattribute:"true"`
+}
+
+// Attach the standard input and output to the serial console of a local
+// Unikraft virtual machine.
+func Attach(ctx context.Context, opts *AttachOptions, args ...string) error {
+	if opts == nil {
+		opts = &AttachOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&AttachOptions{}, cobra.Command{
+		Short:   "Attach to the console of a running unikernel",
+		Use:     "attach [FLAGS] MACHINE",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{},
+		Long: heredoc.Docf(`
+			Attach the standard input and output of the terminal to the serial console
+			of a running unikernel.
+
+			Detach from the unikernel without stopping it by typing the detach key
+			sequence, which defaults to %s.
+		`, "`"+DefaultDetachKeys+"`"),
+		Example: heredoc.Doc(`
+			# Attach to the console of a running unikernel
+			$ kraft attach my-machine
+
+			# Attach to the console of a running unikernel and detach with ctrl-x
+			$ kraft attach --detach-keys ctrl-x my-machine
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *AttachOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *AttachOptions) Run(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("please supply exactly one machine ID or name")
+	}
+
+	var err error
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.Platform == "" || opts.Platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.Platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.Platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.Platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+	for _, candidate := range machines.Items {
+		if args[0] == candidate.Name || args[0] == string(candidate.UID) {
+			machine = &candidate
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("machine not found: %s", args[0])
+	}
+
+	detachKeys := opts.DetachKeys
+	if detachKeys == "" {
+		detachKeys = DefaultDetachKeys
+	}
+
+	escapeKeys, err := term.ToBytes(detachKeys)
+	if err != nil {
+		return fmt.Errorf("invalid detach keys: %w", err)
+	}
+
+	conn, err := Console(machine)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	if !opts.NoStdin {
+		detached, stop, err := Forward(ctx, conn, escapeKeys)
+		if err != nil {
+			return err
+		}
+
+		defer stop()
+
+		go func() {
+			<-detached
+			conn.Close()
+		}()
+	}
+
+	if _, err := io.Copy(iostreams.G(ctx).Out, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Console returns a connection to the serial console of the provided running
+// machine.
+func Console(machine *machineapi.Machine) (net.Conn, error) {
+	if machine.Status.State != machineapi.MachineStateRunning && machine.Status.State != machineapi.MachineStatePaused {
+		return nil, fmt.Errorf("machine %s is not running", machine.Name)
+	}
+
+	if machine.Status.ConsoleSocket == "" {
+		return nil, fmt.Errorf("machine %s does not support attaching to its console", machine.Name)
+	}
+
+	conn, err := net.Dial("unix", machine.Status.ConsoleSocket)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the console of %s: %w", machine.Name, err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/moby/term"
+	xterm "golang.org/x/term"
+
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+)
+
+// Forward forwards the standard input to the provided console in the
+// background until the detach key sequence is typed or the returned stop
+// function is called, whichever happens first.  If the standard input is a
+// terminal, it is set to raw mode for as long as it is forwarded such that
+// each key press reaches the guest as-is, including those which would
+// otherwise be interpreted by the terminal, e.g. Ctrl+C.  The returned channel
+// is closed once the detach key sequence has been typed and the terminal has
+// been restored.
+func Forward(ctx context.Context, console io.Writer, escapeKeys []byte) (<-chan struct{}, func(), error) {
+	streams := iostreams.G(ctx)
+
+	in, cancel := cancelableReader(streams.In)
+
+	var state *xterm.State
+	if streams.IsStdinTTY() {
+		var err error
+		state, err = xterm.MakeRaw(int(streams.In.Fd()))
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("could not set terminal to raw mode: %w", err)
+		}
+	}
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+
+			if state == nil {
+				return
+			}
+
+			if err := xterm.Restore(int(streams.In.Fd()), state); err != nil {
+				log.G(ctx).Debugf("could not restore terminal: %v", err)
+			}
+		})
+	}
+
+	detached := make(chan struct{})
+
+	go func() {
+		_, err := io.Copy(console, term.NewEscapeProxy(in, escapeKeys))
+		stop()
+
+		if errors.As(err, &term.EscapeError{}) {
+			close(detached)
+		}
+	}()
+
+	return detached, stop, nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/moby/term"
+
+	"kraftkit.sh/iostreams"
+)
+
+func TestForward(t *testing.T) {
+	escapeKeys, err := term.ToBytes(DefaultDetachKeys)
+	if err != nil {
+		t.Fatal("ToBytes:", err)
+	}
+
+	tests := []struct {
+		name string
+		// end stops forwarding, given the writing end of the standard input and
+		// the returned stop function.
+		end          func(stdin *os.File, stop func())
+		wantDetached bool
+	}{
+		{
+			name: "stopped",
+			end: func(_ *os.File, stop func()) {
+				stop()
+			},
+		},
+		{
+			name: "detach keys",
+			end: func(stdin *os.File, _ func()) {
+				_, _ = stdin.Write(escapeKeys)
+			},
+			wantDetached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal("Pipe:", err)
+			}
+
+			defer r.Close()
+			defer w.Close()
+
+			ctx := iostreams.WithIOStreams(context.Background(), &iostreams.IOStreams{
+				In:     r,
+				Out:    iostreams.NewNoTTYWriter(io.Discard, 0),
+				ErrOut: io.Discard,
+			})
+
+			console, guest := net.Pipe()
+			defer console.Close()
+			defer guest.Close()
+
+			detached, stop, err := Forward(ctx, console, escapeKeys)
+			if err != nil {
+				t.Fatal("Forward:", err)
+			}
+
+			defer stop()
+
+			if _, err := w.Write([]byte("hello")); err != nil {
+				t.Fatal("Write:", err)
+			}
+
+			buf := make([]byte, len("hello"))
+			if _, err := io.ReadFull(guest, buf); err != nil {
+				t.Fatal("ReadFull:", err)
+			} else if string(buf) != "hello" {
+				t.Fatalf("expected console to receive %q, got %q", "hello", buf)
+			}
+
+			tt.end(w, stop)
+
+			select {
+			case <-detached:
+				if !tt.wantDetached {
+					t.Fatal("expected forwarding not to detach")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantDetached {
+					t.Fatal("expected forwarding to detach")
+				}
+			}
+
+			// The standard input is no longer read once forwarding has stopped,
+			// such that input is left to the next reader.
+			if _, err := w.Write([]byte("left")); err != nil {
+				t.Fatal("Write:", err)
+			}
+
+			buf = make([]byte, len("left"))
+			if _, err := io.ReadFull(r, buf); err != nil {
+				t.Fatal("ReadFull:", err)
+			} else if string(buf) != "left" {
+				t.Fatalf("expected standard input to retain %q, got %q", "left", buf)
+			}
+		})
+	}
+}
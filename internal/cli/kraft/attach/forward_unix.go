@@ -0,0 +1,52 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cancelableReader returns a reader of the provided input whose pending read
+// returns once the returned function is called, which a read of the input
+// itself would only do once the next key is pressed.  To this end, the input
+// is duplicated in non-blocking mode such that it is managed by the runtime
+// poller, which unblocks reads of a file when it is closed.
+func cancelableReader(in interface {
+	io.Reader
+	Fd() uintptr
+},
+) (io.Reader, func()) {
+	fd := int(in.Fd())
+
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		return in, func() {}
+	}
+
+	dup, err := unix.Dup(fd)
+	if err != nil {
+		return in, func() {}
+	}
+
+	// The non-blocking mode is shared with the original input, such that it is
+	// restored once the duplicate is no longer read.
+	if err := unix.SetNonblock(dup, true); err != nil {
+		unix.Close(dup)
+		return in, func() {}
+	}
+
+	f := os.NewFile(uintptr(dup), "stdin")
+
+	return f, func() {
+		f.Close()
+		_ = unix.SetNonblock(fd, flags&unix.O_NONBLOCK != 0)
+	}
+}
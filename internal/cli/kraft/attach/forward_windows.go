@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"io"
+)
+
+// cancelableReader returns the provided input as-is since a pending read of
+// the console cannot be interrupted, such that the reader stops once the next
+// key is pressed.
+func cancelableReader(in interface {
+	io.Reader
+	Fd() uintptr
+},
+) (io.Reader, func()) {
+	return in, func() {}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/initrd"
 	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/internal/fancymap"
 	"kraftkit.sh/iostreams"
@@ -51,6 +52,7 @@ type BuildOptions struct {
 	Platform     string         `long:"plat" short:"p" usage:"Filter the creation of the build by platform of known targets"`
 	PrintStats   bool           `long:"print-stats" usage:"Print build statistics"`
 	Rootfs       string         `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RootfsType   string         `long:"rootfs-type" usage:"Set the type of the root file system (cpio, erofs, squashfs)" default:"cpio"`
 	SaveBuildLog string         `long:"build-log" usage:"Use the specified file to save the output from the build"`
 	Target       *target.Target `noattribute:"true"`
 	TargetName   string         `long:"target" short:"t" usage:"Build a particular known target"`
@@ -128,7 +130,16 @@ func Build(ctx context.Context, opts *BuildOptions, args ...string) error {
 		return fmt.Errorf("could not complete build: %w", err)
 	}
 
-	if opts.Rootfs, _, _, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, false, *opts.Target); err != nil {
+	// Images are attached as block devices and can therefore not be embedded.
+	rootfsType := initrd.Format(opts.RootfsType)
+	if rootfsType.IsImage() && (*opts.Target).KConfig().AnyYes(
+		"CONFIG_LIBVFSCORE_AUTOMOUNT_EINITRD",
+		"CONFIG_LIBVFSCORE_AUTOMOUNT_CI_EINITRD",
+	) {
+		return fmt.Errorf("cannot embed a %s root file system into the unikernel", rootfsType)
+	}
+
+	if opts.Rootfs, _, _, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, false, rootfsType, *opts.Target); err != nil {
 		return err
 	}
 
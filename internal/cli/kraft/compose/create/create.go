@@ -8,8 +8,11 @@ package create
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/compose-spec/compose-go/v2/types"
@@ -171,13 +174,34 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 			driver = network.Driver
 		}
 
-		subnet := ""
-		if len(network.Ipam.Config) > 0 {
-			subnet = network.Ipam.Config[0].Subnet
+		subnet, subnet6 := "", ""
+		for _, config := range network.Ipam.Config {
+			if config == nil {
+				continue
+			}
+
+			if ip, _, err := net.ParseCIDR(config.Subnet); err == nil && ip.To4() == nil {
+				if subnet6 == "" {
+					subnet6 = config.Subnet
+				}
+			} else if subnet == "" {
+				subnet = config.Subnet
+			}
 		}
+
+		// As with Docker, IPv6 subnets are only used once enabled explicitly.
+		if !network.EnableIPv6 {
+			subnet6 = ""
+		} else if subnet6 == "" {
+			subnet6 = "auto"
+		}
+
 		createOptions := netcreate.CreateOptions{
 			Driver:  driver,
+			IPv6:    subnet6,
 			Network: subnet,
+			// Internal networks have no access to the outside world.
+			NoNAT: network.Internal,
 		}
 
 		log.G(ctx).Infof("creating network %s...", network.Name)
@@ -481,7 +505,24 @@ func createService(ctx context.Context, project *compose.Project, service types.
 	if len(service.DNS) > 1 {
 		dns1 = service.DNS[1]
 	}
+	// Services are resolvable by their name and aliases via the DNS resolvers
+	// of their networks, in addition to their machine name.
+	aliases := []string{service.Name}
+	ip6 := ""
 	for name, network := range service.Networks {
+		if network != nil {
+			for _, alias := range network.Aliases {
+				if !slices.Contains(aliases, alias) {
+					aliases = append(aliases, alias)
+				}
+			}
+
+			// Static IPv6 addresses can only be assigned on a single network.
+			if len(service.Networks) == 1 {
+				ip6 = network.Ipv6Address
+			}
+		}
+
 		arg := uknetdev.NetdevIp{
 			CIDR:     network.Ipv4Address,
 			DNS0:     dns0,
@@ -524,16 +565,23 @@ func createService(ctx context.Context, project *compose.Project, service types.
 	}
 
 	runOptions := run.RunOptions{
-		Architecture: arch,
-		Detach:       true,
-		Env:          environ,
-		Memory:       memory,
-		Name:         service.ContainerName,
-		Networks:     networks,
-		NoStart:      true,
-		Platform:     plat,
-		Ports:        ports,
-		Volumes:      volumes,
+		Architecture:   arch,
+		Detach:         true,
+		Env:            environ,
+		IP6:            ip6,
+		Memory:         memory,
+		Name:           service.ContainerName,
+		NetworkAliases: aliases,
+		Networks:       networks,
+		NoStart:        true,
+		Platform:       plat,
+		Ports:          ports,
+		Restart:        service.Restart,
+		Volumes:        volumes,
+	}
+
+	if err := assignHealthCheck(&runOptions, service); err != nil {
+		return err
 	}
 
 	if service.Image != "" {
@@ -542,3 +590,57 @@ func createService(ctx context.Context, project *compose.Project, service types.
 
 	return runOptions.Run(ctx, []string{service.Build.Context})
 }
+
+// assignHealthCheck maps the health check of the service onto the options of
+// `kraft run`.  Since unikernels cannot execute commands, the test of the
+// health check is expected to be one of ["tcp", "<port>"],
+// ["http", "<port>[/path]"] or ["log", "<pattern>"], optionally prefixed with
+// "CMD".
+func assignHealthCheck(runOptions *run.RunOptions, service types.ServiceConfig) error {
+	hc := service.HealthCheck
+	if hc == nil || hc.Disable {
+		return nil
+	}
+
+	test := []string(hc.Test)
+	if len(test) > 0 {
+		switch test[0] {
+		case "NONE":
+			return nil
+		case "CMD":
+			test = test[1:]
+		case "CMD-SHELL":
+			test = strings.Fields(strings.Join(test[1:], " "))
+		}
+	}
+
+	if len(test) != 2 {
+		return fmt.Errorf("service %s has an unsupported healthcheck test: expected one of [tcp, <port>], [http, <port>[/path]] or [log, <pattern>]", service.Name)
+	}
+
+	switch test[0] {
+	case "tcp":
+		runOptions.HealthTCP = test[1]
+	case "http":
+		runOptions.HealthHTTP = test[1]
+	case "log":
+		runOptions.HealthLog = test[1]
+	default:
+		return fmt.Errorf("service %s has an unsupported healthcheck type: %s", service.Name, test[0])
+	}
+
+	if hc.Interval != nil {
+		runOptions.HealthInterval = time.Duration(*hc.Interval)
+	}
+	if hc.Timeout != nil {
+		runOptions.HealthTimeout = time.Duration(*hc.Timeout)
+	}
+	if hc.Retries != nil {
+		runOptions.HealthRetries = int(*hc.Retries)
+	}
+	if hc.StartPeriod != nil {
+		runOptions.HealthStartPeriod = time.Duration(*hc.StartPeriod)
+	}
+
+	return nil
+}
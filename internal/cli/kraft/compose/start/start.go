@@ -6,9 +6,12 @@ package start
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
@@ -18,6 +21,7 @@ import (
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	kernelstart "kraftkit.sh/internal/cli/kraft/start"
+	"kraftkit.sh/machine/health"
 	mplatform "kraftkit.sh/machine/platform"
 )
 
@@ -92,24 +96,80 @@ func (opts *StartOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	orderedServices := project.ServicesOrderedByDependencies(ctx, services, true)
-	machinesToStart := []string{}
+
+	// Machines are started in the order of their dependencies such that those
+	// which depend on healthy services are only started once they are healthy.
+	started := map[string]*machineapi.Machine{}
+
 	for _, service := range orderedServices {
-		for _, machine := range machines.Items {
-			if service.ContainerName == machine.Name {
-				if machine.Status.State == machineapi.MachineStateCreated || machine.Status.State == machineapi.MachineStateExited {
-					machinesToStart = append(machinesToStart, machine.Name)
-				}
+		var machine *machineapi.Machine
+		for i := range machines.Items {
+			if service.ContainerName == machines.Items[i].Name {
+				machine = &machines.Items[i]
+				break
 			}
 		}
-	}
 
-	kernelStartOptions := kernelstart.StartOptions{
-		Detach:   true,
-		Platform: "auto",
+		if machine == nil {
+			continue
+		}
+
+		started[service.Name] = machine
+
+		if err := waitForHealthyDependencies(ctx, service, started); err != nil {
+			return err
+		}
+
+		if machine.Status.State != machineapi.MachineStateCreated && machine.Status.State != machineapi.MachineStateExited {
+			continue
+		}
+
+		kernelStartOptions := kernelstart.StartOptions{
+			Detach:   true,
+			Platform: "auto",
+		}
+
+		if err := kernelStartOptions.Run(ctx, []string{machine.Name}); err != nil {
+			return err
+		}
+
+		// Record the start such that the start period of the machine's health
+		// check is honoured by dependants waiting for it to be healthy.
+		machine.Status.StartedAt = time.Now()
 	}
 
-	if err := kernelStartOptions.Run(ctx, machinesToStart); err != nil {
-		return err
+	return nil
+}
+
+// waitForHealthyDependencies blocks until each of the dependencies of the
+// provided service with the "service_healthy" condition is healthy.
+func waitForHealthyDependencies(ctx context.Context, service types.ServiceConfig, machines map[string]*machineapi.Machine) error {
+	for name, dependency := range service.DependsOn {
+		if dependency.Condition != types.ServiceConditionHealthy {
+			continue
+		}
+
+		machine, ok := machines[name]
+		if !ok {
+			if dependency.Required {
+				return fmt.Errorf("service %s depends on %s which has not been created", service.Name, name)
+			}
+
+			continue
+		}
+
+		if machine.Spec.HealthCheck == nil {
+			return fmt.Errorf("service %s depends on %s being healthy but it has no healthcheck", service.Name, name)
+		}
+
+		log.G(ctx).
+			WithField("service", service.Name).
+			WithField("on", name).
+			Info("waiting for dependency to be healthy")
+
+		if err := health.Wait(ctx, machine); err != nil {
+			return fmt.Errorf("dependency %s of service %s: %w", name, service.Name, err)
+		}
 	}
 
 	return nil
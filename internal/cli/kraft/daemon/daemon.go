@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	kraftd "kraftkit.sh/daemon"
+)
+
+type DaemonOptions struct {
+	Socket string `long:"socket" short:"s" usage:"Path to the Unix socket to serve at (default is the configured daemon socket)"`
+}
+
+// Daemon serves the local machine, network, volume and compose services until
+// the provided context is cancelled.
+func Daemon(ctx context.Context, opts *DaemonOptions, args ...string) error {
+	if opts == nil {
+		opts = &DaemonOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DaemonOptions{}, cobra.Command{
+		Short:   "Run the local KraftKit daemon",
+		Use:     "daemon [FLAGS]",
+		Aliases: []string{"kraftd"},
+		Args:    cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Run the local KraftKit daemon.
+
+			The daemon hosts the machine, network, volume and compose services behind
+			a Unix socket with a versioned JSON/HTTP API.  Whilst it is running, all
+			other invocations of kraft transparently use it rather than instantiating
+			the drivers themselves.  Set KRAFTKIT_NO_DAEMON=true to bypass it.
+
+			The daemon also supervises the machines it hosts: machines which have exited
+			are restarted according to their restart policy and the health of machines
+			with a health check is monitored.
+		`),
+		Example: heredoc.Doc(`
+			# Run the daemon in the foreground
+			$ kraft daemon
+
+			# Run the daemon at a specific socket
+			$ kraft daemon --socket /run/kraftd.sock
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DaemonOptions) Run(ctx context.Context, _ []string) error {
+	var dopts []kraftd.DaemonOption
+	if opts.Socket != "" {
+		dopts = append(dopts, kraftd.WithSocket(opts.Socket))
+	}
+
+	daemon, err := kraftd.NewDaemon(ctx, dopts...)
+	if err != nil {
+		return err
+	}
+
+	return daemon.Serve(ctx)
+}
@@ -6,7 +6,6 @@ package events
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -20,15 +19,16 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
-	"kraftkit.sh/internal/waitgroup"
+	"kraftkit.sh/daemon/client"
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
-	"kraftkit.sh/machine/qemu/qmp"
+	"kraftkit.sh/machine/supervisor"
+	"kraftkit.sh/store"
 )
 
 type EventOptions struct {
 	platform     string
-	Granularity  time.Duration `long:"poll-granularity" short:"g" usage:"How often the machine store and state should polled"`
+	Granularity  time.Duration `long:"poll-granularity" short:"g" usage:"Deprecated: the machine store is watched for changes instead" hidden:"true"`
 	QuitTogether bool          `long:"quit-together" short:"q" usage:"Exit event loop when machine exits"`
 }
 
@@ -68,8 +68,6 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
-var observations = waitgroup.WaitGroup[*machineapi.Machine]{}
-
 func (opts *EventOptions) Pre(cmd *cobra.Command, _ []string) error {
 	opts.platform = cmd.Flag("plat").Value.String()
 	return nil
@@ -80,6 +78,11 @@ func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 
 	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
 
+	// Machines must not be restarted by more than one supervisor.
+	if client.Available(ctx) {
+		return fmt.Errorf("machines are already supervised by the daemon")
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	platform := mplatform.PlatformUnknown
 
@@ -156,84 +159,24 @@ func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 	// TODO: Should we throw an error here if a process file already exists?  We
 	// use a pid file for `kraft run` to continuously monitor running machines.
 
-	// Actively seek for machines whose events we wish to monitor.  The thread
-	// will continuously read from the machine store which can be updated
-	// elsewhere and acts as the source-of-truth for VMs which are being
-	// instantiated by KraftKit.  The thread dies if there is nothing in the store
-	// and the `--quit-together` flag is set.
-seek:
-	for {
-		select {
-		case <-ctx.Done():
-			break seek
-		default:
-		}
-
-		machines, err := controller.List(ctx, &machineapi.MachineList{})
-		if err != nil {
-			return fmt.Errorf("could not list machines: %v", err)
-		}
-
-		for _, machine := range machines.Items {
-			if len(args) == 0 || (args[0] == string(machine.UID) || args[0] == machine.Name) {
-				switch machine.Status.State {
-				case machineapi.MachineStateFailed,
-					machineapi.MachineStateExited,
-					machineapi.MachineStateUnknown:
-					if opts.QuitTogether {
-						continue
-					}
-				default:
-				}
-
-				observations.Add(&machine)
-			}
-		}
-
-		if len(observations.Items()) == 0 && opts.QuitTogether {
-			cancel()
-			break seek
-		}
-
-		for _, machine := range observations.Items() {
-			machine := machine // loop closure
-
-			go func() {
-				events, errs, err := controller.Watch(ctx, machine)
-				if err != nil {
-					log.G(ctx).Debugf("could not listen for status updates for %s: %v", machine.Name, err)
-					return
-				}
-
-				for {
-					// Wait on either channel
-					select {
-					case machine := <-events:
-						log.G(ctx).Infof("%s : %s", machine.Name, machine.Status.State.String())
-						switch machine.Status.State {
-						case machineapi.MachineStateExited, machineapi.MachineStateFailed:
-							observations.Done(machine)
-							return
-						}
-
-					case err := <-errs:
-						if !errors.Is(err, qmp.ErrAcceptedNonEvent) {
-							log.G(ctx).Errorf("%v", err)
-						}
-						observations.Done(machine)
-
-					case <-ctx.Done():
-						observations.Done(machine)
-						return
-					}
-				}
-			}()
-		}
+	// Supervise the machines of the machine store.  The loop ends if there is
+	// nothing left to observe and the `--quit-together` flag is set.
+	machineStore, err := store.NewRuntimeStore[machineapi.MachineSpec, machineapi.MachineStatus](ctx, "machinev1alpha1")
+	if err != nil {
+		cancel()
+		return err
+	}
 
-		time.Sleep(time.Second * opts.Granularity)
+	var sopts []supervisor.SupervisorOption
+	if len(args) > 0 {
+		sopts = append(sopts, supervisor.WithMachine(args[0]))
+	}
+	if opts.QuitTogether {
+		sopts = append(sopts, supervisor.WithQuitTogether())
 	}
 
-	observations.Wait()
+	err = supervisor.Supervise(ctx, controller, machineStore, sopts...)
+	cancel()
 
-	return nil
+	return err
 }
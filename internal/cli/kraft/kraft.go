@@ -26,10 +26,12 @@ import (
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 
+	"kraftkit.sh/internal/cli/kraft/attach"
 	"kraftkit.sh/internal/cli/kraft/build"
 	"kraftkit.sh/internal/cli/kraft/clean"
 	"kraftkit.sh/internal/cli/kraft/cloud"
 	"kraftkit.sh/internal/cli/kraft/compose"
+	"kraftkit.sh/internal/cli/kraft/daemon"
 	"kraftkit.sh/internal/cli/kraft/events"
 	"kraftkit.sh/internal/cli/kraft/fetch"
 	"kraftkit.sh/internal/cli/kraft/login"
@@ -42,7 +44,9 @@ import (
 	"kraftkit.sh/internal/cli/kraft/run"
 	"kraftkit.sh/internal/cli/kraft/set"
 	"kraftkit.sh/internal/cli/kraft/start"
+	"kraftkit.sh/internal/cli/kraft/stats"
 	"kraftkit.sh/internal/cli/kraft/stop"
+	"kraftkit.sh/internal/cli/kraft/system"
 	"kraftkit.sh/internal/cli/kraft/unset"
 	"kraftkit.sh/internal/cli/kraft/version"
 	"kraftkit.sh/internal/cli/kraft/volume"
@@ -91,12 +95,15 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(pkg.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "run", Title: "LOCAL RUNTIME COMMANDS"})
+	cmd.AddCommand(attach.NewCmd())
+	cmd.AddCommand(daemon.NewCmd())
 	cmd.AddCommand(events.NewCmd())
 	cmd.AddCommand(logs.NewCmd())
 	cmd.AddCommand(ps.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(run.NewCmd())
 	cmd.AddCommand(start.NewCmd())
+	cmd.AddCommand(stats.NewCmd())
 	cmd.AddCommand(stop.NewCmd())
 	cmd.AddCommand(pause.NewCmd())
 
@@ -119,6 +126,7 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(volume.NewCmd())
 
 	cmd.AddCommand(login.NewCmd())
+	cmd.AddCommand(system.NewCmd())
 	cmd.AddCommand(version.NewCmd())
 	cmd.AddCommand(x.NewCmd())
 
@@ -248,7 +248,12 @@ func FollowLogs(ctx context.Context, machine *machineapi.Machine, controller mac
 		for {
 			// Wait on either channel
 			select {
-			case status := <-events:
+			case status, ok := <-events:
+				if !ok {
+					cancel()
+					break loop
+				}
+
 				switch status.Status.State {
 				case machineapi.MachineStateErrored:
 					exitErr = fmt.Errorf("machine fatally exited")
@@ -260,7 +265,12 @@ func FollowLogs(ctx context.Context, machine *machineapi.Machine, controller mac
 					break loop
 				}
 
-			case err := <-errs:
+			case err, ok := <-errs:
+				if !ok {
+					cancel()
+					break loop
+				}
+
 				log.G(ctx).Errorf("received event error: %v", err)
 				exitErr = err
 				cancel()
@@ -0,0 +1,303 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/bpf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/capture"
+	"kraftkit.sh/machine/network/macvtap"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/qemu"
+)
+
+type CaptureOptions struct {
+	Count   int    `long:"count" short:"c" usage:"Exit after capturing the given number of packets"`
+	Driver  string `noattribute:"true"`
+	Filter  string `long:"filter" short:"f" usage:"Only capture packets matching the given filter expression, e.g. 'tcp port 80'"`
+	Format  string `long:"format" usage:"Set the output format (pcap, pcapng), by default derived from the output file's extension"`
+	Machine string `long:"machine" short:"m" usage:"Only capture the traffic of the given machine"`
+	Output  string `long:"write" short:"w" usage:"Write the packets to the given file instead of stdout"`
+	Snaplen int    `long:"snaplen" short:"s" usage:"Capture at most the given number of bytes of each packet" default:"262144"`
+}
+
+// errDone indicates that the requested number of packets has been captured.
+var errDone = errors.New("done")
+
+// Capture the traffic of a local machine network.
+func Capture(ctx context.Context, opts *CaptureOptions, args ...string) error {
+	if opts == nil {
+		opts = &CaptureOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CaptureOptions{}, cobra.Command{
+		Short: "Capture the traffic of a network",
+		Use:   "capture [FLAGS] [NETWORK]",
+		Args:  cobra.MaximumNArgs(1),
+		Long: heredoc.Doc(`
+			Capture the traffic of a network or a machine in the pcap or pcapng
+			format.
+
+			Without a machine, the traffic of the network's bridge, or the parent
+			interface of a macvtap network, is captured.  Otherwise, only the traffic
+			of the machine's interface on the network is captured.  The network can
+			be omitted for machines which are not attached to any network, in which
+			case the traffic of their user-mode network device is dumped by QEMU.
+
+			The filter expression supports a subset of the pcap-filter(7) syntax:
+			the primitives ip, ip6, arp, tcp, udp, icmp, icmp6, [src|dst] host ADDR,
+			[src|dst] net CIDR, [tcp|udp] [src|dst] port PORT and
+			ether [src|dst] host MAC, combined with and, or, not and parentheses.
+		`),
+		Example: heredoc.Doc(`
+			# Capture the traffic of a network to a file
+			$ kraft network capture my-network -w out.pcap
+
+			# Capture the HTTP traffic of a machine in the pcapng format
+			$ kraft network capture my-network --machine my-machine --filter 'tcp port 80' -w out.pcapng
+
+			# Inspect the traffic of a network live with Wireshark
+			$ kraft network capture my-network | wireshark -k -i -
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "net",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CaptureOptions) Pre(cmd *cobra.Command, args []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+
+	if len(args) == 0 && opts.Machine == "" {
+		return fmt.Errorf("either a network or a machine must be provided")
+	}
+
+	if opts.Format == "" {
+		opts.Format = string(capture.FormatPcap)
+		if strings.HasSuffix(opts.Output, ".pcapng") {
+			opts.Format = string(capture.FormatPcapng)
+		}
+	}
+
+	if opts.Snaplen <= 0 {
+		opts.Snaplen = capture.DefaultSnaplen
+	}
+
+	return nil
+}
+
+func (opts *CaptureOptions) Run(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	filter, err := capture.CompileFilter(opts.Filter, opts.Snaplen)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+
+	var machine *machineapi.Machine
+	if opts.Machine != "" {
+		machine, err = opts.findMachine(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ifname string
+	if len(args) > 0 {
+		ifname, err = opts.networkInterface(ctx, args[0], machine)
+	} else if len(machine.Spec.Networks) > 0 && len(machine.Spec.Networks[0].Interfaces) > 0 {
+		ifname = machine.Spec.Networks[0].Interfaces[0].Spec.IfName
+	} else if machine.Spec.Platform != mplatform.PlatformQEMU.String() {
+		err = fmt.Errorf("machine %s is not attached to any network", machine.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer
+	if opts.Output == "" || opts.Output == "-" {
+		if iostreams.G(ctx).IsStdoutTTY() {
+			return fmt.Errorf("refusing to write packets to a terminal, use --write to write them to a file")
+		}
+
+		out = iostreams.G(ctx).Out
+	} else {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	buf := bufio.NewWriter(out)
+	defer buf.Flush()
+
+	writer, err := capture.NewWriter(buf, capture.Format(opts.Format), opts.Snaplen, ifname)
+	if err != nil {
+		return err
+	}
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctrlc
+		cancel()
+	}()
+
+	captured := 0
+	handle := func(ci capture.CaptureInfo, data []byte) error {
+		if err := writer.WritePacket(ci, data); err != nil {
+			return err
+		}
+
+		// Flush each packet such that the output can be inspected live.
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+
+		captured++
+		if opts.Count > 0 && captured >= opts.Count {
+			return errDone
+		}
+
+		return nil
+	}
+
+	if ifname != "" {
+		log.G(ctx).WithField("interface", ifname).Info("capturing packets")
+		err = capture.Interface(ctx, ifname, filter, opts.Snaplen, handle)
+	} else {
+		err = opts.dump(ctx, machine, filter, handle)
+	}
+
+	log.G(ctx).Infof("%d packets captured", captured)
+
+	if errors.Is(err, errDone) {
+		return nil
+	}
+
+	return err
+}
+
+// findMachine returns the machine set via the options.
+func (opts *CaptureOptions) findMachine(ctx context.Context) (*machineapi.Machine, error) {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range machines.Items {
+		if opts.Machine == machine.Name || opts.Machine == string(machine.UID) {
+			return &machine, nil
+		}
+	}
+
+	return nil, fmt.Errorf("machine not found: %s", opts.Machine)
+}
+
+// networkInterface returns the name of the host interface carrying the
+// traffic of the network with the provided name or, if provided, of the
+// machine's interface on it.
+func (opts *CaptureOptions) networkInterface(ctx context.Context, name string, machine *machineapi.Machine) (string, error) {
+	strategy, ok := network.Strategies()[opts.Driver]
+	if !ok {
+		return "", fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	found, err := controller.Get(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if machine == nil {
+		if found.Spec.Driver == macvtap.DriverName {
+			return found.Spec.Parent, nil
+		}
+
+		return found.Spec.IfName, nil
+	}
+
+	// The interfaces of the machine are a subset of those of the network.
+	for _, mnet := range machine.Spec.Networks {
+		for _, miface := range mnet.Interfaces {
+			for _, iface := range found.Spec.Interfaces {
+				if iface.UID == miface.UID {
+					return iface.Spec.IfName, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("machine %s is not attached to network %s", machine.Name, found.Name)
+}
+
+// dump captures the traffic of a QEMU machine which has no interface on the
+// host via its network device backend.
+func (opts *CaptureOptions) dump(ctx context.Context, machine *machineapi.Machine, filter []bpf.Instruction, handle func(capture.CaptureInfo, []byte) error) error {
+	path := filepath.Join(machine.Status.StateDir, "capture.pcap")
+
+	stop, err := qemu.DumpNetDevice(ctx, machine, path, opts.Snaplen)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+	defer func() {
+		if err := stop(); err != nil {
+			log.G(ctx).Warnf("could not stop dumping network device: %v", err)
+		}
+	}()
+
+	log.G(ctx).WithField("machine", machine.Name).Info("capturing packets")
+
+	return capture.File(ctx, path, filter, handle)
+}
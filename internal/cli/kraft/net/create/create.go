@@ -16,13 +16,20 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/utils"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/macvtap"
 )
 
 type CreateOptions struct {
+	DHCP    bool   `long:"dhcp" usage:"Lease the addresses of the network's interfaces to machines via DHCP"`
 	Driver  string `noattribute:"true"`
-	Network string `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format."`
+	IPv6    string `long:"ipv6" usage:"Additionally set the IPv6 subnet of the network in CIDR format, or 'auto' to allocate one from the configured pools"`
+	Network string `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format, otherwise allocate one from the configured pools."`
+	NoNAT   bool   `long:"no-nat" usage:"Do not masquerade traffic from the network to the outside world"`
+	Parent  string `long:"parent" usage:"Set the host interface to attach the network to (macvtap driver only)"`
 }
 
 // Create a new local machine network.
@@ -40,10 +47,43 @@ func NewCmd() *cobra.Command {
 		Use:     "create [FLAGS] NETWORK",
 		Aliases: []string{"add"},
 		Args:    cobra.ExactArgs(1),
-		Long:    "Create a new machine network.",
+		Long: heredoc.Doc(`
+			Create a new machine network.
+
+			Traffic from machines on the network to the outside world is masqueraded
+			behind the address of the host unless --no-nat is set.  Machines on
+			different networks cannot reach each other.
+
+			Unless provided, the subnet of the network is allocated from the address
+			pools set via the network.pools configuration option or the
+			KRAFTKIT_NETWORK_POOLS environment variable, e.g.
+			"10.10.0.0/16:24,fd00:1::/48:64".  Subnets which intersect with existing
+			networks or routes of the host are skipped.
+
+			The macvtap driver attaches machines directly to the network of the host
+			interface set via --parent instead, such that they appear as hosts on
+			that network.  Machines without an address acquire one from the network
+			itself, e.g. via its DHCP server.  Note that the host cannot reach
+			machines on a macvtap network via the parent interface.
+		`),
 		Example: heredoc.Doc(`
 			# Create a new machine network
 			$ kraft network create my-network --network 133.37.0.1/12
+
+			# Create a new machine network which configures machines via DHCP
+			$ kraft network create my-network --dhcp
+
+			# Create a new dual-stack machine network
+			$ kraft network create my-network --ipv6 fd00::/64
+
+			# Create a new machine network whose machines cannot reach the outside world
+			$ kraft network create my-network --no-nat
+
+			# Create a new machine network from a custom address pool
+			$ KRAFTKIT_NETWORK_POOLS=10.10.0.0/16:24 kraft network create my-network
+
+			# Create a new machine network which attaches machines to the network of eth0
+			$ kraft network create my-network --driver macvtap --parent eth0
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "net",
@@ -75,17 +115,40 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if opts.Driver == macvtap.DriverName {
+		return opts.createMacvtap(ctx, controller, args[0])
+	} else if opts.Parent != "" {
+		return fmt.Errorf("the --parent flag is only supported by the %s driver", macvtap.DriverName)
+	}
+
+	existingNetworks, err := controller.List(ctx, &networkapi.NetworkList{})
+	if err != nil {
+		return err
+	}
+
+	// Never allocate subnets which are already routed elsewhere by the host.
+	routes, err := network.HostRoutes()
+	if err != nil {
+		log.G(ctx).Warnf("could not determine host routes: %v", err)
+	}
+
+	var pools []string
+
 	if opts.Network == "" {
-		existingNetworks, err := controller.List(ctx, &networkapi.NetworkList{})
+		pool, err := network.ConfiguredNetworkPool(ctx, false)
 		if err != nil {
 			return err
 		}
 
-		freeNetwork, err := network.FindFreeNetwork(network.DefaultNetworkPool, existingNetworks)
+		freeNetwork, err := network.FindFreeNetwork(pool, existingNetworks, routes...)
 		if err != nil {
 			return err
 		}
 
+		if entry, ok := pool.Entry(freeNetwork.IP); ok {
+			pools = append(pools, entry.Subnet)
+		}
+
 		opts.Network = freeNetwork.String()
 	}
 
@@ -94,19 +157,111 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	if _, err := controller.Create(ctx, &networkapi.Network{
+	var ipv6 string
+	if opts.IPv6 == "auto" {
+		pool, err := network.ConfiguredNetworkPool(ctx, true)
+		if err != nil {
+			return err
+		}
+
+		freeNetwork, err := network.FindFreeNetwork(pool, existingNetworks, routes...)
+		if err != nil {
+			return err
+		}
+
+		if entry, ok := pool.Entry(freeNetwork.IP); ok {
+			pools = append(pools, entry.Subnet)
+		}
+
+		ipv6 = freeNetwork.String()
+	} else if opts.IPv6 != "" {
+		ip, subnet, err := net.ParseCIDR(opts.IPv6)
+		if err != nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 subnet: %s", opts.IPv6)
+		}
+
+		// Use the first address of the subnet as the gateway unless a specific
+		// address has been provided.
+		if ip.Equal(subnet.IP) {
+			ip[len(ip)-1]++
+		}
+
+		ones, _ := subnet.Mask.Size()
+		ipv6 = fmt.Sprintf("%s/%d", ip, ones)
+	}
+
+	created, err := controller.Create(ctx, &networkapi.Network{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: args[0],
 		},
 		Spec: networkapi.NetworkSpec{
-			Gateway: addr.IP.String(),
-			Netmask: net.IP(addr.Mask).String(),
+			Gateway:    addr.IP.String(),
+			Netmask:    net.IP(addr.Mask).String(),
+			IPv6:       ipv6,
+			Pools:      pools,
+			DHCP:       opts.DHCP,
+			DisableNAT: opts.NoNAT,
 		},
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
 
+	if err := utils.Spawn(ctx, created, "dns"); err != nil {
+		return err
+	}
+
+	if created.Spec.DHCP {
+		if err := utils.Spawn(ctx, created, "dhcp"); err != nil {
+			return err
+		}
+	}
+
+	if created.Spec.IPv6 != "" {
+		if err := utils.Spawn(ctx, created, "ra"); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(iostreams.G(ctx).Out, args[0])
 
 	return nil
 }
+
+// createMacvtap creates a network which attaches machines directly to the
+// network of the parent interface.  Its subnet is that of the parent's network
+// and hence only set if provided, as it cannot be allocated from the pools.
+func (opts *CreateOptions) createMacvtap(ctx context.Context, controller networkapi.NetworkService, name string) error {
+	if opts.Parent == "" {
+		return fmt.Errorf("the %s driver requires the --parent flag", macvtap.DriverName)
+	}
+
+	spec := networkapi.NetworkSpec{
+		Parent: opts.Parent,
+		IPv6:   opts.IPv6,
+		DHCP:   opts.DHCP,
+	}
+
+	if opts.Network != "" {
+		addr, err := netlink.ParseAddr(opts.Network)
+		if err != nil {
+			return err
+		}
+
+		spec.Gateway = addr.IP.String()
+		spec.Netmask = net.IP(addr.Mask).String()
+	}
+
+	if _, err := controller.Create(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: spec,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, name)
+
+	return nil
+}
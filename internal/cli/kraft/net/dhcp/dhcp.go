@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/utils"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dhcp"
+	"kraftkit.sh/store"
+)
+
+type DHCPOptions struct {
+	Driver string `noattribute:"true"`
+}
+
+// DHCP serves the addresses of the interfaces of a local machine network via
+// DHCPv4 until the provided context is cancelled or the network is removed.
+func DHCP(ctx context.Context, opts *DHCPOptions, args ...string) error {
+	if opts == nil {
+		opts = &DHCPOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DHCPOptions{}, cobra.Command{
+		Short:   "Serve DHCP on a machine network",
+		Hidden:  true,
+		Use:     "dhcp NETWORK",
+		Aliases: []string{},
+		Args:    cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Serve the addresses of the interfaces of a machine network via DHCPv4.
+
+			The server is started in the background for networks which are created
+			with the --dhcp flag and exits once the network is removed.
+		`),
+		Example: heredoc.Doc(`
+			# Serve DHCP on a machine network in the foreground
+			$ kraft network dhcp my-network
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup:  "net",
+			cmdfactory.AnnotationHelpHidden: "true",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DHCPOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *DHCPOptions) Run(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	strategy, ok := network.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	found, err := controller.Get(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found.Spec.DHCP {
+		return fmt.Errorf("DHCP is not enabled on network %s", found.Name)
+	}
+
+	removePidFile, err := utils.WritePidFile(ctx, found, "dhcp")
+	if err != nil {
+		return err
+	}
+
+	defer removePidFile()
+
+	// The leases are persisted alongside the network such that they can be
+	// inspected and are released when the network's interfaces are removed.
+	networkStore, err := store.NewRuntimeStore[networkapi.NetworkSpec, networkapi.NetworkStatus](ctx, "networkv1alpha1")
+	if err != nil {
+		return err
+	}
+
+	server, err := dhcp.NewServer(
+		dhcp.WithLeaseHandler(func(ctx context.Context, lease networkapi.NetworkDHCPLease, released bool) error {
+			_, err := store.Update[networkapi.NetworkSpec, networkapi.NetworkStatus](ctx, networkStore, found.UID, func(existing *networkapi.Network) error {
+				if released {
+					log.G(ctx).Infof("%s : released %s", lease.MacAddress, lease.IP)
+					dhcp.ReleaseLease(existing, lease.MacAddress)
+				} else {
+					log.G(ctx).Infof("%s : leased %s until %s", lease.MacAddress, lease.IP, lease.ExpiresAt.Format("15:04:05"))
+					dhcp.RecordLease(existing, lease)
+				}
+
+				dhcp.PruneLeases(existing)
+
+				return nil
+			})
+			return err
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.SetNetwork(found); err != nil {
+		return err
+	}
+
+	// Follow the network such that interfaces which are added or removed
+	// afterwards, e.g. when machines are created or deleted, are served.
+	if err := utils.Follow(ctx, networkStore, found,
+		func(updated *networkapi.Network) {
+			if err := server.SetNetwork(updated); err != nil {
+				log.G(ctx).Warnf("could not reload network %s: %v", found.Name, err)
+			}
+		},
+		func() {
+			log.G(ctx).Infof("network %s has been removed", found.Name)
+			cancel()
+		},
+	); err != nil {
+		return err
+	}
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-ctrlc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.G(ctx).Infof("serving DHCP on %s", found.Spec.IfName)
+
+	return server.Serve(ctx)
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/utils"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dns"
+	"kraftkit.sh/store"
+)
+
+type DNSOptions struct {
+	Driver string `noattribute:"true"`
+}
+
+// DNS resolves the names of the machines on a local machine network until the
+// provided context is cancelled or the network is removed.
+func DNS(ctx context.Context, opts *DNSOptions, args ...string) error {
+	if opts == nil {
+		opts = &DNSOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DNSOptions{}, cobra.Command{
+		Short:   "Serve DNS on a machine network",
+		Hidden:  true,
+		Use:     "dns NETWORK",
+		Aliases: []string{},
+		Args:    cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Resolve the names of the machines on a machine network via DNS.
+
+			The resolver listens on the gateway address of the network and answers
+			for the names of the machines, their compose services and aliases.  Other
+			names are resolved by the name servers of the host.  It is started in the
+			background when the network is created or brought up and exits once the
+			network is removed.
+		`),
+		Example: heredoc.Doc(`
+			# Serve DNS on a machine network in the foreground
+			$ kraft network dns my-network
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup:  "net",
+			cmdfactory.AnnotationHelpHidden: "true",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DNSOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *DNSOptions) Run(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	strategy, ok := network.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	found, err := controller.Get(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	removePidFile, err := utils.WritePidFile(ctx, found, "dns")
+	if err != nil {
+		return err
+	}
+
+	defer removePidFile()
+
+	networkStore, err := store.NewRuntimeStore[networkapi.NetworkSpec, networkapi.NetworkStatus](ctx, "networkv1alpha1")
+	if err != nil {
+		return err
+	}
+
+	server, err := dns.NewServer()
+	if err != nil {
+		return err
+	}
+
+	if err := server.SetNetwork(found); err != nil {
+		return err
+	}
+
+	// Follow the network such that machines which join or leave it afterwards
+	// are resolved accordingly.
+	if err := utils.Follow(ctx, networkStore, found,
+		func(updated *networkapi.Network) {
+			if err := server.SetNetwork(updated); err != nil {
+				log.G(ctx).Warnf("could not reload network %s: %v", found.Name, err)
+			}
+		},
+		func() {
+			log.G(ctx).Infof("network %s has been removed", found.Name)
+			cancel()
+		},
+	); err != nil {
+		return err
+	}
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-ctrlc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.G(ctx).Infof("serving DNS on %s", found.Spec.Gateway)
+
+	return server.Serve(ctx)
+}
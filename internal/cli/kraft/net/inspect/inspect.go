@@ -7,7 +7,12 @@ package inspect
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -21,6 +26,7 @@ import (
 
 type InspectOptions struct {
 	Driver string `noattribute:"true"`
+	Watch  bool   `long:"watch" short:"w" usage:"Stream the changes of the network's state until interrupted"`
 }
 
 func NewCmd() *cobra.Command {
@@ -33,6 +39,9 @@ func NewCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			# Inspect a machine network
 			$ kraft network inspect my-network
+
+			# Stream the changes of the state of a machine network
+			$ kraft network inspect -w my-network
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "net",
@@ -72,7 +81,49 @@ func (opts *InspectOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	ret, err := json.Marshal(network)
+	if !opts.Watch {
+		return printJSON(ctx, network)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctrlc
+		cancel()
+	}()
+
+	// The first event is the current state of the network.
+	events, errs, err := controller.Watch(ctx, network)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+
+		case network := <-events:
+			if err := printJSON(ctx, network); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// printJSON prints the provided object as a single line of JSON.
+func printJSON(ctx context.Context, obj any) error {
+	ret, err := json.Marshal(obj)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -89,6 +90,7 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 		id      string
 		name    string
 		network string
+		pool    string
 		driver  string
 		status  networkapi.NetworkState
 	}
@@ -100,10 +102,22 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 			IP:   net.ParseIP(network.Spec.Gateway),
 			Mask: net.IPMask(net.ParseIP(network.Spec.Netmask)),
 		}
+		var subnets []string
+		if network.Spec.Gateway != "" {
+			subnets = append(subnets, addr.String())
+		}
+		if network.Spec.IPv6 != "" {
+			subnets = append(subnets, network.Spec.IPv6)
+		}
+		if network.Spec.Parent != "" {
+			subnets = append(subnets, "via "+network.Spec.Parent)
+		}
+
 		items = append(items, netTable{
 			id:      string(network.UID),
 			name:    network.Name,
-			network: addr.String(),
+			network: strings.Join(subnets, ", "),
+			pool:    strings.Join(network.Spec.Pools, ", "),
 			driver:  opts.Driver,
 			status:  network.Status.State,
 		})
@@ -133,6 +147,7 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 	}
 	table.AddField("NAME", cs.Bold)
 	table.AddField("NETWORK", cs.Bold)
+	table.AddField("POOL", cs.Bold)
 	table.AddField("DRIVER", cs.Bold)
 	table.AddField("STATUS", cs.Bold)
 	table.EndRow()
@@ -143,6 +158,7 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 		}
 		table.AddField(item.name, nil)
 		table.AddField(item.network, nil)
+		table.AddField(item.pool, nil)
 		table.AddField(item.driver, nil)
 		table.AddField(item.status.String(), nil)
 		table.EndRow()
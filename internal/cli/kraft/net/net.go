@@ -13,10 +13,14 @@ import (
 	"github.com/spf13/pflag"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/capture"
 	"kraftkit.sh/internal/cli/kraft/net/create"
+	"kraftkit.sh/internal/cli/kraft/net/dhcp"
+	"kraftkit.sh/internal/cli/kraft/net/dns"
 	"kraftkit.sh/internal/cli/kraft/net/down"
 	"kraftkit.sh/internal/cli/kraft/net/inspect"
 	"kraftkit.sh/internal/cli/kraft/net/list"
+	"kraftkit.sh/internal/cli/kraft/net/ra"
 	"kraftkit.sh/internal/cli/kraft/net/remove"
 	"kraftkit.sh/internal/cli/kraft/net/up"
 	"kraftkit.sh/internal/set"
@@ -46,10 +50,14 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(capture.NewCmd())
 	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(dhcp.NewCmd())
+	cmd.AddCommand(dns.NewCmd())
 	cmd.AddCommand(down.NewCmd())
 	cmd.AddCommand(inspect.NewCmd())
 	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(ra.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(up.NewCmd())
 
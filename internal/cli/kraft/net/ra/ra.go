@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/utils"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/ra"
+	"kraftkit.sh/store"
+)
+
+type RAOptions struct {
+	Driver string `noattribute:"true"`
+}
+
+// RA advertises the IPv6 prefix of a local machine network until the provided
+// context is cancelled or the network is removed.
+func RA(ctx context.Context, opts *RAOptions, args ...string) error {
+	if opts == nil {
+		opts = &RAOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RAOptions{}, cobra.Command{
+		Short:   "Advertise the IPv6 prefix of a machine network",
+		Hidden:  true,
+		Use:     "ra NETWORK",
+		Aliases: []string{},
+		Args:    cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Advertise the IPv6 prefix of a machine network via router advertisements.
+
+			Machines on the network configure their IPv6 addresses from the prefix
+			via stateless address autoconfiguration (SLAAC) and use the gateway of
+			the network as their default router and name server.  It is started in
+			the background for networks which are created with the --ipv6 flag and
+			exits once the network is removed.
+		`),
+		Example: heredoc.Doc(`
+			# Advertise the IPv6 prefix of a machine network in the foreground
+			$ kraft network ra my-network
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup:  "net",
+			cmdfactory.AnnotationHelpHidden: "true",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RAOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *RAOptions) Run(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	strategy, ok := network.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	found, err := controller.Get(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if found.Spec.IPv6 == "" {
+		return fmt.Errorf("IPv6 is not enabled on network %s", found.Name)
+	}
+
+	removePidFile, err := utils.WritePidFile(ctx, found, "ra")
+	if err != nil {
+		return err
+	}
+
+	defer removePidFile()
+
+	networkStore, err := store.NewRuntimeStore[networkapi.NetworkSpec, networkapi.NetworkStatus](ctx, "networkv1alpha1")
+	if err != nil {
+		return err
+	}
+
+	server, err := ra.NewServer()
+	if err != nil {
+		return err
+	}
+
+	if err := server.SetNetwork(found); err != nil {
+		return err
+	}
+
+	// Follow the network such that changes of its prefix are advertised.
+	if err := utils.Follow(ctx, networkStore, found,
+		func(updated *networkapi.Network) {
+			if err := server.SetNetwork(updated); err != nil {
+				log.G(ctx).Warnf("could not reload network %s: %v", found.Name, err)
+			}
+		},
+		func() {
+			log.G(ctx).Infof("network %s has been removed", found.Name)
+			cancel()
+		},
+	); err != nil {
+		return err
+	}
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-ctrlc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.G(ctx).Infof("advertising %s on %s", found.Spec.IPv6, found.Spec.IfName)
+
+	return server.Serve(ctx)
+}
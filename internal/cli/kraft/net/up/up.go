@@ -14,6 +14,7 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/net/utils"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
 )
@@ -78,6 +79,29 @@ func (opts *UpOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if !utils.HasServices(network) {
+		fmt.Fprintln(iostreams.G(ctx).Out, network.Name)
+		return nil
+	}
+
+	// The services of the network do not survive a restart of the host, so
+	// bring them back together with the network.
+	if err := utils.Spawn(ctx, network, "dns"); err != nil {
+		return err
+	}
+
+	if network.Spec.DHCP {
+		if err := utils.Spawn(ctx, network, "dhcp"); err != nil {
+			return err
+		}
+	}
+
+	if network.Spec.IPv6 != "" {
+		if err := utils.Spawn(ctx, network, "ra"); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(iostreams.G(ctx).Out, network.Name)
 
 	return nil
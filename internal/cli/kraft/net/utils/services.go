@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package utils contains helpers which are shared between the `kraft net`
+// subcommands, notably for managing the background services of a network such
+// as its DHCP server and DNS resolver.  Each service is a hidden subcommand of
+// `kraft net` which is spawned in the background and exits once its network
+// has been removed.
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	zip "api.zip"
+	goprocess "github.com/shirou/gopsutil/v3/process"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/exec"
+	"kraftkit.sh/log"
+)
+
+// PidFile returns the path to the pid file of the background service with the
+// provided name of the provided network.
+func PidFile(ctx context.Context, network *networkapi.Network, service string) string {
+	return filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, string(network.UID), service+".pid")
+}
+
+// Running returns whether the background service with the provided name of
+// the provided network is running.
+func Running(ctx context.Context, network *networkapi.Network, service string) bool {
+	b, err := os.ReadFile(PidFile(ctx, network, service))
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+
+	exists, err := goprocess.PidExistsWithContext(ctx, int32(pid))
+	return err == nil && exists
+}
+
+// WritePidFile records the current process as the background service with the
+// provided name of the provided network.  The returned function removes the
+// pid file again.
+func WritePidFile(ctx context.Context, network *networkapi.Network, service string) (func(), error) {
+	if Running(ctx, network, service) {
+		return nil, fmt.Errorf("%s of network %s is already running", service, network.Name)
+	}
+
+	pidfile := PidFile(ctx, network, service)
+	if err := os.MkdirAll(filepath.Dir(pidfile), 0o775); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(pidfile, []byte(strconv.Itoa(os.Getpid())), 0o666); err != nil {
+		return nil, fmt.Errorf("could not write pid file: %w", err)
+	}
+
+	return func() {
+		if err := os.Remove(pidfile); err != nil {
+			log.G(ctx).Errorf("could not remove pid file: %v", err)
+		}
+	}, nil
+}
+
+// HasServices returns whether background services can be run for the provided
+// network, which requires the host to own the gateway of the network as is the
+// case for bridge networks.
+func HasServices(network *networkapi.Network) bool {
+	return network.Spec.Driver == "" || network.Spec.Driver == "bridge"
+}
+
+// Spawn starts the background service with the provided name of the provided
+// network unless it is already running.  The service is the name of the
+// hidden `kraft net` subcommand which implements it.
+func Spawn(ctx context.Context, network *networkapi.Network, service string) error {
+	if !HasServices(network) {
+		return fmt.Errorf("network %s does not support background services", network.Name)
+	}
+
+	if Running(ctx, network, service) {
+		return nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine executable: %w", err)
+	}
+
+	stateDir := filepath.Dir(PidFile(ctx, network, service))
+	if err := os.MkdirAll(stateDir, 0o775); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(stateDir, service+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open %s log: %w", service, err)
+	}
+
+	defer logFile.Close()
+
+	e, err := exec.NewExecutable(self, nil,
+		"net",
+		"--driver", network.Spec.Driver,
+		service,
+		network.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	process, err := exec.NewProcessFromExecutable(e,
+		exec.WithStdout(logFile),
+		exec.WithStderr(logFile),
+		exec.WithEnvKey("KRAFTKIT_RUNTIME_DIR", config.G[config.KraftKit](ctx).RuntimeDir),
+		exec.WithDetach(true),
+	)
+	if err != nil {
+		return fmt.Errorf("could not prepare %s: %w", service, err)
+	}
+
+	if err := process.Start(ctx); err != nil {
+		return fmt.Errorf("could not start %s: %w", service, err)
+	}
+
+	return nil
+}
+
+// Follow watches the provided store for changes of the provided network until
+// the context is cancelled.  The update function is called with each new
+// revision of the network and the removed function once it has been removed.
+func Follow(ctx context.Context, networkStore zip.Store, network *networkapi.Network, update func(*networkapi.Network), removed func()) error {
+	watcher, err := networkStore.Watch(ctx, "", storage.ListOptions{
+		Recursive: true,
+	})
+	if err != nil {
+		return fmt.Errorf("could not watch network store: %w", err)
+	}
+
+	go func() {
+		defer watcher.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+
+				updated, ok := event.Object.(*networkapi.Network)
+				if !ok || updated.UID != network.UID {
+					continue
+				}
+
+				switch event.Type {
+				case watch.Deleted:
+					removed()
+					return
+
+				case watch.Added, watch.Modified:
+					update(updated)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
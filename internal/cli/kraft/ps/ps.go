@@ -85,18 +85,20 @@ func (opts *PsOptions) Pre(cmd *cobra.Command, _ []string) error {
 }
 
 type PsEntry struct {
-	ID      string
-	Name    string
-	Kernel  string
-	Args    string
-	Created string
-	State   machineapi.MachineState
-	Mem     string
-	Ports   string
-	Pid     int32
-	Arch    string
-	Plat    string
-	IPs     []string
+	ID       string
+	Name     string
+	Kernel   string
+	Args     string
+	Created  string
+	State    machineapi.MachineState
+	Restarts int
+	Health   machineapi.HealthStatus
+	Mem      string
+	Ports    string
+	Pid      int32
+	Arch     string
+	Plat     string
+	IPs      []string
 }
 
 type colorFunc func(string) string
@@ -124,6 +126,16 @@ var (
 		machineapi.MachineStateExited:     nil,
 		machineapi.MachineStateErrored:    nil,
 	}
+	HealthStatusColor = map[machineapi.HealthStatus]colorFunc{
+		machineapi.HealthStatusStarting:  iostreams.Yellow,
+		machineapi.HealthStatusHealthy:   iostreams.Green,
+		machineapi.HealthStatusUnhealthy: iostreams.Red,
+	}
+	HealthStatusColorNil = map[machineapi.HealthStatus]colorFunc{
+		machineapi.HealthStatusStarting:  nil,
+		machineapi.HealthStatusHealthy:   nil,
+		machineapi.HealthStatusUnhealthy: nil,
+	}
 )
 
 func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
@@ -175,27 +187,34 @@ func (opts *PsOptions) PsTable(ctx context.Context) ([]PsEntry, error) {
 	}
 
 	for _, machine := range machines.Items {
-		if !opts.ShowAll && machine.Status.State != machineapi.MachineStateRunning {
+		// Machines which are being restarted are considered to be running.
+		if !opts.ShowAll && machine.Status.State != machineapi.MachineStateRunning && machine.Status.State != machineapi.MachineStateRestarting {
 			continue
 		}
 		entry := PsEntry{
-			ID:      string(machine.UID),
-			Name:    machine.Name,
-			Args:    strings.Join(machine.Spec.ApplicationArgs, " "),
-			Kernel:  machine.Spec.Kernel,
-			State:   machine.Status.State,
-			Mem:     machine.Spec.Resources.Requests.Memory().String(),
-			Created: humanize.Time(machine.ObjectMeta.CreationTimestamp.Time),
-			Arch:    machine.Spec.Architecture,
-			Pid:     machine.Status.Pid,
-			Plat:    machine.Spec.Platform,
-			IPs:     []string{},
+			ID:       string(machine.UID),
+			Name:     machine.Name,
+			Args:     strings.Join(machine.Spec.ApplicationArgs, " "),
+			Kernel:   machine.Spec.Kernel,
+			State:    machine.Status.State,
+			Restarts: machine.Status.RestartCount,
+			Mem:      machine.Spec.Resources.Requests.Memory().String(),
+			Created:  humanize.Time(machine.ObjectMeta.CreationTimestamp.Time),
+			Arch:     machine.Spec.Architecture,
+			Pid:      machine.Status.Pid,
+			Plat:     machine.Spec.Platform,
+			IPs:      []string{},
 		}
 
 		if machine.Status.State == machineapi.MachineStateRunning {
 			entry.Ports = machine.Spec.Ports.String()
 		}
 
+		// The health of machines which are no longer running is stale.
+		if machine.Status.State == machineapi.MachineStateRunning || machine.Status.State == machineapi.MachineStateRestarting {
+			entry.Health = machine.Status.Health.Status
+		}
+
 		for _, net := range machine.Spec.Networks {
 			for _, iface := range net.Interfaces {
 				entry.IPs = append(entry.IPs, iface.Spec.CIDR)
@@ -235,6 +254,8 @@ func (opts *PsOptions) PrintPsTable(ctx context.Context, items []PsEntry) error
 	table.AddField("ARGS", cs.Bold)
 	table.AddField("CREATED", cs.Bold)
 	table.AddField("STATUS", cs.Bold)
+	table.AddField("HEALTH", cs.Bold)
+	table.AddField("RESTARTS", cs.Bold)
 	table.AddField("MEM", cs.Bold)
 	table.AddField("PORTS", cs.Bold)
 	if opts.Long {
@@ -249,6 +270,7 @@ func (opts *PsOptions) PrintPsTable(ctx context.Context, items []PsEntry) error
 
 	if config.G[config.KraftKit](ctx).NoColor {
 		MachineStateColor = MachineStateColorNil
+		HealthStatusColor = HealthStatusColorNil
 	}
 
 	for _, item := range items {
@@ -260,6 +282,8 @@ func (opts *PsOptions) PrintPsTable(ctx context.Context, items []PsEntry) error
 		table.AddField(item.Args, nil)
 		table.AddField(item.Created, nil)
 		table.AddField(item.State.String(), MachineStateColor[item.State])
+		table.AddField(item.Health.String(), HealthStatusColor[item.Health])
+		table.AddField(fmt.Sprintf("%d", item.Restarts), nil)
 		table.AddField(item.Mem, nil)
 		table.AddField(item.Ports, nil)
 		if opts.Long {
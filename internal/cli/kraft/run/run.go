@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/sirupsen/logrus"
@@ -23,6 +24,7 @@ import (
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/health"
 	mplatform "kraftkit.sh/machine/platform"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/tui/selection"
@@ -30,30 +32,40 @@ import (
 )
 
 type RunOptions struct {
-	Architecture  string   `long:"arch" short:"m" usage:"Set the architecture"`
-	Detach        bool     `long:"detach" short:"d" usage:"Run unikernel in background"`
-	DisableAccel  bool     `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
-	Env           []string `long:"env" short:"e" usage:"Set environment variables, int the format key[=value]"`
-	InitRd        string   `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
-	IP            string   `long:"ip" usage:"Assign the provided IP address"`
-	KernelArgs    []string `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
-	Kraftfile     string   `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	MacAddress    string   `long:"mac" usage:"Assign the provided MAC address"`
-	Memory        string   `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
-	Name          string   `long:"name" short:"n" usage:"Name of the instance"`
-	Networks      []string `long:"network" usage:"Attach instance to the provided network, in the format <network>[:ip[/mask][:gw[:dns0[:dns1[:hostname[:domain]]]]]], e.g. kraft0:172.100.0.2"`
-	NoStart       bool     `long:"no-start" usage:"Do not start the machine"`
-	Platform      string   `noattribute:"true"`
-	Ports         []string `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
-	Prefix        string   `long:"prefix" usage:"Prefix each log line with the given string"`
-	PrefixName    bool     `long:"prefix-name" usage:"Prefix each log line with the machine name"`
-	Remove        bool     `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
-	Rootfs        string   `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
-	RunAs         string   `long:"as" usage:"Force a specific runner"`
-	Runtime       string   `long:"runtime" short:"r" usage:"Set an alternative unikernel runtime"`
-	Target        string   `long:"target" short:"t" usage:"Explicitly use the defined project target"`
-	Volumes       []string `long:"volume" short:"v" usage:"Bind a volume to the instance"`
-	WithKernelDbg bool     `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
+	Architecture      string        `long:"arch" short:"m" usage:"Set the architecture"`
+	Detach            bool          `long:"detach" short:"d" usage:"Run unikernel in background"`
+	DisableAccel      bool          `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
+	Env               []string      `long:"env" short:"e" usage:"Set environment variables, int the format key[=value]"`
+	HealthHTTP        string        `long:"health-http" usage:"Probe the health of the unikernel via an HTTP GET request, in the format <port>[/path]"`
+	HealthInterval    time.Duration `long:"health-interval" usage:"Time between running the health check (default 30s)"`
+	HealthLog         string        `long:"health-log" usage:"Probe the health of the unikernel by matching the regular expression against its console log"`
+	HealthRetries     int           `long:"health-retries" usage:"Consecutive failures needed to report unhealthy (default 3)"`
+	HealthStartPeriod time.Duration `long:"health-start-period" usage:"Start period for the unikernel to initialize before counting failed health checks"`
+	HealthTCP         string        `long:"health-tcp" usage:"Probe the health of the unikernel by connecting to the TCP port"`
+	HealthTimeout     time.Duration `long:"health-timeout" usage:"Maximum time to allow one health check to run (default 30s)"`
+	InitRd            string        `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
+	IP                string        `long:"ip" usage:"Assign the provided IP address"`
+	IP6               string        `long:"ip6" usage:"Assign the provided IPv6 (SLAAC) address, which determines the MAC address"`
+	KernelArgs        []string      `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
+	Kraftfile         string        `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	MacAddress        string        `long:"mac" usage:"Assign the provided MAC address"`
+	Memory            string        `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	Name              string        `long:"name" short:"n" usage:"Name of the instance"`
+	NetworkAliases    []string      `long:"network-alias" usage:"Add a name under which the instance is resolvable by other instances on its networks"`
+	Networks          []string      `long:"network" usage:"Attach instance to the provided network, in the format <network>[:ip[/mask][:gw[:dns0[:dns1[:hostname[:domain]]]]]], e.g. kraft0:172.100.0.2"`
+	NoStart           bool          `long:"no-start" usage:"Do not start the machine"`
+	Platform          string        `noattribute:"true"`
+	Ports             []string      `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
+	Prefix            string        `long:"prefix" usage:"Prefix each log line with the given string"`
+	PrefixName        bool          `long:"prefix-name" usage:"Prefix each log line with the machine name"`
+	Remove            bool          `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
+	Restart           string        `long:"restart" usage:"Restart policy to apply when the unikernel exits (no, on-failure[:max-retries], always, unless-stopped)" default:"no"`
+	Rootfs            string        `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RunAs             string        `long:"as" usage:"Force a specific runner"`
+	Runtime           string        `long:"runtime" short:"r" usage:"Set an alternative unikernel runtime"`
+	Target            string        `long:"target" short:"t" usage:"Explicitly use the defined project target"`
+	Volumes           []string      `long:"volume" short:"v" usage:"Bind a volume to the instance, optionally with the volume driver and mount options to use (e.g. ./dir:/dir:virtiofs,ro)"`
+	WithKernelDbg     bool          `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
 
 	workdir           string
 	platform          mplatform.Platform
@@ -111,6 +123,12 @@ func NewCmd() *cobra.Command {
 			Mount a bi-directional path from on the host to the unikernel mapped to /dir:
 			$ kraft run -v ./path/to/dir:/dir
 
+			Share a path from the host with the unikernel at /dir via virtio-fs instead of 9pfs:
+			$ kraft run -v ./path/to/dir:/dir:virtiofs
+
+			Mount a path from the host read-only at /dir with additional 9pfs mount options:
+			$ kraft run -v ./path/to/dir:/dir:ro,cache=loose,security_model=mapped-xattr
+
 			Supply a read-only root file system at / via initramfs CPIO archive and mount a bi-directional volume at /dir:
 			$ kraft run --rootfs ./initramfs.cpio --volume ./path/to/dir:/dir
 
@@ -274,6 +292,10 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if err := opts.assignRestartPolicy(ctx, machine); err != nil {
+		return err
+	}
+
 	var run runner
 	var errs []error
 	runners, err := runners()
@@ -350,11 +372,11 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		machine.Spec.Resources.Requests[corev1.ResourceMemory] = quantity
 	}
 
-	if err := opts.parseNetworks(ctx, machine); err != nil {
+	if err := opts.assignName(ctx, machine); err != nil {
 		return err
 	}
 
-	if err := opts.assignName(ctx, machine); err != nil {
+	if err := opts.parseNetworks(ctx, machine); err != nil {
 		return err
 	}
 
@@ -366,10 +388,21 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if err := opts.attachRootfsImage(ctx, machine); err != nil {
+		return err
+	}
+
 	if err := opts.parseEnvs(ctx, machine); err != nil {
 		return err
 	}
 
+	if err := opts.parseHealthCheck(ctx, machine); err != nil {
+		return err
+	}
+
+	// The machine is starting until its health has been probed.
+	machine.Status.Health = health.Initial(machine)
+
 	// Create the machine
 	machine, err = opts.machineController.Create(ctx, machine)
 	if err != nil {
@@ -383,5 +383,9 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 		return err
 	}
 
+	if err := opts.parseKraftfileHealthCheck(ctx, runner.project, machine); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -206,5 +206,9 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 		return err
 	}
 
+	if err := opts.parseKraftfileHealthCheck(ctx, runner.project, machine); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/containerd/nerdctl/pkg/strutil"
@@ -16,12 +17,16 @@ import (
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/daemon/client"
 	"kraftkit.sh/initrd"
+	netutils "kraftkit.sh/internal/cli/kraft/net/utils"
 	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/log"
 	machinename "kraftkit.sh/machine/name"
 	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/iputils"
 	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
 	"kraftkit.sh/tui/processtree"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
@@ -45,12 +50,138 @@ func (opts *RunOptions) assignPorts(ctx context.Context, machine *machineapi.Mac
 	return utils.CheckPorts(ctx, opts.machineController, machine)
 }
 
+// warnUnsupervised warns that the provided feature only takes effect whilst
+// machines are supervised by the events loop or the daemon, if neither is
+// running.
+func warnUnsupervised(ctx context.Context, feature string) {
+	if client.Available(ctx) {
+		return
+	}
+
+	if _, err := os.Stat(config.G[config.KraftKit](ctx).EventsPidFile); err != nil {
+		log.G(ctx).Warnf("%s only takes effect whilst `kraft events` or `kraft daemon` is running", feature)
+	}
+}
+
+// Was a restart policy specified? E.g. --restart=on-failure:3
+func (opts *RunOptions) assignRestartPolicy(ctx context.Context, machine *machineapi.Machine) error {
+	if opts.Restart == "" {
+		return nil
+	}
+
+	policy, err := machineapi.ParseRestartPolicy(opts.Restart)
+	if err != nil {
+		return err
+	}
+
+	if policy.Name == machineapi.RestartPolicyNo {
+		return nil
+	}
+
+	if opts.Remove {
+		return fmt.Errorf("the --restart and --rm flags cannot be used together")
+	}
+
+	warnUnsupervised(ctx, fmt.Sprintf("restart policy '%s'", policy.String()))
+
+	machine.Spec.RestartPolicy = policy
+
+	return nil
+}
+
+// Was a health check supplied in the Kraftfile
+func (opts *RunOptions) parseKraftfileHealthCheck(_ context.Context, project app.Application, machine *machineapi.Machine) error {
+	hc := project.HealthCheck()
+	if hc == nil {
+		return nil
+	}
+
+	check, err := machineapi.ParseHealthCheckTarget(machineapi.HealthCheckType(hc.Type()), hc.Target())
+	if err != nil {
+		return fmt.Errorf("invalid healthcheck in Kraftfile: %w", err)
+	}
+
+	check.Interval = hc.Interval()
+	check.Timeout = hc.Timeout()
+	check.Retries = hc.Retries()
+	check.StartPeriod = hc.StartPeriod()
+
+	machine.Spec.HealthCheck = check
+
+	return nil
+}
+
+// Was a health check specified? E.g. --health-http=8080/healthz
+func (opts *RunOptions) parseHealthCheck(ctx context.Context, machine *machineapi.Machine) error {
+	probes := map[machineapi.HealthCheckType]string{}
+	if opts.HealthTCP != "" {
+		probes[machineapi.HealthCheckTCP] = opts.HealthTCP
+	}
+	if opts.HealthHTTP != "" {
+		probes[machineapi.HealthCheckHTTP] = opts.HealthHTTP
+	}
+	if opts.HealthLog != "" {
+		probes[machineapi.HealthCheckLog] = opts.HealthLog
+	}
+
+	if len(probes) > 1 {
+		return fmt.Errorf("only one of --health-tcp, --health-http or --health-log can be used")
+	}
+
+	// Override the probe of any health check provided by the Kraftfile whilst
+	// retaining its timings unless they are also overridden.
+	for typ, target := range probes {
+		check, err := machineapi.ParseHealthCheckTarget(typ, target)
+		if err != nil {
+			return err
+		}
+
+		if machine.Spec.HealthCheck != nil {
+			check.Interval = machine.Spec.HealthCheck.Interval
+			check.Timeout = machine.Spec.HealthCheck.Timeout
+			check.Retries = machine.Spec.HealthCheck.Retries
+			check.StartPeriod = machine.Spec.HealthCheck.StartPeriod
+		}
+
+		machine.Spec.HealthCheck = check
+	}
+
+	if machine.Spec.HealthCheck == nil {
+		if opts.HealthInterval != 0 || opts.HealthTimeout != 0 || opts.HealthRetries != 0 || opts.HealthStartPeriod != 0 {
+			return fmt.Errorf("health check options require one of --health-tcp, --health-http or --health-log")
+		}
+
+		return nil
+	}
+
+	if opts.HealthInterval != 0 {
+		machine.Spec.HealthCheck.Interval = opts.HealthInterval
+	}
+	if opts.HealthTimeout != 0 {
+		machine.Spec.HealthCheck.Timeout = opts.HealthTimeout
+	}
+	if opts.HealthRetries != 0 {
+		machine.Spec.HealthCheck.Retries = opts.HealthRetries
+	}
+	if opts.HealthStartPeriod != 0 {
+		machine.Spec.HealthCheck.StartPeriod = opts.HealthStartPeriod
+	}
+
+	warnUnsupervised(ctx, fmt.Sprintf("health check '%s'", machine.Spec.HealthCheck.String()))
+
+	return nil
+}
+
 // Was a network specified? E.g. --network=kraft0
 func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.Machine) error {
 	if opts.IP != "" && len(opts.Networks) != 1 {
 		return fmt.Errorf("the --ip flag only works when providing exactly one network")
 	}
 
+	if opts.IP6 != "" && len(opts.Networks) != 1 {
+		return fmt.Errorf("the --ip6 flag only works when providing exactly one network")
+	}
+
 	if len(opts.Networks) == 0 {
 		return nil
 	}
@@ -115,6 +246,48 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 			interfaceSpec.Gateway = found.Spec.Gateway
 		}
 
+		if opts.IP6 != "" {
+			if found.Spec.IPv6 == "" {
+				return fmt.Errorf("the --ip6 flag requires network %s to have an IPv6 subnet", found.Name)
+			}
+
+			_, subnet6, err := net.ParseCIDR(found.Spec.IPv6)
+			if err != nil {
+				return fmt.Errorf("could not parse IPv6 subnet of network %s: %w", found.Name, err)
+			}
+
+			ip6 := net.ParseIP(strings.SplitN(opts.IP6, "/", 2)[0])
+			if ip6 == nil || ip6.To4() != nil || !subnet6.Contains(ip6) {
+				return fmt.Errorf("invalid IPv6 address for network %s (%s): %s", found.Name, subnet6, opts.IP6)
+			}
+
+			// The machine configures its IPv6 address itself via SLAAC from the
+			// router advertisements of the network, such that the address must be
+			// derived from the MAC address of its interface.  Assign the MAC
+			// address which the provided address is derived from.
+			mac := iputils.EUI64HardwareAddr(ip6)
+			if mac == nil || !iputils.EUI64(subnet6, mac).Equal(ip6) {
+				return fmt.Errorf("IPv6 address %s is not a SLAAC (EUI-64) address within the /64 subnet %s of network %s", ip6, subnet6, found.Name)
+			}
+
+			sz, _ := subnet6.Mask.Size()
+			interfaceSpec.CIDR6 = fmt.Sprintf("%s/%d", ip6, sz)
+			interfaceSpec.MacAddress = mac.String()
+		}
+
+		// Make the machine resolvable by its name and aliases via the DNS
+		// resolver of the network, which is also used by the machine unless
+		// another name server has been provided.
+		interfaceSpec.Aliases = append([]string{machine.Name}, opts.NetworkAliases...)
+
+		if netutils.HasServices(found) {
+			if err := netutils.Spawn(ctx, found, "dns"); err != nil {
+				log.G(ctx).Warnf("could not start DNS resolver of network %s: %v", found.Name, err)
+			} else if interfaceSpec.DNS0 == "" {
+				interfaceSpec.DNS0 = found.Spec.Gateway
+			}
+		}
+
 		// Generate the UID pre-emptively so that we can uniquely reference the
 		// network interface which will allow us to clean it up later. Additionally,
 		// it's OK if the IP or MAC address are empty, the network controller will
@@ -188,58 +361,55 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 		return nil
 	}
 
-	var err error
 	controllers := map[string]volumeapi.VolumeService{}
 
 	if machine.Spec.Volumes == nil {
 		machine.Spec.Volumes = make([]volumeapi.Volume, 0)
 	}
 	for _, volLine := range opts.Volumes {
-		var volName, mountPath string
+		var volName, mountPath, driver string
+		var readOnly bool
+		var options []string
 		split := strings.Split(volLine, ":")
-		if len(split) == 2 {
+		switch len(split) {
+		case 2:
+			volName = split[0]
+			mountPath = split[1]
+		case 3:
 			volName = split[0]
 			mountPath = split[1]
-		} else {
-			return fmt.Errorf("invalid syntax for --volume=%s expected --volume=<host>:<machine>", volLine)
-		}
-
-		var driver string
-
-		for sname, strategy := range volume.Strategies() {
-			if ok, _ := strategy.IsCompatible(volName, nil); !ok || err != nil {
-				continue
-			}
 
-			if _, ok := controllers[sname]; !ok {
-				controllers[sname], err = strategy.NewVolumeV1alpha1(ctx)
-				if err != nil {
-					return fmt.Errorf("could not prepare %s volume service: %w", sname, err)
-				}
+			var err error
+			driver, readOnly, options, err = parseVolumeOptions(split[2])
+			if err != nil {
+				return fmt.Errorf("invalid options for --volume=%s: %w", volLine, err)
 			}
-
-			driver = sname
-		}
-
-		if len(driver) == 0 {
-			return fmt.Errorf("could not find compatible volume driver for %s", volName)
+		default:
+			return fmt.Errorf("invalid syntax for --volume=%s expected --volume=<host>:<machine>[:<driver>,<option>,...]", volLine)
 		}
 
 		// Check if this could be a named volume
-		vol, err := controllers[driver].Get(ctx, &volumeapi.Volume{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: volName,
-			},
-		})
+		vol, err := getNamedVolume(ctx, controllers, volName)
 		if err != nil {
-			return fmt.Errorf("failed to get volume: %w", err)
+			return err
 		}
 		if vol != nil {
+			if len(driver) > 0 && driver != vol.Spec.Driver {
+				return fmt.Errorf("volume %s uses the %s driver and not %s", volName, vol.Spec.Driver, driver)
+			}
+
+			// The options only apply to this mount of the named volume.
 			vol.Spec.Destination = mountPath
+			vol.Spec.ReadOnly = vol.Spec.ReadOnly || readOnly
+			vol.Spec.Options = append(vol.Spec.Options, options...)
 			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
 			continue
 		}
 
+		if err := selectVolumeDriver(ctx, volName, &driver); err != nil {
+			return err
+		}
+
 		vol, err = controllers[driver].Create(ctx, &volumeapi.Volume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: fmt.Sprintf("%s-%d", machine.ObjectMeta.Name, len(machine.Spec.Volumes)),
@@ -248,7 +418,8 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 				Driver:      driver,
 				Source:      volName,
 				Destination: mountPath,
-				ReadOnly:    false, // TODO(nderjung): Options are not yet supported.
+				ReadOnly:    readOnly,
+				Options:     options,
 			},
 		})
 		if err != nil {
@@ -261,13 +432,93 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 	return nil
 }
 
+// parseVolumeOptions parses the comma-separated options of a --volume flag,
+// which consist of an optional volume driver, either "ro" or "rw", and
+// driver-specific mount options in the form key[=value].
+func parseVolumeOptions(field string) (driver string, readOnly bool, options []string, err error) {
+	drivers := volume.DriverNames()
+
+	for _, option := range strings.Split(field, ",") {
+		switch {
+		case len(option) == 0:
+			continue
+		case option == "ro":
+			readOnly = true
+		case option == "rw":
+			readOnly = false
+		case slices.Contains(drivers, option):
+			if len(driver) > 0 {
+				return "", false, nil, fmt.Errorf("multiple volume drivers specified: %s and %s", driver, option)
+			}
+
+			driver = option
+		default:
+			options = append(options, option)
+		}
+	}
+
+	return driver, readOnly, options, nil
+}
+
+// getNamedVolume returns the existing volume with the provided name from the
+// volume driver which manages it, or nil if there is no such volume.  Volume
+// services are instantiated as needed and cached in the provided map.
+func getNamedVolume(ctx context.Context, controllers map[string]volumeapi.VolumeService, name string) (*volumeapi.Volume, error) {
+	var err error
+
+	for sname, strategy := range volume.Strategies() {
+		if _, ok := controllers[sname]; !ok {
+			controllers[sname], err = strategy.NewVolumeV1alpha1(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("could not prepare %s volume service: %w", sname, err)
+			}
+		}
+
+		// All volume drivers share the same store, so a volume is only considered
+		// to be found when it is managed by the driver it was retrieved with.
+		vol, err := controllers[sname].Get(ctx, &volumeapi.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		})
+		if err != nil || vol == nil || vol.Spec.Driver != sname || len(vol.Spec.Source) == 0 {
+			continue
+		}
+
+		return vol, nil
+	}
+
+	return nil, nil
+}
+
+// selectVolumeDriver sets the provided driver to the driver which is used for
+// a new volume of the provided source if it is unset, or otherwise checks that
+// the requested driver is able to handle the source.
+func selectVolumeDriver(ctx context.Context, source string, driver *string) error {
+	if len(*driver) == 0 {
+		var err error
+		*driver, err = volume.CompatibleDriverName(ctx, source)
+		return err
+	}
+
+	strategy, exists := volume.Strategies()[*driver]
+	if !exists {
+		return fmt.Errorf("unknown volume driver %s specified", *driver)
+	}
+
+	if ok, err := strategy.IsCompatible(source, nil); err != nil || !ok {
+		return fmt.Errorf("volume driver %s is incompatible with source %s", *driver, source)
+	}
+
+	return nil
+}
+
 // Were any volumes supplied in the Kraftfile
 func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.Application, machine *machineapi.Machine) error {
 	if project.Volumes() == nil {
 		return nil
 	}
 
-	var err error
 	controllers := map[string]volumeapi.VolumeService{}
 	if machine.Spec.Volumes == nil {
 		machine.Spec.Volumes = make([]volumeapi.Volume, 0)
@@ -276,56 +527,26 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 	for _, volcfg := range project.Volumes() {
 		driver := volcfg.Driver()
 
-		if len(driver) == 0 {
-			for sname, strategy := range volume.Strategies() {
-				if ok, _ := strategy.IsCompatible(volcfg.Source(), nil); !ok || err != nil {
-					continue
-				}
-
-				if _, ok := controllers[sname]; !ok {
-					log.G(ctx).WithField("volume strategy", sname).Debug("found volume strategy")
-					controllers[sname], err = strategy.NewVolumeV1alpha1(ctx)
-					if err != nil {
-						return fmt.Errorf("could not prepare %s volume service: %w", sname, err)
-					}
-				}
-
-				driver = sname
-			}
-		} else {
-			strategy, exists := volume.Strategies()[driver]
-			if !exists {
-				return fmt.Errorf("unknown volume driver %s specified", driver)
-			}
-			if ok, _ := strategy.IsCompatible(volcfg.Source(), nil); !ok || err != nil {
-				return fmt.Errorf("volume driver %s is incompatible with source %s", driver, volcfg.Source())
-			}
-			if _, ok := controllers[driver]; !ok {
-				log.G(ctx).WithField("volume strategy", driver).Debug("found volume strategy")
-				controllers[driver], err = strategy.NewVolumeV1alpha1(ctx)
-				if err != nil {
-					return fmt.Errorf("could not prepare %s volume service: %w", driver, err)
-				}
-			}
-		}
-
-		if len(driver) == 0 {
-			return fmt.Errorf("could not find compatible volume driver for %s", volcfg.Source())
-		}
-
 		// Check if this could be a named volume
-		vol, err := controllers[driver].Get(ctx, &volumeapi.Volume{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: volcfg.Source(),
-			},
-		})
+		vol, err := getNamedVolume(ctx, controllers, volcfg.Source())
+		if err != nil {
+			return err
+		}
+		if vol != nil {
+			if len(driver) > 0 && driver != vol.Spec.Driver {
+				return fmt.Errorf("volume %s uses the %s driver and not %s", volcfg.Source(), vol.Spec.Driver, driver)
+			}
 
-		if err == nil && vol.Spec.Source != "" {
 			vol.Spec.Destination = volcfg.Destination()
+			vol.Spec.ReadOnly = vol.Spec.ReadOnly || volcfg.ReadOnly()
 			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
 			continue
 		}
 
+		if err := selectVolumeDriver(ctx, volcfg.Source(), &driver); err != nil {
+			return err
+		}
+
 		vol, err = controllers[driver].Create(ctx, &volumeapi.Volume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: fmt.Sprintf("%s-%d", machine.ObjectMeta.Name, len(machine.Spec.Volumes)),
@@ -423,6 +644,51 @@ func (opts *RunOptions) prepareRootfs(ctx context.Context, machine *machineapi.M
 	return treemodel.Start()
 }
 
+// attachRootfsImage attaches a root file system which is an EROFS or squashfs
+// image as a read-only block device mounted at the root, in place of an
+// initramfs which is extracted into the memory of the unikernel.
+func (opts *RunOptions) attachRootfsImage(_ context.Context, machine *machineapi.Machine) error {
+	if machine.Status.InitrdPath == "" {
+		return nil
+	}
+
+	format, err := initrd.DetectFormat(machine.Status.InitrdPath)
+	if err != nil || !format.IsImage() {
+		return nil
+	}
+
+	source, err := filepath.Abs(machine.Status.InitrdPath)
+	if err != nil {
+		return fmt.Errorf("cannot get absolute path for root file system: %w", err)
+	}
+
+	rootfs := volumeapi.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rootfs",
+		},
+		Spec: volumeapi.VolumeSpec{
+			Driver:      blk.DriverName,
+			Source:      source,
+			Destination: "/",
+			Filesystem:  format.String(),
+			ReadOnly:    true,
+		},
+	}
+
+	// The root file system is mounted before any other volume.
+	if i := slices.IndexFunc(machine.Spec.Volumes, func(vol volumeapi.Volume) bool {
+		return vol.Spec.Driver == "initrd"
+	}); i >= 0 {
+		machine.Spec.Volumes[i] = rootfs
+	} else {
+		machine.Spec.Volumes = append([]volumeapi.Volume{rootfs}, machine.Spec.Volumes...)
+	}
+
+	machine.Status.InitrdPath = ""
+
+	return nil
+}
+
 func (opts *RunOptions) parseKraftfileEnv(_ context.Context, project app.Application, machine *machineapi.Machine) error {
 	if project.Env() == nil {
 		return nil
@@ -8,8 +8,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/MakeNowJust/heredoc"
+	"github.com/moby/term"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -17,10 +19,12 @@ import (
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/attach"
 	"kraftkit.sh/internal/cli/kraft/logs"
 	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/health"
 	"kraftkit.sh/machine/network"
 	mplatform "kraftkit.sh/machine/platform"
 	"kraftkit.sh/machine/volume"
@@ -138,6 +142,7 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 
 	var errGroup []error
 	loggedMachines := []string{}
+	var startedMachine *machineapi.Machine
 
 	volumeController, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
 	if err != nil {
@@ -157,7 +162,13 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 			WithField("machine", machine.Name).
 			Trace("starting")
 
-		if _, err := machineController.Start(ctx, &machine); err != nil {
+		// The machine is subject to its restart policy again and its health is
+		// yet to be determined.
+		machine.Status.StoppedByUser = false
+		machine.Status.Health = health.Initial(&machine)
+
+		started, err := machineController.Start(ctx, &machine)
+		if err != nil {
 			return err
 		}
 
@@ -175,12 +186,20 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 		}
 
 		loggedMachines = append(loggedMachines, machine.Name)
+		startedMachine = started
 	}
 
 	if opts.Detach {
 		return nil
 	}
 
+	// Forward the standard input to the console of a single machine such that
+	// interactive unikernels can be used.
+	if len(loggedMachines) == 1 {
+		stopForwarding := forwardStdin(ctx, startedMachine)
+		defer stopForwarding()
+	}
+
 	logOptions := logs.LogOptions{
 		Follow:   true,
 		NoPrefix: opts.NoPrefix,
@@ -193,10 +212,23 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 
 	for _, machine := range machines {
 		machine := machine // Go closures
+
+		// A machine which has exited on its own is left to its supervisor to be
+		// restarted according to its restart policy.
+		if latest, err := machineController.Get(ctx, &machine); err == nil {
+			switch {
+			case latest.Status.State == machineapi.MachineStateRestarting,
+				latest.Status.State == machineapi.MachineStateExited && latest.Spec.RestartPolicy.ShouldRestart(latest.Status):
+				continue
+			}
+		}
+
 		log.G(ctx).
 			WithField("machine", machine.Name).
 			Trace("stopping")
 
+		machine.Status.StoppedByUser = true
+
 		if _, err := machineController.Stop(ctx, &machine); err != nil {
 			log.G(ctx).Errorf("could not stop: %v", err)
 		}
@@ -291,3 +323,44 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 
 	return errors.Join(errGroup...)
 }
+
+// forwardStdin forwards the standard input to the serial console of the
+// provided machine in the background until the returned function is called or
+// the default detach key sequence is typed, after which the terminal is
+// restored and the logs of the machine continue to be followed.  The output of
+// the console is discarded since it is followed via the logs of the machine
+// instead.
+func forwardStdin(ctx context.Context, machine *machineapi.Machine) func() {
+	conn, err := attach.Console(machine)
+	if err != nil {
+		log.G(ctx).Debugf("not forwarding standard input: %v", err)
+		return func() {}
+	}
+
+	escapeKeys, err := term.ToBytes(attach.DefaultDetachKeys)
+	if err != nil {
+		conn.Close()
+		return func() {}
+	}
+
+	detached, stop, err := attach.Forward(ctx, conn, escapeKeys)
+	if err != nil {
+		log.G(ctx).Debugf("not forwarding standard input: %v", err)
+		conn.Close()
+		return func() {}
+	}
+
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	go func() {
+		<-detached
+		conn.Close()
+	}()
+
+	return func() {
+		stop()
+		conn.Close()
+	}
+}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+	mstats "kraftkit.sh/machine/stats"
+)
+
+// statsInterval is the time between two consecutive samples.
+const statsInterval = time.Second
+
+type StatsOptions struct {
+	NoStream bool `long:"no-stream" usage:"Output a single sample of the statistics as JSON"`
+	platform string
+}
+
+// StatsEntry is a sample of the resource usage of a single machine.
+type StatsEntry struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	CPUPercentage    float64 `json:"cpuPercentage"`
+	MemoryPercentage float64 `json:"memoryPercentage"`
+
+	machineapi.MachineStats
+}
+
+// Stats returns a sample of the resource usage of the running machines with
+// the provided names or IDs, or of all running machines if none are provided.
+func Stats(ctx context.Context, opts *StatsOptions, args ...string) ([]StatsEntry, error) {
+	if opts == nil {
+		opts = &StatsOptions{}
+	}
+
+	controller, err := opts.controller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a previous sample the CPU usage is averaged since the machine was
+	// started, so take an initial sample to determine the current usage.
+	previous := map[types.UID]machineapi.MachineStats{}
+	if _, err := opts.sample(ctx, controller, args, previous); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(statsInterval):
+	}
+
+	return opts.sample(ctx, controller, args, previous)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&StatsOptions{}, cobra.Command{
+		Short:   "Display the resource usage of running unikernels",
+		Use:     "stats [FLAGS] [MACHINE [MACHINE [...]]]",
+		Aliases: []string{},
+		Long: heredoc.Doc(`
+			Display a live stream of the CPU, memory, network and block device usage
+			of running unikernels.
+		`),
+		Example: heredoc.Doc(`
+			# Display the resource usage of all running unikernels
+			$ kraft stats
+
+			# Display the resource usage of a specific unikernel
+			$ kraft stats my-machine
+
+			# Output a single sample of the resource usage as JSON
+			$ kraft stats --no-stream
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("all"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.",
+	)
+
+	return cmd
+}
+
+func (opts *StatsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *StatsOptions) Run(ctx context.Context, args []string) error {
+	if opts.NoStream {
+		entries, err := Stats(ctx, opts, args...)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(iostreams.G(ctx).Out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(entries)
+	}
+
+	controller, err := opts.controller(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous := map[types.UID]machineapi.MachineStats{}
+
+	entries, err := opts.sample(ctx, controller, args, previous)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		iostreams.G(ctx).RefreshScreen()
+
+		if err := printStatsTable(ctx, entries); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		entries, err = opts.sample(ctx, controller, args, previous)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// controller returns the machine service of the selected platform.
+func (opts *StatsOptions) controller(ctx context.Context) (machineapi.MachineService, error) {
+	if opts.platform == "" || opts.platform == "all" {
+		return mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	}
+
+	var platform mplatform.Platform
+	var err error
+
+	if opts.platform == "auto" {
+		platform, _, err = mplatform.Detect(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var ok bool
+		platform, ok = mplatform.PlatformsByName()[opts.platform]
+		if !ok {
+			return nil, fmt.Errorf("unknown platform driver: %s", opts.platform)
+		}
+	}
+
+	strategy, ok := mplatform.Strategies()[platform]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+	}
+
+	return strategy.NewMachineV1alpha1(ctx)
+}
+
+// sample the resource usage of the selected running machines.  The CPU usage
+// is determined relative to the provided previous samples, which are replaced
+// by the new ones.
+func (opts *StatsOptions) sample(ctx context.Context, controller machineapi.MachineService, args []string, previous map[types.UID]machineapi.MachineStats) ([]StatsEntry, error) {
+	machines, err := controller.List(mstats.WithSampling(ctx), &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(args))
+	for _, arg := range args {
+		selected[arg] = false
+	}
+
+	entries := []StatsEntry{}
+
+	for _, machine := range machines.Items {
+		if len(args) > 0 {
+			if _, ok := selected[machine.Name]; ok {
+				selected[machine.Name] = true
+			} else if _, ok := selected[string(machine.UID)]; ok {
+				selected[string(machine.UID)] = true
+			} else {
+				continue
+			}
+		}
+
+		if machine.Status.Stats == nil {
+			continue
+		}
+
+		entry := StatsEntry{
+			ID:               string(machine.UID),
+			Name:             machine.Name,
+			MemoryPercentage: machine.Status.Stats.MemoryPercentage(),
+			MachineStats:     *machine.Status.Stats,
+		}
+
+		if last, ok := previous[machine.UID]; ok {
+			entry.CPUPercentage = machine.Status.Stats.CPUPercentage(&last, machine.Status.StartedAt)
+		} else {
+			entry.CPUPercentage = machine.Status.Stats.CPUPercentage(nil, machine.Status.StartedAt)
+		}
+
+		previous[machine.UID] = *machine.Status.Stats
+
+		entries = append(entries, entry)
+	}
+
+	for arg, found := range selected {
+		if !found {
+			return nil, fmt.Errorf("no such machine: %s", arg)
+		}
+	}
+
+	return entries, nil
+}
+
+// printStatsTable prints the provided samples as a table.
+func printStatsTable(ctx context.Context, entries []StatsEntry) error {
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("NAME", cs.Bold)
+	table.AddField("CPU %", cs.Bold)
+	table.AddField("MEM USAGE / LIMIT", cs.Bold)
+	table.AddField("MEM %", cs.Bold)
+	table.AddField("NET I/O", cs.Bold)
+	table.AddField("BLOCK I/O", cs.Bold)
+	table.EndRow()
+
+	for _, entry := range entries {
+		table.AddField(entry.Name, nil)
+		table.AddField(fmt.Sprintf("%.2f%%", entry.CPUPercentage), nil)
+		table.AddField(fmt.Sprintf("%s / %s", humanize.IBytes(entry.MemoryUsage), humanize.IBytes(entry.MemoryLimit)), nil)
+		table.AddField(fmt.Sprintf("%.2f%%", entry.MemoryPercentage), nil)
+		table.AddField(fmt.Sprintf("%s / %s", humanize.IBytes(entry.NetRxBytes), humanize.IBytes(entry.NetTxBytes)), nil)
+		table.AddField(fmt.Sprintf("%s / %s", humanize.IBytes(entry.BlockReadBytes), humanize.IBytes(entry.BlockWriteBytes)), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
@@ -137,7 +137,13 @@ func (opts *StopOptions) Run(ctx context.Context, args []string) error {
 	for _, machine := range stop {
 		if machine.Status.State == machineapi.MachineStateExited {
 			continue
-		} else if _, err := controller.Stop(ctx, &machine); err != nil {
+		}
+
+		// Prevent the machine from being restarted according to its restart
+		// policy.
+		machine.Status.StoppedByUser = true
+
+		if _, err := controller.Stop(ctx, &machine); err != nil {
 			log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
 		} else {
 			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package migratestore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/set"
+	"kraftkit.sh/log"
+	"kraftkit.sh/store"
+)
+
+type MigrateStoreOptions struct {
+	From string `long:"from" usage:"The store backend to move the local state from. Choice of: [badger, json]" default:"badger"`
+	To   string `long:"to" usage:"The store backend to move the local state to (default is the configured store). Choice of: [badger, json]"`
+}
+
+// MigrateStore moves the local state of machines, networks, volumes and
+// compose projects between store backends.
+func MigrateStore(ctx context.Context, opts *MigrateStoreOptions, args ...string) error {
+	if opts == nil {
+		opts = &MigrateStoreOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&MigrateStoreOptions{}, cobra.Command{
+		Short: "Move the local state between store backends",
+		Use:   "migrate-store [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Move the local state of machines, networks, volumes and compose projects
+			between store backends.
+
+			Each set of objects is written to the destination store at once and only
+			then removed from the source store, such that a failed migration leaves it
+			in the source store.  Afterwards, set the 'store' configuration option to
+			the destination backend such that it is used.
+
+			The JSON store holds a human-readable copy of each object for inspection
+			only.  Edits made to it by hand are ignored.
+		`),
+		Example: heredoc.Doc(`
+			# Move the local state from the default badger store into the JSON store
+			$ kraft system migrate-store --from badger --to json
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "system",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *MigrateStoreOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.To == "" {
+		opts.To = config.G[config.KraftKit](cmd.Context()).Store
+	}
+
+	backends := set.NewStringSet(store.BackendNames()...)
+	if !backends.Contains(opts.From) {
+		return fmt.Errorf("unsupported store backend: %s", opts.From)
+	} else if !backends.Contains(opts.To) {
+		return fmt.Errorf("unsupported store backend: %s", opts.To)
+	} else if opts.From == opts.To {
+		return fmt.Errorf("source and destination store backends must differ")
+	}
+
+	return nil
+}
+
+func (opts *MigrateStoreOptions) Run(ctx context.Context, _ []string) error {
+	from := store.Backend(opts.From)
+	to := store.Backend(opts.To)
+
+	for name, migrate := range map[string]func(context.Context, string, store.Backend, store.Backend) (int, error){
+		"composev1":       migrateStore[composev1.ComposeSpec, composev1.ComposeStatus],
+		"machinev1alpha1": migrateStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus],
+		"networkv1alpha1": migrateStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus],
+		"volumev1alpha1":  migrateStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus],
+	} {
+		count, err := migrate(ctx, name, from, to)
+		if err != nil {
+			return fmt.Errorf("could not migrate %s: %w", name, err)
+		}
+
+		log.G(ctx).
+			WithField("from", from).
+			WithField("to", to).
+			Infof("migrated %d object(s) of %s", count, name)
+	}
+
+	if config.G[config.KraftKit](ctx).Store != opts.To {
+		log.G(ctx).Infof("to use the migrated store, set:")
+		log.G(ctx).Infof("")
+		log.G(ctx).Infof("\texport KRAFTKIT_STORE=%s", opts.To)
+	}
+
+	return nil
+}
+
+// migrateStore moves the named set of objects between the provided backends
+// within the runtime directory.
+func migrateStore[Spec, Status any](ctx context.Context, name string, from, to store.Backend) (int, error) {
+	runtimeDir := config.G[config.KraftKit](ctx).RuntimeDir
+
+	src, err := store.NewStore[Spec, Status](from, runtimeDir, name)
+	if err != nil {
+		return 0, err
+	}
+
+	dst, err := store.NewStore[Spec, Status](to, runtimeDir, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return store.Migrate[Spec, Status](ctx, src, dst)
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package system
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+
+	"kraftkit.sh/internal/cli/kraft/system/migratestore"
+)
+
+type SystemOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SystemOptions{}, cobra.Command{
+		Short: "Manage the local KraftKit installation",
+		Use:   "system SUBCOMMAND",
+		Long:  "Manage the local KraftKit installation.",
+		Example: heredoc.Doc(`
+			# Move the local state into the JSON store
+			$ kraft system migrate-store --to json
+		`),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(migratestore.NewCmd())
+
+	return cmd
+}
+
+func (opts *SystemOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
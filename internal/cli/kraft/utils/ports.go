@@ -8,6 +8,9 @@ package utils
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 )
@@ -24,7 +27,7 @@ func CheckPorts(ctx context.Context, controller machineapi.MachineService, machi
 	for _, existingMachine := range existingMachines.Items {
 		for _, existingPort := range existingMachine.Spec.Ports {
 			for _, newPort := range machine.Spec.Ports {
-				if existingPort.HostIP == newPort.HostIP && existingPort.HostPort == newPort.HostPort && existingMachine.Status.State == machineapi.MachineStateRunning {
+				if portsOverlap(existingPort, newPort) && existingMachine.Status.State == machineapi.MachineStateRunning {
 					return fmt.Errorf("port %s:%d is already in use by %s", existingPort.HostIP, existingPort.HostPort, existingMachine.Name)
 				}
 			}
@@ -33,3 +36,28 @@ func CheckPorts(ctx context.Context, controller machineapi.MachineService, machi
 
 	return nil
 }
+
+// portsOverlap returns whether the two ports are bound to the same host port
+// with the same protocol, where a port bound to all host addresses overlaps
+// with a port bound to any specific host address.
+func portsOverlap(a, b machineapi.MachinePort) bool {
+	if a.HostPort != b.HostPort || portProtocol(a) != portProtocol(b) {
+		return false
+	}
+
+	return a.HostIP == b.HostIP || isWildcardIP(a.HostIP) || isWildcardIP(b.HostIP)
+}
+
+// portProtocol returns the protocol of the port, defaulting to TCP.
+func portProtocol(port machineapi.MachinePort) corev1.Protocol {
+	if port.Protocol == "" {
+		return machineapi.DefaultProtocol
+	}
+
+	return corev1.Protocol(strings.ToUpper(string(port.Protocol)))
+}
+
+// isWildcardIP returns whether the host IP refers to all host addresses.
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"testing"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestPortsOverlap(t *testing.T) {
+	tests := []struct {
+		name   string
+		a      string
+		b      string
+		expect bool
+	}{
+		{name: "same port", a: "8080:80", b: "8080:80", expect: true},
+		{name: "same host port to different machine ports", a: "8080:80", b: "8080:8080", expect: true},
+		{name: "disjoint ports", a: "8080:80", b: "8081:80", expect: false},
+		{name: "same protocol", a: "53:53/udp", b: "53:53/udp", expect: true},
+		{name: "protocol mismatch", a: "53:53/tcp", b: "53:53/udp", expect: false},
+		{name: "default protocol", a: "8080:80", b: "8080:80/tcp", expect: true},
+		{name: "wildcard and specific address", a: "8080:80", b: "127.0.0.1:8080:80", expect: true},
+		{name: "specific and unspecified IPv4 address", a: "127.0.0.1:8080:80", b: "0.0.0.0:8080:80", expect: true},
+		{name: "specific and unspecified IPv6 address", a: "::1:8080:80", b: ":::8080:80", expect: true},
+		{name: "same specific address", a: "127.0.0.1:8080:80", b: "127.0.0.1:8080:80", expect: true},
+		{name: "different specific addresses", a: "127.0.0.1:8080:80", b: "127.0.0.2:8080:80", expect: false},
+		{name: "single port within range", a: "8080-8082:80-82", b: "8081:80", expect: true},
+		{name: "single port outside range", a: "8080-8082:80-82", b: "8083:80", expect: false},
+		{name: "overlapping ranges", a: "8080-8082:80-82", b: "8082-8084:80-82", expect: true},
+		{name: "disjoint ranges", a: "8080-8082:80-82", b: "8083-8085:80-82", expect: false},
+		{name: "ranges with protocol mismatch", a: "8080-8082:80-82/tcp", b: "8081-8083:80-82/udp", expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := machineapi.ParsePort(tt.a)
+			if err != nil {
+				t.Fatalf("parsing %s: %v", tt.a, err)
+			}
+
+			b, err := machineapi.ParsePort(tt.b)
+			if err != nil {
+				t.Fatalf("parsing %s: %v", tt.b, err)
+			}
+
+			// Ranges are expanded into a port each, which overlap if any pair does.
+			got := false
+			for _, pa := range a {
+				for _, pb := range b {
+					if portsOverlap(pa, pb) != portsOverlap(pb, pa) {
+						t.Errorf("expected overlap of %s and %s to be symmetric", tt.a, tt.b)
+					}
+
+					got = got || portsOverlap(pa, pb)
+				}
+			}
+
+			if got != tt.expect {
+				t.Errorf("expected overlap of %s and %s to be %t, got %t", tt.a, tt.b, tt.expect, got)
+			}
+		})
+	}
+}
@@ -18,9 +18,9 @@ import (
 	"kraftkit.sh/unikraft/target"
 )
 
-// BuildRootfs generates a rootfs based on the provided working directory and
-// the rootfs entrypoint for the provided target(s).
-func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, targ target.Target) (string, []string, []string, error) {
+// BuildRootfs generates a rootfs in the provided format based on the provided
+// working directory and the rootfs entrypoint for the provided target(s).
+func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, format initrd.Format, targ target.Target) (string, []string, []string, error) {
 	if rootfs == "" {
 		return "", nil, nil, nil
 	}
@@ -34,7 +34,7 @@ func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, tar
 		initrd.WithOutput(filepath.Join(
 			workdir,
 			unikraft.BuildDir,
-			fmt.Sprintf(initrd.DefaultInitramfsArchFileName, targ.Architecture().String()),
+			initrd.ArchFileName(format, targ.Architecture().String()),
 		)),
 		initrd.WithCacheDir(filepath.Join(
 			workdir,
@@ -43,6 +43,7 @@ func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, tar
 		)),
 		initrd.WithArchitecture(targ.Architecture().String()),
 		initrd.WithCompression(compress),
+		initrd.WithOutputFormat(format),
 	)
 	if err != nil {
 		return "", nil, nil, fmt.Errorf("could not initialize initramfs builder: %w", err)
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package clone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type CloneOptions struct {
+	Driver string `noattribute:"true"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CloneOptions{}, cobra.Command{
+		Short:   "Clone a volume",
+		Use:     "clone SOURCE DESTINATION",
+		Aliases: []string{"cp", "copy"},
+		Args:    cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Create a new volume with the contents of an existing volume.
+
+			Block-device volumes are cloned with reflinks when supported by the host's
+			filesystem, which makes cloning instantaneous regardless of their size.
+		`),
+		Example: heredoc.Doc(`
+			# Clone a volume
+			$ kraft volume clone my-volume my-other-volume
+
+			# Clone a block-device volume
+			$ kraft volume --driver blk clone my-volume my-other-volume
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CloneOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *CloneOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	strategy, ok := volume.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	src, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if src == nil {
+		return fmt.Errorf("volume %s does not exist", args[0])
+	}
+
+	dst, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[1],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if dst != nil {
+		return fmt.Errorf("volume %s already exists", args[1])
+	}
+
+	if dst, err = controller.Create(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[1],
+		},
+		Spec: volumeapi.VolumeSpec{
+			Driver:     src.Spec.Driver,
+			Size:       src.Spec.Size,
+			Filesystem: src.Spec.Filesystem,
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err := volume.Copy(ctx, src, dst); err != nil {
+		_, _ = controller.Delete(ctx, dst)
+		return err
+	}
+
+	if _, err := controller.Update(ctx, dst); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, dst.Name)
+
+	return nil
+}
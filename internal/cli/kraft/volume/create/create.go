@@ -10,16 +10,20 @@ import (
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
 )
 
 type CreateOptions struct {
-	Driver string `noattribute:"true"`
+	Driver     string `noattribute:"true"`
+	Filesystem string `long:"fs" usage:"Set the filesystem of the volume for block-device volume drivers (ext2, fat)"`
+	Size       string `long:"size" short:"s" usage:"Set the size of the volume for block-device volume drivers (e.g. 512Mi, 1Gi)"`
 }
 
 func NewCmd() *cobra.Command {
@@ -36,6 +40,12 @@ func NewCmd() *cobra.Command {
 
 			# Create a volume with a specific name
 			$ kraft volume create my-volume
+
+			# Create a 1GiB block-device volume formatted with ext2
+			$ kraft volume create --driver blk --size 1Gi my-volume
+
+			# Create a block-device volume formatted with FAT
+			$ kraft volume create --driver blk --size 256Mi --fs fat my-volume
 		`),
 	})
 	if err != nil {
@@ -47,6 +57,11 @@ func NewCmd() *cobra.Command {
 
 func (opts *CreateOptions) Pre(cmd *cobra.Command, _ []string) error {
 	opts.Driver = cmd.Flag("driver").Value.String()
+
+	if opts.Driver != blk.DriverName && (len(opts.Size) > 0 || len(opts.Filesystem) > 0) {
+		return fmt.Errorf("the size and filesystem can only be set for the %s volume driver", blk.DriverName)
+	}
+
 	return nil
 }
 
@@ -78,15 +93,27 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	if vol != nil {
-		return fmt.Errorf("volume %s already exists", args[0])
+		return fmt.Errorf("volume %s already exists", name)
+	}
+
+	var size int64
+	if len(opts.Size) > 0 {
+		quantity, err := resource.ParseQuantity(opts.Size)
+		if err != nil {
+			return fmt.Errorf("could not parse volume size: %w", err)
+		}
+
+		size = quantity.Value()
 	}
 
 	if vol, err = controller.Create(ctx, &volumeapi.Volume{
 		ObjectMeta: v1.ObjectMeta{
-			Name: args[0],
+			Name: name,
 		},
 		Spec: volumeapi.VolumeSpec{
-			Driver: opts.Driver,
+			Driver:     opts.Driver,
+			Size:       size,
+			Filesystem: opts.Filesystem,
 		},
 	}); err != nil {
 		return err
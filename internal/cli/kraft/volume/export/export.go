@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/archive"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type ExportOptions struct {
+	Driver       string `noattribute:"true"`
+	Output       string `long:"output" short:"o" usage:"Write the tarball to a file instead of stdout (gzip-compressed if it ends with .gz or .tgz)"`
+	Reproducible bool   `long:"reproducible" usage:"Omit timestamps such that identical contents yield an identical tarball"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ExportOptions{}, cobra.Command{
+		Short: "Export the contents of a volume as a tarball",
+		Use:   "export [FLAGS] VOLUME",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Export the contents of a volume as a tarball.
+
+			The tarball of a block-device volume holds its disk image.
+		`),
+		Example: heredoc.Doc(`
+			# Export a volume to a tarball
+			$ kraft volume export my-volume -o my-volume.tar
+
+			# Export a volume to a gzip-compressed tarball
+			$ kraft volume export my-volume -o my-volume.tar.gz
+
+			# Export a volume to stdout
+			$ kraft volume export my-volume > my-volume.tar
+
+			# Export a volume such that it can be committed as a test fixture
+			$ kraft volume export --reproducible my-volume -o fixture.tar.gz
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ExportOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *ExportOptions) Run(ctx context.Context, args []string) (retErr error) {
+	var err error
+
+	strategy, ok := volume.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if vol == nil {
+		return fmt.Errorf("volume %s does not exist", args[0])
+	}
+
+	var out io.Writer
+	if len(opts.Output) == 0 || opts.Output == "-" {
+		if iostreams.G(ctx).IsStdoutTTY() {
+			return fmt.Errorf("refusing to write tarball to a terminal: redirect stdout or use --output")
+		}
+
+		out = iostreams.G(ctx).Out
+	} else {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not create tarball: %w", err)
+		}
+
+		defer func() {
+			retErr = errors.Join(retErr, f.Close())
+			if retErr != nil {
+				os.Remove(opts.Output)
+			}
+		}()
+
+		out = f
+	}
+
+	if strings.HasSuffix(opts.Output, ".gz") || strings.HasSuffix(opts.Output, ".tgz") {
+		gzw := gzip.NewWriter(out)
+		defer func() {
+			retErr = errors.Join(retErr, gzw.Close())
+		}()
+
+		out = gzw
+	}
+
+	return volume.Export(ctx, vol, out,
+		archive.WithStripTimes(opts.Reproducible),
+	)
+}
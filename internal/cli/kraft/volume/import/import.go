@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package vimport // "v(olume)import"; "import" is a reserved keyword
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type ImportOptions struct {
+	Driver string `noattribute:"true"`
+	Input  string `long:"input" short:"i" usage:"Read the tarball from a file instead of stdin"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ImportOptions{}, cobra.Command{
+		Short: "Import the contents of a tarball into a volume",
+		Use:   "import [FLAGS] VOLUME",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Import the contents of a tarball, which may be gzip-compressed, into a
+			volume.
+
+			The volume is created if it does not exist.  Files which already exist in
+			the volume are overwritten.  The disk image of a block-device volume is
+			replaced by the first file of the tarball.
+		`),
+		Example: heredoc.Doc(`
+			# Import a tarball into a volume
+			$ kraft volume import my-volume < my-volume.tar
+
+			# Import a gzip-compressed tarball into a volume
+			$ kraft volume import my-volume -i my-volume.tar.gz
+
+			# Seed a new block-device volume from an exported disk image
+			$ kraft volume --driver blk import my-volume -i my-volume.tar.gz
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ImportOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *ImportOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	var in io.Reader
+	if len(opts.Input) == 0 || opts.Input == "-" {
+		if iostreams.G(ctx).IsStdinTTY() {
+			return fmt.Errorf("refusing to read tarball from a terminal: redirect stdin or use --input")
+		}
+
+		in = iostreams.G(ctx).In
+	} else {
+		f, err := os.Open(opts.Input)
+		if err != nil {
+			return fmt.Errorf("could not open tarball: %w", err)
+		}
+
+		defer f.Close()
+
+		in = f
+	}
+
+	strategy, ok := volume.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	created := vol == nil
+	if created {
+		if vol, err = controller.Create(ctx, &volumeapi.Volume{
+			ObjectMeta: v1.ObjectMeta{
+				Name: args[0],
+			},
+			Spec: volumeapi.VolumeSpec{
+				Driver: opts.Driver,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := volume.Import(ctx, vol, in); err != nil {
+		// Do not leave behind a volume which would only be partially seeded.
+		if created {
+			_, _ = controller.Delete(ctx, vol)
+		}
+
+		return err
+	}
+
+	if _, err := controller.Update(ctx, vol); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, vol.Name)
+
+	return nil
+}
@@ -7,7 +7,12 @@ package inspect
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -21,6 +26,7 @@ import (
 
 type Inspect struct {
 	Driver string `noattribute:"true"`
+	Watch  bool   `long:"watch" short:"w" usage:"Stream the changes of the volume's state until interrupted"`
 }
 
 func NewCmd() *cobra.Command {
@@ -35,6 +41,9 @@ func NewCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			# Inspect a volume
 			$ kraft volume inspect my-volume
+
+			# Stream the changes of the state of a volume
+			$ kraft volume inspect -w my-volume
 		`),
 	})
 	if err != nil {
@@ -71,7 +80,49 @@ func (opts *Inspect) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	ret, err := json.Marshal(volume)
+	if !opts.Watch {
+		return printJSON(ctx, volume)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ctrlc := make(chan os.Signal, 1)
+	signal.Notify(ctrlc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctrlc
+		cancel()
+	}()
+
+	// The first event is the current state of the volume.
+	events, errs, err := controller.Watch(ctx, volume)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+
+		case volume := <-events:
+			if err := printJSON(ctx, volume); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// printJSON prints the provided object as a single line of JSON.
+func printJSON(ctx context.Context, obj any) error {
+	ret, err := json.Marshal(obj)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
+)
+
+type CreateOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CreateOptions{}, cobra.Command{
+		Short: "Take a snapshot of a block-device volume",
+		Use:   "create VOLUME [SNAPSHOT]",
+		Args:  cobra.RangeArgs(1, 2),
+		Long: heredoc.Doc(`
+			Take a point-in-time snapshot of a block-device volume.
+
+			The name of the snapshot defaults to the current time.  A volume which is in
+			use by a machine can be snapshotted, in which case the snapshot is as
+			consistent as after a power loss.
+		`),
+		Example: heredoc.Doc(`
+			# Take a snapshot of a volume named after the current time
+			$ kraft volume snapshot create my-volume
+
+			# Take a named snapshot of a volume
+			$ kraft volume snapshot create my-volume before-upgrade
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	controller, err := volume.Strategies()[blk.DriverName].NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if vol == nil {
+		return fmt.Errorf("%s volume %s does not exist", blk.DriverName, args[0])
+	}
+
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	}
+
+	snapshot, err := blk.Snapshot(ctx, vol, name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := controller.Update(ctx, vol); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, snapshot.Name)
+
+	return nil
+}
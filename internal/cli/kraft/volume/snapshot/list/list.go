@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package list
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
+)
+
+type List struct {
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&List{}, cobra.Command{
+		Short:   "List the snapshots of a block-device volume",
+		Use:     "ls [FLAGS] VOLUME",
+		Aliases: []string{"list"},
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *List) Run(ctx context.Context, args []string) error {
+	var err error
+
+	controller, err := volume.Strategies()[blk.DriverName].NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if vol == nil {
+		return fmt.Errorf("%s volume %s does not exist", blk.DriverName, args[0])
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("SNAPSHOT NAME", cs.Bold)
+	table.AddField("CREATED", cs.Bold)
+	table.AddField("SOURCE", cs.Bold)
+	table.EndRow()
+
+	for _, snapshot := range vol.Status.Snapshots {
+		table.AddField(snapshot.Name, nil)
+		table.AddField(humanize.Time(snapshot.CreatedAt.Time), nil)
+		table.AddField(snapshot.Source, nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
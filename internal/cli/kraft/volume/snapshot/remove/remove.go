@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package remove
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
+)
+
+type RemoveOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
+		Short:   "Remove a snapshot of a block-device volume",
+		Use:     "remove VOLUME SNAPSHOT",
+		Aliases: []string{"rm", "delete", "del"},
+		Args:    cobra.ExactArgs(2),
+		Example: heredoc.Doc(`
+			# Remove a snapshot of a volume
+			$ kraft volume snapshot remove my-volume before-upgrade
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	controller, err := volume.Strategies()[blk.DriverName].NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if vol == nil {
+		return fmt.Errorf("%s volume %s does not exist", blk.DriverName, args[0])
+	}
+
+	if err := blk.RemoveSnapshot(ctx, vol, args[1]); err != nil {
+		return err
+	}
+
+	if _, err := controller.Update(ctx, vol); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, args[1])
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package restore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/blk"
+)
+
+type RestoreOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RestoreOptions{}, cobra.Command{
+		Short: "Restore a block-device volume to a snapshot",
+		Use:   "restore VOLUME SNAPSHOT",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Restore the contents of a block-device volume to those of one of its
+			snapshots.  The volume must not be in use by a machine.
+		`),
+		Example: heredoc.Doc(`
+			# Restore a volume to a snapshot
+			$ kraft volume snapshot restore my-volume before-upgrade
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RestoreOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	controller, err := volume.Strategies()[blk.DriverName].NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := controller.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if vol == nil {
+		return fmt.Errorf("%s volume %s does not exist", blk.DriverName, args[0])
+	}
+
+	if err := blk.Restore(ctx, vol, args[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, args[1])
+
+	return nil
+}
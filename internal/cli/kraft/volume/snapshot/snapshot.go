@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package snapshot
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/volume/snapshot/create"
+	"kraftkit.sh/internal/cli/kraft/volume/snapshot/list"
+	"kraftkit.sh/internal/cli/kraft/volume/snapshot/remove"
+	"kraftkit.sh/internal/cli/kraft/volume/snapshot/restore"
+)
+
+type Snapshot struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&Snapshot{}, cobra.Command{
+		Short:   "Manage snapshots of block-device volumes",
+		Use:     "snapshot SUBCOMMAND",
+		Aliases: []string{"snap", "snapshots"},
+		Long: heredoc.Doc(`
+			Manage point-in-time snapshots of block-device volumes.
+
+			Snapshots are taken with reflinks when supported by the host's filesystem,
+			in which case they are instantaneous and only occupy the space of the
+			blocks which have changed since.
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(restore.NewCmd())
+
+	return cmd
+}
+
+func (opts *Snapshot) Run(ctx context.Context, args []string) error {
+	return pflag.ErrHelp
+}
@@ -12,10 +12,14 @@ import (
 	"github.com/spf13/pflag"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/volume/clone"
 	"kraftkit.sh/internal/cli/kraft/volume/create"
+	"kraftkit.sh/internal/cli/kraft/volume/export"
+	vimport "kraftkit.sh/internal/cli/kraft/volume/import"
 	"kraftkit.sh/internal/cli/kraft/volume/inspect"
 	"kraftkit.sh/internal/cli/kraft/volume/list"
 	"kraftkit.sh/internal/cli/kraft/volume/remove"
+	"kraftkit.sh/internal/cli/kraft/volume/snapshot"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/machine/volume"
 )
@@ -38,10 +42,14 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(clone.NewCmd())
 	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(export.NewCmd())
+	cmd.AddCommand(vimport.NewCmd())
 	cmd.AddCommand(inspect.NewCmd())
 	cmd.AddCommand(list.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(snapshot.NewCmd())
 
 	return cmd
 }
@@ -31,6 +31,10 @@ import (
 	"kraftkit.sh/internal/run"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network/macaddr"
+	"kraftkit.sh/machine/network/macvtap"
+	"kraftkit.sh/machine/network/portforward"
+	"kraftkit.sh/machine/stats"
+	"kraftkit.sh/machine/volume/blk"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
@@ -41,6 +45,10 @@ const (
 	FirecrackerBin         = "firecracker"
 	DefaultClientTimout    = time.Second * 5
 	FirecrackerMemoryScale = 1024 * 1024
+
+	// FirecrackerWatchInterval is the interval at which the state of the
+	// machine is polled via the API socket when watching it.
+	FirecrackerWatchInterval = time.Millisecond * 500
 )
 
 // machineV1alpha1Service ...
@@ -74,8 +82,20 @@ func NewMachineV1alpha1Service(ctx context.Context, opts ...any) (machinev1alpha
 // Create implements kraftkit.sh/api/machine/v1alpha1.MachineService.Create
 func (service *machineV1alpha1Service) Create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
 	// Start with fail-safe checks for unsupported specification declarations.
+	// Firecracker has no user-mode networking, so published ports are forwarded
+	// on the host to the machine's address on its network.
 	if len(machine.Spec.Ports) > 0 {
-		return machine, fmt.Errorf("kraftkit does not yet support port forwarding to firecracker (contributions welcome): please use a network instead")
+		if _, _, err := portforward.Target(machine.Spec.Networks); err != nil {
+			return machine, err
+		}
+	}
+
+	// The devices of macvtap interfaces are character devices rather than TAP
+	// devices which Firecracker is able to attach.
+	for _, network := range machine.Spec.Networks {
+		if network.Driver == macvtap.DriverName {
+			return machine, fmt.Errorf("macvtap networks are not supported by Firecracker")
+		}
 	}
 
 	if machine.Status.KernelPath == "" {
@@ -106,9 +126,41 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	}
 
 	var fstab []string
+	var drives []*models.Drive
 
 	for _, vol := range machine.Spec.Volumes {
+		if len(vol.Spec.Options) > 0 {
+			return machine, fmt.Errorf("mount options are not supported by Firecracker")
+		}
+
+		// Only drives can be attached read-only.
+		if vol.Spec.ReadOnly && vol.Spec.Driver != blk.DriverName {
+			return machine, fmt.Errorf("read-only %s volumes are not supported by Firecracker", vol.Spec.Driver)
+		}
+
 		switch vol.Spec.Driver {
+		case blk.DriverName:
+			var flags vfscore.MountFlags
+			if vol.Spec.ReadOnly {
+				flags |= vfscore.MountFlagReadOnly
+			}
+
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				blk.DeviceName(len(drives)),
+				vol.Spec.Destination,
+				vol.Spec.Filesystem,
+				flags.String(),
+				"",
+				"mkmp",
+			).String())
+
+			drives = append(drives, &models.Drive{
+				DriveID:      firecracker.String(fmt.Sprintf("hblk%d", len(drives))),
+				PathOnHost:   firecracker.String(vol.Spec.Source),
+				IsRootDevice: firecracker.Bool(false),
+				IsReadOnly:   firecracker.Bool(vol.Spec.ReadOnly),
+			})
+
 		case "initrd":
 			fstab = append(fstab, vfscore.NewFstabEntry(
 				"initrd0",
@@ -290,6 +342,15 @@ watch:
 					return machine, err
 				}
 
+				// Increment the host network ID for additional interfaces.
+				i++
+
+				// Interfaces without an address acquire one from their network
+				// instead.
+				if iface.Spec.CIDR == "" {
+					continue
+				}
+
 				kernelArgs = append(kernelArgs,
 					uknetdev.NewParamIp().WithValue(uknetdev.NetdevIp{
 						CIDR:     iface.Spec.CIDR,
@@ -300,9 +361,6 @@ watch:
 						Domain:   iface.Spec.Domain,
 					}),
 				)
-
-				// Increment the host network ID for additional interfaces.
-				i++
 			}
 		}
 	}
@@ -318,6 +376,14 @@ watch:
 	}
 	args = append(args, machine.Spec.ApplicationArgs...)
 
+	// Attach the block-device volumes in the same order as their entries in the
+	// fstab, such that their device names match.
+	for _, drive := range drives {
+		if _, err := client.PutGuestDriveByID(ctx, *drive.DriveID, drive); err != nil {
+			return machine, err
+		}
+	}
+
 	// Set the machine's resource configuration.
 	if _, err := client.PutMachineConfiguration(ctx, &models.MachineConfiguration{
 		VcpuCount:  firecracker.Int64(machine.Spec.Resources.Requests.Cpu().Value()),
@@ -347,6 +413,18 @@ watch:
 		}
 	}
 
+	// Publish the ports of the machine.
+	if len(machine.Spec.Ports) > 0 {
+		ifname, addr, err := portforward.Target(machine.Spec.Networks)
+		if err != nil {
+			return machine, err
+		}
+
+		if err := portforward.Add(ctx, string(machine.UID), ifname, addr, machine.Spec.Ports); err != nil {
+			return machine, fmt.Errorf("could not publish ports: %w", err)
+		}
+	}
+
 	machine.Status.Pid = int32(pid)
 	machine.Status.State = machinev1alpha1.MachineStateCreated
 
@@ -382,31 +460,62 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 	return events, errs, nil
 }
 
+// watch periodically queries the state of the machine via the API socket and
+// emits the machine on the events channel whenever its state changes.  Unlike
+// QEMU, Firecracker does not expose an event stream, so polling is the only
+// means of observing transitions such as a pause or resume of the VM.
 func (service *machineV1alpha1Service) watch(ctx context.Context, machine *machinev1alpha1.Machine, events *chan *machinev1alpha1.Machine, errs *chan error) {
+	// firstCall is used to initialize the channel with the current state of the
+	// machine, so that it can be immediately acted upon.
+	firstCall := true
+	lastState := machine.Status.State
+
+	// The machine is refreshed on a copy of its own since the provided one
+	// remains in use by the caller.
+	current := *machine
+
+	ticker := time.NewTicker(FirecrackerWatchInterval)
+	defer ticker.Stop()
+
 	for {
-		select {
-		case <-ctx.Done():
-			log.G(ctx).Info("context cancelled (watch)")
-			*errs <- ctx.Err()
-			return
-		default:
-			process, err := os.FindProcess(int(machine.Status.Pid))
-			if err != nil {
-				return
+		// Only emit the machine if its state has changed, or if this is the first
+		// iteration of the loop.
+		machine, err := service.Get(ctx, &current)
+		if err != nil {
+			select {
+			case *errs <- err:
+			case <-ctx.Done():
 			}
+			return
+		}
+
+		if firstCall || machine.Status.State != lastState {
+			firstCall = false
+			lastState = machine.Status.State
 
-			state, err := process.Wait()
-			if err != nil {
-				*errs <- err
+			// Each event carries a copy such that it is not modified by subsequent
+			// refreshes whilst in use by the consumer.
+			event := *machine
+
+			select {
+			case *events <- &event:
+			case <-ctx.Done():
+				*errs <- ctx.Err()
 				return
 			}
+		}
 
-			if state.Exited() {
-				machine.Status.State = machinev1alpha1.MachineStateExited
-				machine.Status.ExitCode = state.ExitCode()
-			}
+		// There is nothing more to observe once the VMM has exited.
+		if machine.Status.State == machinev1alpha1.MachineStateExited {
+			return
+		}
 
-			*events <- machine
+		select {
+		case <-ctx.Done():
+			log.G(ctx).Info("context cancelled (watch)")
+			*errs <- ctx.Err()
+			return
+		case <-ticker.C:
 		}
 	}
 }
@@ -418,25 +527,96 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 		return machine, err
 	}
 
+	// The VMM of a machine which has exited, e.g. one which is being restarted
+	// by its supervisor, must be re-created before the VM can be booted again.
+	switch machine.Status.State {
+	case machinev1alpha1.MachineStateExited, machinev1alpha1.MachineStateRestarting:
+		if process, err := goprocess.NewProcess(machine.Status.Pid); err == nil {
+			if running, _ := process.IsRunning(); running {
+				break
+			}
+		}
+
+		if err := os.Remove(fccfg.SocketPath); err != nil && !os.IsNotExist(err) {
+			return machine, fmt.Errorf("could not remove stale API socket: %w", err)
+		}
+
+		machine, err = service.Create(ctx, machine)
+		if err != nil {
+			return machine, err
+		}
+
+		fccfg, err = getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig)
+		if err != nil {
+			return machine, err
+		}
+	}
+
 	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
-	action := models.InstanceActionInfoActionTypeInstanceStart
-	info := models.InstanceActionInfo{
-		ActionType: &action,
+
+	// Determine whether the VM has already been booted, in which case it can
+	// only be resumed since Firecracker does not permit the InstanceStart action
+	// to be issued more than once.
+	info, err := client.GetInstanceInfo(ctx)
+	if err != nil {
+		return machine, fmt.Errorf("could not query machine status via API socket: %v", err)
 	}
 
-	if _, err := client.CreateSyncAction(ctx, &info); err != nil {
-		return machine, err
+	if info.Payload == nil || info.Payload.State == nil {
+		return machine, fmt.Errorf("machine status reported via API socket lacks a state")
+	}
+
+	switch *info.Payload.State {
+	case models.InstanceInfoStatePaused:
+		if _, err := client.PatchVM(ctx, &models.VM{
+			State: firecracker.String(models.VMStateResumed),
+		}); err != nil {
+			return machine, fmt.Errorf("could not resume firecracker instance: %v", err)
+		}
+
+	case models.InstanceInfoStateRunning:
+		// The machine is already running, nothing to do.
+
+	default:
+		action := models.InstanceActionInfoActionTypeInstanceStart
+		info := models.InstanceActionInfo{
+			ActionType: &action,
+		}
+
+		if _, err := client.CreateSyncAction(ctx, &info); err != nil {
+			return machine, err
+		}
+
+		machine.Status.StartedAt = time.Now()
+	}
+
+	if machine.Status.StartedAt.IsZero() {
+		machine.Status.StartedAt = time.Now()
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateRunning
-	machine.Status.StartedAt = time.Now()
 
 	return machine, nil
 }
 
 // Pause implements kraftkit.sh/api/machine/v1alpha1.MachineService
 func (service *machineV1alpha1Service) Pause(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/firecracker.machineV1alpha1Service.Pause")
+	fccfg, err := getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig)
+	if err != nil {
+		return machine, err
+	}
+
+	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
+
+	if _, err := client.PatchVM(ctx, &models.VM{
+		State: firecracker.String(models.VMStatePaused),
+	}); err != nil {
+		return machine, fmt.Errorf("could not pause firecracker instance: %v", err)
+	}
+
+	machine.Status.State = machinev1alpha1.MachineStatePaused
+
+	return machine, nil
 }
 
 // Logs implements kraftkit.sh/api/machine/v1alpha1.MachineService
@@ -477,6 +657,9 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		}
 	}
 
+	// Resource usage is only sampled whilst the machine is running.
+	machine.Status.Stats = nil
+
 	exitedAt := machine.Status.ExitedAt
 	exitCode := machine.Status.ExitCode
 
@@ -505,18 +688,34 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	}()
 
 	if !activeProcess {
+		// A machine which is awaiting its restart by its supervisor has no
+		// process until it has been started again.
+		if savedState == machinev1alpha1.MachineStateRestarting {
+			state = savedState
+			return machine, nil
+		}
+
 		state = machinev1alpha1.MachineStateExited
 		if savedState == machinev1alpha1.MachineStateRunning {
 			exitCode = 1
 		}
+
+		// Release the published ports of a machine which has exited on its own
+		// such that they do not shadow those of other machines.
+		if savedState != machinev1alpha1.MachineStateExited && len(machine.Spec.Ports) > 0 {
+			if err := portforward.Remove(ctx, string(machine.UID)); err != nil {
+				log.G(ctx).Debugf("could not remove published ports: %v", err)
+			}
+		}
+
 		return machine, nil
 	}
 
 	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
 
 	// Grab the actual state of the machine by querying the API socket
-	ctx, cancel := context.WithTimeout(ctx, service.timeout)
-	info, err := client.GetInstanceInfo(ctx)
+	infoCtx, cancel := context.WithTimeout(ctx, service.timeout)
+	info, err := client.GetInstanceInfo(infoCtx)
 	if err != nil {
 		cancel()
 		// We cannot amend the status at this point, even if the process is
@@ -527,6 +726,10 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 
 	cancel()
 
+	if info.Payload == nil || info.Payload.State == nil {
+		return machine, fmt.Errorf("machine status reported via API socket lacks a state")
+	}
+
 	// Map the Firecracker state to supported machine states
 	switch *info.Payload.State {
 	case models.InstanceInfoStateNotStarted:
@@ -542,11 +745,37 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		exitCode = -1
 	}
 
+	if stats.Sampling(ctx) && (state == machinev1alpha1.MachineStateRunning || state == machinev1alpha1.MachineStatePaused) {
+		machine.Status.Stats = sampleStats(ctx, machine)
+	}
+
 	machine.Status.PlatformConfig = fccfg
 
 	return machine, nil
 }
 
+// sampleStats samples the resource usage of the provided running machine.
+// Failures are only logged since the statistics are informational.
+func sampleStats(ctx context.Context, machine *machinev1alpha1.Machine) *machinev1alpha1.MachineStats {
+	sample := machinev1alpha1.MachineStats{
+		CollectedAt: time.Now(),
+	}
+
+	if memory, ok := machine.Spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		sample.MemoryLimit = uint64(memory.Value())
+	}
+
+	if err := stats.Process(ctx, machine.Status.Pid, &sample); err != nil {
+		log.G(ctx).Debugf("could not sample process statistics: %v", err)
+	}
+
+	if err := stats.Interfaces(ctx, machine, &sample); err != nil {
+		log.G(ctx).Debugf("could not sample network statistics: %v", err)
+	}
+
+	return &sample
+}
+
 // List implements kraftkit.sh/api/machine/v1alpha1.MachineService.List
 func (service *machineV1alpha1Service) List(ctx context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
 	cached := machines.Items
@@ -572,6 +801,13 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, nil
 	}
 
+	// A machine which is awaiting its restart has no process to stop.
+	if machine.Status.State == machinev1alpha1.MachineStateRestarting {
+		machine.Status.State = machinev1alpha1.MachineStateExited
+		machine.Status.ExitedAt = time.Now()
+		return machine, nil
+	}
+
 	process, err := goprocess.NewProcess(machine.Status.Pid)
 	if err != nil {
 		return machine, err
@@ -581,6 +817,12 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, err
 	}
 
+	if len(machine.Spec.Ports) > 0 {
+		if err := portforward.Remove(ctx, string(machine.UID)); err != nil {
+			return machine, fmt.Errorf("could not remove published ports: %w", err)
+		}
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateExited
 	machine.Status.ExitedAt = time.Now()
 
@@ -596,6 +838,10 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 
 	var errs merr.Errors
 
+	if len(machine.Spec.Ports) > 0 {
+		errs = append(errs, portforward.Remove(ctx, string(machine.UID)))
+	}
+
 	errs = append(errs, os.Remove(machine.Status.LogFile))
 	errs = append(errs, os.Remove(fccfg.LogPath))
 	errs = append(errs, os.RemoveAll(machine.Status.StateDir))
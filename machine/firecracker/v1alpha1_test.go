@@ -0,0 +1,342 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firecracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/machine/firecracker"
+	"kraftkit.sh/machine/network/macvtap"
+)
+
+// fakeAPI is a minimal implementation of the Firecracker API socket which
+// tracks the state of the instance across lifecycle requests.
+type fakeAPI struct {
+	mu    sync.Mutex
+	state string
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/":
+		info := map[string]string{
+			"app_name":    "Firecracker",
+			"id":          "test",
+			"vmm_version": "1.0.0",
+		}
+
+		// An empty state is omitted from the response altogether.
+		if api.state != "" {
+			info["state"] = api.state
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+
+	case r.Method == http.MethodPut && r.URL.Path == "/actions":
+		if api.state != "Not started" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		api.state = "Running"
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPatch && r.URL.Path == "/vm":
+		var body struct {
+			State string `json:"state"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch body.State {
+		case "Paused":
+			api.state = "Paused"
+		case "Resumed":
+			api.state = "Running"
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (api *fakeAPI) State() string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.state
+}
+
+// newFakeMachine serves a fakeAPI on a temporary socket and returns a machine
+// whose platform configuration points to it.
+func newFakeMachine(t *testing.T) (*fakeAPI, *machinev1alpha1.Machine) {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "firecracker.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal("Listen:", err)
+	}
+
+	api := &fakeAPI{state: "Not started"}
+	server := &http.Server{Handler: api}
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return api, &machinev1alpha1.Machine{
+		Spec: machinev1alpha1.MachineSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{},
+			},
+		},
+		Status: machinev1alpha1.MachineStatus{
+			// Use the test's own PID such that the VMM is considered alive.
+			Pid:   int32(os.Getpid()),
+			State: machinev1alpha1.MachineStateCreated,
+			PlatformConfig: firecracker.FirecrackerConfig{
+				SocketPath: socketPath,
+				Memory:     "64Mi",
+			},
+		},
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	ctx := context.Background()
+	api, machine := newFakeMachine(t)
+
+	service, err := firecracker.NewMachineV1alpha1Service(ctx)
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machine, err = service.Start(ctx, machine)
+	if err != nil {
+		t.Fatal("Start:", err)
+	}
+	if got := api.State(); got != "Running" {
+		t.Fatalf("Expected API state Running after start, got %s", got)
+	}
+
+	machine, err = service.Pause(ctx, machine)
+	if err != nil {
+		t.Fatal("Pause:", err)
+	}
+	if got := api.State(); got != "Paused" {
+		t.Fatalf("Expected API state Paused after pause, got %s", got)
+	}
+
+	machine, err = service.Get(ctx, machine)
+	if err != nil {
+		t.Fatal("Get:", err)
+	}
+	if machine.Status.State != machinev1alpha1.MachineStatePaused {
+		t.Errorf("Expected machine state %s, got %s", machinev1alpha1.MachineStatePaused, machine.Status.State)
+	}
+
+	startedAt := machine.Status.StartedAt
+
+	// Starting a paused machine must resume it rather than issue a second
+	// InstanceStart action, which Firecracker rejects.
+	machine, err = service.Start(ctx, machine)
+	if err != nil {
+		t.Fatal("Start (resume):", err)
+	}
+	if got := api.State(); got != "Running" {
+		t.Fatalf("Expected API state Running after resume, got %s", got)
+	}
+	if !machine.Status.StartedAt.Equal(startedAt) {
+		t.Errorf("Expected start time to be preserved on resume")
+	}
+
+	machine, err = service.Get(ctx, machine)
+	if err != nil {
+		t.Fatal("Get:", err)
+	}
+	if machine.Status.State != machinev1alpha1.MachineStateRunning {
+		t.Errorf("Expected machine state %s, got %s", machinev1alpha1.MachineStateRunning, machine.Status.State)
+	}
+}
+
+func TestWatchReportsPause(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, machine := newFakeMachine(t)
+
+	service, err := firecracker.NewMachineV1alpha1Service(ctx)
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machine, err = service.Start(ctx, machine)
+	if err != nil {
+		t.Fatal("Start:", err)
+	}
+
+	// Watch a copy of the machine since it is updated concurrently.
+	watched := *machine
+	watched.Spec.Resources.Requests = corev1.ResourceList{}
+
+	events, errs, err := service.Watch(ctx, &watched)
+	if err != nil {
+		t.Fatal("Watch:", err)
+	}
+
+	expect := []machinev1alpha1.MachineState{
+		machinev1alpha1.MachineStateRunning,
+		machinev1alpha1.MachineStatePaused,
+	}
+
+	for i, want := range expect {
+		select {
+		case event := <-events:
+			if event.Status.State != want {
+				t.Fatalf("Expected event %d to have state %s, got %s", i, want, event.Status.State)
+			}
+		case err := <-errs:
+			t.Fatal("Watch:", err)
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for state %s", want)
+		}
+
+		if i == 0 {
+			if _, err := service.Pause(ctx, machine); err != nil {
+				t.Fatal("Pause:", err)
+			}
+		}
+	}
+}
+
+func TestGetWithoutState(t *testing.T) {
+	ctx := context.Background()
+	api, machine := newFakeMachine(t)
+
+	api.mu.Lock()
+	api.state = ""
+	api.mu.Unlock()
+
+	service, err := firecracker.NewMachineV1alpha1Service(ctx)
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	if _, err := service.Get(ctx, machine); err == nil {
+		t.Error("Expected Get to fail when the API omits the state")
+	}
+
+	if _, err := service.Start(ctx, machine); err == nil {
+		t.Error("Expected Start to fail when the API omits the state")
+	}
+}
+
+func TestWatchEmitsCopies(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, machine := newFakeMachine(t)
+
+	service, err := firecracker.NewMachineV1alpha1Service(ctx)
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machine, err = service.Start(ctx, machine)
+	if err != nil {
+		t.Fatal("Start:", err)
+	}
+
+	watched := *machine
+	watched.Status.State = machinev1alpha1.MachineStateCreated
+
+	events, errs, err := service.Watch(ctx, &watched)
+	if err != nil {
+		t.Fatal("Watch:", err)
+	}
+
+	var received []*machinev1alpha1.Machine
+	for len(received) < 2 {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case err := <-errs:
+			t.Fatal("Watch:", err)
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for events")
+		}
+
+		if len(received) == 1 {
+			if _, err := service.Pause(ctx, machine); err != nil {
+				t.Fatal("Pause:", err)
+			}
+		}
+	}
+
+	if received[0] == received[1] || received[0] == &watched || received[1] == &watched {
+		t.Fatal("Expected each event to carry a distinct copy of the machine")
+	}
+
+	// Earlier events are not modified by subsequent refreshes.
+	if received[0].Status.State != machinev1alpha1.MachineStateRunning {
+		t.Errorf("Expected first event to retain state %s, got %s", machinev1alpha1.MachineStateRunning, received[0].Status.State)
+	}
+	if received[1].Status.State != machinev1alpha1.MachineStatePaused {
+		t.Errorf("Expected second event to have state %s, got %s", machinev1alpha1.MachineStatePaused, received[1].Status.State)
+	}
+
+	if watched.Status.State != machinev1alpha1.MachineStateCreated {
+		t.Errorf("Expected the watched machine not to be modified, got state %s", watched.Status.State)
+	}
+}
+
+func TestCreateRejectsMacvtap(t *testing.T) {
+	ctx := context.Background()
+
+	service, err := firecracker.NewMachineV1alpha1Service(ctx)
+	if err != nil {
+		t.Fatal("NewMachineV1alpha1Service:", err)
+	}
+
+	machine := &machinev1alpha1.Machine{
+		Spec: machinev1alpha1.MachineSpec{
+			Networks: []networkv1alpha1.NetworkSpec{{
+				IfName: "eth0",
+				Driver: macvtap.DriverName,
+				Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{{
+					Spec: networkv1alpha1.NetworkInterfaceSpec{IfName: "kraft0v0"},
+				}},
+			}},
+		},
+		Status: machinev1alpha1.MachineStatus{
+			KernelPath: "/dev/null",
+			StateDir:   t.TempDir(),
+		},
+	}
+
+	if _, err := service.Create(ctx, machine); err == nil || err.Error() != "macvtap networks are not supported by Firecracker" {
+		t.Errorf("Expected macvtap network to be rejected, got %v", err)
+	}
+}
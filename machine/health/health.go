@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package health determines the health of machines by periodically probing
+// them according to their health check, either by connecting to a TCP port,
+// by performing an HTTP request or by matching their console log.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// ErrUnhealthy is returned by Wait when the machine has become unhealthy.
+var ErrUnhealthy = errors.New("machine is unhealthy")
+
+// Probe performs a single probe of the provided machine according to its
+// health check and returns an error if the probe failed.
+func Probe(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	if machine.Spec.HealthCheck == nil {
+		return fmt.Errorf("machine has no health check")
+	}
+
+	check := machine.Spec.HealthCheck.WithDefaults()
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	switch check.Type {
+	case machinev1alpha1.HealthCheckTCP:
+		addr, err := Address(machine, check.Port)
+		if err != nil {
+			return err
+		}
+
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+
+	case machinev1alpha1.HealthCheckHTTP:
+		addr, err := Address(machine, check.Port)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+check.Path, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		return nil
+
+	case machinev1alpha1.HealthCheckLog:
+		pattern, err := regexp.Compile(check.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		logs, err := os.ReadFile(machine.Status.LogFile)
+		if err != nil {
+			return fmt.Errorf("could not read console log: %w", err)
+		}
+
+		if !pattern.Match(logs) {
+			return fmt.Errorf("pattern not found in console log: %s", check.Pattern)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown health check type: %s", check.Type)
+}
+
+// Address returns the address at which the provided port of the machine can
+// be reached from the host.  Published ports are reached via the host and
+// otherwise the machine's IP address on its first network is used.
+func Address(machine *machinev1alpha1.Machine, port int32) (string, error) {
+	for _, published := range machine.Spec.Ports {
+		if published.MachinePort != port || (published.Protocol != "" && published.Protocol != corev1.ProtocolTCP) {
+			continue
+		}
+
+		host := published.HostIP
+		if host == "" || net.ParseIP(host).IsUnspecified() {
+			host = "127.0.0.1"
+		}
+
+		return net.JoinHostPort(host, strconv.Itoa(int(published.HostPort))), nil
+	}
+
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			ip, _, err := net.ParseCIDR(iface.Spec.CIDR)
+			if err != nil {
+				ip = net.ParseIP(iface.Spec.CIDR)
+			}
+			if ip == nil {
+				continue
+			}
+
+			return net.JoinHostPort(ip.String(), strconv.Itoa(int(port))), nil
+		}
+	}
+
+	return "", fmt.Errorf("port %d is neither published nor is the machine attached to a network", port)
+}
+
+// Initial returns the health of the provided machine before it has been
+// probed since it was (re-)started.
+func Initial(machine *machinev1alpha1.Machine) machinev1alpha1.MachineHealth {
+	if machine.Spec.HealthCheck == nil {
+		return machinev1alpha1.MachineHealth{}
+	}
+
+	return machinev1alpha1.MachineHealth{
+		Status: machinev1alpha1.HealthStatusStarting,
+	}
+}
+
+// Next returns the health of a machine following a probe at the provided time
+// which resulted in the provided error, given its previous health.  Failed
+// probes within the start period of the health check are only counted once
+// the machine has been healthy.
+func Next(check machinev1alpha1.MachineHealthCheck, previous machinev1alpha1.MachineHealth, startedAt, now time.Time, err error) machinev1alpha1.MachineHealth {
+	check = check.WithDefaults()
+
+	next := machinev1alpha1.MachineHealth{
+		Status:    previous.Status,
+		LastProbe: now,
+	}
+
+	if next.Status == machinev1alpha1.HealthStatusNone {
+		next.Status = machinev1alpha1.HealthStatusStarting
+	}
+
+	if err == nil {
+		next.Status = machinev1alpha1.HealthStatusHealthy
+		return next
+	}
+
+	next.LastError = err.Error()
+
+	if next.Status == machinev1alpha1.HealthStatusStarting && now.Sub(startedAt) < check.StartPeriod {
+		return next
+	}
+
+	next.FailingStreak = previous.FailingStreak + 1
+	if next.FailingStreak >= check.Retries {
+		next.Status = machinev1alpha1.HealthStatusUnhealthy
+	}
+
+	return next
+}
+
+// Monitor periodically probes the provided machine according to its health
+// check until the context is cancelled or the callback returns an error,
+// which is returned.  The callback is invoked with the health of the machine
+// following each probe.
+func Monitor(ctx context.Context, machine *machinev1alpha1.Machine, fn func(machinev1alpha1.MachineHealth) error) error {
+	if machine.Spec.HealthCheck == nil {
+		return fmt.Errorf("machine has no health check")
+	}
+
+	check := machine.Spec.HealthCheck.WithDefaults()
+	health := machinev1alpha1.MachineHealth{
+		Status: machinev1alpha1.HealthStatusStarting,
+	}
+
+	startedAt := machine.Status.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		health = Next(check, health, startedAt, time.Now(), Probe(ctx, machine))
+
+		if err := fn(health); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wait probes the provided machine according to its health check until it is
+// healthy.  ErrUnhealthy is returned if the machine becomes unhealthy first.
+func Wait(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	errHealthy := errors.New("healthy")
+
+	err := Monitor(ctx, machine, func(health machinev1alpha1.MachineHealth) error {
+		switch health.Status {
+		case machinev1alpha1.HealthStatusHealthy:
+			return errHealthy
+		case machinev1alpha1.HealthStatusUnhealthy:
+			return fmt.Errorf("%w: %s", ErrUnhealthy, health.LastError)
+		}
+
+		return nil
+	})
+	if errors.Is(err, errHealthy) {
+		return nil
+	}
+
+	return err
+}
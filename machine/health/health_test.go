@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/health"
+)
+
+func TestNext(t *testing.T) {
+	check := machinev1alpha1.MachineHealthCheck{
+		Retries:     2,
+		StartPeriod: 10 * time.Second,
+	}
+	started := time.Now()
+	failure := errors.New("connection refused")
+
+	got := health.Next(check, machinev1alpha1.MachineHealth{}, started, started.Add(time.Second), failure)
+	if got.Status != machinev1alpha1.HealthStatusStarting || got.FailingStreak != 0 {
+		t.Fatalf("Expected failures within the start period to be ignored, got %+v", got)
+	}
+
+	got = health.Next(check, got, started, started.Add(11*time.Second), failure)
+	if got.Status != machinev1alpha1.HealthStatusStarting || got.FailingStreak != 1 {
+		t.Fatalf("Expected a failing streak of 1, got %+v", got)
+	}
+
+	got = health.Next(check, got, started, started.Add(12*time.Second), failure)
+	if got.Status != machinev1alpha1.HealthStatusUnhealthy {
+		t.Fatalf("Expected unhealthy after the retries are exhausted, got %+v", got)
+	}
+
+	got = health.Next(check, got, started, started.Add(13*time.Second), nil)
+	if got.Status != machinev1alpha1.HealthStatusHealthy || got.FailingStreak != 0 || got.LastError != "" {
+		t.Fatalf("Expected healthy after a successful probe, got %+v", got)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostPort, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logFile := filepath.Join(t.TempDir(), "machine.log")
+	if err := os.WriteFile(logFile, []byte("Booting...\nListening on :8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	machine := &machinev1alpha1.Machine{
+		Spec: machinev1alpha1.MachineSpec{
+			Ports: machinev1alpha1.MachinePorts{{
+				HostIP:      host,
+				HostPort:    int32(hostPort),
+				MachinePort: 8080,
+			}},
+		},
+		Status: machinev1alpha1.MachineStatus{
+			LogFile: logFile,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		check   machinev1alpha1.MachineHealthCheck
+		wantErr bool
+	}{
+		{
+			name:  "tcp",
+			check: machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckTCP, Port: 8080},
+		},
+		{
+			name:    "tcp unpublished port",
+			check:   machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckTCP, Port: 9090},
+			wantErr: true,
+		},
+		{
+			name:  "http",
+			check: machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckHTTP, Port: 8080, Path: "/healthz"},
+		},
+		{
+			name:    "http not found",
+			check:   machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckHTTP, Port: 8080, Path: "/missing"},
+			wantErr: true,
+		},
+		{
+			name:  "log",
+			check: machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckLog, Pattern: "Listening on :[0-9]+"},
+		},
+		{
+			name:    "log no match",
+			check:   machinev1alpha1.MachineHealthCheck{Type: machinev1alpha1.HealthCheckLog, Pattern: "Ready"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := tt.check
+			check.Timeout = time.Second
+			machine.Spec.HealthCheck = &check
+
+			if err := health.Probe(context.Background(), machine); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -96,3 +96,36 @@ search:
 
 	return ip, nil
 }
+
+// AllocateIP6 allocates a free IPv6 address within the provided subnet for
+// an interface with the provided hardware address.  Within a /64 subnet, the
+// address which the machine configures itself via SLAAC is preferred.  The
+// gateway and used addresses are skipped.
+func AllocateIP6(ctx context.Context, subnet *net.IPNet, gateway net.IP, mac net.HardwareAddr, used map[string]bool) (net.IP, error) {
+	if ip := iputils.EUI64(subnet, mac); ip != nil && !ip.Equal(gateway) && !used[ip.String()] {
+		return ip, nil
+	}
+
+	ip := subnet.IP.To16()
+
+	for {
+		ip = iputils.IncreaseIP(ip).To16()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled")
+		default:
+		}
+
+		switch {
+		case ip == nil || !subnet.Contains(ip):
+			return nil, fmt.Errorf("could not allocate IP address in %v", subnet.String())
+
+		case ip.Equal(gateway), used[ip.String()]:
+			continue
+
+		default:
+			return ip, nil
+		}
+	}
+}
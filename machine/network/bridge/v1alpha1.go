@@ -16,10 +16,15 @@ import (
 	"github.com/erikh/ping"
 	"github.com/vishvananda/netlink"
 	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network/dhcp"
+	"kraftkit.sh/machine/network/firewall"
+	"kraftkit.sh/machine/network/linkwatch"
 	"kraftkit.sh/machine/network/macaddr"
 )
 
@@ -112,11 +117,28 @@ func (service *v1alpha1Network) Create(ctx context.Context, network *networkv1al
 		return nil, fmt.Errorf("adding address %s to bridge %s failed: %v", addr.String(), network.Name, err)
 	}
 
+	if err := addIPv6(br, network); err != nil {
+		return nil, err
+	}
+
 	// Bring the bridge up.
 	if err := netlink.LinkSetUp(br); err != nil {
 		return nil, fmt.Errorf("bringing bridge %s up failed: %v", network.Name, err)
 	}
 
+	if err := setupFirewall(ctx, network); err != nil {
+		// Remove the bridge and any rules installed before the failure such that
+		// the network can be created again.
+		if err := firewall.Teardown(ctx, string(network.UID), network.Spec.IfName); err != nil {
+			log.G(ctx).Debugf("could not remove firewall rules of %s: %v", network.Name, err)
+		}
+		if err := netlink.LinkDel(br); err != nil {
+			log.G(ctx).Debugf("could not delete %s link: %v", network.Name, err)
+		}
+
+		return nil, err
+	}
+
 	network.CreationTimestamp = metav1.Now()
 
 	link, err := netlink.LinkByName(network.Spec.IfName)
@@ -224,6 +246,15 @@ func (service *v1alpha1Network) Start(ctx context.Context, network *networkv1alp
 		return network, fmt.Errorf("could not bring %s link up: %v", network.Name, err)
 	}
 
+	if err := addIPv6(link, network); err != nil {
+		return network, err
+	}
+
+	// The rules do not survive a restart of the host, so (re)install them.
+	if err := setupFirewall(ctx, network); err != nil {
+		return network, err
+	}
+
 	network.Status.State = networkv1alpha1.NetworkStateUp
 
 	return network, nil
@@ -299,6 +330,25 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 		return network, fmt.Errorf("could not prepare MAC address generator: %v", err)
 	}
 
+	// Keep track of the IPv6 addresses of the interfaces, which, unlike IPv4
+	// addresses, cannot be probed for.
+	var gateway6 net.IP
+	var ipnet6 *net.IPNet
+	inuse6 := make(map[string]bool)
+
+	if network.Spec.IPv6 != "" {
+		gateway6, ipnet6, err = net.ParseCIDR(network.Spec.IPv6)
+		if err != nil {
+			return network, fmt.Errorf("could not parse IPv6 subnet: %v", err)
+		}
+
+		for _, iface := range network.Spec.Interfaces {
+			if ip, _, err := net.ParseCIDR(iface.Spec.CIDR6); err == nil {
+				inuse6[ip.String()] = true
+			}
+		}
+	}
+
 	// Populate a hashmap of link aliases that allow us to quickly reference later
 	// on when we're clearing up unused interfaces.
 	inuse := make(map[string]bool)
@@ -365,6 +415,23 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 			iface.Spec.CIDR = fmt.Sprintf("%s/%d", ip.String(), sz)
 		}
 
+		if ipnet6 != nil && iface.Spec.CIDR6 == "" {
+			hwaddr, err := net.ParseMAC(iface.Spec.MacAddress)
+			if err != nil {
+				return network, fmt.Errorf("could not parse MAC address of %s: %v", iface.Spec.IfName, err)
+			}
+
+			ip, err := AllocateIP6(ctx, ipnet6, gateway6, hwaddr, inuse6)
+			if err != nil {
+				return network, fmt.Errorf("could not allocate interface IPv6 for %s: %v", iface.Spec.IfName, err)
+			}
+
+			inuse6[ip.String()] = true
+
+			sz, _ := ipnet6.Mask.Size()
+			iface.Spec.CIDR6 = fmt.Sprintf("%s/%d", ip.String(), sz)
+		}
+
 		tap := &netlink.Tuntap{
 			LinkAttrs: netlink.NewLinkAttrs(),
 			Mode:      netlink.TUNTAP_MODE_TAP,
@@ -438,11 +505,18 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 		}
 	}
 
+	// Release the DHCP leases of removed interfaces.
+	dhcp.PruneLeases(network)
+
 	return network, nil
 }
 
 // Delete implements kraftkit.sh/api/network/v1alpha1.Delete
 func (service *v1alpha1Network) Delete(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	if network.Spec.Driver != "" && network.Spec.Driver != "bridge" {
+		return network, fmt.Errorf("network %s is not a bridge network", network.Name)
+	}
+
 	// Remove any interfaces.
 	for _, iface := range network.Spec.Interfaces {
 		// Get the link.
@@ -487,9 +561,88 @@ func (service *v1alpha1Network) Delete(ctx context.Context, network *networkv1al
 		return network, fmt.Errorf("could not delete %s link: %v", network.Name, err)
 	}
 
+	if err := firewall.Teardown(ctx, string(network.UID), network.Spec.IfName); err != nil {
+		return network, fmt.Errorf("could not remove firewall rules of %s: %v", network.Name, err)
+	}
+
 	return nil, nil
 }
 
+// addIPv6 assigns the IPv6 gateway address of the provided network, if any, to
+// the provided bridge.  Unlike IPv4 addresses, the kernel removes IPv6
+// addresses whenever the bridge is brought down, so they must be re-added
+// whenever it is brought up again.
+func addIPv6(link netlink.Link, network *networkv1alpha1.Network) error {
+	if network.Spec.IPv6 == "" {
+		return nil
+	}
+
+	ip, subnet, err := net.ParseCIDR(network.Spec.IPv6)
+	if err != nil || ip.To4() != nil {
+		return fmt.Errorf("invalid IPv6 subnet: %s", network.Spec.IPv6)
+	}
+
+	addr := &netlink.Addr{
+		IPNet: &net.IPNet{
+			IP:   ip,
+			Mask: subnet.Mask,
+		},
+		// Skip duplicate address detection, as the bridge is the only host on
+		// the network at this point and the address is usable immediately.
+		Flags: unix.IFA_F_NODAD,
+	}
+
+	if err := netlink.AddrReplace(link, addr); err != nil {
+		return fmt.Errorf("adding address %s to bridge %s failed: %v", addr.String(), network.Name, err)
+	}
+
+	return nil
+}
+
+// globalIPv6 returns the IPv6 gateway address of the provided bridge in CIDR
+// notation, or an empty string if it has none.
+func globalIPv6(bridge *netlink.Bridge) (string, error) {
+	addrs, err := netlink.AddrList(bridge, nl.FAMILY_V6)
+	if err != nil {
+		return "", err
+	}
+
+	for _, addr := range addrs {
+		// Skip the link-local address every interface has.
+		if addr.IP.IsGlobalUnicast() {
+			return addr.IPNet.String(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// setupFirewall isolates the provided network from all other networks and,
+// unless disabled, masquerades its traffic to the outside world.
+func setupFirewall(ctx context.Context, network *networkv1alpha1.Network) error {
+	subnet, err := firewall.Subnet(network.Spec.Gateway, network.Spec.Netmask)
+	if err != nil {
+		return fmt.Errorf("could not determine subnet of %s: %v", network.Name, err)
+	}
+
+	subnets := []*net.IPNet{subnet}
+
+	if network.Spec.IPv6 != "" {
+		_, subnet6, err := net.ParseCIDR(network.Spec.IPv6)
+		if err != nil {
+			return fmt.Errorf("could not parse IPv6 subnet of %s: %v", network.Name, err)
+		}
+
+		subnets = append(subnets, subnet6)
+	}
+
+	if err := firewall.Setup(ctx, string(network.UID), network.Spec.IfName, subnets, !network.Spec.DisableNAT); err != nil {
+		return fmt.Errorf("could not configure firewall of %s: %v", network.Name, err)
+	}
+
+	return nil
+}
+
 // mapBridgeStatistics embeds the provided bridge's statistics to the provided
 // network's status statistics, these are a 1-to-1 match.
 func mapBridgeStatistics(network *networkv1alpha1.Network, bridge *netlink.Bridge) {
@@ -517,6 +670,32 @@ func mapBridgeStatistics(network *networkv1alpha1.Network, bridge *netlink.Bridg
 	network.Status.TxWindowErrors = bridge.Statistics.TxWindowErrors
 }
 
+// mapBridgePorts sets the carrier of the provided network and the state of
+// each of the interfaces, e.g. taps of machines, attached to its bridge.
+func mapBridgePorts(network *networkv1alpha1.Network, bridge *netlink.Bridge) error {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return fmt.Errorf("could not gather list of existing links: %v", err)
+	}
+
+	network.Status.Carrier = bridge.OperState == netlink.OperUp
+	network.Status.Interfaces = map[string]networkv1alpha1.NetworkInterfaceState{}
+
+	for _, link := range links {
+		if link.Attrs().MasterIndex != bridge.Index {
+			continue
+		}
+
+		if link.Attrs().OperState == netlink.OperUp {
+			network.Status.Interfaces[link.Attrs().Name] = networkv1alpha1.NetworkInterfaceStateConnected
+		} else {
+			network.Status.Interfaces[link.Attrs().Name] = networkv1alpha1.NetworkInterfaceStateDisconnected
+		}
+	}
+
+	return nil
+}
+
 // Get implements kraftkit.sh/api/network/v1alpha1.Get
 func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
 	if network.UID == "" {
@@ -550,6 +729,15 @@ func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha
 	network.Spec.Gateway = addrs[0].IP.String()
 	network.Spec.Netmask = net.IP(addrs[0].Mask).String()
 
+	addr6, err := globalIPv6(bridge)
+	if err != nil {
+		return network, err
+	}
+
+	if addr6 != "" {
+		network.Spec.IPv6 = addr6
+	}
+
 	// Use the internal network bridge networking system to determine
 	// whether the identified network is online.
 	if net.FlagUp&bridge.Flags == 1 || net.FlagRunning&bridge.Flags == 1 {
@@ -563,6 +751,10 @@ func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha
 
 	mapBridgeStatistics(network, bridge)
 
+	if err := mapBridgePorts(network, bridge); err != nil {
+		return network, err
+	}
+
 	return network, nil
 }
 
@@ -570,6 +762,15 @@ func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha
 func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alpha1.NetworkList) (*networkv1alpha1.NetworkList, error) {
 	knownBridges := make(map[string]bool, len(networks.Items))
 
+	// Networks of other drivers share the same store, skip them.
+	known := networks.Items[:0]
+	for _, network := range networks.Items {
+		if network.Spec.Driver == "" || network.Spec.Driver == "bridge" {
+			known = append(known, network)
+		}
+	}
+	networks.Items = known
+
 	// Update existing known networks
 	for i, network := range networks.Items {
 		network, err := service.Get(ctx, &network)
@@ -632,6 +833,10 @@ func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alp
 			Netmask: net.IP(addrs[0].Mask).String(),
 		}
 
+		if addr6, err := globalIPv6(bridge); err == nil {
+			network.Spec.IPv6 = addr6
+		}
+
 		// Use the internal network bridge networking system to determine
 		// whether the identified network is online.
 		if net.FlagUp&bridge.Flags == 1 || net.FlagRunning&bridge.Flags == 1 {
@@ -645,6 +850,10 @@ func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alp
 
 		mapBridgeStatistics(&network, bridge)
 
+		if err := mapBridgePorts(&network, bridge); err != nil {
+			continue // TODO(nderjung): error groups
+		}
+
 		networks.Items = append(networks.Items, network)
 	}
 
@@ -685,6 +894,46 @@ func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alp
 }
 
 // Watch implements kraftkit.sh/api/network/v1alpha1.Watch
-func (service *v1alpha1Network) Watch(context.Context, *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
-	panic("not implemented: kraftkit.sh/machine/network/bridge.v1alpha1Network.Watch")
+func (service *v1alpha1Network) Watch(ctx context.Context, network *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
+	if network.Spec.Driver != "" && network.Spec.Driver != "bridge" {
+		return nil, nil, fmt.Errorf("network %s is not a bridge network", network.Name)
+	}
+
+	link, err := netlink.LinkByName(network.Spec.IfName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get link %s: %v", network.Spec.IfName, err)
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not gather list of existing links: %v", err)
+	}
+
+	// Track the ports of the bridge, as a port which is detached from it is no
+	// longer recognizable by its master.
+	index := link.Attrs().Index
+	ports := map[int]bool{}
+	for _, link := range links {
+		if link.Attrs().MasterIndex == index {
+			ports[link.Attrs().Index] = true
+		}
+	}
+
+	return linkwatch.Watch(ctx, network, service.Get, func(update linkwatch.Update) bool {
+		if update.Index == index {
+			return true
+		}
+
+		if update.MasterIndex == index && !update.Deleted {
+			ports[update.Index] = true
+			return true
+		}
+
+		if ports[update.Index] {
+			delete(ports, update.Index)
+			return true
+		}
+
+		return false
+	})
 }
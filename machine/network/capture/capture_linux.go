@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// Interface captures the packets on the interface with the provided name which
+// match the provided filter, if any, and passes each of them to fn until the
+// context is cancelled or fn returns an error.  At most snaplen bytes are
+// captured of each packet.
+func Interface(ctx context.Context, ifname string, filter []bpf.Instruction, snaplen int, fn func(CaptureInfo, []byte) error) error {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return fmt.Errorf("could not get interface %s: %w", ifname, err)
+	}
+
+	// The socket does not receive any packets until it is bound, such that the
+	// filter applies to all of them.
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("could not open packet socket: %w", err)
+	}
+
+	defer unix.Close(fd)
+
+	if len(filter) > 0 {
+		raw, err := bpf.Assemble(filter)
+		if err != nil {
+			return fmt.Errorf("could not assemble filter: %w", err)
+		}
+
+		prog := make([]unix.SockFilter, len(raw))
+		for i, ins := range raw {
+			prog[i] = unix.SockFilter{Code: ins.Op, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+		}
+
+		if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &unix.SockFprog{
+			Len:    uint16(len(prog)),
+			Filter: &prog[0],
+		}); err != nil {
+			return fmt.Errorf("could not attach filter: %w", err)
+		}
+	}
+
+	// Wake up regularly to check whether the context has been cancelled.
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Usec: 200000}); err != nil {
+		return fmt.Errorf("could not set receive timeout: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_ALL),
+		Ifindex:  iface.Index,
+	}); err != nil {
+		return fmt.Errorf("could not bind to interface %s: %w", ifname, err)
+	}
+
+	buf := make([]byte, snaplen)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		// Receive the original length of truncated packets.
+		n, _, err := unix.Recvfrom(fd, buf, unix.MSG_TRUNC)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not receive packet: %w", err)
+		}
+
+		ci := CaptureInfo{
+			Timestamp:     time.Now(),
+			CaptureLength: min(n, snaplen),
+			Length:        n,
+		}
+
+		if err := fn(ci, buf[:ci.CaptureLength]); err != nil {
+			return err
+		}
+	}
+}
+
+// htons converts the provided value to network byte order.
+func htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return binary.NativeEndian.Uint16(b)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/net/bpf"
+)
+
+// Interface captures the packets on the interface with the provided name.  It
+// is only supported on Linux.
+func Interface(ctx context.Context, ifname string, filter []bpf.Instruction, snaplen int, fn func(CaptureInfo, []byte) error) error {
+	return fmt.Errorf("capturing packets on interfaces is only supported on Linux")
+}
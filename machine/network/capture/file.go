@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/net/bpf"
+)
+
+// File follows the pcap file at the provided path, which is written to by
+// another process, e.g. by the filter-dump object of QEMU, and passes each of
+// its packets which match the provided filter, if any, to fn until the context
+// is cancelled or fn returns an error.
+func File(ctx context.Context, path string, filter []bpf.Instruction, fn func(CaptureInfo, []byte) error) error {
+	var vm *bpf.VM
+	if len(filter) > 0 {
+		var err error
+		vm, err = bpf.NewVM(filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	pr, err := NewPcapReader(&follower{ctx: ctx, r: f})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	for {
+		ci, data, err := pr.ReadPacket()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if vm != nil {
+			n, err := vm.Run(data)
+			if err != nil {
+				return fmt.Errorf("could not run filter: %w", err)
+			} else if n == 0 {
+				continue
+			}
+
+			data = data[:min(n, len(data))]
+			ci.CaptureLength = len(data)
+		}
+
+		if err := fn(ci, data); err != nil {
+			return err
+		}
+	}
+}
+
+// follower reads from a file which is still being written to, waiting for
+// more data at its end until the context is cancelled.
+type follower struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read implements io.Reader.
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
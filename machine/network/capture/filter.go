@@ -0,0 +1,558 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/bpf"
+)
+
+// Offsets within Ethernet frames carrying IPv4 and IPv6 packets without VLAN
+// tags or IPv6 extension headers.
+const (
+	offEtherType = 12
+	offIPv4Proto = 23
+	offIPv4Frag  = 20
+	offIPv4Src   = 26
+	offIPv4Dst   = 30
+	offIPv6Next  = 20
+	offIPv6Src   = 22
+	offIPv6Dst   = 38
+	offIPv6Ports = 54
+	offIPHeader  = 14
+
+	etherTypeIPv4 = 0x0800
+	etherTypeARP  = 0x0806
+	etherTypeIPv6 = 0x86dd
+
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+)
+
+// filterNode is a node of the syntax tree of a filter expression.
+type filterNode interface{}
+
+type andNode struct{ left, right filterNode }
+
+type orNode struct{ left, right filterNode }
+
+type notNode struct{ node filterNode }
+
+// testNode compares the value of the accumulator after running the loads
+// against a constant.
+type testNode struct {
+	loads []bpf.Instruction
+	cond  bpf.JumpTest
+	val   uint32
+}
+
+// CompileFilter compiles the provided filter expression to a classic BPF
+// program which accepts up to snaplen bytes of matching Ethernet frames.  An
+// empty expression results in a nil program.
+//
+// The expression is a subset of the pcap-filter(7) syntax, consisting of the
+// primitives
+//
+//	ip, ip6, arp, tcp, udp, icmp, icmp6
+//	[ip|ip6] [src|dst] host ADDR
+//	[ip|ip6] [src|dst] net CIDR
+//	[tcp|udp] [src|dst] port PORT
+//	ether [src|dst] host MAC
+//
+// which can be combined with and (&&), or (||), not (!) and parentheses.
+func CompileFilter(expr string, snaplen int) ([]bpf.Instruction, error) {
+	tokens := tokenize(expr)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &filterParser{tokens: tokens}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in filter expression", p.tokens[p.pos])
+	}
+
+	return generate(node, snaplen)
+}
+
+// tokenize splits the provided filter expression into its tokens.
+func tokenize(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+
+		switch {
+		case unicode.IsSpace(rune(c)):
+			flush()
+		case c == '(' || c == ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case (c == '&' || c == '|') && i+1 < len(expr) && expr[i+1] == c:
+			flush()
+			tokens = append(tokens, expr[i:i+2])
+			i++
+		case c == '!' && word.Len() == 0:
+			tokens = append(tokens, "!")
+		default:
+			word.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return tokens
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the current token or an empty string at the end of the
+// expression.
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+// accept advances past the current token if it is any of the provided ones.
+func (p *filterParser) accept(tokens ...string) bool {
+	for _, token := range tokens {
+		if p.peek() == token {
+			p.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("or", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("and", "&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterNode, error) {
+	if p.accept("not", "!") {
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{node}, nil
+	}
+
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')' in filter expression")
+		}
+
+		return node, nil
+	}
+
+	return p.parsePrimitive()
+}
+
+func (p *filterParser) parsePrimitive() (filterNode, error) {
+	var proto, dir, kind string
+
+	if p.accept("ether", "ip", "ip6", "arp", "tcp", "udp", "icmp", "icmp6") {
+		proto = p.tokens[p.pos-1]
+	}
+	if p.accept("src", "dst") {
+		dir = p.tokens[p.pos-1]
+	}
+	if p.accept("host", "net", "port") {
+		kind = p.tokens[p.pos-1]
+	}
+
+	if kind == "" {
+		switch {
+		case dir != "":
+			return nil, fmt.Errorf("expected host, net or port after '%s'", dir)
+		case proto == "" && p.peek() == "":
+			return nil, fmt.Errorf("unexpected end of filter expression")
+		case proto == "":
+			return nil, fmt.Errorf("unknown primitive '%s' in filter expression", p.peek())
+		}
+
+		return protoNode(proto)
+	}
+
+	value := p.peek()
+	if value == "" || value == ")" {
+		return nil, fmt.Errorf("expected value after '%s'", kind)
+	}
+
+	p.pos++
+
+	switch kind {
+	case "host":
+		if proto == "ether" {
+			return etherHostNode(dir, value)
+		}
+
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid host address: %s", value)
+		}
+
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+
+		return netNode(proto, dir, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+	case "net":
+		_, subnet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network: %s", value)
+		}
+
+		return netNode(proto, dir, subnet)
+
+	default:
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", value)
+		}
+
+		return portNode(proto, dir, uint32(port))
+	}
+}
+
+// and returns a node which matches if all of the provided nodes match.
+func and(nodes ...filterNode) filterNode {
+	node := nodes[0]
+	for _, right := range nodes[1:] {
+		node = andNode{node, right}
+	}
+
+	return node
+}
+
+// or returns a node which matches if any of the provided nodes match.
+func or(nodes ...filterNode) filterNode {
+	node := nodes[0]
+	for _, right := range nodes[1:] {
+		node = orNode{node, right}
+	}
+
+	return node
+}
+
+// load returns a test of the value of the provided size at the provided
+// offset.
+func load(off, size uint32, val uint32) testNode {
+	return testNode{
+		loads: []bpf.Instruction{bpf.LoadAbsolute{Off: off, Size: int(size)}},
+		cond:  bpf.JumpEqual,
+		val:   val,
+	}
+}
+
+func etherType(typ uint32) filterNode {
+	return load(offEtherType, 2, typ)
+}
+
+func ipv4Proto(proto uint32) filterNode {
+	return and(etherType(etherTypeIPv4), load(offIPv4Proto, 1, proto))
+}
+
+func ipv6Next(next uint32) filterNode {
+	return and(etherType(etherTypeIPv6), load(offIPv6Next, 1, next))
+}
+
+func protoNode(proto string) (filterNode, error) {
+	switch proto {
+	case "ip":
+		return etherType(etherTypeIPv4), nil
+	case "ip6":
+		return etherType(etherTypeIPv6), nil
+	case "arp":
+		return etherType(etherTypeARP), nil
+	case "tcp":
+		return or(ipv4Proto(protoTCP), ipv6Next(protoTCP)), nil
+	case "udp":
+		return or(ipv4Proto(protoUDP), ipv6Next(protoUDP)), nil
+	case "icmp":
+		return ipv4Proto(protoICMP), nil
+	case "icmp6":
+		return ipv6Next(protoICMPv6), nil
+	default:
+		return nil, fmt.Errorf("'%s' requires a host qualifier", proto)
+	}
+}
+
+// directions returns the nodes matching the source and/or destination as
+// selected by dir from the provided nodes.
+func directions(dir string, src, dst filterNode) filterNode {
+	switch dir {
+	case "src":
+		return src
+	case "dst":
+		return dst
+	default:
+		return or(src, dst)
+	}
+}
+
+func etherHostNode(dir, value string) (filterNode, error) {
+	mac, err := net.ParseMAC(value)
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", value)
+	}
+
+	hi := binary.BigEndian.Uint32(mac[0:4])
+	lo := uint32(binary.BigEndian.Uint16(mac[4:6]))
+
+	return directions(dir,
+		and(load(6, 4, hi), load(10, 2, lo)),
+		and(load(0, 4, hi), load(4, 2, lo)),
+	), nil
+}
+
+// netNode returns a node matching IP packets from and/or to the provided
+// subnet.
+func netNode(proto, dir string, subnet *net.IPNet) (filterNode, error) {
+	ones, _ := subnet.Mask.Size()
+
+	if ip4 := subnet.IP.To4(); ip4 != nil {
+		if proto != "" && proto != "ip" {
+			return nil, fmt.Errorf("'%s' cannot be combined with IPv4 address %s", proto, subnet.IP)
+		}
+
+		return and(etherType(etherTypeIPv4), directions(dir,
+			maskedNode(offIPv4Src, ip4, net.CIDRMask(ones, 32)),
+			maskedNode(offIPv4Dst, ip4, net.CIDRMask(ones, 32)),
+		)), nil
+	}
+
+	if proto != "" && proto != "ip6" {
+		return nil, fmt.Errorf("'%s' cannot be combined with IPv6 address %s", proto, subnet.IP)
+	}
+
+	return and(etherType(etherTypeIPv6), directions(dir,
+		maskedNode(offIPv6Src, subnet.IP.To16(), net.CIDRMask(ones, 128)),
+		maskedNode(offIPv6Dst, subnet.IP.To16(), net.CIDRMask(ones, 128)),
+	)), nil
+}
+
+// maskedNode returns a node matching the address at the provided offset
+// against the provided address under the provided mask, word by word.
+func maskedNode(off uint32, ip net.IP, mask net.IPMask) filterNode {
+	var nodes []filterNode
+
+	for i := 0; i < len(ip); i += 4 {
+		m := binary.BigEndian.Uint32(mask[i : i+4])
+		if m == 0 {
+			break
+		}
+
+		node := load(off+uint32(i), 4, binary.BigEndian.Uint32(ip[i:i+4])&m)
+		if m != 0xffffffff {
+			node.loads = append(node.loads, bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: m})
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		// A zero-length prefix matches any address.
+		return testNode{
+			loads: []bpf.Instruction{bpf.LoadConstant{Dst: bpf.RegA, Val: 0}},
+			cond:  bpf.JumpEqual,
+			val:   0,
+		}
+	}
+
+	return and(nodes...)
+}
+
+// portNode returns a node matching TCP and/or UDP packets from and/or to the
+// provided port.
+func portNode(proto, dir string, port uint32) (filterNode, error) {
+	var protos []uint32
+
+	switch proto {
+	case "":
+		protos = []uint32{protoTCP, protoUDP}
+	case "tcp":
+		protos = []uint32{protoTCP}
+	case "udp":
+		protos = []uint32{protoUDP}
+	default:
+		return nil, fmt.Errorf("'%s' cannot be combined with port", proto)
+	}
+
+	var v4, v6 []filterNode
+	for _, p := range protos {
+		v4 = append(v4, load(offIPv4Proto, 1, p))
+		v6 = append(v6, load(offIPv6Next, 1, p))
+	}
+
+	// The ports of IPv4 packets follow the variable-length IPv4 header and are
+	// only present in the first fragment.
+	ipv4Port := func(off uint32) filterNode {
+		return testNode{
+			loads: []bpf.Instruction{
+				bpf.LoadMemShift{Off: offIPHeader},
+				bpf.LoadIndirect{Off: offIPHeader + off, Size: 2},
+			},
+			cond: bpf.JumpEqual,
+			val:  port,
+		}
+	}
+
+	notFragment := testNode{
+		loads: []bpf.Instruction{bpf.LoadAbsolute{Off: offIPv4Frag, Size: 2}},
+		cond:  bpf.JumpBitsNotSet,
+		val:   0x1fff,
+	}
+
+	return or(
+		and(etherType(etherTypeIPv4), or(v4...), notFragment, directions(dir, ipv4Port(0), ipv4Port(2))),
+		and(etherType(etherTypeIPv6), or(v6...), directions(dir,
+			load(offIPv6Ports, 2, port),
+			load(offIPv6Ports+2, 2, port),
+		)),
+	), nil
+}
+
+// program assembles a BPF program whose jumps refer to labels.
+type program struct {
+	insns  []bpf.Instruction
+	jumps  map[int][2]int
+	labels []int
+}
+
+func (p *program) label() int {
+	p.labels = append(p.labels, -1)
+	return len(p.labels) - 1
+}
+
+func (p *program) place(label int) {
+	p.labels[label] = len(p.insns)
+}
+
+// gen emits the instructions of the provided node, which continue at the
+// label t if the node matches and at the label f otherwise.
+func (p *program) gen(node filterNode, t, f int) {
+	switch n := node.(type) {
+	case andNode:
+		next := p.label()
+		p.gen(n.left, next, f)
+		p.place(next)
+		p.gen(n.right, t, f)
+
+	case orNode:
+		next := p.label()
+		p.gen(n.left, t, next)
+		p.place(next)
+		p.gen(n.right, t, f)
+
+	case notNode:
+		p.gen(n.node, f, t)
+
+	case testNode:
+		p.insns = append(p.insns, n.loads...)
+		p.jumps[len(p.insns)] = [2]int{t, f}
+		p.insns = append(p.insns, bpf.JumpIf{Cond: n.cond, Val: n.val})
+	}
+}
+
+// generate returns the BPF program of the provided syntax tree.
+func generate(node filterNode, snaplen int) ([]bpf.Instruction, error) {
+	p := &program{jumps: map[int][2]int{}}
+
+	accept := p.label()
+	reject := p.label()
+
+	p.gen(node, accept, reject)
+
+	p.place(accept)
+	p.insns = append(p.insns, bpf.RetConstant{Val: uint32(snaplen)})
+	p.place(reject)
+	p.insns = append(p.insns, bpf.RetConstant{Val: 0})
+
+	// Resolve the labels, which always follow the jumps referring to them.
+	for i, targets := range p.jumps {
+		jump := p.insns[i].(bpf.JumpIf)
+
+		skipTrue := p.labels[targets[0]] - i - 1
+		skipFalse := p.labels[targets[1]] - i - 1
+		if skipTrue > 255 || skipFalse > 255 {
+			return nil, fmt.Errorf("filter expression is too complex")
+		}
+
+		jump.SkipTrue = uint8(skipTrue)
+		jump.SkipFalse = uint8(skipFalse)
+		p.insns[i] = jump
+	}
+
+	return p.insns, nil
+}
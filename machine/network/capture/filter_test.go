@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"golang.org/x/net/bpf"
+)
+
+// testFrame returns an Ethernet frame of a TCP or UDP packet between the
+// provided addresses and ports.
+func testFrame(proto byte, src, dst string, sport, dport uint16) []byte {
+	srcIP, dstIP := net.ParseIP(src), net.ParseIP(dst)
+
+	frame := []byte{
+		0x02, 0, 0, 0, 0, 0x02, // Destination MAC
+		0x02, 0, 0, 0, 0, 0x01, // Source MAC
+	}
+
+	if srcIP.To4() != nil {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeIPv4)
+		ip := make([]byte, 24) // Includes 4 bytes of options.
+		ip[0] = 0x46
+		ip[9] = proto
+		copy(ip[12:16], srcIP.To4())
+		copy(ip[16:20], dstIP.To4())
+		frame = append(frame, ip...)
+	} else {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeIPv6)
+		ip := make([]byte, 40)
+		ip[0] = 0x60
+		ip[6] = proto
+		copy(ip[8:24], srcIP)
+		copy(ip[24:40], dstIP)
+		frame = append(frame, ip...)
+	}
+
+	frame = binary.BigEndian.AppendUint16(frame, sport)
+	frame = binary.BigEndian.AppendUint16(frame, dport)
+
+	return append(frame, make([]byte, 16)...)
+}
+
+func TestCompileFilter(t *testing.T) {
+	tcp4 := testFrame(protoTCP, "10.0.0.2", "10.0.0.3", 40000, 80)
+	udp4 := testFrame(protoUDP, "10.0.0.3", "192.168.1.1", 53, 40000)
+	tcp6 := testFrame(protoTCP, "fd00::2", "fd00:1::3", 40000, 443)
+
+	for _, tc := range []struct {
+		expr string
+		want [3]bool // tcp4, udp4, tcp6
+	}{
+		{"tcp", [3]bool{true, false, true}},
+		{"udp", [3]bool{false, true, false}},
+		{"ip6", [3]bool{false, false, true}},
+		{"host 10.0.0.3", [3]bool{true, true, false}},
+		{"src host 10.0.0.3", [3]bool{false, true, false}},
+		{"ip6 dst host fd00:1::3", [3]bool{false, false, true}},
+		{"net 192.168.0.0/16", [3]bool{false, true, false}},
+		{"net fd00::/32", [3]bool{false, false, true}},
+		{"net fd00::/64", [3]bool{false, false, true}},
+		{"dst net fd00::/64", [3]bool{false, false, false}},
+		{"port 80", [3]bool{true, false, false}},
+		{"tcp dst port 443", [3]bool{false, false, true}},
+		{"udp port 40000", [3]bool{false, true, false}},
+		{"port 40000 and not udp", [3]bool{true, false, true}},
+		{"!(port 53 || port 80)", [3]bool{false, false, true}},
+		{"ether src host 02:00:00:00:00:01", [3]bool{true, true, true}},
+		{"ether dst host 02:00:00:00:00:01", [3]bool{false, false, false}},
+		{"arp or icmp", [3]bool{false, false, false}},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			prog, err := CompileFilter(tc.expr, DefaultSnaplen)
+			if err != nil {
+				t.Fatal("CompileFilter:", err)
+			}
+
+			vm, err := bpf.NewVM(prog)
+			if err != nil {
+				t.Fatal("NewVM:", err)
+			}
+
+			for i, frame := range [][]byte{tcp4, udp4, tcp6} {
+				n, err := vm.Run(frame)
+				if err != nil {
+					t.Fatal("Run:", err)
+				}
+
+				if (n > 0) != tc.want[i] {
+					t.Errorf("Expected match of frame %d to be %t", i, tc.want[i])
+				}
+			}
+		})
+	}
+
+	if prog, err := CompileFilter("  ", DefaultSnaplen); err != nil || prog != nil {
+		t.Errorf("Expected no program for empty expression, got %v, %v", prog, err)
+	}
+
+	for _, expr := range []string{
+		"foo",
+		"host",
+		"src tcp",
+		"ip6 host 10.0.0.1",
+		"icmp port 80",
+		"port 70000",
+		"(tcp",
+		"tcp udp",
+		"ether",
+	} {
+		if _, err := CompileFilter(expr, DefaultSnaplen); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
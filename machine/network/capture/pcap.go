@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package capture captures the traffic of machine networks and writes it in
+// the pcap or pcapng file formats, such that it can be inspected with tools
+// like Wireshark or tcpdump.
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// DefaultSnaplen is the default maximum number of bytes which are captured
+	// of each packet, which is sufficient for any packet.
+	DefaultSnaplen = 262144
+
+	// LinkTypeEthernet is the link-layer header type of Ethernet frames.
+	LinkTypeEthernet = 1
+)
+
+const (
+	pcapMagicMicroseconds = 0xa1b2c3d4
+	pcapMagicNanoseconds  = 0xa1b23c4d
+
+	pcapngBlockSectionHeader   = 0x0a0d0d0a
+	pcapngBlockInterface       = 0x00000001
+	pcapngBlockEnhancedPacket  = 0x00000006
+	pcapngByteOrderMagic       = 0x1a2b3c4d
+	pcapngOptionEndOfOptions   = 0
+	pcapngOptionInterfaceName  = 2
+	pcapngOptionTimeResolution = 9
+)
+
+// CaptureInfo describes a captured packet.
+type CaptureInfo struct {
+	// Timestamp is the time at which the packet has been captured.
+	Timestamp time.Time
+
+	// CaptureLength is the number of bytes of the packet which have been
+	// captured.
+	CaptureLength int
+
+	// Length is the original length of the packet, which exceeds the capture
+	// length if the packet has been truncated.
+	Length int
+}
+
+// Writer writes captured packets to a file.
+type Writer interface {
+	// WritePacket writes the provided packet.
+	WritePacket(ci CaptureInfo, data []byte) error
+}
+
+// Format is a file format of captured packets.
+type Format string
+
+const (
+	FormatPcap   = Format("pcap")
+	FormatPcapng = Format("pcapng")
+)
+
+// NewWriter returns a writer of the provided format which writes packets
+// captured on the interface with the provided name to w.
+func NewWriter(w io.Writer, format Format, snaplen int, ifname string) (Writer, error) {
+	switch format {
+	case FormatPcap:
+		return NewPcapWriter(w, snaplen)
+	case FormatPcapng:
+		return NewPcapngWriter(w, snaplen, ifname)
+	default:
+		return nil, fmt.Errorf("unsupported capture format: %s", format)
+	}
+}
+
+type pcapWriter struct {
+	w       io.Writer
+	snaplen int
+}
+
+// NewPcapWriter writes the header of a pcap file with the provided snapshot
+// length to w and returns a writer of its packets.
+func NewPcapWriter(w io.Writer, snaplen int) (Writer, error) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:4], pcapMagicMicroseconds)
+	binary.LittleEndian.PutUint16(hdr[4:6], 2) // Major version
+	binary.LittleEndian.PutUint16(hdr[6:8], 4) // Minor version
+	binary.LittleEndian.PutUint32(hdr[16:20], uint32(snaplen))
+	binary.LittleEndian.PutUint32(hdr[20:24], LinkTypeEthernet)
+
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+
+	return &pcapWriter{w: w, snaplen: snaplen}, nil
+}
+
+// WritePacket implements Writer.
+func (pw *pcapWriter) WritePacket(ci CaptureInfo, data []byte) error {
+	if ci.CaptureLength != len(data) {
+		return fmt.Errorf("capture length %d does not match packet of %d bytes", ci.CaptureLength, len(data))
+	}
+
+	hdr := make([]byte, 16)
+	binary.LittleEndian.PutUint32(hdr[0:4], uint32(ci.Timestamp.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(ci.Timestamp.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:12], uint32(ci.CaptureLength))
+	binary.LittleEndian.PutUint32(hdr[12:16], uint32(ci.Length))
+
+	if _, err := pw.w.Write(hdr); err != nil {
+		return err
+	}
+
+	_, err := pw.w.Write(data)
+	return err
+}
+
+type pcapngWriter struct {
+	w io.Writer
+}
+
+// NewPcapngWriter writes the section header and the description of the
+// interface with the provided name and snapshot length of a pcapng file to w
+// and returns a writer of its packets.
+func NewPcapngWriter(w io.Writer, snaplen int, ifname string) (Writer, error) {
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:4], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:6], 1) // Major version
+	binary.LittleEndian.PutUint16(shb[6:8], 0) // Minor version
+	// The length of the section is unspecified.
+	binary.LittleEndian.PutUint64(shb[8:16], 0xffffffffffffffff)
+
+	if err := writePcapngBlock(w, pcapngBlockSectionHeader, shb); err != nil {
+		return nil, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:2], LinkTypeEthernet)
+	binary.LittleEndian.PutUint32(idb[4:8], uint32(snaplen))
+
+	if ifname != "" {
+		idb = appendPcapngOption(idb, pcapngOptionInterfaceName, []byte(ifname))
+	}
+
+	// Timestamps are in microseconds, which is the default resolution.
+	idb = appendPcapngOption(idb, pcapngOptionTimeResolution, []byte{6})
+	idb = appendPcapngOption(idb, pcapngOptionEndOfOptions, nil)
+
+	if err := writePcapngBlock(w, pcapngBlockInterface, idb); err != nil {
+		return nil, err
+	}
+
+	return &pcapngWriter{w: w}, nil
+}
+
+// WritePacket implements Writer.
+func (pw *pcapngWriter) WritePacket(ci CaptureInfo, data []byte) error {
+	if ci.CaptureLength != len(data) {
+		return fmt.Errorf("capture length %d does not match packet of %d bytes", ci.CaptureLength, len(data))
+	}
+
+	ts := uint64(ci.Timestamp.UnixMicro())
+
+	epb := make([]byte, 20, 20+len(data)+3)
+	binary.LittleEndian.PutUint32(epb[0:4], 0) // Interface ID
+	binary.LittleEndian.PutUint32(epb[4:8], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:12], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:16], uint32(ci.CaptureLength))
+	binary.LittleEndian.PutUint32(epb[16:20], uint32(ci.Length))
+	epb = append(epb, pad(data)...)
+
+	return writePcapngBlock(pw.w, pcapngBlockEnhancedPacket, epb)
+}
+
+// writePcapngBlock writes a pcapng block of the provided type with the
+// provided body, which must be padded to 32 bits.
+func writePcapngBlock(w io.Writer, typ uint32, body []byte) error {
+	length := uint32(12 + len(body))
+
+	block := make([]byte, 0, length)
+	block = binary.LittleEndian.AppendUint32(block, typ)
+	block = binary.LittleEndian.AppendUint32(block, length)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, length)
+
+	_, err := w.Write(block)
+	return err
+}
+
+// appendPcapngOption appends the pcapng option with the provided code and
+// value to b.
+func appendPcapngOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	return append(b, pad(value)...)
+}
+
+// pad pads the provided data with zeros to 32 bits.
+func pad(data []byte) []byte {
+	if len(data)%4 == 0 {
+		return data
+	}
+
+	return append(data[:len(data):len(data)], make([]byte, 4-len(data)%4)...)
+}
+
+// PcapReader reads the packets of a pcap file.
+type PcapReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	nanos bool
+}
+
+// NewPcapReader reads the header of a pcap file of Ethernet frames from r and
+// returns a reader of its packets.
+func NewPcapReader(r io.Reader) (*PcapReader, error) {
+	hdr := make([]byte, 24)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("could not read pcap header: %w", err)
+	}
+
+	pr := &PcapReader{r: r}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(hdr[0:4]) {
+		case pcapMagicMicroseconds:
+			pr.order = order
+		case pcapMagicNanoseconds:
+			pr.order = order
+			pr.nanos = true
+		}
+	}
+
+	if pr.order == nil {
+		return nil, fmt.Errorf("not a pcap file")
+	}
+
+	if linktype := pr.order.Uint32(hdr[20:24]); linktype != LinkTypeEthernet {
+		return nil, fmt.Errorf("unsupported link-layer header type: %d", linktype)
+	}
+
+	return pr, nil
+}
+
+// ReadPacket reads the next packet of the file.
+func (pr *PcapReader) ReadPacket() (CaptureInfo, []byte, error) {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(pr.r, hdr); err != nil {
+		return CaptureInfo{}, nil, err
+	}
+
+	frac := time.Duration(pr.order.Uint32(hdr[4:8]))
+	if !pr.nanos {
+		frac *= time.Microsecond
+	}
+
+	ci := CaptureInfo{
+		Timestamp:     time.Unix(int64(pr.order.Uint32(hdr[0:4])), int64(frac)),
+		CaptureLength: int(pr.order.Uint32(hdr[8:12])),
+		Length:        int(pr.order.Uint32(hdr[12:16])),
+	}
+
+	if ci.CaptureLength > DefaultSnaplen {
+		return CaptureInfo{}, nil, fmt.Errorf("invalid capture length: %d", ci.CaptureLength)
+	}
+
+	data := make([]byte, ci.CaptureLength)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		return CaptureInfo{}, nil, err
+	}
+
+	return ci, data, nil
+}
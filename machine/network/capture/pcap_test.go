@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPcapRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewPcapWriter(&buf, 64)
+	if err != nil {
+		t.Fatal("NewPcapWriter:", err)
+	}
+
+	ts := time.Unix(1700000000, 123456000)
+	packets := [][]byte{
+		testFrame(protoTCP, "10.0.0.2", "10.0.0.3", 40000, 80),
+		testFrame(protoUDP, "10.0.0.3", "10.0.0.2", 53, 40000),
+	}
+
+	for _, packet := range packets {
+		if err := w.WritePacket(CaptureInfo{Timestamp: ts, CaptureLength: len(packet), Length: len(packet) + 10}, packet); err != nil {
+			t.Fatal("WritePacket:", err)
+		}
+	}
+
+	r, err := NewPcapReader(&buf)
+	if err != nil {
+		t.Fatal("NewPcapReader:", err)
+	}
+
+	for _, packet := range packets {
+		ci, data, err := r.ReadPacket()
+		if err != nil {
+			t.Fatal("ReadPacket:", err)
+		}
+
+		if !ci.Timestamp.Equal(ts) {
+			t.Errorf("Expected timestamp %s, got %s", ts, ci.Timestamp)
+		}
+		if ci.Length != len(packet)+10 {
+			t.Errorf("Expected length %d, got %d", len(packet)+10, ci.Length)
+		}
+		if !bytes.Equal(data, packet) {
+			t.Errorf("Packet does not match")
+		}
+	}
+}
+
+func TestPcapngWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewPcapngWriter(&buf, DefaultSnaplen, "kraft0")
+	if err != nil {
+		t.Fatal("NewPcapngWriter:", err)
+	}
+
+	packet := []byte{1, 2, 3, 4, 5}
+	if err := w.WritePacket(CaptureInfo{Timestamp: time.Now(), CaptureLength: len(packet), Length: len(packet)}, packet); err != nil {
+		t.Fatal("WritePacket:", err)
+	}
+
+	// Walk the blocks, each of which is padded to 32 bits and ends with its
+	// length.
+	b := buf.Bytes()
+	var types []uint32
+	for len(b) > 0 {
+		length := binary.LittleEndian.Uint32(b[4:8])
+		if length%4 != 0 || int(length) > len(b) || binary.LittleEndian.Uint32(b[length-4:length]) != length {
+			t.Fatalf("Invalid block of length %d", length)
+		}
+
+		types = append(types, binary.LittleEndian.Uint32(b[0:4]))
+		b = b[length:]
+	}
+
+	if len(types) != 3 || types[0] != pcapngBlockSectionHeader || types[1] != pcapngBlockInterface || types[2] != pcapngBlockEnhancedPacket {
+		t.Errorf("Unexpected blocks: %x", types)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.pcap")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	w, err := NewPcapWriter(f, DefaultSnaplen)
+	if err != nil {
+		t.Fatal("NewPcapWriter:", err)
+	}
+
+	filter, err := CompileFilter("udp", DefaultSnaplen)
+	if err != nil {
+		t.Fatal("CompileFilter:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan []byte)
+	done := make(chan error)
+	go func() {
+		done <- File(ctx, path, filter, func(ci CaptureInfo, data []byte) error {
+			received <- data
+			return nil
+		})
+	}()
+
+	// Packets which are appended to the file later on are followed.
+	udp := testFrame(protoUDP, "10.0.0.3", "10.0.0.2", 53, 40000)
+	for _, packet := range [][]byte{testFrame(protoTCP, "10.0.0.2", "10.0.0.3", 40000, 80), udp} {
+		time.Sleep(150 * time.Millisecond)
+		if err := w.WritePacket(CaptureInfo{Timestamp: time.Now(), CaptureLength: len(packet), Length: len(packet)}, packet); err != nil {
+			t.Fatal("WritePacket:", err)
+		}
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, udp) {
+			t.Errorf("Expected the UDP packet")
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for packet")
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Error("File:", err)
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"strings"
+	"time"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// RecordLease records the provided lease in the status of the network,
+// replacing any previous lease of the same interface.
+func RecordLease(network *networkv1alpha1.Network, lease networkv1alpha1.NetworkDHCPLease) {
+	ReleaseLease(network, lease.MacAddress)
+	network.Status.DHCPLeases = append(network.Status.DHCPLeases, lease)
+}
+
+// ReleaseLease removes the lease held by the interface with the provided
+// hardware address from the status of the network.
+func ReleaseLease(network *networkv1alpha1.Network, mac string) {
+	leases := make([]networkv1alpha1.NetworkDHCPLease, 0, len(network.Status.DHCPLeases))
+	for _, lease := range network.Status.DHCPLeases {
+		if !strings.EqualFold(lease.MacAddress, mac) {
+			leases = append(leases, lease)
+		}
+	}
+
+	network.Status.DHCPLeases = leases
+}
+
+// PruneLeases removes leases from the status of the network which have
+// expired or whose interface has been removed from the network, e.g. because
+// the machine it belonged to was deleted.
+func PruneLeases(network *networkv1alpha1.Network) {
+	if len(network.Status.DHCPLeases) == 0 {
+		return
+	}
+
+	interfaces := make(map[string]bool, len(network.Spec.Interfaces))
+	for _, iface := range network.Spec.Interfaces {
+		interfaces[string(iface.UID)] = true
+	}
+
+	now := time.Now()
+	leases := make([]networkv1alpha1.NetworkDHCPLease, 0, len(network.Status.DHCPLeases))
+	for _, lease := range network.Status.DHCPLeases {
+		if interfaces[string(lease.InterfaceUID)] && lease.ExpiresAt.After(now) {
+			leases = append(leases, lease)
+		}
+	}
+
+	network.Status.DHCPLeases = leases
+}
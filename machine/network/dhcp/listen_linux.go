@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// listen opens the server's socket on the provided interface.  The socket is
+// bound to the interface such that servers of multiple networks can listen on
+// the same port and broadcast replies leave through the correct interface.
+func listen(ctx context.Context, ifname string) (net.PacketConn, error) {
+	lc := net.ListenConfig{
+		Control: func(_, _ string, c syscall.RawConn) error {
+			var serr error
+			if err := c.Control(func(fd uintptr) {
+				if serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); serr != nil {
+					return
+				}
+				if serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_BROADCAST, 1); serr != nil {
+					return
+				}
+				serr = unix.BindToDevice(int(fd), ifname)
+			}); err != nil {
+				return err
+			}
+
+			return serr
+		},
+	}
+
+	return lc.ListenPacket(ctx, "udp4", fmt.Sprintf(":%d", ServerPort))
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// listen opens the server's socket on the provided interface.  It is only
+// supported on Linux.
+func listen(ctx context.Context, ifname string) (net.PacketConn, error) {
+	return nil, errors.New("serving DHCP is only supported on Linux")
+}
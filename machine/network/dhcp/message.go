@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Ports of the DHCPv4 server and client, see RFC 2131.
+const (
+	ServerPort = 67
+	ClientPort = 68
+)
+
+// Opcodes of a DHCPv4 message.
+const (
+	opBootRequest = 1
+	opBootReply   = 2
+)
+
+// MessageType is the value of the DHCP message type option.
+type MessageType uint8
+
+const (
+	MessageTypeDiscover = MessageType(1)
+	MessageTypeOffer    = MessageType(2)
+	MessageTypeRequest  = MessageType(3)
+	MessageTypeDecline  = MessageType(4)
+	MessageTypeAck      = MessageType(5)
+	MessageTypeNak      = MessageType(6)
+	MessageTypeRelease  = MessageType(7)
+	MessageTypeInform   = MessageType(8)
+)
+
+// String implements fmt.Stringer
+func (mt MessageType) String() string {
+	switch mt {
+	case MessageTypeDiscover:
+		return "DHCPDISCOVER"
+	case MessageTypeOffer:
+		return "DHCPOFFER"
+	case MessageTypeRequest:
+		return "DHCPREQUEST"
+	case MessageTypeDecline:
+		return "DHCPDECLINE"
+	case MessageTypeAck:
+		return "DHCPACK"
+	case MessageTypeNak:
+		return "DHCPNAK"
+	case MessageTypeRelease:
+		return "DHCPRELEASE"
+	case MessageTypeInform:
+		return "DHCPINFORM"
+	default:
+		return fmt.Sprintf("DHCP(%d)", uint8(mt))
+	}
+}
+
+// Option codes which are understood by the server, see RFC 2132.
+const (
+	OptionPad              = 0
+	OptionSubnetMask       = 1
+	OptionRouter           = 3
+	OptionDNS              = 6
+	OptionHostname         = 12
+	OptionDomainName       = 15
+	OptionBroadcastAddress = 28
+	OptionRequestedIP      = 50
+	OptionLeaseTime        = 51
+	OptionMessageType      = 53
+	OptionServerIdentifier = 54
+	OptionRenewalTime      = 58
+	OptionRebindingTime    = 59
+	OptionEnd              = 255
+)
+
+// magicCookie precedes the options of a DHCP message.
+var magicCookie = []byte{99, 130, 83, 99}
+
+// headerLen is the length of the fixed-size part of a DHCP message up to and
+// including the magic cookie.
+const headerLen = 240
+
+// flagBroadcast is set by clients which cannot receive unicast datagrams
+// before their interface has been configured.
+const flagBroadcast = 0x8000
+
+// Message is a DHCPv4 message.
+type Message struct {
+	Op      uint8
+	HType   uint8
+	HLen    uint8
+	Hops    uint8
+	XID     uint32
+	Secs    uint16
+	Flags   uint16
+	CIAddr  net.IP
+	YIAddr  net.IP
+	SIAddr  net.IP
+	GIAddr  net.IP
+	CHAddr  net.HardwareAddr
+	Options map[uint8][]byte
+}
+
+// Type returns the DHCP message type of the message, or zero if the message
+// is a plain BOOTP message.
+func (msg *Message) Type() MessageType {
+	if opt := msg.Options[OptionMessageType]; len(opt) == 1 {
+		return MessageType(opt[0])
+	}
+
+	return 0
+}
+
+// IPOption returns the IPv4 address held by the provided option, if any.
+func (msg *Message) IPOption(code uint8) net.IP {
+	if opt := msg.Options[code]; len(opt) == net.IPv4len {
+		return net.IP(opt)
+	}
+
+	return nil
+}
+
+// Unmarshal parses a DHCPv4 message from its wire format.
+func Unmarshal(b []byte) (*Message, error) {
+	if len(b) < headerLen {
+		return nil, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+
+	if string(b[236:240]) != string(magicCookie) {
+		return nil, fmt.Errorf("message is missing the magic cookie")
+	}
+
+	hlen := b[2]
+	if hlen > 16 {
+		return nil, fmt.Errorf("invalid hardware address length: %d", hlen)
+	}
+
+	msg := &Message{
+		Op:      b[0],
+		HType:   b[1],
+		HLen:    hlen,
+		Hops:    b[3],
+		XID:     binary.BigEndian.Uint32(b[4:8]),
+		Secs:    binary.BigEndian.Uint16(b[8:10]),
+		Flags:   binary.BigEndian.Uint16(b[10:12]),
+		CIAddr:  net.IP(append([]byte{}, b[12:16]...)),
+		YIAddr:  net.IP(append([]byte{}, b[16:20]...)),
+		SIAddr:  net.IP(append([]byte{}, b[20:24]...)),
+		GIAddr:  net.IP(append([]byte{}, b[24:28]...)),
+		CHAddr:  net.HardwareAddr(append([]byte{}, b[28:28+hlen]...)),
+		Options: map[uint8][]byte{},
+	}
+
+	opts := b[headerLen:]
+	for i := 0; i < len(opts); {
+		code := opts[i]
+		if code == OptionEnd {
+			break
+		}
+		if code == OptionPad {
+			i++
+			continue
+		}
+
+		if i+1 >= len(opts) {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+
+		length := int(opts[i+1])
+		if i+2+length > len(opts) {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+
+		// Options which occur multiple times are concatenated, see RFC 3396.
+		msg.Options[code] = append(msg.Options[code], opts[i+2:i+2+length]...)
+		i += 2 + length
+	}
+
+	return msg, nil
+}
+
+// Marshal serializes the message into its wire format.
+func (msg *Message) Marshal() []byte {
+	b := make([]byte, headerLen, 576)
+
+	b[0] = msg.Op
+	b[1] = msg.HType
+	b[2] = msg.HLen
+	b[3] = msg.Hops
+	binary.BigEndian.PutUint32(b[4:8], msg.XID)
+	binary.BigEndian.PutUint16(b[8:10], msg.Secs)
+	binary.BigEndian.PutUint16(b[10:12], msg.Flags)
+	copy(b[12:16], msg.CIAddr.To4())
+	copy(b[16:20], msg.YIAddr.To4())
+	copy(b[20:24], msg.SIAddr.To4())
+	copy(b[24:28], msg.GIAddr.To4())
+	copy(b[28:44], msg.CHAddr)
+	copy(b[236:240], magicCookie)
+
+	// The message type is conventionally the first option.
+	codes := []uint8{OptionMessageType}
+	for code := range msg.Options {
+		if code != OptionMessageType {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes[1:], func(i, j int) bool {
+		return codes[1+i] < codes[1+j]
+	})
+
+	for _, code := range codes {
+		value, ok := msg.Options[code]
+		if !ok {
+			continue
+		}
+
+		// Values longer than a single option are split, see RFC 3396.
+		for len(value) > 255 {
+			b = append(b, code, 255)
+			b = append(b, value[:255]...)
+			value = value[255:]
+		}
+
+		b = append(b, code, uint8(len(value)))
+		b = append(b, value...)
+	}
+
+	b = append(b, OptionEnd)
+
+	// Pad to the minimum size of a BOOTP message which some clients expect.
+	for len(b) < 300 {
+		b = append(b, OptionPad)
+	}
+
+	return b
+}
@@ -0,0 +1,397 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package dhcp implements a minimal DHCPv4 server for host-side networks.  The
+// server does not manage an address pool of its own.  Instead, it only answers
+// the interfaces of the network and hands out the addresses which the network
+// driver has already reserved for them, such that the configuration a machine
+// obtains via DHCP is consistent with the one passed on its command-line.
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// DefaultLeaseTime is the duration of a lease unless overridden.
+const DefaultLeaseTime = time.Hour
+
+// LeaseHandler is called whenever the server has acknowledged a lease or a
+// machine has released its lease.
+type LeaseHandler func(ctx context.Context, lease networkv1alpha1.NetworkDHCPLease, released bool) error
+
+// reservation is the configuration which is handed out to a single interface
+// of the network.
+type reservation struct {
+	uid      types.UID
+	ip       net.IP
+	mask     net.IPMask
+	router   net.IP
+	dns      []net.IP
+	hostname string
+	domain   string
+}
+
+// Server is a DHCPv4 server for the interfaces of a single network.
+type Server struct {
+	leaseTime time.Duration
+	handler   LeaseHandler
+
+	mu           sync.RWMutex
+	ifname       string
+	serverIP     net.IP
+	reservations map[string]reservation
+}
+
+// ServerOption is an option which configures the DHCP server.
+type ServerOption func(*Server) error
+
+// WithLeaseTime sets the duration of the leases handed out by the server.
+func WithLeaseTime(leaseTime time.Duration) ServerOption {
+	return func(server *Server) error {
+		if leaseTime < time.Second {
+			return fmt.Errorf("lease time too short: %s", leaseTime)
+		}
+
+		server.leaseTime = leaseTime
+		return nil
+	}
+}
+
+// WithLeaseHandler sets the handler which is called whenever a lease is
+// acknowledged or released, e.g. to persist the leases of the network.
+func WithLeaseHandler(handler LeaseHandler) ServerOption {
+	return func(server *Server) error {
+		server.handler = handler
+		return nil
+	}
+}
+
+// NewServer prepares a DHCP server.  The network whose interfaces are served
+// must be set via SetNetwork before serving.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	server := &Server{
+		leaseTime:    DefaultLeaseTime,
+		reservations: map[string]reservation{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	return server, nil
+}
+
+// SetNetwork (re)loads the interfaces of the provided network which are served
+// by the server.  It can be called whilst serving to follow changes of the
+// network.
+func (server *Server) SetNetwork(network *networkv1alpha1.Network) error {
+	gateway := net.ParseIP(network.Spec.Gateway).To4()
+	if gateway == nil {
+		return fmt.Errorf("network %s has no IPv4 gateway", network.Name)
+	}
+
+	reservations := make(map[string]reservation, len(network.Spec.Interfaces))
+
+	for _, iface := range network.Spec.Interfaces {
+		if iface.Spec.MacAddress == "" || iface.Spec.CIDR == "" {
+			continue
+		}
+
+		mac, err := net.ParseMAC(iface.Spec.MacAddress)
+		if err != nil {
+			return fmt.Errorf("could not parse hardware address of %s: %w", iface.Spec.IfName, err)
+		}
+
+		ip, ipnet, err := net.ParseCIDR(iface.Spec.CIDR)
+		if err != nil {
+			return fmt.Errorf("could not parse address of %s: %w", iface.Spec.IfName, err)
+		}
+
+		if ip.To4() == nil {
+			continue
+		}
+
+		res := reservation{
+			uid:      iface.UID,
+			ip:       ip.To4(),
+			mask:     ipnet.Mask,
+			router:   gateway,
+			hostname: iface.Spec.Hostname,
+			domain:   iface.Spec.Domain,
+		}
+
+		if router := net.ParseIP(iface.Spec.Gateway).To4(); router != nil {
+			res.router = router
+		}
+
+		for _, dns := range []string{iface.Spec.DNS0, iface.Spec.DNS1} {
+			if addr := net.ParseIP(dns).To4(); addr != nil {
+				res.dns = append(res.dns, addr)
+			}
+		}
+
+		reservations[mac.String()] = res
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.ifname = network.Spec.IfName
+	server.serverIP = gateway
+	server.reservations = reservations
+
+	return nil
+}
+
+// Serve answers DHCP requests arriving at the network's interface until the
+// provided context is cancelled.
+func (server *Server) Serve(ctx context.Context) error {
+	server.mu.RLock()
+	ifname := server.ifname
+	server.mu.RUnlock()
+
+	if ifname == "" {
+		return fmt.Errorf("no network to serve")
+	}
+
+	conn, err := listen(ctx, ifname)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", ifname, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 1500)
+
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		req, err := Unmarshal(buf[:n])
+		if err != nil {
+			log.G(ctx).Debugf("dhcp: ignoring malformed message: %v", err)
+			continue
+		}
+
+		reply := server.handle(ctx, req)
+		if reply == nil {
+			continue
+		}
+
+		if _, err := conn.WriteTo(reply.Marshal(), destination(req, reply)); err != nil {
+			log.G(ctx).Warnf("dhcp: could not send %s to %s: %v", reply.Type(), req.CHAddr, err)
+		}
+	}
+}
+
+// destination returns the address to which the reply to the provided request
+// is sent, see RFC 2131 section 4.1.
+func destination(req, reply *Message) net.Addr {
+	if specified(req.GIAddr) {
+		return &net.UDPAddr{IP: req.GIAddr, Port: ServerPort}
+	}
+
+	if reply.Type() != MessageTypeNak && specified(req.CIAddr) {
+		return &net.UDPAddr{IP: req.CIAddr, Port: ClientPort}
+	}
+
+	// The client has no address yet and cannot be reached without resolving
+	// its hardware address, which is not possible from a UDP socket.
+	return &net.UDPAddr{IP: net.IPv4bcast, Port: ClientPort}
+}
+
+// specified returns whether the provided address is set.
+func specified(ip net.IP) bool {
+	return len(ip) > 0 && !ip.IsUnspecified()
+}
+
+// handle returns the reply to the provided request, if any.
+func (server *Server) handle(ctx context.Context, req *Message) *Message {
+	if req.Op != opBootRequest || req.HLen != 6 {
+		return nil
+	}
+
+	server.mu.RLock()
+	res, reserved := server.reservations[req.CHAddr.String()]
+	serverIP := server.serverIP
+	server.mu.RUnlock()
+
+	switch req.Type() {
+	case MessageTypeDiscover:
+		if !reserved {
+			log.G(ctx).Debugf("dhcp: ignoring %s from unknown interface %s", req.Type(), req.CHAddr)
+			return nil
+		}
+
+		return server.reply(req, res, serverIP, MessageTypeOffer)
+
+	case MessageTypeRequest:
+		// The client has selected the offer of another server.
+		if id := req.IPOption(OptionServerIdentifier); id != nil && !id.Equal(serverIP) {
+			return nil
+		}
+
+		// Remain silent for clients which are unknown to the server, another
+		// server may be responsible for them.
+		if !reserved {
+			return nil
+		}
+
+		requested := req.IPOption(OptionRequestedIP)
+		if requested == nil {
+			requested = req.CIAddr
+		}
+
+		if !requested.Equal(res.ip) {
+			log.G(ctx).Debugf("dhcp: rejecting request of %s for %s, reserved address is %s", req.CHAddr, requested, res.ip)
+			return server.nak(req, serverIP)
+		}
+
+		server.notify(ctx, req.CHAddr, res, false)
+
+		return server.reply(req, res, serverIP, MessageTypeAck)
+
+	case MessageTypeRelease, MessageTypeDecline:
+		if reserved {
+			server.notify(ctx, req.CHAddr, res, true)
+		}
+
+		return nil
+
+	case MessageTypeInform:
+		if !reserved {
+			return nil
+		}
+
+		// The client has already configured its address and only requests the
+		// remaining parameters, see RFC 2131 section 3.4.
+		ack := server.reply(req, res, serverIP, MessageTypeAck)
+		ack.YIAddr = net.IPv4zero
+		delete(ack.Options, OptionLeaseTime)
+		delete(ack.Options, OptionRenewalTime)
+		delete(ack.Options, OptionRebindingTime)
+
+		return ack
+	}
+
+	return nil
+}
+
+// notify passes the lease of the provided interface to the lease handler.
+func (server *Server) notify(ctx context.Context, mac net.HardwareAddr, res reservation, released bool) {
+	if server.handler == nil {
+		return
+	}
+
+	lease := networkv1alpha1.NetworkDHCPLease{
+		InterfaceUID: res.uid,
+		MacAddress:   mac.String(),
+		IP:           res.ip.String(),
+		Hostname:     res.hostname,
+		ExpiresAt:    time.Now().Add(server.leaseTime),
+	}
+
+	if err := server.handler(ctx, lease, released); err != nil {
+		log.G(ctx).Warnf("dhcp: could not record lease of %s: %v", mac, err)
+	}
+}
+
+// reply prepares a reply of the provided type which carries the configuration
+// of the provided reservation.
+func (server *Server) reply(req *Message, res reservation, serverIP net.IP, mt MessageType) *Message {
+	reply := server.response(req, serverIP, mt)
+	reply.YIAddr = res.ip
+
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = res.ip[i] | ^res.mask[i]
+	}
+
+	reply.Options[OptionSubnetMask] = []byte(res.mask)
+	reply.Options[OptionRouter] = []byte(res.router)
+	reply.Options[OptionBroadcastAddress] = []byte(broadcast)
+	reply.Options[OptionLeaseTime] = seconds(server.leaseTime)
+	reply.Options[OptionRenewalTime] = seconds(server.leaseTime / 2)
+	reply.Options[OptionRebindingTime] = seconds(server.leaseTime * 7 / 8)
+
+	if len(res.dns) > 0 {
+		var dns []byte
+		for _, addr := range res.dns {
+			dns = append(dns, addr...)
+		}
+
+		reply.Options[OptionDNS] = dns
+	}
+
+	if res.hostname != "" {
+		reply.Options[OptionHostname] = []byte(res.hostname)
+	}
+
+	if res.domain != "" {
+		reply.Options[OptionDomainName] = []byte(strings.TrimSuffix(res.domain, "."))
+	}
+
+	return reply
+}
+
+// nak prepares a negative acknowledgement of the provided request.
+func (server *Server) nak(req *Message, serverIP net.IP) *Message {
+	reply := server.response(req, serverIP, MessageTypeNak)
+	reply.Flags |= flagBroadcast
+
+	return reply
+}
+
+// response prepares an empty reply of the provided type to the provided
+// request.
+func (server *Server) response(req *Message, serverIP net.IP, mt MessageType) *Message {
+	return &Message{
+		Op:     opBootReply,
+		HType:  req.HType,
+		HLen:   req.HLen,
+		XID:    req.XID,
+		Flags:  req.Flags,
+		CIAddr: net.IPv4zero,
+		YIAddr: net.IPv4zero,
+		SIAddr: serverIP,
+		GIAddr: req.GIAddr,
+		CHAddr: req.CHAddr,
+		Options: map[uint8][]byte{
+			OptionMessageType:      {byte(mt)},
+			OptionServerIdentifier: []byte(serverIP),
+		},
+	}
+}
+
+// seconds encodes the provided duration as the 32-bit number of seconds used
+// by time-related options.
+func seconds(d time.Duration) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(d/time.Second))
+	return b
+}
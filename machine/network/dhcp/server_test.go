@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dhcp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func testNetwork() *networkv1alpha1.Network {
+	return &networkv1alpha1.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kraft0",
+		},
+		Spec: networkv1alpha1.NetworkSpec{
+			IfName:  "kraft0",
+			Gateway: "172.44.0.1",
+			Netmask: "255.255.255.0",
+			DHCP:    true,
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{
+				{
+					ObjectMeta: metav1.ObjectMeta{UID: "iface0"},
+					Spec: networkv1alpha1.NetworkInterfaceSpec{
+						CIDR:       "172.44.0.2/24",
+						MacAddress: "02:b0:b0:00:00:01",
+						DNS0:       "1.1.1.1",
+						Hostname:   "nginx",
+					},
+				},
+			},
+		},
+	}
+}
+
+func testRequest(mt MessageType, mac string, opts map[uint8][]byte) *Message {
+	hwaddr, _ := net.ParseMAC(mac)
+	msg := &Message{
+		Op:      opBootRequest,
+		HType:   1,
+		HLen:    6,
+		XID:     0xdeadbeef,
+		CIAddr:  net.IPv4zero,
+		YIAddr:  net.IPv4zero,
+		SIAddr:  net.IPv4zero,
+		GIAddr:  net.IPv4zero,
+		CHAddr:  hwaddr,
+		Options: map[uint8][]byte{OptionMessageType: {byte(mt)}},
+	}
+
+	for code, value := range opts {
+		msg.Options[code] = value
+	}
+
+	return msg
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	req := testRequest(MessageTypeRequest, "02:b0:b0:00:00:01", map[uint8][]byte{
+		OptionRequestedIP: net.ParseIP("172.44.0.2").To4(),
+		OptionHostname:    make([]byte, 300),
+	})
+
+	parsed, err := Unmarshal(req.Marshal())
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+
+	if parsed.Type() != MessageTypeRequest {
+		t.Errorf("Expected %s, got %s", MessageTypeRequest, parsed.Type())
+	}
+	if parsed.XID != req.XID {
+		t.Errorf("Expected XID %x, got %x", req.XID, parsed.XID)
+	}
+	if parsed.CHAddr.String() != req.CHAddr.String() {
+		t.Errorf("Expected hardware address %s, got %s", req.CHAddr, parsed.CHAddr)
+	}
+	if !parsed.IPOption(OptionRequestedIP).Equal(net.ParseIP("172.44.0.2")) {
+		t.Errorf("Expected requested address 172.44.0.2, got %s", parsed.IPOption(OptionRequestedIP))
+	}
+	if len(parsed.Options[OptionHostname]) != 300 {
+		t.Errorf("Expected split option of 300 bytes, got %d", len(parsed.Options[OptionHostname]))
+	}
+
+	if _, err := Unmarshal(make([]byte, 100)); err == nil {
+		t.Error("Expected error for short message")
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	ctx := context.Background()
+
+	var leases []networkv1alpha1.NetworkDHCPLease
+	var released []networkv1alpha1.NetworkDHCPLease
+
+	server, err := NewServer(WithLeaseHandler(func(_ context.Context, lease networkv1alpha1.NetworkDHCPLease, release bool) error {
+		if release {
+			released = append(released, lease)
+		} else {
+			leases = append(leases, lease)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal("NewServer:", err)
+	}
+
+	if err := server.SetNetwork(testNetwork()); err != nil {
+		t.Fatal("SetNetwork:", err)
+	}
+
+	offer := server.handle(ctx, testRequest(MessageTypeDiscover, "02:b0:b0:00:00:01", nil))
+	if offer == nil || offer.Type() != MessageTypeOffer {
+		t.Fatalf("Expected %s, got %v", MessageTypeOffer, offer)
+	}
+	if !offer.YIAddr.Equal(net.ParseIP("172.44.0.2")) {
+		t.Errorf("Expected offered address 172.44.0.2, got %s", offer.YIAddr)
+	}
+	if !offer.IPOption(OptionRouter).Equal(net.ParseIP("172.44.0.1")) {
+		t.Errorf("Expected router 172.44.0.1, got %s", offer.IPOption(OptionRouter))
+	}
+	if !offer.IPOption(OptionDNS).Equal(net.ParseIP("1.1.1.1")) {
+		t.Errorf("Expected DNS server 1.1.1.1, got %s", offer.IPOption(OptionDNS))
+	}
+	if mask := net.IPMask(offer.Options[OptionSubnetMask]); mask.String() != "ffffff00" {
+		t.Errorf("Expected subnet mask ffffff00, got %s", mask)
+	}
+	if !offer.IPOption(OptionBroadcastAddress).Equal(net.ParseIP("172.44.0.255")) {
+		t.Errorf("Expected broadcast address 172.44.0.255, got %s", offer.IPOption(OptionBroadcastAddress))
+	}
+	if string(offer.Options[OptionHostname]) != "nginx" {
+		t.Errorf("Expected hostname nginx, got %s", offer.Options[OptionHostname])
+	}
+	if len(leases) != 0 {
+		t.Errorf("Expected no lease to be recorded for an offer, got %d", len(leases))
+	}
+
+	if reply := server.handle(ctx, testRequest(MessageTypeDiscover, "02:b0:b0:00:00:02", nil)); reply != nil {
+		t.Errorf("Expected unknown interface to be ignored, got %s", reply.Type())
+	}
+
+	ack := server.handle(ctx, testRequest(MessageTypeRequest, "02:b0:b0:00:00:01", map[uint8][]byte{
+		OptionRequestedIP:      net.ParseIP("172.44.0.2").To4(),
+		OptionServerIdentifier: net.ParseIP("172.44.0.1").To4(),
+	}))
+	if ack == nil || ack.Type() != MessageTypeAck {
+		t.Fatalf("Expected %s, got %v", MessageTypeAck, ack)
+	}
+	if len(leases) != 1 || leases[0].IP != "172.44.0.2" || leases[0].InterfaceUID != "iface0" {
+		t.Errorf("Expected lease of 172.44.0.2 for iface0, got %v", leases)
+	}
+
+	nak := server.handle(ctx, testRequest(MessageTypeRequest, "02:b0:b0:00:00:01", map[uint8][]byte{
+		OptionRequestedIP: net.ParseIP("172.44.0.3").To4(),
+	}))
+	if nak == nil || nak.Type() != MessageTypeNak {
+		t.Fatalf("Expected %s, got %v", MessageTypeNak, nak)
+	}
+
+	if reply := server.handle(ctx, testRequest(MessageTypeRequest, "02:b0:b0:00:00:01", map[uint8][]byte{
+		OptionRequestedIP:      net.ParseIP("172.44.0.2").To4(),
+		OptionServerIdentifier: net.ParseIP("172.44.0.254").To4(),
+	})); reply != nil {
+		t.Errorf("Expected request for another server to be ignored, got %s", reply.Type())
+	}
+
+	server.handle(ctx, testRequest(MessageTypeRelease, "02:b0:b0:00:00:01", nil))
+	if len(released) != 1 || released[0].MacAddress != "02:b0:b0:00:00:01" {
+		t.Errorf("Expected lease of 02:b0:b0:00:00:01 to be released, got %v", released)
+	}
+}
+
+func TestPruneLeases(t *testing.T) {
+	network := testNetwork()
+
+	RecordLease(network, networkv1alpha1.NetworkDHCPLease{
+		InterfaceUID: "iface0",
+		MacAddress:   "02:b0:b0:00:00:01",
+		IP:           "172.44.0.2",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	RecordLease(network, networkv1alpha1.NetworkDHCPLease{
+		InterfaceUID: "iface0",
+		MacAddress:   "02:b0:b0:00:00:01",
+		IP:           "172.44.0.2",
+		ExpiresAt:    time.Now().Add(2 * time.Hour),
+	})
+	RecordLease(network, networkv1alpha1.NetworkDHCPLease{
+		InterfaceUID: "iface1",
+		MacAddress:   "02:b0:b0:00:00:02",
+		IP:           "172.44.0.3",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+
+	if len(network.Status.DHCPLeases) != 2 {
+		t.Fatalf("Expected 2 leases, got %d", len(network.Status.DHCPLeases))
+	}
+
+	PruneLeases(network)
+
+	if len(network.Status.DHCPLeases) != 1 || network.Status.DHCPLeases[0].InterfaceUID != "iface0" {
+		t.Errorf("Expected only the lease of iface0 to remain, got %v", network.Status.DHCPLeases)
+	}
+
+	network.Spec.Interfaces = nil
+	PruneLeases(network)
+
+	if len(network.Status.DHCPLeases) != 0 {
+		t.Errorf("Expected lease of removed interface to be released, got %v", network.Status.DHCPLeases)
+	}
+}
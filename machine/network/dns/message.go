@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Port is the port of the DNS resolver.
+const Port = 53
+
+// Record types and classes which are understood by the resolver, see RFC 1035
+// and RFC 3596.
+const (
+	TypeA    = 1
+	TypeAAAA = 28
+	ClassIN  = 1
+)
+
+// Response codes of a DNS message.
+const (
+	RcodeSuccess        = 0
+	RcodeFormatError    = 1
+	RcodeServerFailure  = 2
+	RcodeNameError      = 3
+	RcodeNotImplemented = 4
+)
+
+// headerLen is the length of the header of a DNS message.
+const headerLen = 12
+
+// Bits of the flags of the header of a DNS message.
+const (
+	flagQR     = 1 << 15
+	flagAA     = 1 << 10
+	flagRD     = 1 << 8
+	flagRA     = 1 << 7
+	maskOpcode = 0xf << 11
+)
+
+// Question is the single question of a DNS query.
+type Question struct {
+	// Name is the queried domain name in lower case and without trailing dot.
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// Query is a parsed DNS query.
+type Query struct {
+	ID       uint16
+	Flags    uint16
+	Question Question
+
+	// raw holds the question section as sent by the client, which is echoed
+	// in responses.
+	raw []byte
+}
+
+// ParseQuery parses a DNS query which holds a single question.  Queries with
+// multiple questions are not used in practice and are rejected.
+func ParseQuery(b []byte) (*Query, error) {
+	if len(b) < headerLen {
+		return nil, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+
+	query := &Query{
+		ID:    binary.BigEndian.Uint16(b[0:2]),
+		Flags: binary.BigEndian.Uint16(b[2:4]),
+	}
+
+	if query.Flags&flagQR != 0 {
+		return nil, fmt.Errorf("message is not a query")
+	}
+
+	if qdcount := binary.BigEndian.Uint16(b[4:6]); qdcount != 1 {
+		return nil, fmt.Errorf("unsupported number of questions: %d", qdcount)
+	}
+
+	labels := []string{}
+	i := headerLen
+
+	for {
+		if i >= len(b) {
+			return nil, fmt.Errorf("truncated question")
+		}
+
+		length := int(b[i])
+		i++
+
+		if length == 0 {
+			break
+		}
+
+		// Compression pointers cannot refer to anything in the first question.
+		if length&0xc0 != 0 {
+			return nil, fmt.Errorf("invalid label in question")
+		}
+
+		if i+length > len(b) {
+			return nil, fmt.Errorf("truncated question")
+		}
+
+		labels = append(labels, string(b[i:i+length]))
+		i += length
+	}
+
+	if i+4 > len(b) {
+		return nil, fmt.Errorf("truncated question")
+	}
+
+	query.Question = Question{
+		Name:  strings.ToLower(strings.Join(labels, ".")),
+		Type:  binary.BigEndian.Uint16(b[i : i+2]),
+		Class: binary.BigEndian.Uint16(b[i+2 : i+4]),
+	}
+	query.raw = b[headerLen : i+4]
+
+	return query, nil
+}
+
+// Opcode returns the kind of the query, zero being a standard query.
+func (query *Query) Opcode() uint16 {
+	return (query.Flags & maskOpcode) >> 11
+}
+
+// Response builds an authoritative response to the query with the provided
+// response code which answers with the provided addresses.
+func (query *Query) Response(rcode uint16, ttl uint32, addrs ...net.IP) []byte {
+	b := make([]byte, headerLen, headerLen+len(query.raw)+len(addrs)*28)
+
+	binary.BigEndian.PutUint16(b[0:2], query.ID)
+	binary.BigEndian.PutUint16(b[2:4], flagQR|flagAA|flagRA|(query.Flags&(maskOpcode|flagRD))|rcode)
+	binary.BigEndian.PutUint16(b[4:6], 1)
+	binary.BigEndian.PutUint16(b[6:8], uint16(len(addrs)))
+
+	b = append(b, query.raw...)
+
+	for _, addr := range addrs {
+		rtype, rdata := uint16(TypeA), []byte(addr.To4())
+		if rdata == nil {
+			rtype, rdata = TypeAAAA, []byte(addr.To16())
+		}
+
+		// The name is a pointer to the one of the question.
+		b = append(b, 0xc0, headerLen)
+		b = binary.BigEndian.AppendUint16(b, rtype)
+		b = binary.BigEndian.AppendUint16(b, ClassIN)
+		b = binary.BigEndian.AppendUint32(b, ttl)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(rdata)))
+		b = append(b, rdata...)
+	}
+
+	return b
+}
+
+// ErrorResponse builds a response to the raw query which only consists of the
+// provided response code, for queries which could not be parsed.
+func ErrorResponse(b []byte, rcode uint16) []byte {
+	if len(b) < headerLen {
+		return nil
+	}
+
+	// Never respond to responses.
+	flags := binary.BigEndian.Uint16(b[2:4])
+	if flags&flagQR != 0 {
+		return nil
+	}
+
+	resp := make([]byte, headerLen)
+	copy(resp[0:2], b[0:2])
+	binary.BigEndian.PutUint16(resp[2:4], flagQR|flagRA|(flags&(maskOpcode|flagRD))|rcode)
+
+	return resp
+}
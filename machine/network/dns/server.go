@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package dns implements a minimal DNS resolver for host-side networks which
+// allows machines on the same network to discover each other by name.  The
+// resolver listens on the gateway addresses of the network and answers for the
+// names and aliases of the network's interfaces.  All other queries are
+// forwarded to the upstream name servers of the host.
+package dns
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// DefaultTTL is the time for which answers of the resolver may be cached
+// unless overridden.  It is kept short since addresses change as machines come
+// and go.
+const DefaultTTL = 10 * time.Second
+
+// DefaultResolvConf is the path to the resolver configuration of the host from
+// which the upstream name servers are read by default.
+const DefaultResolvConf = "/etc/resolv.conf"
+
+// forwardTimeout is the maximum time to wait for an upstream name server.
+const forwardTimeout = 2 * time.Second
+
+// Server is a DNS resolver for the interfaces of a single network.
+type Server struct {
+	ttl       time.Duration
+	upstreams []string
+
+	mu      sync.RWMutex
+	addrs   []net.IP
+	records map[string][]net.IP
+}
+
+// ServerOption is an option which configures the DNS resolver.
+type ServerOption func(*Server) error
+
+// WithTTL sets the time for which the answers of the resolver may be cached.
+func WithTTL(ttl time.Duration) ServerOption {
+	return func(server *Server) error {
+		if ttl < 0 {
+			return fmt.Errorf("invalid ttl: %s", ttl)
+		}
+
+		server.ttl = ttl
+		return nil
+	}
+}
+
+// WithUpstreams sets the name servers to which queries for names outside of
+// the network are forwarded.
+func WithUpstreams(upstreams ...string) ServerOption {
+	return func(server *Server) error {
+		server.upstreams = upstreams
+		return nil
+	}
+}
+
+// NewServer prepares a DNS resolver.  The network whose interfaces are served
+// must be set via SetNetwork before serving.  Unless otherwise provided, the
+// upstream name servers are those of the host.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	server := &Server{
+		ttl:     DefaultTTL,
+		records: map[string][]net.IP{},
+	}
+
+	upstreams, err := Upstreams(DefaultResolvConf)
+	if err == nil {
+		server.upstreams = upstreams
+	}
+
+	for _, opt := range opts {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	return server, nil
+}
+
+// Upstreams returns the addresses of the name servers in the provided resolver
+// configuration file.
+func Upstreams(resolvConf string) ([]string, error) {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var upstreams []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		// Strip any zone, e.g. fe80::1%eth0, which cannot be dialed as is.
+		addr := net.ParseIP(strings.SplitN(fields[1], "%", 2)[0])
+		if addr == nil {
+			continue
+		}
+
+		upstreams = append(upstreams, net.JoinHostPort(addr.String(), fmt.Sprint(Port)))
+	}
+
+	return upstreams, scanner.Err()
+}
+
+// Names returns the names under which the provided interface is resolvable.
+func Names(iface networkv1alpha1.NetworkInterfaceTemplateSpec) []string {
+	var names []string
+
+	for _, name := range append([]string{iface.Spec.Hostname}, iface.Spec.Aliases...) {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name == "" {
+			continue
+		}
+
+		names = append(names, name)
+
+		if domain := strings.ToLower(strings.Trim(iface.Spec.Domain, ".")); domain != "" && !strings.HasSuffix(name, "."+domain) {
+			names = append(names, name+"."+domain)
+		}
+	}
+
+	return names
+}
+
+// SetNetwork (re)loads the names of the interfaces of the provided network.
+// It can be called whilst serving to follow changes of the network.
+func (server *Server) SetNetwork(network *networkv1alpha1.Network) error {
+	var gateways []net.IP
+
+	if gateway := net.ParseIP(network.Spec.Gateway).To4(); gateway != nil {
+		gateways = append(gateways, gateway)
+	}
+
+	if network.Spec.IPv6 != "" {
+		gateway, _, err := net.ParseCIDR(network.Spec.IPv6)
+		if err != nil {
+			return fmt.Errorf("could not parse IPv6 gateway of %s: %w", network.Name, err)
+		}
+
+		gateways = append(gateways, gateway)
+	}
+
+	if len(gateways) == 0 {
+		return fmt.Errorf("network %s has no gateway", network.Name)
+	}
+
+	records := map[string][]net.IP{}
+
+	for _, iface := range network.Spec.Interfaces {
+		for _, cidr := range []string{iface.Spec.CIDR, iface.Spec.CIDR6} {
+			if cidr == "" {
+				continue
+			}
+
+			ip, _, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return fmt.Errorf("could not parse address of %s: %w", iface.Spec.IfName, err)
+			}
+
+			for _, name := range Names(iface) {
+				records[name] = append(records[name], ip)
+			}
+		}
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.addrs = gateways
+	server.records = records
+
+	return nil
+}
+
+// Lookup returns the addresses of the provided name on the network and whether
+// the name is known.
+func (server *Server) Lookup(name string) ([]net.IP, bool) {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	addrs, ok := server.records[strings.ToLower(strings.TrimSuffix(name, "."))]
+	return addrs, ok
+}
+
+// Serve answers DNS queries arriving at the gateway addresses of the network
+// until the provided context is cancelled.
+func (server *Server) Serve(ctx context.Context) error {
+	server.mu.RLock()
+	addrs := server.addrs
+	server.mu.RUnlock()
+
+	if len(addrs) == 0 {
+		return fmt.Errorf("no network to serve")
+	}
+
+	var conns []net.PacketConn
+
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for _, addr := range addrs {
+		network := "udp4"
+		if addr.To4() == nil {
+			network = "udp6"
+		}
+
+		var lc net.ListenConfig
+		conn, err := lc.ListenPacket(ctx, network, net.JoinHostPort(addr.String(), fmt.Sprint(Port)))
+		if err != nil {
+			return fmt.Errorf("could not listen on %s: %w", addr, err)
+		}
+
+		conns = append(conns, conn)
+	}
+
+	errs := make(chan error, len(conns))
+
+	for _, conn := range conns {
+		go func(conn net.PacketConn) {
+			errs <- server.serve(ctx, conn)
+		}(conn)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// serve answers DNS queries arriving at the provided connection until the
+// provided context is cancelled or the connection is closed.
+func (server *Server) serve(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		buf := make([]byte, 4096)
+
+		n, client, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		// Forwarded queries may take a while, so answer each concurrently.
+		go func() {
+			resp := server.handle(ctx, buf[:n])
+			if resp == nil {
+				return
+			}
+
+			if _, err := conn.WriteTo(resp, client); err != nil {
+				log.G(ctx).Debugf("dns: could not respond to %s: %v", client, err)
+			}
+		}()
+	}
+}
+
+// handle returns the response to the provided raw query, if any.
+func (server *Server) handle(ctx context.Context, b []byte) []byte {
+	query, err := ParseQuery(b)
+	if err != nil {
+		log.G(ctx).Debugf("dns: ignoring malformed query: %v", err)
+		return ErrorResponse(b, RcodeFormatError)
+	}
+
+	if query.Opcode() != 0 {
+		return ErrorResponse(b, RcodeNotImplemented)
+	}
+
+	question := query.Question
+	ttl := uint32(server.ttl / time.Second)
+
+	if question.Class == ClassIN {
+		if addrs, ok := server.Lookup(question.Name); ok {
+			// A name which exists but has no addresses of the requested type is
+			// answered without records.
+			var answers []net.IP
+			for _, addr := range addrs {
+				if (question.Type == TypeA && addr.To4() != nil) || (question.Type == TypeAAAA && addr.To4() == nil) {
+					answers = append(answers, addr)
+				}
+			}
+
+			return query.Response(RcodeSuccess, ttl, answers...)
+		}
+
+		// Single-label names can only refer to machines on the network and are
+		// not leaked to the upstream name servers.
+		if question.Name != "" && !strings.Contains(question.Name, ".") {
+			return query.Response(RcodeNameError, ttl)
+		}
+	}
+
+	resp, err := server.forward(ctx, query.ID, b)
+	if err != nil {
+		log.G(ctx).Debugf("dns: could not resolve %s: %v", question.Name, err)
+		return ErrorResponse(b, RcodeServerFailure)
+	}
+
+	return resp
+}
+
+// forward relays the provided raw query to the upstream name servers in order
+// and returns the first response.
+func (server *Server) forward(ctx context.Context, id uint16, b []byte) ([]byte, error) {
+	if len(server.upstreams) == 0 {
+		return nil, fmt.Errorf("no upstream name servers")
+	}
+
+	var errs []error
+
+	for _, upstream := range server.upstreams {
+		resp, err := exchange(ctx, upstream, id, b)
+		if err == nil {
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", upstream, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// exchange sends the provided raw query to the provided name server and
+// returns its response.
+func exchange(ctx context.Context, upstream string, id uint16, b []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Discard stray responses to earlier queries.
+		if n >= headerLen && uint16(buf[0])<<8|uint16(buf[1]) == id {
+			return buf[:n], nil
+		}
+	}
+}
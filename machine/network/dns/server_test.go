@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func testQuery(id uint16, name string, qtype uint16) []byte {
+	b := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(b[0:2], id)
+	binary.BigEndian.PutUint16(b[2:4], flagRD)
+	binary.BigEndian.PutUint16(b[4:6], 1)
+
+	for _, label := range strings.Split(name, ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	b = append(b, 0)
+	b = binary.BigEndian.AppendUint16(b, qtype)
+	b = binary.BigEndian.AppendUint16(b, ClassIN)
+
+	return b
+}
+
+// answers returns the response code and the addresses of the answers of the
+// provided response.
+func answers(t *testing.T, resp []byte, query []byte) (uint16, []net.IP) {
+	t.Helper()
+
+	if len(resp) < len(query) {
+		t.Fatalf("Response too short: %d bytes", len(resp))
+	}
+	if binary.BigEndian.Uint16(resp[0:2]) != binary.BigEndian.Uint16(query[0:2]) {
+		t.Fatalf("Response ID does not match query")
+	}
+
+	flags := binary.BigEndian.Uint16(resp[2:4])
+	count := int(binary.BigEndian.Uint16(resp[6:8]))
+
+	var addrs []net.IP
+	i := len(query)
+	for range count {
+		rdlen := int(binary.BigEndian.Uint16(resp[i+10 : i+12]))
+		addrs = append(addrs, net.IP(resp[i+12:i+12+rdlen]))
+		i += 12 + rdlen
+	}
+
+	return flags & 0xf, addrs
+}
+
+func TestServerHandle(t *testing.T) {
+	ctx := context.Background()
+
+	// Fake an upstream name server which answers every query with 192.0.2.1.
+	upstream, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket:", err)
+	}
+	defer upstream.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			query, err := ParseQuery(buf[:n])
+			if err != nil {
+				continue
+			}
+
+			_, _ = upstream.WriteTo(query.Response(RcodeSuccess, 60, net.ParseIP("192.0.2.1")), addr)
+		}
+	}()
+
+	server, err := NewServer(WithUpstreams(upstream.LocalAddr().String()))
+	if err != nil {
+		t.Fatal("NewServer:", err)
+	}
+
+	if err := server.SetNetwork(&networkv1alpha1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+		Spec: networkv1alpha1.NetworkSpec{
+			Gateway: "172.44.0.1",
+			Netmask: "255.255.255.0",
+			IPv6:    "fd00::1/64",
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{
+				{
+					Spec: networkv1alpha1.NetworkInterfaceSpec{
+						CIDR:    "172.44.0.2/24",
+						Aliases: []string{"myproject-db", "db"},
+						Domain:  "internal.",
+					},
+				},
+				{
+					Spec: networkv1alpha1.NetworkInterfaceSpec{
+						CIDR:     "172.44.0.3/24",
+						CIDR6:    "fd00::3/64",
+						Hostname: "Web",
+					},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal("SetNetwork:", err)
+	}
+
+	tests := []struct {
+		name      string
+		qtype     uint16
+		wantRcode uint16
+		wantAddrs []string
+	}{
+		{name: "db", qtype: TypeA, wantRcode: RcodeSuccess, wantAddrs: []string{"172.44.0.2"}},
+		{name: "DB.internal", qtype: TypeA, wantRcode: RcodeSuccess, wantAddrs: []string{"172.44.0.2"}},
+		{name: "myproject-db", qtype: TypeA, wantRcode: RcodeSuccess, wantAddrs: []string{"172.44.0.2"}},
+		{name: "web", qtype: TypeA, wantRcode: RcodeSuccess, wantAddrs: []string{"172.44.0.3"}},
+		{name: "web", qtype: TypeAAAA, wantRcode: RcodeSuccess, wantAddrs: []string{"fd00::3"}},
+		{name: "db", qtype: TypeAAAA, wantRcode: RcodeSuccess},
+		{name: "cache", qtype: TypeA, wantRcode: RcodeNameError},
+		{name: "unikraft.org", qtype: TypeA, wantRcode: RcodeSuccess, wantAddrs: []string{"192.0.2.1"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := testQuery(uint16(i+1), tt.name, tt.qtype)
+
+			rcode, addrs := answers(t, server.handle(ctx, query), query)
+			if rcode != tt.wantRcode {
+				t.Errorf("Expected response code %d, got %d", tt.wantRcode, rcode)
+			}
+
+			if len(addrs) != len(tt.wantAddrs) {
+				t.Fatalf("Expected %d answers, got %v", len(tt.wantAddrs), addrs)
+			}
+
+			for j, addr := range addrs {
+				if !addr.Equal(net.ParseIP(tt.wantAddrs[j])) {
+					t.Errorf("Expected answer %s, got %s", tt.wantAddrs[j], addr)
+				}
+			}
+		})
+	}
+}
+
+func TestServerIPv6Only(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := NewServer(WithUpstreams())
+	if err != nil {
+		t.Fatal("NewServer:", err)
+	}
+
+	if err := server.SetNetwork(&networkv1alpha1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft6"},
+		Spec: networkv1alpha1.NetworkSpec{
+			IPv6: "fd00:6::1/64",
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{
+				{
+					Spec: networkv1alpha1.NetworkInterfaceSpec{
+						CIDR6:    "fd00:6::2/64",
+						Hostname: "db",
+					},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal("SetNetwork:", err)
+	}
+
+	if len(server.addrs) != 1 || !server.addrs[0].Equal(net.ParseIP("fd00:6::1")) {
+		t.Errorf("Expected to listen on fd00:6::1, got %v", server.addrs)
+	}
+
+	query := testQuery(1, "db", TypeAAAA)
+	if rcode, addrs := answers(t, server.handle(ctx, query), query); rcode != RcodeSuccess || len(addrs) != 1 || !addrs[0].Equal(net.ParseIP("fd00:6::2")) {
+		t.Errorf("Unexpected answer: %d %v", rcode, addrs)
+	}
+
+	// Without upstream name servers, names outside of the network fail.
+	query = testQuery(2, "unikraft.org", TypeAAAA)
+	if rcode, _ := answers(t, server.handle(ctx, query), query[:headerLen]); rcode != RcodeServerFailure {
+		t.Errorf("Expected response code %d, got %d", RcodeServerFailure, rcode)
+	}
+
+	if err := server.SetNetwork(&networkv1alpha1.Network{}); err == nil {
+		t.Error("Expected error for network without gateway")
+	}
+}
+
+func TestUpstreams(t *testing.T) {
+	resolvConf := filepath.Join(t.TempDir(), "resolv.conf")
+	if err := os.WriteFile(resolvConf, []byte("# comment\nnameserver 10.0.0.53\nsearch example.com\nnameserver fe80::1%eth0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	upstreams, err := Upstreams(resolvConf)
+	if err != nil {
+		t.Fatal("Upstreams:", err)
+	}
+
+	if len(upstreams) != 2 || upstreams[0] != "10.0.0.53:53" || upstreams[1] != "[fe80::1]:53" {
+		t.Errorf("Unexpected upstreams: %v", upstreams)
+	}
+}
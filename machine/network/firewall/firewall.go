@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package firewall configures the host firewall for host-side networks (e.g.
+// bridges) such that, similar to the bridge driver of Docker, traffic leaving
+// a network is masqueraded behind the IPv4 and IPv6 addresses of the host and
+// separate networks are isolated from each other.  The rules are installed via
+// nftables and are identified by the network they belong to, such that they
+// can be removed again without affecting any other network.  Chains of other
+// tables which drop forwarded packets by default, e.g. those of iptables on
+// hosts running Docker, are left untouched and are warned about instead.
+package firewall
+
+import (
+	"fmt"
+	"net"
+)
+
+// TableName is the name of the table which holds all rules of networks
+// managed by KraftKit.
+const TableName = "kraftkit-network"
+
+// Subnet returns the IPv4 subnet of a network with the provided gateway
+// address and netmask.
+func Subnet(gateway, netmask string) (*net.IPNet, error) {
+	ip := net.ParseIP(gateway).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IPv4 gateway: %s", gateway)
+	}
+
+	mask := net.ParseIP(netmask).To4()
+	if mask == nil {
+		return nil, fmt.Errorf("invalid IPv4 netmask: %s", netmask)
+	}
+
+	if ones, bits := net.IPMask(mask).Size(); ones == 0 && bits == 0 {
+		return nil, fmt.Errorf("non-canonical netmask: %s", netmask)
+	}
+
+	return &net.IPNet{
+		IP:   ip.Mask(net.IPMask(mask)),
+		Mask: net.IPMask(mask),
+	}, nil
+}
@@ -0,0 +1,381 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+
+	"kraftkit.sh/log"
+)
+
+// chains is the set of chains and sets within the KraftKit network table.
+type chains struct {
+	table       *nftables.Table
+	forward     *nftables.Chain
+	postrouting *nftables.Chain
+
+	// bridges holds the names of the host-side interfaces of all isolated
+	// networks.
+	bridges *nftables.Set
+}
+
+// all returns each chain in the set.
+func (c chains) all() []*nftables.Chain {
+	return []*nftables.Chain{c.forward, c.postrouting}
+}
+
+// newChains returns the definitions of the table, chains and sets used for
+// the rules of networks.
+func newChains() chains {
+	table := &nftables.Table{
+		Family: nftables.TableFamilyINet,
+		Name:   TableName,
+	}
+
+	return chains{
+		table: table,
+		forward: &nftables.Chain{
+			Name:     "forward",
+			Table:    table,
+			Type:     nftables.ChainTypeFilter,
+			Hooknum:  nftables.ChainHookForward,
+			Priority: nftables.ChainPriorityFilter,
+		},
+		postrouting: &nftables.Chain{
+			Name:     "postrouting",
+			Table:    table,
+			Type:     nftables.ChainTypeNAT,
+			Hooknum:  nftables.ChainHookPostrouting,
+			Priority: nftables.ChainPriorityNATSource,
+		},
+		bridges: &nftables.Set{
+			Table:   table,
+			Name:    "bridges",
+			KeyType: nftables.TypeIFName,
+		},
+	}
+}
+
+// Setup installs the rules of the network identified by id whose host-side
+// interface is ifname.  Traffic between the network and any other isolated
+// network is dropped and, if masquerade is set, traffic from the IPv4 and IPv6
+// subnets of the network which leaves the host is masqueraded.  Any rules previously
+// installed for the same network are replaced, such that it is safe to call
+// Setup again, e.g. after a restart of the host.
+func Setup(ctx context.Context, id, ifname string, subnets []*net.IPNet, masquerade bool) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("could not connect to nftables: %w", err)
+	}
+
+	c := newChains()
+	conn.AddTable(c.table)
+	for _, chain := range c.all() {
+		conn.AddChain(chain)
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not create nftables table '%s': %w", TableName, err)
+	}
+
+	if set, err := conn.GetSetByName(c.table, c.bridges.Name); err == nil {
+		c.bridges = set
+	} else if err := conn.AddSet(c.bridges, nil); err != nil {
+		return fmt.Errorf("could not create nftables set '%s': %w", c.bridges.Name, err)
+	}
+
+	if err := deleteRules(conn, c, id); err != nil {
+		return err
+	}
+
+	log.G(ctx).
+		WithField("network", id).
+		WithField("ifname", ifname).
+		WithField("masquerade", masquerade).
+		Debug("installing network rules")
+
+	if err := conn.SetAddElements(c.bridges, []nftables.SetElement{{Key: ifnameKey(ifname)}}); err != nil {
+		return fmt.Errorf("could not add %s to nftables set '%s': %w", ifname, c.bridges.Name, err)
+	}
+
+	conn.AddRule(&nftables.Rule{
+		Table:    c.table,
+		Chain:    c.forward,
+		Exprs:    isolateExprs(ifname, c.bridges),
+		UserData: []byte(id),
+	})
+
+	if masquerade {
+		for _, subnet := range subnets {
+			conn.AddRule(&nftables.Rule{
+				Table:    c.table,
+				Chain:    c.postrouting,
+				Exprs:    masqueradeExprs(ifname, subnet),
+				UserData: []byte(id),
+			})
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not add network rules: %w", err)
+	}
+
+	// Packets accepted above are still subject to the chains of other tables
+	// hooked into forwarding, e.g. those of iptables which Docker sets to drop
+	// forwarded packets by default, where the network must be accepted as well.
+	if all, err := conn.ListChains(); err != nil {
+		log.G(ctx).Debugf("could not list nftables chains: %v", err)
+	} else {
+		for _, chain := range droppingForwardChains(all) {
+			hint := ""
+			switch chain.Table.Family {
+			case nftables.TableFamilyIPv4:
+				hint = fmt.Sprintf(", e.g. via 'iptables -I %s -i %s -j ACCEPT' and 'iptables -I %s -o %s -j ACCEPT'", chain.Name, ifname, chain.Name, ifname)
+			case nftables.TableFamilyIPv6:
+				hint = fmt.Sprintf(", e.g. via 'ip6tables -I %s -i %s -j ACCEPT' and 'ip6tables -I %s -o %s -j ACCEPT'", chain.Name, ifname, chain.Name, ifname)
+			}
+
+			log.G(ctx).Warnf("chain '%s' of table '%s' drops forwarded packets by default: accept the traffic of %s there%s", chain.Name, chain.Table.Name, ifname, hint)
+		}
+	}
+
+	if masquerade {
+		// Masquerading is pointless unless the host routes the traffic of the
+		// network in the first place.
+		for _, subnet := range subnets {
+			sysctl, family := "/proc/sys/net/ipv4/ip_forward", "IPv4"
+			if subnet.IP.To4() == nil {
+				sysctl, family = "/proc/sys/net/ipv6/conf/all/forwarding", "IPv6"
+			}
+
+			if err := os.WriteFile(sysctl, []byte("1"), 0o644); err != nil {
+				return fmt.Errorf("could not enable %s forwarding: %w", family, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Teardown removes all rules of the network identified by id whose host-side
+// interface is ifname.  The table is removed once no network has any rules
+// left.  It is not an error if no rules exist for the network.
+func Teardown(ctx context.Context, id, ifname string) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("could not connect to nftables: %w", err)
+	}
+
+	tables, err := conn.ListTablesOfFamily(nftables.TableFamilyINet)
+	if err != nil {
+		return fmt.Errorf("could not list nftables tables: %w", err)
+	}
+
+	found := false
+	for _, table := range tables {
+		if table.Name == TableName {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	log.G(ctx).
+		WithField("network", id).
+		WithField("ifname", ifname).
+		Debug("removing network rules")
+
+	c := newChains()
+
+	if err := deleteRules(conn, c, id); err != nil {
+		return err
+	}
+
+	if set, err := conn.GetSetByName(c.table, c.bridges.Name); err == nil {
+		if err := conn.SetDeleteElements(set, []nftables.SetElement{{Key: ifnameKey(ifname)}}); err != nil {
+			return fmt.Errorf("could not remove %s from nftables set '%s': %w", ifname, set.Name, err)
+		}
+
+		// The element may have already been removed, in which case the kernel
+		// rejects the batch.  This is of no concern during teardown.
+		_ = conn.Flush()
+	}
+
+	for _, chain := range c.all() {
+		rules, err := conn.GetRules(c.table, chain)
+		if err != nil {
+			return fmt.Errorf("could not list rules of chain '%s': %w", chain.Name, err)
+		}
+
+		if len(rules) > 0 {
+			return nil
+		}
+	}
+
+	conn.DelTable(c.table)
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not remove nftables table '%s': %w", TableName, err)
+	}
+
+	return nil
+}
+
+// deleteRules removes all rules from the supplied chains which belong to the
+// network identified by id.
+func deleteRules(conn *nftables.Conn, c chains, id string) error {
+	for _, chain := range c.all() {
+		rules, err := conn.GetRules(c.table, chain)
+		if err != nil {
+			return fmt.Errorf("could not list rules of chain '%s': %w", chain.Name, err)
+		}
+
+		for _, rule := range rules {
+			if !bytes.Equal(rule.UserData, []byte(id)) {
+				continue
+			}
+
+			if err := conn.DelRule(rule); err != nil {
+				return fmt.Errorf("could not delete rule: %w", err)
+			}
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not remove network rules: %w", err)
+	}
+
+	return nil
+}
+
+// droppingForwardChains returns the chains of the provided chains which hook
+// into forwarding with a policy of dropping packets, except for those of
+// KraftKit itself.
+func droppingForwardChains(all []*nftables.Chain) []*nftables.Chain {
+	var ret []*nftables.Chain
+
+	for _, chain := range all {
+		if chain.Table == nil || chain.Table.Name == TableName {
+			continue
+		} else if chain.Hooknum == nil || *chain.Hooknum != *nftables.ChainHookForward {
+			continue
+		} else if chain.Policy == nil || *chain.Policy != nftables.ChainPolicyDrop {
+			continue
+		}
+
+		ret = append(ret, chain)
+	}
+
+	return ret
+}
+
+// ifnameKey returns the representation of the provided interface name as
+// used by nftables, i.e. padded with zeroes to IFNAMSIZ bytes.
+func ifnameKey(ifname string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, ifname+"\x00")
+	return b
+}
+
+// isolateExprs returns the expressions equivalent to:
+//
+//	iifname <ifname> oifname @<bridges> oifname != <ifname> drop
+func isolateExprs(ifname string, bridges *nftables.Set) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{
+			Key:      expr.MetaKeyIIFNAME,
+			Register: 1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     ifnameKey(ifname),
+		},
+		&expr.Meta{
+			Key:      expr.MetaKeyOIFNAME,
+			Register: 1,
+		},
+		&expr.Lookup{
+			SourceRegister: 1,
+			SetName:        bridges.Name,
+			SetID:          bridges.ID,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpNeq,
+			Register: 1,
+			Data:     ifnameKey(ifname),
+		},
+		&expr.Verdict{
+			Kind: expr.VerdictDrop,
+		},
+	}
+}
+
+// masqueradeExprs returns the expressions equivalent to either of:
+//
+//	meta nfproto ipv4 ip saddr <subnet> oifname != <ifname> masquerade
+//	meta nfproto ipv6 ip6 saddr <subnet> oifname != <ifname> masquerade
+func masqueradeExprs(ifname string, subnet *net.IPNet) []expr.Any {
+	nfproto, offset, ip := byte(unix.NFPROTO_IPV4), uint32(12), subnet.IP.To4()
+	if ip == nil {
+		nfproto, offset, ip = unix.NFPROTO_IPV6, 8, subnet.IP.To16()
+	}
+
+	mask := subnet.Mask
+	if len(mask) > len(ip) {
+		mask = mask[len(mask)-len(ip):]
+	}
+
+	return []expr.Any{
+		&expr.Meta{
+			Key:      expr.MetaKeyNFPROTO,
+			Register: 1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     []byte{nfproto},
+		},
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       offset,
+			Len:          uint32(len(ip)),
+		},
+		&expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            uint32(len(ip)),
+			Mask:           mask,
+			Xor:            make([]byte, len(ip)),
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     ip,
+		},
+		&expr.Meta{
+			Key:      expr.MetaKeyOIFNAME,
+			Register: 1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpNeq,
+			Register: 1,
+			Data:     ifnameKey(ifname),
+		},
+		&expr.Masq{},
+	}
+}
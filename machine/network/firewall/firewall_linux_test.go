@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firewall
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+func TestIfnameKey(t *testing.T) {
+	key := ifnameKey("kraft0")
+	if len(key) != unix.IFNAMSIZ {
+		t.Fatalf("Expected key of %d bytes, got %d", unix.IFNAMSIZ, len(key))
+	}
+
+	if !bytes.Equal(key, append([]byte("kraft0"), make([]byte, unix.IFNAMSIZ-len("kraft0"))...)) {
+		t.Errorf("Expected zero-padded interface name, got %q", key)
+	}
+}
+
+func TestIsolateExprs(t *testing.T) {
+	bridges := &nftables.Set{Name: "bridges", ID: 42}
+
+	exprs := isolateExprs("kraft0", bridges)
+
+	expect := []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifnameKey("kraft0")},
+		&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+		&expr.Lookup{SourceRegister: 1, SetName: "bridges", SetID: 42},
+		&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: ifnameKey("kraft0")},
+		&expr.Verdict{Kind: expr.VerdictDrop},
+	}
+
+	if !reflect.DeepEqual(exprs, expect) {
+		t.Errorf("Expected %#v, got %#v", expect, exprs)
+	}
+}
+
+func TestMasqueradeExprs(t *testing.T) {
+	tests := []struct {
+		name    string
+		subnet  string
+		nfproto byte
+		offset  uint32
+		ip      []byte
+		mask    []byte
+	}{
+		{
+			name:    "IPv4",
+			subnet:  "172.44.0.0/24",
+			nfproto: unix.NFPROTO_IPV4,
+			offset:  12,
+			ip:      []byte{172, 44, 0, 0},
+			mask:    []byte{255, 255, 255, 0},
+		},
+		{
+			name:    "IPv6",
+			subnet:  "fd00:44::/64",
+			nfproto: unix.NFPROTO_IPV6,
+			offset:  8,
+			ip:      net.ParseIP("fd00:44::").To16(),
+			mask:    net.CIDRMask(64, 128),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, subnet, err := net.ParseCIDR(tt.subnet)
+			if err != nil {
+				t.Fatal("ParseCIDR:", err)
+			}
+
+			exprs := masqueradeExprs("kraft0", subnet)
+
+			expect := []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{tt.nfproto}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: tt.offset, Len: uint32(len(tt.ip))},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: uint32(len(tt.ip)), Mask: tt.mask, Xor: make([]byte, len(tt.ip))},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: tt.ip},
+				&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: ifnameKey("kraft0")},
+				&expr.Masq{},
+			}
+
+			if !reflect.DeepEqual(exprs, expect) {
+				t.Errorf("Expected %#v, got %#v", expect, exprs)
+			}
+		})
+	}
+
+	// The IPv4 subnet of a network is represented with 16 byte addresses and
+	// masks when derived from its gateway and netmask.
+	subnet := &net.IPNet{
+		IP:   net.ParseIP("172.44.0.0"),
+		Mask: net.IPMask(net.ParseIP("255.255.255.0")),
+	}
+
+	bitwise, ok := masqueradeExprs("kraft0", subnet)[3].(*expr.Bitwise)
+	if !ok {
+		t.Fatal("Expected bitwise expression")
+	} else if !bytes.Equal(bitwise.Mask, []byte{255, 255, 255, 0}) {
+		t.Errorf("Expected 4 byte mask, got %v", bitwise.Mask)
+	}
+}
+
+func TestDroppingForwardChains(t *testing.T) {
+	drop, accept := nftables.ChainPolicyDrop, nftables.ChainPolicyAccept
+
+	iptables := &nftables.Table{Family: nftables.TableFamilyIPv4, Name: "filter"}
+	kraftkit := &nftables.Table{Family: nftables.TableFamilyINet, Name: TableName}
+
+	dockerForward := &nftables.Chain{Table: iptables, Name: "FORWARD", Hooknum: nftables.ChainHookForward, Policy: &drop}
+
+	chains := []*nftables.Chain{
+		dockerForward,
+		{Table: iptables, Name: "INPUT", Hooknum: nftables.ChainHookInput, Policy: &drop},
+		{Table: iptables, Name: "DOCKER-USER"},
+		{Table: &nftables.Table{Family: nftables.TableFamilyIPv6, Name: "filter"}, Name: "FORWARD", Hooknum: nftables.ChainHookForward, Policy: &accept},
+		{Table: kraftkit, Name: "forward", Hooknum: nftables.ChainHookForward, Policy: &drop},
+	}
+
+	got := droppingForwardChains(chains)
+	if len(got) != 1 || got[0] != dockerForward {
+		t.Errorf("Expected only the FORWARD chain of iptables, got %v", got)
+	}
+}
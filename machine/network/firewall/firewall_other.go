@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firewall
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Setup installs the rules of the network identified by id.  It is only
+// supported on Linux.
+func Setup(ctx context.Context, id, ifname string, subnets []*net.IPNet, masquerade bool) error {
+	return errors.New("configuring the host firewall is only supported on Linux")
+}
+
+// Teardown removes all rules of the network identified by id.
+func Teardown(ctx context.Context, id, ifname string) error {
+	return nil
+}
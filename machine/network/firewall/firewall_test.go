@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firewall
+
+import (
+	"testing"
+)
+
+func TestSubnet(t *testing.T) {
+	subnet, err := Subnet("172.44.0.1", "255.255.255.0")
+	if err != nil {
+		t.Fatal("Subnet:", err)
+	}
+	if subnet.String() != "172.44.0.0/24" {
+		t.Errorf("Expected subnet 172.44.0.0/24, got %s", subnet)
+	}
+
+	for _, tc := range []struct{ gateway, netmask string }{
+		{"", "255.255.255.0"},
+		{"fd00::1", "255.255.255.0"},
+		{"172.44.0.1", "255.0.255.0"},
+	} {
+		if _, err := Subnet(tc.gateway, tc.netmask); err == nil {
+			t.Errorf("Expected error for %s/%s", tc.gateway, tc.netmask)
+		}
+	}
+}
@@ -46,3 +46,45 @@ func IsUnicastIP(ip net.IP, mask net.IPMask) bool {
 	// global unicast
 	return ip.IsGlobalUnicast()
 }
+
+// EUI64 returns the address within the provided /64 prefix whose interface
+// identifier is derived from the provided hardware address as per RFC 4291,
+// which is the address a host configures via stateless address
+// autoconfiguration (SLAAC).
+func EUI64(prefix *net.IPNet, mac net.HardwareAddr) net.IP {
+	if ones, bits := prefix.Mask.Size(); ones != 64 || bits != 128 || len(mac) != 6 {
+		return nil
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix.IP.To16()[:8])
+	ip[8] = mac[0] ^ 0x02
+	ip[9] = mac[1]
+	ip[10] = mac[2]
+	ip[11] = 0xff
+	ip[12] = 0xfe
+	ip[13] = mac[3]
+	ip[14] = mac[4]
+	ip[15] = mac[5]
+
+	return ip
+}
+
+// EUI64HardwareAddr returns the hardware address from which the interface
+// identifier of the provided IPv6 address was derived as per RFC 4291, i.e. the
+// hardware address which yields the provided address within its /64 prefix via
+// EUI64.  If the interface identifier was not derived from a unicast hardware
+// address, nil is returned.
+func EUI64HardwareAddr(ip net.IP) net.HardwareAddr {
+	ip = ip.To16()
+	if ip == nil || ip.To4() != nil || ip[11] != 0xff || ip[12] != 0xfe {
+		return nil
+	}
+
+	mac := net.HardwareAddr{ip[8] ^ 0x02, ip[9], ip[10], ip[13], ip[14], ip[15]}
+	if mac[0]&0x01 != 0 {
+		return nil
+	}
+
+	return mac
+}
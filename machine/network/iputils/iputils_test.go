@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package iputils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEUI64HardwareAddr(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("fd00:44::/64")
+	if err != nil {
+		t.Fatal("ParseCIDR:", err)
+	}
+
+	mac, err := net.ParseMAC("52:54:00:12:34:56")
+	if err != nil {
+		t.Fatal("ParseMAC:", err)
+	}
+
+	ip := EUI64(subnet, mac)
+	if expect := net.ParseIP("fd00:44::5054:ff:fe12:3456"); !ip.Equal(expect) {
+		t.Fatalf("EUI64 = %s, expected %s", ip, expect)
+	}
+
+	if got := EUI64HardwareAddr(ip); got.String() != mac.String() {
+		t.Errorf("EUI64HardwareAddr(%s) = %s, expected %s", ip, got, mac)
+	}
+
+	for _, addr := range []string{
+		"fd00:44::10",                // not derived from a hardware address
+		"fd00:44::5154:ff:fe12:3456", // derived from a multicast address
+		"172.44.0.2",
+	} {
+		if got := EUI64HardwareAddr(net.ParseIP(addr)); got != nil {
+			t.Errorf("EUI64HardwareAddr(%s) = %s, expected none", addr, got)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	zip "api.zip"
 	"github.com/acorn-io/baaah/pkg/merr"
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/daemon/client"
 )
 
 type networkV1alpha1ServiceIterator struct {
@@ -23,6 +24,11 @@ type networkV1alpha1ServiceIterator struct {
 // useful in circumstances where the driver is not supplied.  The first network
 // driver to succeed is returned in all circumstances.
 func NewNetworkV1alpha1ServiceIterator(ctx context.Context) (networkv1alpha1.NetworkService, error) {
+	// The daemon iterates over its own supported strategies.
+	if client.Available(ctx) {
+		return client.NewNetworkV1alpha1Service(ctx, "")
+	}
+
 	var err error
 	iterator := networkV1alpha1ServiceIterator{
 		strategies: map[string]networkv1alpha1.NetworkService{},
@@ -157,3 +163,20 @@ func (iterator *networkV1alpha1ServiceIterator) List(ctx context.Context, cached
 
 	return cached, nil
 }
+
+// Watch implements kraftkit.sh/api/network/v1alpha1.Watch
+func (iterator *networkV1alpha1ServiceIterator) Watch(ctx context.Context, network *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
+	var errs []error
+
+	for _, strategy := range iterator.strategies {
+		eventChan, errChan, err := strategy.Watch(ctx, network)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return eventChan, errChan, nil
+	}
+
+	return nil, nil, fmt.Errorf("all iterated drivers failed: %w", merr.NewErrors(errs...))
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package linkwatch implements the watching of networks for the network
+// drivers by subscribing to the changes of the links of the host, such that
+// changes of the state of a network are observed as they happen instead of
+// by polling it.
+package linkwatch
+
+import (
+	"context"
+	"maps"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// Update describes a change of a link of the host.
+type Update struct {
+	// Index of the link.
+	Index int
+
+	// MasterIndex is the index of the link's master, e.g. the bridge it is
+	// attached to, or zero.
+	MasterIndex int
+
+	// Name of the link.
+	Name string
+
+	// Deleted indicates that the link has been removed.
+	Deleted bool
+}
+
+// GetFunc returns the current representation of the provided network.
+type GetFunc func(context.Context, *networkv1alpha1.Network) (*networkv1alpha1.Network, error)
+
+// FilterFunc indicates whether the provided update could affect the state of
+// the watched network.  It is always called from the same goroutine, such that
+// it can safely track links across updates.
+type FilterFunc func(Update) bool
+
+// changed indicates whether the state of the network differs between the
+// provided representations, disregarding its statistics which change
+// constantly.
+func changed(prev, next *networkv1alpha1.Network) bool {
+	return prev.Status.State != next.Status.State ||
+		prev.Status.Carrier != next.Status.Carrier ||
+		!maps.Equal(prev.Status.Interfaces, next.Status.Interfaces)
+}
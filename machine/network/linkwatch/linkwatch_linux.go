@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package linkwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// Watch emits the provided network as returned by get, starting with its
+// current state and subsequently whenever its state changes.  The network is
+// only re-evaluated upon updates of links which pass the provided filter.  The
+// stream ends with an error once the network can no longer be retrieved, e.g.
+// because its interface has been removed, or once the context is cancelled.
+func Watch(ctx context.Context, network *networkv1alpha1.Network, get GetFunc, filter FilterFunc) (chan *networkv1alpha1.Network, chan error, error) {
+	// Get populates the network it is provided with, so each call receives a
+	// copy of the original network.
+	copied := *network
+
+	current, err := get(ctx, &copied)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := make(chan netlink.LinkUpdate)
+	done := make(chan struct{})
+
+	if err := netlink.LinkSubscribeWithOptions(updates, done, netlink.LinkSubscribeOptions{
+		ErrorCallback: func(err error) {
+			log.G(ctx).Debugf("link subscription: %v", err)
+		},
+	}); err != nil {
+		return nil, nil, fmt.Errorf("could not subscribe to link updates: %w", err)
+	}
+
+	events := make(chan *networkv1alpha1.Network)
+	errs := make(chan error)
+
+	go func() {
+		defer func() {
+			close(done)
+
+			// Unblock the subscription until it has noticed that it is done.
+			for range updates {
+			}
+		}()
+
+		// Initialize the channel with the current state of the network, so that it
+		// can be immediately acted upon.
+		select {
+		case events <- current:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			var update netlink.LinkUpdate
+			var ok bool
+
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok = <-updates:
+			}
+
+			if !ok {
+				sendErr(ctx, errs, errors.New("link subscription closed unexpectedly"))
+				return
+			}
+
+			if !filter(Update{
+				Index:       update.Attrs().Index,
+				MasterIndex: update.Attrs().MasterIndex,
+				Name:        update.Attrs().Name,
+				Deleted:     update.Header.Type == unix.RTM_DELLINK,
+			}) {
+				continue
+			}
+
+			copied := *network
+
+			next, err := get(ctx, &copied)
+			if err != nil {
+				sendErr(ctx, errs, err)
+				return
+			}
+
+			if !changed(current, next) {
+				continue
+			}
+
+			current = next
+
+			select {
+			case events <- current:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
+
+// sendErr sends the provided error unless the context is cancelled before it
+// is received.
+func sendErr(ctx context.Context, errs chan error, err error) {
+	select {
+	case errs <- err:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package linkwatch
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestWatch(t *testing.T) {
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "kwatch0"}}
+	if err := netlink.LinkAdd(bridge); err != nil {
+		t.Skipf("cannot create bridge: %v", err)
+	}
+
+	defer netlink.LinkDel(bridge)
+
+	get := func(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+		link, err := netlink.LinkByName(network.Spec.IfName)
+		if err != nil {
+			return nil, err
+		}
+
+		if link.Attrs().Flags&net.FlagUp != 0 {
+			network.Status.State = networkv1alpha1.NetworkStateUp
+		} else {
+			network.Status.State = networkv1alpha1.NetworkStateDown
+		}
+
+		return network, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	network := &networkv1alpha1.Network{
+		Spec: networkv1alpha1.NetworkSpec{
+			IfName: bridge.Name,
+		},
+	}
+
+	events, errs, err := Watch(ctx, network, get, func(update Update) bool {
+		return update.Name == bridge.Name
+	})
+	if err != nil {
+		t.Fatal("Watch:", err)
+	}
+
+	next := func() *networkv1alpha1.Network {
+		select {
+		case network := <-events:
+			return network
+		case err := <-errs:
+			t.Fatal("Watch:", err)
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for event")
+		}
+
+		return nil
+	}
+
+	if state := next().Status.State; state != networkv1alpha1.NetworkStateDown {
+		t.Errorf("Expected initial state %s, got %s", networkv1alpha1.NetworkStateDown, state)
+	}
+
+	if err := netlink.LinkSetUp(bridge); err != nil {
+		t.Fatal("LinkSetUp:", err)
+	}
+
+	if state := next().Status.State; state != networkv1alpha1.NetworkStateUp {
+		t.Errorf("Expected state %s, got %s", networkv1alpha1.NetworkStateUp, state)
+	}
+
+	if network.Status.State != "" {
+		t.Errorf("Expected the provided network to be left untouched")
+	}
+
+	// The stream ends with an error once the network is gone.
+	if err := netlink.LinkDel(bridge); err != nil {
+		t.Fatal("LinkDel:", err)
+	}
+
+	select {
+	case <-events:
+		t.Error("Expected no event after deletion")
+	case err := <-errs:
+		if err == nil {
+			t.Error("Expected an error after deletion")
+		}
+	case <-ctx.Done():
+		t.Error("Timed out waiting for error")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package linkwatch
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// Watch emits the provided network as returned by get, starting with its
+// current state and subsequently whenever its state changes.
+func Watch(ctx context.Context, network *networkv1alpha1.Network, get GetFunc, filter FilterFunc) (chan *networkv1alpha1.Network, chan error, error) {
+	return nil, nil, fmt.Errorf("watching networks is not supported on %s", runtime.GOOS)
+}
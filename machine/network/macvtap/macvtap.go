@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package macvtap implements a network driver which attaches machines directly
+// to the layer 2 segment of a parent interface of the host without a bridge.
+// Each network interface is a macvtap device on top of the parent interface,
+// such that machines appear as first-class hosts on the parent's network and
+// are addressed by its infrastructure, e.g. its DHCP server.
+//
+// Due to the nature of macvtap devices, the host itself cannot reach machines
+// on such a network via the parent interface.
+package macvtap
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// DriverName is the name of the driver as set in the spec of its networks.
+const DriverName = "macvtap"
+
+// OpenDevice opens the character device of the macvtap interface with the
+// provided name.  Unlike tap interfaces, macvtap interfaces cannot be opened
+// by name, so a hypervisor must be handed the opened device instead.
+func OpenDevice(ifname string) (*os.File, error) {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, fmt.Errorf("could not get interface %s: %w", ifname, err)
+	}
+
+	return os.OpenFile(fmt.Sprintf("/dev/tap%d", iface.Index), os.O_RDWR, 0)
+}
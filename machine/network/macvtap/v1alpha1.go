@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package macvtap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/machine/network/linkwatch"
+	"kraftkit.sh/machine/network/macaddr"
+)
+
+type v1alpha1Network struct{}
+
+func NewNetworkServiceV1alpha1(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
+	return &v1alpha1Network{}, nil
+}
+
+// Create implements kraftkit.sh/api/network/v1alpha1.Create
+func (service *v1alpha1Network) Create(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	if network.Name == "" {
+		return nil, fmt.Errorf("cannot create network without name")
+	}
+
+	if network.ObjectMeta.UID != "" {
+		return network, fmt.Errorf("network already exists: %s", network.Name)
+	}
+
+	// Validate the options.
+	if network.Spec.Parent == "" {
+		return nil, fmt.Errorf("parent interface cannot be empty")
+	}
+
+	// The gateway of the network belongs to the parent's network and not to the
+	// host, so none of the services of the host can be provided on it.
+	if network.Spec.DHCP {
+		return nil, fmt.Errorf("DHCP is not supported by the macvtap driver")
+	}
+	if network.Spec.IPv6 != "" {
+		return nil, fmt.Errorf("IPv6 router advertisements are not supported by the macvtap driver")
+	}
+
+	if (network.Spec.Gateway == "") != (network.Spec.Netmask == "") {
+		return nil, fmt.Errorf("gateway and netmask must be set together")
+	}
+
+	parent, err := netlink.LinkByName(network.Spec.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("could not get parent interface %s: %v", network.Spec.Parent, err)
+	}
+
+	if _, ok := parent.(*netlink.Bridge); ok {
+		return nil, fmt.Errorf("parent interface %s is a bridge, use the bridge driver instead", network.Spec.Parent)
+	}
+
+	network.ObjectMeta.UID = uuid.NewUUID()
+	network.Spec.Driver = DriverName
+	network.CreationTimestamp = metav1.Now()
+
+	setState(network, parent)
+
+	return service.Update(ctx, network)
+}
+
+// Start implements kraftkit.sh/api/network/v1alpha1.Start
+func (service *v1alpha1Network) Start(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	parent, err := parentOf(network)
+	if err != nil {
+		return network, err
+	}
+
+	for _, iface := range network.Spec.Interfaces {
+		link, err := netlink.LinkByName(iface.Spec.IfName)
+		if err != nil {
+			return network, fmt.Errorf("getting link %s failed: %v", iface.Spec.IfName, err)
+		}
+
+		if err := netlink.LinkSetUp(link); err != nil {
+			return network, fmt.Errorf("could not bring %s link up: %v", iface.Spec.IfName, err)
+		}
+	}
+
+	setState(network, parent)
+
+	return network, nil
+}
+
+// Stop implements kraftkit.sh/api/network/v1alpha1.Stop
+func (service *v1alpha1Network) Stop(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	if _, err := parentOf(network); err != nil {
+		return network, err
+	}
+
+	// The parent interface is not owned by the network, so only its own
+	// interfaces are brought down.
+	for _, iface := range network.Spec.Interfaces {
+		link, err := netlink.LinkByName(iface.Spec.IfName)
+		if err != nil {
+			return network, fmt.Errorf("getting link %s failed: %v", iface.Spec.IfName, err)
+		}
+
+		if err := netlink.LinkSetDown(link); err != nil {
+			return network, fmt.Errorf("could not bring %s link down: %v", iface.Spec.IfName, err)
+		}
+	}
+
+	network.Status.State = networkv1alpha1.NetworkStateDown
+
+	return network, nil
+}
+
+// Update implements kraftkit.sh/api/network/v1alpha1.Update.  Interfaces
+// without an address are left unaddressed, such that machines can acquire one
+// from the parent's network, e.g. via its DHCP server.
+func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	parent, err := parentOf(network)
+	if err != nil {
+		return network, err
+	}
+
+	// Populate a hashmap of link aliases that allow us to quickly reference later
+	// on when we're clearing up unused interfaces.
+	inuse := make(map[string]bool)
+
+	for i, iface := range network.Spec.Interfaces {
+		if iface.ObjectMeta.UID == "" {
+			iface.ObjectMeta.UID = uuid.NewUUID()
+		}
+
+		if iface.ObjectMeta.CreationTimestamp == *new(metav1.Time) {
+			iface.ObjectMeta.CreationTimestamp = metav1.Now()
+		}
+
+		if iface.Spec.IfName == "" {
+			iface.Spec.IfName, err = ifname(network)
+			if err != nil {
+				return network, err
+			}
+		}
+
+		// Machines are hosts on the parent's network, so each of them is given
+		// an individual MAC address rather than one of a sequence.
+		if iface.Spec.MacAddress == "" {
+			mac, err := macaddr.GenerateMacAddress(false)
+			if err != nil {
+				return network, fmt.Errorf("could not generate MAC address: %v", err)
+			}
+
+			iface.Spec.MacAddress = mac.String()
+		}
+
+		mac, err := net.ParseMAC(iface.Spec.MacAddress)
+		if err != nil {
+			return network, fmt.Errorf("could not parse MAC address of %s: %v", iface.Spec.IfName, err)
+		}
+
+		var link netlink.Link
+		if existing, err := netlink.LinkByName(iface.Spec.IfName); err == nil {
+			link = existing
+		} else {
+			macvtap := &netlink.Macvtap{
+				Macvlan: netlink.Macvlan{
+					LinkAttrs: netlink.NewLinkAttrs(),
+					// Allow machines on the same parent to reach each other.
+					Mode: netlink.MACVLAN_MODE_BRIDGE,
+				},
+			}
+			macvtap.Name = iface.Spec.IfName
+			macvtap.ParentIndex = parent.Attrs().Index
+			macvtap.HardwareAddr = mac
+
+			if err := netlink.LinkAdd(macvtap); err != nil {
+				return network, fmt.Errorf("could not create %s link: %v", iface.Spec.IfName, err)
+			}
+
+			link = macvtap
+		}
+
+		// Set the alias such that it can be referenced later as the unique
+		// combination of the network and this interface.
+		alias := fmt.Sprintf("%s:%s", network.ObjectMeta.UID, iface.ObjectMeta.UID)
+		if err := netlink.LinkSetAlias(link, alias); err != nil {
+			return network, fmt.Errorf("could not set link alias: %v", err)
+		}
+
+		if err := netlink.LinkSetUp(link); err != nil {
+			return network, fmt.Errorf("could not bring %s link up: %v", iface.Spec.IfName, err)
+		}
+
+		inuse[alias] = true
+		network.Spec.Interfaces[i] = iface
+	}
+
+	// Clean up any removed interfaces.
+	links, err := netlink.LinkList()
+	if err != nil {
+		return network, fmt.Errorf("could not gather list of existing links: %v", err)
+	}
+
+	for _, link := range links {
+		macvtap, ok := link.(*netlink.Macvtap)
+		if !ok {
+			continue // Skip non-macvtap interfaces
+		}
+
+		if _, ok := inuse[macvtap.Alias]; ok {
+			continue // Skip in-use interfaces
+		}
+
+		if !strings.HasPrefix(macvtap.Alias, string(network.ObjectMeta.UID)+":") {
+			continue
+		}
+
+		if err := netlink.LinkDel(macvtap); err != nil {
+			return network, fmt.Errorf("could not remove %s: %v", macvtap.Name, err)
+		}
+	}
+
+	return network, nil
+}
+
+// Delete implements kraftkit.sh/api/network/v1alpha1.Delete
+func (service *v1alpha1Network) Delete(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	if network.Spec.Driver != DriverName {
+		return network, fmt.Errorf("network %s is not a macvtap network", network.Name)
+	}
+
+	for _, iface := range network.Spec.Interfaces {
+		link, err := netlink.LinkByName(iface.Spec.IfName)
+		if err != nil {
+			return network, fmt.Errorf("could not get %s link: %v", iface.Spec.IfName, err)
+		}
+
+		if err := netlink.LinkDel(link); err != nil {
+			return network, fmt.Errorf("could not delete %s link: %v", iface.Spec.IfName, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// Get implements kraftkit.sh/api/network/v1alpha1.Get
+func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	if network.UID == "" {
+		return nil, fmt.Errorf("no such network: %s", network.Name)
+	}
+
+	parent, err := parentOf(network)
+	if err != nil {
+		return network, err
+	}
+
+	if network.ObjectMeta.CreationTimestamp == *new(metav1.Time) {
+		network.CreationTimestamp = metav1.Now()
+	}
+
+	setState(network, parent)
+
+	return network, nil
+}
+
+// List implements kraftkit.sh/api/network/v1alpha1.List
+func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alpha1.NetworkList) (*networkv1alpha1.NetworkList, error) {
+	var items []networkv1alpha1.Network
+
+	// Unlike bridges, macvtap networks cannot be discovered from the host as
+	// they have no interface of their own, so only known networks are listed.
+	for _, network := range networks.Items {
+		if network.Spec.Driver != DriverName {
+			continue
+		}
+
+		if found, err := service.Get(ctx, &network); err == nil {
+			network = *found
+		} else {
+			network.Status.State = networkv1alpha1.NetworkStateUnknown
+		}
+
+		items = append(items, network)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+	})
+
+	networks.Items = items
+
+	return networks, nil
+}
+
+// Watch implements kraftkit.sh/api/network/v1alpha1.Watch
+func (service *v1alpha1Network) Watch(ctx context.Context, network *networkv1alpha1.Network) (chan *networkv1alpha1.Network, chan error, error) {
+	parent, err := parentOf(network)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := parent.Attrs().Index
+	ifnames := map[string]bool{}
+	for _, iface := range network.Spec.Interfaces {
+		ifnames[iface.Spec.IfName] = true
+	}
+
+	return linkwatch.Watch(ctx, network, service.Get, func(update linkwatch.Update) bool {
+		return update.Index == index || ifnames[update.Name]
+	})
+}
+
+// parentOf returns the parent interface of the provided macvtap network.
+func parentOf(network *networkv1alpha1.Network) (netlink.Link, error) {
+	if network.Spec.Driver != DriverName {
+		return nil, fmt.Errorf("network %s is not a macvtap network", network.Name)
+	}
+
+	parent, err := netlink.LinkByName(network.Spec.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("could not get parent interface %s of %s: %v", network.Spec.Parent, network.Name, err)
+	}
+
+	return parent, nil
+}
+
+// setState sets the state of the provided network from the state of its
+// parent interface, as the network is unusable whenever the parent is down,
+// and the state of each of its interfaces.
+func setState(network *networkv1alpha1.Network, parent netlink.Link) {
+	if parent.Attrs().Flags&net.FlagUp != 0 {
+		network.Status.State = networkv1alpha1.NetworkStateUp
+	} else {
+		network.Status.State = networkv1alpha1.NetworkStateDown
+	}
+
+	network.Status.Carrier = parent.Attrs().OperState == netlink.OperUp
+	network.Status.Interfaces = map[string]networkv1alpha1.NetworkInterfaceState{}
+
+	for _, iface := range network.Spec.Interfaces {
+		link, err := netlink.LinkByName(iface.Spec.IfName)
+		if err != nil || link.Attrs().OperState != netlink.OperUp {
+			network.Status.Interfaces[iface.Spec.IfName] = networkv1alpha1.NetworkInterfaceStateDisconnected
+		} else {
+			network.Status.Interfaces[iface.Spec.IfName] = networkv1alpha1.NetworkInterfaceStateConnected
+		}
+	}
+}
+
+// ifname returns an unused name for a new interface of the provided network.
+func ifname(network *networkv1alpha1.Network) (string, error) {
+	for j := 0; ; j++ {
+		name := fmt.Sprintf("%s@if%d", network.Name, j)
+
+		// Interface names are limited to 15 characters, so fall back to a
+		// shorter name generated from a new hash.
+		if len(name) > 15 {
+			name = fmt.Sprintf("mvt-%s", uuid.NewUUID()[:8])
+		}
+
+		if _, err := netlink.LinkByName(name); err != nil {
+			if _, ok := err.(netlink.LinkNotFoundError); ok {
+				return name, nil
+			}
+
+			return "", err
+		}
+	}
+}
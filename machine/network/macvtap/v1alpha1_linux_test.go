@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package macvtap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestCreateValidation(t *testing.T) {
+	ctx := context.Background()
+	service, _ := NewNetworkServiceV1alpha1(ctx)
+
+	for name, spec := range map[string]networkv1alpha1.NetworkSpec{
+		"no parent": {},
+		"dhcp":      {Parent: "eth0", DHCP: true},
+		"ipv6":      {Parent: "eth0", IPv6: "fd00::1/64"},
+		"netmask":   {Parent: "eth0", Gateway: "192.168.1.1"},
+	} {
+		if _, err := service.Create(ctx, &networkv1alpha1.Network{
+			ObjectMeta: metav1.ObjectMeta{Name: "kraft-mvt"},
+			Spec:       spec,
+		}); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+// TestLifecycle attaches a network to a dummy interface, which requires
+// CAP_NET_ADMIN and the dummy and macvtap kernel modules.
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	dummy := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "kraft-dummy0"}}
+	if err := netlink.LinkAdd(dummy); err != nil {
+		t.Skip("cannot create dummy interface:", err)
+	}
+
+	defer func() { _ = netlink.LinkDel(dummy) }()
+
+	if err := netlink.LinkSetUp(dummy); err != nil {
+		t.Fatal("LinkSetUp:", err)
+	}
+
+	parent, err := netlink.LinkByName(dummy.Name)
+	if err != nil {
+		t.Fatal("LinkByName:", err)
+	}
+
+	service, _ := NewNetworkServiceV1alpha1(ctx)
+
+	network, err := service.Create(ctx, &networkv1alpha1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "mvt"},
+		Spec: networkv1alpha1.NetworkSpec{
+			Parent: dummy.Name,
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{
+				{},
+			},
+		},
+	})
+	if err != nil {
+		t.Skip("cannot create macvtap network:", err)
+	}
+
+	if network.Status.State != networkv1alpha1.NetworkStateUp {
+		t.Errorf("Expected network to be up, got %s", network.Status.State)
+	}
+
+	iface := network.Spec.Interfaces[0]
+	link, err := netlink.LinkByName(iface.Spec.IfName)
+	if err != nil {
+		t.Fatal("LinkByName:", err)
+	}
+
+	if _, ok := link.(*netlink.Macvtap); !ok {
+		t.Errorf("Expected %s to be a macvtap interface, got %s", iface.Spec.IfName, link.Type())
+	}
+	if link.Attrs().ParentIndex != parent.Attrs().Index {
+		t.Errorf("Expected %s to be attached to %s", iface.Spec.IfName, dummy.Name)
+	}
+	if link.Attrs().HardwareAddr.String() != iface.Spec.MacAddress {
+		t.Errorf("Expected MAC address %s, got %s", iface.Spec.MacAddress, link.Attrs().HardwareAddr)
+	}
+
+	file, err := OpenDevice(iface.Spec.IfName)
+	if err != nil {
+		t.Error("OpenDevice:", err)
+	} else {
+		file.Close()
+	}
+
+	// Removing the interface from the network removes its link.
+	network.Spec.Interfaces = nil
+	if _, err := service.Update(ctx, network); err != nil {
+		t.Fatal("Update:", err)
+	}
+
+	if _, err := netlink.LinkByName(iface.Spec.IfName); err == nil {
+		t.Errorf("Expected %s to be removed", iface.Spec.IfName)
+	}
+
+	if _, err := service.Delete(ctx, network); err != nil {
+		t.Error("Delete:", err)
+	}
+}
@@ -6,10 +6,16 @@
 package network
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/config"
 )
 
 // NetworkPoolEntry describes a network to be used for allocating IP ranges.
@@ -22,6 +28,12 @@ type NetworkPoolEntry struct {
 
 type NetworkPool []NetworkPoolEntry
 
+// NetworkPoolEnv is the environment variable which, if set, takes precedence
+// over the network pools of the configuration file.  It holds a
+// comma-separated list of subnets and the sizes of the networks to allocate
+// from them, e.g. "10.10.0.0/16:24,fd00:1::/48:64".
+const NetworkPoolEnv = "KRAFTKIT_NETWORK_POOLS"
+
 var DefaultNetworkPool = []NetworkPoolEntry{
 	{"172.17.0.0/16", 16},
 	{"172.18.0.0/16", 16},
@@ -32,48 +44,170 @@ var DefaultNetworkPool = []NetworkPoolEntry{
 	{"192.168.0.0/16", 20},
 }
 
-// FindFreeNetwork finds a free network in the pool.
-func FindFreeNetwork(pool NetworkPool, existingNetworks *networkapi.NetworkList) (*net.IPNet, error) {
-	convertedNetworks := []net.IPNet{}
+// DefaultNetworkPool6 is the pool from which the IPv6 subnets of networks are
+// allocated unless otherwise provided.  It is a unique local prefix, such that
+// the subnets do not collide with any global addresses.
+var DefaultNetworkPool6 = []NetworkPoolEntry{
+	{"fd6b:7261:6674::/48", 64},
+}
 
-	for _, network := range existingNetworks.Items {
-		maskBytes := net.ParseIP(network.Spec.Netmask).To4()
-		mask := net.IPv4Mask(maskBytes[0], maskBytes[1], maskBytes[2], maskBytes[3])
-		// Setup IP address for bridge.
-		convertedNetworks = append(convertedNetworks,
-			net.IPNet{
-				IP:   net.ParseIP(network.Spec.Gateway),
-				Mask: mask,
+// ParseNetworkPool parses a network pool in the format of NetworkPoolEnv.
+func ParseNetworkPool(s string) (NetworkPool, error) {
+	var pool NetworkPool
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// The size is separated by the last colon, as IPv6 subnets contain
+		// colons themselves.
+		idx := strings.LastIndex(entry, ":")
+		if idx < 0 || !strings.Contains(entry[:idx], "/") {
+			return nil, fmt.Errorf("invalid network pool entry '%s': expected <subnet>:<size>", entry)
+		}
+
+		size, err := strconv.Atoi(entry[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid size of network pool entry '%s': %w", entry, err)
+		}
+
+		pool = append(pool, NetworkPoolEntry{
+			Subnet: entry[:idx],
+			Size:   size,
+		})
+	}
+
+	return pool, pool.Validate()
+}
+
+// ConfiguredNetworkPool returns the network pool of the provided address
+// family as set via NetworkPoolEnv or the configuration file.  If neither sets
+// any entries of the family, the respective default pool is returned.
+func ConfiguredNetworkPool(ctx context.Context, ipv6 bool) (NetworkPool, error) {
+	var pool NetworkPool
+
+	if env := os.Getenv(NetworkPoolEnv); env != "" {
+		parsed, err := ParseNetworkPool(env)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", NetworkPoolEnv, err)
+		}
+
+		pool = parsed
+	} else {
+		for _, entry := range config.G[config.KraftKit](ctx).Network.Pools {
+			pool = append(pool, NetworkPoolEntry{
+				Subnet: entry.Subnet,
+				Size:   entry.Size,
 			})
+		}
+
+		if err := pool.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid network pools in configuration: %w", err)
+		}
+	}
+
+	if family := pool.Family(ipv6); len(family) > 0 {
+		return family, nil
+	}
+
+	if ipv6 {
+		return DefaultNetworkPool6, nil
+	}
+
+	return DefaultNetworkPool, nil
+}
+
+// Validate checks whether each entry of the pool is a valid subnet which can
+// be split into networks of the entry's size.
+func (pool NetworkPool) Validate() error {
+	for _, entry := range pool {
+		_, subnet, err := net.ParseCIDR(entry.Subnet)
+		if err != nil {
+			return err
+		}
+
+		if ones, bits := subnet.Mask.Size(); entry.Size < ones || entry.Size > bits {
+			return fmt.Errorf("invalid subnet size %d for %s", entry.Size, entry.Subnet)
+		}
+	}
+
+	return nil
+}
+
+// Family returns the entries of the pool of the IPv4 or IPv6 address family.
+func (pool NetworkPool) Family(ipv6 bool) NetworkPool {
+	var family NetworkPool
+
+	for _, entry := range pool {
+		ip, _, err := net.ParseCIDR(entry.Subnet)
+		if err != nil {
+			continue
+		}
+
+		if (ip.To4() == nil) == ipv6 {
+			family = append(family, entry)
+		}
+	}
+
+	return family
+}
+
+// Entry returns the entry of the pool which contains the provided address.
+func (pool NetworkPool) Entry(ip net.IP) (NetworkPoolEntry, bool) {
+	for _, entry := range pool {
+		if _, subnet, err := net.ParseCIDR(entry.Subnet); err == nil && subnet.Contains(ip) {
+			return entry, true
+		}
+	}
+
+	return NetworkPoolEntry{}, false
+}
+
+// maxPoolCandidates is the maximum number of subnets which are considered
+// within a single pool entry, as IPv6 entries can be split into vastly more
+// subnets than could ever be in use.
+const maxPoolCandidates = 1 << 16
+
+// FindFreeNetwork finds a free network in the pool.  The pool may hold IPv4
+// as well as IPv6 entries.  Networks which intersect with any of the existing
+// networks or the additionally reserved subnets, e.g. the routes of the host,
+// are skipped.  The address of the returned network is the first address
+// within it, which is suitable as the gateway of the network.
+func FindFreeNetwork(pool NetworkPool, existingNetworks *networkapi.NetworkList, reserved ...net.IPNet) (*net.IPNet, error) {
+	convertedNetworks := append([]net.IPNet{}, reserved...)
+
+	for _, network := range existingNetworks.Items {
+		convertedNetworks = append(convertedNetworks, Subnets(network.Spec)...)
 	}
 
 	for _, poolEntry := range pool {
-		startingIP, networkToSplit, err := net.ParseCIDR(poolEntry.Subnet)
+		_, networkToSplit, err := net.ParseCIDR(poolEntry.Subnet)
 		if err != nil {
 			return nil, err
 		}
 
-		startingIP = startingIP.To4()
-
-		// subnetIndex is an unsigned integer that is used to calculate the next
-		var subnetIndex uint32 = 0
+		startingIP := networkToSplit.IP
+		ones, bits := networkToSplit.Mask.Size()
+		if poolEntry.Size < ones || poolEntry.Size > bits {
+			return nil, fmt.Errorf("invalid subnet size %d for %s", poolEntry.Size, poolEntry.Subnet)
+		}
 
-		ones, _ := networkToSplit.Mask.Size()
-		numberOfSubnets := 1 << (uint(poolEntry.Size) - uint(ones))
+		numberOfSubnets := uint64(maxPoolCandidates)
+		if poolEntry.Size-ones < 16 {
+			numberOfSubnets = 1 << uint(poolEntry.Size-ones)
+		}
 
-		for range numberOfSubnets {
-			candidateIp := make([]byte, len(startingIP))
-			copy(candidateIp, startingIP)
+		// step is the distance between the addresses of consecutive subnets.
+		step := new(big.Int).Lsh(big.NewInt(1), uint(bits-poolEntry.Size))
 
-			offset := subnetIndex
-			for i := 3; i >= 0; i-- {
-				candidateIp[i] |= byte(offset & 0xff)
-				offset >>= 8
-			}
+		for subnetIndex := range numberOfSubnets {
+			offset := new(big.Int).Mul(step, new(big.Int).SetUint64(subnetIndex))
 
 			candidate := net.IPNet{
-				IP:   (net.IP)(candidateIp).To4(),
-				Mask: net.CIDRMask(poolEntry.Size, 32),
+				IP:   addToIP(startingIP, offset),
+				Mask: net.CIDRMask(poolEntry.Size, bits),
 			}
 
 			// Check if the candidate intersects with any existing network
@@ -87,16 +221,51 @@ func FindFreeNetwork(pool NetworkPool, existingNetworks *networkapi.NetworkList)
 
 			if !intersects {
 				// Increment the candidate by 1 to get the first allocatable IP
-				candidate.IP = candidate.IP.To4()
-				candidate.IP[3] = candidate.IP[3] + 1
+				candidate.IP = addToIP(candidate.IP, big.NewInt(1))
 
 				return &candidate, nil
 			}
-
-			// Increment the candidate IP by the size of one subnetwork
-			subnetIndex = subnetIndex + (1 << (32 - poolEntry.Size))
 		}
 	}
 
 	return nil, fmt.Errorf("unable to find a free network in the network pool")
 }
+
+// Subnets returns the IPv4 and, if any, IPv6 subnets of the provided network.
+// Addresses which cannot be parsed are ignored.
+func Subnets(spec networkapi.NetworkSpec) []net.IPNet {
+	subnets := []net.IPNet{}
+
+	if gateway, mask := net.ParseIP(spec.Gateway).To4(), net.ParseIP(spec.Netmask).To4(); gateway != nil && mask != nil {
+		subnets = append(subnets, net.IPNet{
+			IP:   gateway.Mask(net.IPMask(mask)),
+			Mask: net.IPMask(mask),
+		})
+	}
+
+	if _, subnet, err := net.ParseCIDR(spec.IPv6); err == nil {
+		subnets = append(subnets, *subnet)
+	}
+
+	return subnets
+}
+
+// addToIP returns the address which is offset from the provided one, keeping
+// its length.
+func addToIP(ip net.IP, offset *big.Int) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	sum := new(big.Int).Add(new(big.Int).SetBytes(ip), offset)
+
+	// Truncate any overflow of the address space.
+	ret := make(net.IP, len(ip))
+	b := sum.Bytes()
+	if len(b) > len(ret) {
+		b = b[len(b)-len(ret):]
+	}
+	copy(ret[len(ret)-len(b):], b)
+
+	return ret
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestFindFreeNetwork(t *testing.T) {
+	existing := &networkapi.NetworkList{
+		Items: []networkapi.Network{
+			{Spec: networkapi.NetworkSpec{
+				Gateway: "172.17.0.1",
+				Netmask: "255.255.0.0",
+				IPv6:    "fd6b:7261:6674::1/64",
+			}},
+			{Spec: networkapi.NetworkSpec{
+				// IPv6-only networks must not break the allocation of IPv4 subnets.
+				IPv6: "fd6b:7261:6674:1::1/64",
+			}},
+		},
+	}
+
+	for _, tc := range []struct {
+		pool     NetworkPool
+		expected string
+	}{
+		{DefaultNetworkPool, "172.18.0.1/16"},
+		{DefaultNetworkPool6, "fd6b:7261:6674:2::1/64"},
+		{NetworkPool{{"10.0.0.0/8", 24}}, "10.0.0.1/24"},
+	} {
+		free, err := FindFreeNetwork(tc.pool, existing)
+		if err != nil {
+			t.Fatal("FindFreeNetwork:", err)
+		}
+		if free.String() != tc.expected {
+			t.Errorf("Expected %s, got %s", tc.expected, free)
+		}
+	}
+
+	if _, err := FindFreeNetwork(NetworkPool{{"172.17.0.0/16", 16}}, existing); err == nil {
+		t.Error("Expected error for exhausted pool")
+	}
+}
+
+func TestFindFreeNetworkReserved(t *testing.T) {
+	_, route, _ := net.ParseCIDR("10.0.0.0/23")
+
+	free, err := FindFreeNetwork(NetworkPool{{"10.0.0.0/16", 24}}, &networkapi.NetworkList{}, *route)
+	if err != nil {
+		t.Fatal("FindFreeNetwork:", err)
+	}
+	if free.String() != "10.0.2.1/24" {
+		t.Errorf("Expected 10.0.2.1/24, got %s", free)
+	}
+}
+
+func TestParseNetworkPool(t *testing.T) {
+	pool, err := ParseNetworkPool("10.10.0.0/16:24, fd00:1::/48:64")
+	if err != nil {
+		t.Fatal("ParseNetworkPool:", err)
+	}
+
+	expected := NetworkPool{{"10.10.0.0/16", 24}, {"fd00:1::/48", 64}}
+	if len(pool) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, pool)
+	}
+	for i := range expected {
+		if pool[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], pool[i])
+		}
+	}
+
+	if family := pool.Family(true); len(family) != 1 || family[0] != expected[1] {
+		t.Errorf("Expected IPv6 family %v, got %v", expected[1:], family)
+	}
+
+	for _, invalid := range []string{
+		"10.10.0.0/16",
+		"10.10.0.0:24",
+		"10.10.0.0/16:8",
+		"10.10.0.0/16:33",
+		"fd00::/48:abc",
+	} {
+		if _, err := ParseNetworkPool(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
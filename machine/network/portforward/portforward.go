@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package portforward publishes ports of machines which are attached to a
+// host-side network (e.g. a bridge) by installing destination NAT rules which
+// forward traffic arriving at the host to the machine's address.  Unlike a
+// userspace proxy, the rules live in the kernel and therefore persist for the
+// lifetime of the machine without the need of a supervising process.
+package portforward
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// TableName is the name of the table which holds all port forwarding rules
+// managed by KraftKit.
+const TableName = "kraftkit"
+
+// validate checks whether the supplied ports can be forwarded to the machine
+// and returns the guest address in its 4-byte representation.
+func validate(addr net.IP, ports machinev1alpha1.MachinePorts) (net.IP, error) {
+	guest := addr.To4()
+	if guest == nil {
+		return nil, fmt.Errorf("cannot forward ports to non-IPv4 address: %s", addr)
+	}
+
+	for _, port := range ports {
+		if port.HostPort <= 0 || port.HostPort > 65535 {
+			return nil, fmt.Errorf("invalid host port: %d", port.HostPort)
+		}
+		if port.MachinePort <= 0 || port.MachinePort > 65535 {
+			return nil, fmt.Errorf("invalid machine port: %d", port.MachinePort)
+		}
+		if _, err := protocol(port.Protocol); err != nil {
+			return nil, err
+		}
+		if _, err := hostIP(port.HostIP); err != nil {
+			return nil, err
+		}
+	}
+
+	return guest, nil
+}
+
+// protocol normalizes the supplied port protocol, defaulting to TCP.
+func protocol(proto corev1.Protocol) (corev1.Protocol, error) {
+	switch corev1.Protocol(strings.ToUpper(string(proto))) {
+	case "", corev1.ProtocolTCP:
+		return corev1.ProtocolTCP, nil
+	case corev1.ProtocolUDP:
+		return corev1.ProtocolUDP, nil
+	default:
+		return "", fmt.Errorf("unsupported port protocol: %s", proto)
+	}
+}
+
+// hostIP parses the host IP a port is bound to.  A nil IP is returned if the
+// port is bound to all addresses of the host.
+func hostIP(ip string) (net.IP, error) {
+	if ip == "" {
+		return nil, nil
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid host IP: %s", ip)
+	}
+
+	if parsed.IsUnspecified() {
+		return nil, nil
+	}
+
+	if parsed.To4() == nil {
+		return nil, fmt.Errorf("cannot forward ports bound to non-IPv4 address: %s", ip)
+	}
+
+	return parsed.To4(), nil
+}
+
+// Target returns the name of the host-side interface and the address of the
+// first network interface of the supplied bridge networks, which is where
+// published ports of a machine are forwarded to.
+func Target(networks []networkv1alpha1.NetworkSpec) (string, net.IP, error) {
+	for _, network := range networks {
+		// Only bridge networks are reachable from the host itself.
+		if network.Driver != "" && network.Driver != "bridge" {
+			continue
+		}
+
+		for _, iface := range network.Interfaces {
+			if iface.Spec.CIDR == "" {
+				continue
+			}
+
+			ip, _, err := net.ParseCIDR(iface.Spec.CIDR)
+			if err != nil {
+				return "", nil, fmt.Errorf("could not parse interface address: %w", err)
+			}
+
+			return network.IfName, ip, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("publishing ports requires the machine to be attached to a bridge network")
+}
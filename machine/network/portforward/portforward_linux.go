@@ -0,0 +1,544 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// guardPrefix prefixes the user data of each rule of the guard chain, which is
+// followed by the name of the guarded interface and the value of its
+// route_localnet sysctl before it was enabled, e.g.
+// "route_localnet:kraft0=0".
+const guardPrefix = "route_localnet:"
+
+// chains is the set of chains within the KraftKit table which hold port
+// forwarding rules.
+type chains struct {
+	table       *nftables.Table
+	guard       *nftables.Chain
+	prerouting  *nftables.Chain
+	output      *nftables.Chain
+	postrouting *nftables.Chain
+}
+
+// all returns each chain in the set which holds rules of machines.
+func (c chains) all() []*nftables.Chain {
+	return []*nftables.Chain{c.prerouting, c.output, c.postrouting}
+}
+
+// newChains returns the definitions of the table and chains used for port
+// forwarding.
+func newChains() chains {
+	table := &nftables.Table{
+		Family: nftables.TableFamilyIPv4,
+		Name:   TableName,
+	}
+
+	return chains{
+		table: table,
+		guard: &nftables.Chain{
+			Name:     "guard",
+			Table:    table,
+			Type:     nftables.ChainTypeFilter,
+			Hooknum:  nftables.ChainHookPrerouting,
+			Priority: nftables.ChainPriorityRaw,
+		},
+		prerouting: &nftables.Chain{
+			Name:     "prerouting",
+			Table:    table,
+			Type:     nftables.ChainTypeNAT,
+			Hooknum:  nftables.ChainHookPrerouting,
+			Priority: nftables.ChainPriorityNATDest,
+		},
+		output: &nftables.Chain{
+			Name:     "output",
+			Table:    table,
+			Type:     nftables.ChainTypeNAT,
+			Hooknum:  nftables.ChainHookOutput,
+			Priority: nftables.ChainPriorityNATDest,
+		},
+		postrouting: &nftables.Chain{
+			Name:     "postrouting",
+			Table:    table,
+			Type:     nftables.ChainTypeNAT,
+			Hooknum:  nftables.ChainHookPostrouting,
+			Priority: nftables.ChainPriorityNATSource,
+		},
+	}
+}
+
+// Add forwards each of the supplied ports from the host to the provided
+// address of the machine identified by id.  The ifname is the host-side
+// interface (e.g. a bridge) through which the machine is reachable.  Any rules
+// previously installed for the same machine are replaced.
+func Add(ctx context.Context, id, ifname string, addr net.IP, ports machinev1alpha1.MachinePorts) error {
+	guest, err := validate(addr, ports)
+	if err != nil {
+		return err
+	}
+
+	if len(ports) == 0 {
+		return Remove(ctx, id)
+	}
+
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("could not connect to nftables: %w", err)
+	}
+
+	c := newChains()
+	conn.AddTable(c.table)
+	conn.AddChain(c.guard)
+	for _, chain := range c.all() {
+		conn.AddChain(chain)
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not create nftables table '%s': %w", TableName, err)
+	}
+
+	if err := deleteRules(conn, c, id); err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		proto, _ := protocol(port.Protocol)
+		host, _ := hostIP(port.HostIP)
+
+		exprs := dnatExprs(host, proto, uint16(port.HostPort), guest, uint16(port.MachinePort))
+
+		log.G(ctx).
+			WithField("machine", id).
+			WithField("port", fmt.Sprintf("%s:%d->%s:%d/%s", port.HostIP, port.HostPort, guest, port.MachinePort, proto)).
+			Debug("forwarding")
+
+		// Insert rules at the top of the chain such that the most recent machine
+		// to publish a port takes precedence.
+		for _, chain := range []*nftables.Chain{c.prerouting, c.output} {
+			conn.InsertRule(&nftables.Rule{
+				Table:    c.table,
+				Chain:    chain,
+				Exprs:    exprs,
+				UserData: []byte(id),
+			})
+		}
+	}
+
+	// Traffic originating from the loopback interface must be masqueraded,
+	// otherwise the machine would attempt to respond to its own loopback
+	// address.  The rule also records the interface the ports are forwarded
+	// through.
+	conn.InsertRule(&nftables.Rule{
+		Table:    c.table,
+		Chain:    c.postrouting,
+		Exprs:    masqueradeLoopbackExprs(guest, ifname),
+		UserData: []byte(id),
+	})
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not add port forwarding rules: %w", err)
+	}
+
+	if ifname != "" {
+		if err := guardLocalnet(conn, c, ifname); err != nil {
+			return err
+		}
+	}
+
+	// The machine may have previously forwarded ports through another
+	// interface.
+	return releaseLocalnet(ctx, conn, c)
+}
+
+// Remove deletes all port forwarding rules of the machine identified by id.
+// It is not an error if no rules exist for the machine.
+func Remove(ctx context.Context, id string) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("could not connect to nftables: %w", err)
+	}
+
+	tables, err := conn.ListTablesOfFamily(nftables.TableFamilyIPv4)
+	if err != nil {
+		return fmt.Errorf("could not list nftables tables: %w", err)
+	}
+
+	found := false
+	for _, table := range tables {
+		if table.Name == TableName {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	log.G(ctx).
+		WithField("machine", id).
+		Debug("removing port forwarding rules")
+
+	c := newChains()
+
+	if err := deleteRules(conn, c, id); err != nil {
+		return err
+	}
+
+	return releaseLocalnet(ctx, conn, c)
+}
+
+// routeLocalnetPath returns the path of the sysctl which allows traffic with a
+// loopback address to be routed to and from the provided interface.
+func routeLocalnetPath(ifname string) string {
+	return filepath.Join("/proc/sys/net/ipv4/conf", ifname, "route_localnet")
+}
+
+// guardLocalnet enables route_localnet on the provided interface such that
+// traffic with a loopback source address can be routed to it.  Since this also
+// allows the machines attached to the interface to reach services bound to the
+// loopback address of the host, packets addressed to the loopback network which
+// arrive on the interface are dropped beforehand.  The value of the sysctl
+// before it was enabled is recorded along with this guard such that it can be
+// restored by releaseLocalnet.
+func guardLocalnet(conn *nftables.Conn, c chains, ifname string) error {
+	rules, err := conn.GetRules(c.table, c.guard)
+	if err != nil {
+		return fmt.Errorf("could not list rules of chain '%s': %w", c.guard.Name, err)
+	}
+
+	guarded := false
+	for _, rule := range rules {
+		if name, _, ok := parseGuard(rule.UserData); ok && name == ifname {
+			guarded = true
+			break
+		}
+	}
+
+	if !guarded {
+		prev, err := os.ReadFile(routeLocalnetPath(ifname))
+		if err != nil {
+			return fmt.Errorf("could not read route_localnet of %s: %w", ifname, err)
+		}
+
+		conn.AddRule(&nftables.Rule{
+			Table:    c.table,
+			Chain:    c.guard,
+			Exprs:    guardExprs(ifname),
+			UserData: []byte(guardPrefix + ifname + "=" + strings.TrimSpace(string(prev))),
+		})
+
+		if err := conn.Flush(); err != nil {
+			return fmt.Errorf("could not guard the loopback network from %s: %w", ifname, err)
+		}
+	}
+
+	if err := os.WriteFile(routeLocalnetPath(ifname), []byte("1"), 0o644); err != nil {
+		return fmt.Errorf("could not enable route_localnet on %s: %w", ifname, err)
+	}
+
+	return nil
+}
+
+// releaseLocalnet restores route_localnet of each guarded interface through
+// which no ports are forwarded anymore and removes its guard.
+func releaseLocalnet(ctx context.Context, conn *nftables.Conn, c chains) error {
+	// The table may have been created without the guard chain.
+	conn.AddChain(c.guard)
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not create nftables chain '%s': %w", c.guard.Name, err)
+	}
+
+	guards, err := conn.GetRules(c.table, c.guard)
+	if err != nil {
+		return fmt.Errorf("could not list rules of chain '%s': %w", c.guard.Name, err)
+	}
+
+	forwards, err := conn.GetRules(c.table, c.postrouting)
+	if err != nil {
+		return fmt.Errorf("could not list rules of chain '%s': %w", c.postrouting.Name, err)
+	}
+
+	for _, guard := range guards {
+		ifname, prev, ok := parseGuard(guard.UserData)
+		if !ok || forwardsThrough(forwards, ifname) {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("ifname", ifname).
+			WithField("route_localnet", prev).
+			Debug("restoring")
+
+		// The interface may have been removed in the meantime.
+		if err := os.WriteFile(routeLocalnetPath(ifname), []byte(prev), 0o644); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not restore route_localnet on %s: %w", ifname, err)
+		}
+
+		if err := conn.DelRule(guard); err != nil {
+			return fmt.Errorf("could not delete rule: %w", err)
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not remove guard rules: %w", err)
+	}
+
+	return nil
+}
+
+// parseGuard returns the name of the interface and the previous value of its
+// route_localnet sysctl from the user data of a guard rule.
+func parseGuard(data []byte) (string, string, bool) {
+	guard, ok := strings.CutPrefix(string(data), guardPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	ifname, prev, ok := strings.Cut(guard, "=")
+	if !ok || ifname == "" || prev == "" {
+		return "", "", false
+	}
+
+	return ifname, prev, true
+}
+
+// forwardsThrough returns whether any of the provided rules matches the
+// provided interface as output interface.
+func forwardsThrough(rules []*nftables.Rule, ifname string) bool {
+	for _, rule := range rules {
+		for i := 0; i+1 < len(rule.Exprs); i++ {
+			meta, ok := rule.Exprs[i].(*expr.Meta)
+			if !ok || meta.Key != expr.MetaKeyOIFNAME {
+				continue
+			}
+
+			if cmp, ok := rule.Exprs[i+1].(*expr.Cmp); ok && cmp.Op == expr.CmpOpEq && bytes.Equal(cmp.Data, ifnameKey(ifname)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ifnameKey returns the representation of the provided interface name as
+// used by nftables, i.e. padded with zeroes to IFNAMSIZ bytes.
+func ifnameKey(ifname string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, ifname+"\x00")
+	return b
+}
+
+// guardExprs returns the expressions equivalent to:
+//
+//	iifname <ifname> ip daddr 127.0.0.0/8 drop
+func guardExprs(ifname string) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{
+			Key:      expr.MetaKeyIIFNAME,
+			Register: 1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     ifnameKey(ifname),
+		},
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       16,
+			Len:          4,
+		},
+		&expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            4,
+			Mask:           net.IPv4Mask(255, 0, 0, 0),
+			Xor:            []byte{0, 0, 0, 0},
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     net.IPv4(127, 0, 0, 0).To4(),
+		},
+		&expr.Verdict{
+			Kind: expr.VerdictDrop,
+		},
+	}
+}
+
+// deleteRules removes all rules from the supplied chains which belong to the
+// machine identified by id.
+func deleteRules(conn *nftables.Conn, c chains, id string) error {
+	for _, chain := range c.all() {
+		rules, err := conn.GetRules(c.table, chain)
+		if err != nil {
+			return fmt.Errorf("could not list rules of chain '%s': %w", chain.Name, err)
+		}
+
+		for _, rule := range rules {
+			if !bytes.Equal(rule.UserData, []byte(id)) {
+				continue
+			}
+
+			if err := conn.DelRule(rule); err != nil {
+				return fmt.Errorf("could not delete rule: %w", err)
+			}
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("could not remove port forwarding rules: %w", err)
+	}
+
+	return nil
+}
+
+// dnatExprs returns the expressions equivalent to:
+//
+//	fib daddr type local [ip daddr <host>] meta l4proto <proto> th dport <hport> dnat to <guest>:<gport>
+func dnatExprs(host net.IP, proto corev1.Protocol, hport uint16, guest net.IP, gport uint16) []expr.Any {
+	exprs := []expr.Any{
+		&expr.Fib{
+			Register:       1,
+			FlagDADDR:      true,
+			ResultADDRTYPE: true,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     binaryutil.NativeEndian.PutUint32(unix.RTN_LOCAL),
+		},
+	}
+
+	if host != nil {
+		exprs = append(exprs,
+			&expr.Payload{
+				DestRegister: 1,
+				Base:         expr.PayloadBaseNetworkHeader,
+				Offset:       16,
+				Len:          4,
+			},
+			&expr.Cmp{
+				Op:       expr.CmpOpEq,
+				Register: 1,
+				Data:     host,
+			},
+		)
+	}
+
+	l4proto := byte(unix.IPPROTO_TCP)
+	if proto == corev1.ProtocolUDP {
+		l4proto = unix.IPPROTO_UDP
+	}
+
+	return append(exprs,
+		&expr.Meta{
+			Key:      expr.MetaKeyL4PROTO,
+			Register: 1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     []byte{l4proto},
+		},
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseTransportHeader,
+			Offset:       2,
+			Len:          2,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     binaryutil.BigEndian.PutUint16(hport),
+		},
+		&expr.Immediate{
+			Register: 1,
+			Data:     guest,
+		},
+		&expr.Immediate{
+			Register: 2,
+			Data:     binaryutil.BigEndian.PutUint16(gport),
+		},
+		&expr.NAT{
+			Type:        expr.NATTypeDestNAT,
+			Family:      unix.NFPROTO_IPV4,
+			RegAddrMin:  1,
+			RegProtoMin: 2,
+		},
+	)
+}
+
+// masqueradeLoopbackExprs returns the expressions equivalent to:
+//
+//	ip saddr 127.0.0.0/8 ip daddr <guest> [oifname <ifname>] masquerade
+func masqueradeLoopbackExprs(guest net.IP, ifname string) []expr.Any {
+	exprs := []expr.Any{
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       12,
+			Len:          4,
+		},
+		&expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            4,
+			Mask:           net.IPv4Mask(255, 0, 0, 0),
+			Xor:            []byte{0, 0, 0, 0},
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     net.IPv4(127, 0, 0, 0).To4(),
+		},
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       16,
+			Len:          4,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     guest,
+		},
+	}
+
+	if ifname != "" {
+		exprs = append(exprs,
+			&expr.Meta{
+				Key:      expr.MetaKeyOIFNAME,
+				Register: 1,
+			},
+			&expr.Cmp{
+				Op:       expr.CmpOpEq,
+				Register: 1,
+				Data:     ifnameKey(ifname),
+			},
+		)
+	}
+
+	return append(exprs, &expr.Masq{})
+}
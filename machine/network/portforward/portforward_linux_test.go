@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"github.com/vishvananda/netlink"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestParseGuard(t *testing.T) {
+	for data, expect := range map[string][2]string{
+		"route_localnet:kraft0=0": {"kraft0", "0"},
+		"route_localnet:kraft0=1": {"kraft0", "1"},
+		"route_localnet:kraft0=":  {},
+		"route_localnet:=0":       {},
+		"kraft0=0":                {},
+		"6f1b7c1e-uid":            {},
+	} {
+		ifname, prev, ok := parseGuard([]byte(data))
+		if ok != (expect[0] != "") || ifname != expect[0] || prev != expect[1] {
+			t.Errorf("parseGuard(%q) = %q, %q, %t", data, ifname, prev, ok)
+		}
+	}
+}
+
+func TestForwardsThrough(t *testing.T) {
+	rules := []*nftables.Rule{
+		{Exprs: masqueradeLoopbackExprs(net.IPv4(172, 44, 0, 2).To4(), "kraft0")},
+		{Exprs: masqueradeLoopbackExprs(net.IPv4(172, 45, 0, 2).To4(), "")},
+		{Exprs: guardExprs("kraft1")},
+	}
+
+	if !forwardsThrough(rules, "kraft0") {
+		t.Error("Expected ports to be forwarded through kraft0")
+	}
+
+	// Guards match the input rather than the output interface.
+	if forwardsThrough(rules, "kraft1") {
+		t.Error("Expected no ports to be forwarded through kraft1")
+	}
+
+	if forwardsThrough(rules, "kraft") {
+		t.Error("Expected interface names to be matched exactly")
+	}
+}
+
+func TestGuardExprs(t *testing.T) {
+	exprs := guardExprs("kraft0")
+
+	if verdict, ok := exprs[len(exprs)-1].(*expr.Verdict); !ok || verdict.Kind != expr.VerdictDrop {
+		t.Fatalf("Expected guard to drop packets, got %#v", exprs[len(exprs)-1])
+	}
+
+	if meta, ok := exprs[0].(*expr.Meta); !ok || meta.Key != expr.MetaKeyIIFNAME {
+		t.Errorf("Expected guard to match the input interface, got %#v", exprs[0])
+	}
+}
+
+// readRouteLocalnet returns the value of the route_localnet sysctl of the
+// provided interface.
+func readRouteLocalnet(t *testing.T, ifname string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(routeLocalnetPath(ifname))
+	if err != nil {
+		t.Fatal("ReadFile:", err)
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// TestLocalnetLifecycle forwards ports through a bridge, which requires
+// CAP_NET_ADMIN and nftables.
+func TestLocalnetLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "kraft-pfwd0"}}
+	if err := netlink.LinkAdd(bridge); err != nil {
+		t.Skip("cannot create bridge:", err)
+	}
+
+	defer func() { _ = netlink.LinkDel(bridge) }()
+
+	if _, err := nftables.New(); err != nil {
+		t.Skip("cannot connect to nftables:", err)
+	}
+
+	if readRouteLocalnet(t, bridge.Name) != "0" {
+		t.Fatal("Expected route_localnet to be disabled initially")
+	}
+
+	ports := machinev1alpha1.MachinePorts{{HostPort: 18080, MachinePort: 80}}
+
+	if err := Add(ctx, "kraft-pfwd-a", bridge.Name, net.ParseIP("172.99.0.2"), ports); err != nil {
+		t.Skip("cannot add port forwarding rules:", err)
+	}
+
+	defer func() { _ = Remove(ctx, "kraft-pfwd-a") }()
+
+	if err := Add(ctx, "kraft-pfwd-b", bridge.Name, net.ParseIP("172.99.0.3"), ports); err != nil {
+		t.Fatal("Add:", err)
+	}
+
+	defer func() { _ = Remove(ctx, "kraft-pfwd-b") }()
+
+	if got := readRouteLocalnet(t, bridge.Name); got != "1" {
+		t.Fatalf("Expected route_localnet to be enabled, got %s", got)
+	}
+
+	conn, err := nftables.New()
+	if err != nil {
+		t.Fatal("nftables.New:", err)
+	}
+
+	c := newChains()
+
+	guards := func() int {
+		t.Helper()
+
+		rules, err := conn.GetRules(c.table, c.guard)
+		if err != nil {
+			t.Fatal("GetRules:", err)
+		}
+
+		n := 0
+		for _, rule := range rules {
+			if ifname, _, ok := parseGuard(rule.UserData); ok && ifname == bridge.Name {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	if n := guards(); n != 1 {
+		t.Fatalf("Expected a single guard of %s, got %d", bridge.Name, n)
+	}
+
+	// The sysctl is retained while ports are forwarded through the interface.
+	if err := Remove(ctx, "kraft-pfwd-a"); err != nil {
+		t.Fatal("Remove:", err)
+	}
+
+	if got := readRouteLocalnet(t, bridge.Name); got != "1" {
+		t.Errorf("Expected route_localnet to remain enabled, got %s", got)
+	}
+
+	if err := Remove(ctx, "kraft-pfwd-b"); err != nil {
+		t.Fatal("Remove:", err)
+	}
+
+	if got := readRouteLocalnet(t, bridge.Name); got != "0" {
+		t.Errorf("Expected route_localnet to be restored, got %s", got)
+	}
+
+	if n := guards(); n != 0 {
+		t.Errorf("Expected guard of %s to be removed, got %d", bridge.Name, n)
+	}
+}
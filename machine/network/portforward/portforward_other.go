@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// Add forwards each of the supplied ports from the host to the provided
+// address of the machine identified by id.  It is only supported on Linux.
+func Add(ctx context.Context, id, ifname string, addr net.IP, ports machinev1alpha1.MachinePorts) error {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	return errors.New("host-side port forwarding is only supported on Linux")
+}
+
+// Remove deletes all port forwarding rules of the machine identified by id.
+func Remove(ctx context.Context, id string) error {
+	return nil
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"net"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestTarget(t *testing.T) {
+	networks := []networkv1alpha1.NetworkSpec{
+		{
+			IfName: "kraft0",
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{
+				{Spec: networkv1alpha1.NetworkInterfaceSpec{}},
+				{Spec: networkv1alpha1.NetworkInterfaceSpec{CIDR: "172.44.0.2/24"}},
+			},
+		},
+	}
+
+	ifname, addr, err := Target(networks)
+	if err != nil {
+		t.Fatal("Target:", err)
+	}
+	if ifname != "kraft0" {
+		t.Errorf("Expected interface kraft0, got %s", ifname)
+	}
+	if !addr.Equal(net.ParseIP("172.44.0.2")) {
+		t.Errorf("Expected address 172.44.0.2, got %s", addr)
+	}
+
+	if _, _, err := Target(nil); err == nil {
+		t.Error("Expected error without networks")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    net.IP
+		ports   machinev1alpha1.MachinePorts
+		wantErr bool
+	}{
+		{
+			name: "tcp and udp",
+			addr: net.ParseIP("172.44.0.2"),
+			ports: machinev1alpha1.MachinePorts{
+				{HostPort: 8080, MachinePort: 80},
+				{HostPort: 5353, MachinePort: 53, Protocol: "udp", HostIP: "127.0.0.1"},
+			},
+		},
+		{
+			name: "unspecified host address",
+			addr: net.ParseIP("172.44.0.2"),
+			ports: machinev1alpha1.MachinePorts{
+				{HostPort: 8080, MachinePort: 80, HostIP: "0.0.0.0"},
+			},
+		},
+		{
+			name: "unsupported protocol",
+			addr: net.ParseIP("172.44.0.2"),
+			ports: machinev1alpha1.MachinePorts{
+				{HostPort: 8080, MachinePort: 80, Protocol: corev1.ProtocolSCTP},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid host port",
+			addr: net.ParseIP("172.44.0.2"),
+			ports: machinev1alpha1.MachinePorts{
+				{HostPort: 0, MachinePort: 80},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "IPv6 machine address",
+			addr:    net.ParseIP("fd00::2"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validate(tt.addr, tt.ports)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ra
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ICMPv6 message types of the neighbor discovery protocol, see RFC 4861.
+const (
+	TypeRouterSolicitation  = 133
+	TypeRouterAdvertisement = 134
+)
+
+// Option types of router advertisements, see RFC 4861 and RFC 8106.
+const (
+	OptionSourceLinkLayerAddress = 1
+	OptionPrefixInformation      = 3
+	OptionMTU                    = 5
+	OptionRecursiveDNSServer     = 25
+)
+
+// Flags of the prefix information option.
+const (
+	prefixFlagOnLink     = 1 << 7
+	prefixFlagAutonomous = 1 << 6
+)
+
+// Advertisement is a router advertisement which announces the prefix of a
+// network such that its machines configure their own addresses.
+type Advertisement struct {
+	// HopLimit is the hop limit which machines should use for their packets,
+	// or zero if unspecified.
+	HopLimit uint8
+
+	// RouterLifetime is the duration for which the router may be used as the
+	// default router.  A zero lifetime withdraws the router.
+	RouterLifetime time.Duration
+
+	// Prefix is the prefix from which machines configure their addresses.
+	Prefix *net.IPNet
+
+	// ValidLifetime and PreferredLifetime are the durations for which the
+	// addresses configured from the prefix remain valid and preferred.
+	ValidLifetime     time.Duration
+	PreferredLifetime time.Duration
+
+	// MTU of the link, or zero if unspecified.
+	MTU uint32
+
+	// LinkLayerAddress is the hardware address of the router.
+	LinkLayerAddress net.HardwareAddr
+
+	// DNS holds the addresses of the name servers of the network.
+	DNS []net.IP
+}
+
+// Marshal returns the ICMPv6 message of the advertisement.  The checksum is
+// left empty as it is computed by the kernel.
+func (ra *Advertisement) Marshal() ([]byte, error) {
+	b := make([]byte, 16)
+	b[0] = TypeRouterAdvertisement
+	b[4] = ra.HopLimit
+	binary.BigEndian.PutUint16(b[6:8], seconds16(ra.RouterLifetime))
+
+	if len(ra.LinkLayerAddress) > 0 {
+		b = appendOption(b, OptionSourceLinkLayerAddress, ra.LinkLayerAddress)
+	}
+
+	if ra.MTU > 0 {
+		b = appendOption(b, OptionMTU, binary.BigEndian.AppendUint32([]byte{0, 0}, ra.MTU))
+	}
+
+	if ra.Prefix != nil {
+		prefix := ra.Prefix.IP.Mask(ra.Prefix.Mask).To16()
+		ones, bits := ra.Prefix.Mask.Size()
+		if prefix == nil || ra.Prefix.IP.To4() != nil || bits != 128 {
+			return nil, fmt.Errorf("invalid IPv6 prefix: %s", ra.Prefix)
+		}
+
+		data := []byte{byte(ones), prefixFlagOnLink | prefixFlagAutonomous}
+		data = binary.BigEndian.AppendUint32(data, seconds32(ra.ValidLifetime))
+		data = binary.BigEndian.AppendUint32(data, seconds32(ra.PreferredLifetime))
+		data = append(data, 0, 0, 0, 0)
+		data = append(data, prefix...)
+
+		b = appendOption(b, OptionPrefixInformation, data)
+	}
+
+	if len(ra.DNS) > 0 {
+		data := binary.BigEndian.AppendUint32([]byte{0, 0}, seconds32(ra.RouterLifetime))
+		for _, addr := range ra.DNS {
+			if addr.To4() != nil || addr.To16() == nil {
+				return nil, fmt.Errorf("invalid IPv6 name server: %s", addr)
+			}
+
+			data = append(data, addr.To16()...)
+		}
+
+		b = appendOption(b, OptionRecursiveDNSServer, data)
+	}
+
+	return b, nil
+}
+
+// appendOption appends an option with the provided type and data to the
+// message, padding it to a multiple of 8 bytes.
+func appendOption(b []byte, typ byte, data []byte) []byte {
+	length := (2 + len(data) + 7) / 8
+
+	b = append(b, typ, byte(length))
+	b = append(b, data...)
+
+	return append(b, make([]byte, length*8-2-len(data))...)
+}
+
+// seconds16 returns the provided duration in seconds, saturated to 16 bits.
+func seconds16(d time.Duration) uint16 {
+	if d.Seconds() >= 0xffff {
+		return 0xffff
+	}
+
+	return uint16(d / time.Second)
+}
+
+// seconds32 returns the provided duration in seconds, where negative
+// durations represent infinity.
+func seconds32(d time.Duration) uint32 {
+	if d < 0 || d.Seconds() >= 0xffffffff {
+		return 0xffffffff
+	}
+
+	return uint32(d / time.Second)
+}
@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package ra implements a minimal IPv6 router which advertises the prefix,
+// gateway and name server of a host-side network, such that its machines
+// configure their IPv6 addresses via stateless address autoconfiguration
+// (SLAAC).  As the addresses are derived from the hardware addresses of the
+// machines, they are known upfront and match those allocated by the network.
+package ra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// DefaultInterval is the interval at which unsolicited advertisements are
+// sent unless overridden.  Machines solicit an advertisement when they boot,
+// so the interval only bounds how quickly changes are picked up.
+const DefaultInterval = 30 * time.Second
+
+// Server advertises the IPv6 prefix of a single network.
+type Server struct {
+	interval time.Duration
+
+	mu      sync.RWMutex
+	ifname  string
+	prefix  *net.IPNet
+	gateway net.IP
+}
+
+// ServerOption is an option which configures the router.
+type ServerOption func(*Server) error
+
+// WithInterval sets the interval at which unsolicited advertisements are sent.
+func WithInterval(interval time.Duration) ServerOption {
+	return func(server *Server) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid interval: %s", interval)
+		}
+
+		server.interval = interval
+		return nil
+	}
+}
+
+// NewServer prepares a router.  The network whose prefix is advertised must be
+// set via SetNetwork before serving.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	server := &Server{
+		interval: DefaultInterval,
+	}
+
+	for _, opt := range opts {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	return server, nil
+}
+
+// SetNetwork (re)loads the prefix of the provided network.  It can be called
+// whilst serving to follow changes of the network.
+func (server *Server) SetNetwork(network *networkv1alpha1.Network) error {
+	if network.Spec.IPv6 == "" {
+		return fmt.Errorf("network %s has no IPv6 subnet", network.Name)
+	}
+
+	gateway, prefix, err := net.ParseCIDR(network.Spec.IPv6)
+	if err != nil || gateway.To4() != nil {
+		return fmt.Errorf("invalid IPv6 subnet of %s: %s", network.Name, network.Spec.IPv6)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.ifname = network.Spec.IfName
+	server.prefix = prefix
+	server.gateway = gateway
+
+	return nil
+}
+
+// Advertisement returns the advertisement of the network which is sent via
+// the provided interface.
+func (server *Server) Advertisement(iface *net.Interface) *Advertisement {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	return &Advertisement{
+		HopLimit: 64,
+		// Routers must be re-advertised well within their lifetime.
+		RouterLifetime:    3 * server.interval,
+		Prefix:            server.prefix,
+		ValidLifetime:     -1,
+		PreferredLifetime: -1,
+		MTU:               uint32(iface.MTU),
+		LinkLayerAddress:  iface.HardwareAddr,
+		// The DNS resolver of the network listens on the gateway.
+		DNS: []net.IP{server.gateway},
+	}
+}
+
+// Serve advertises the prefix of the network periodically and in response to
+// router solicitations until the provided context is cancelled.
+func (server *Server) Serve(ctx context.Context) error {
+	server.mu.RLock()
+	ifname := server.ifname
+	server.mu.RUnlock()
+
+	if ifname == "" {
+		return fmt.Errorf("no network to serve")
+	}
+
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return fmt.Errorf("could not get interface %s: %w", ifname, err)
+	}
+
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return fmt.Errorf("could not listen for ICMPv6: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	pc := conn.IPv6PacketConn()
+
+	// Neighbor discovery messages are only accepted with the maximum hop
+	// limit, which proves that they have not been forwarded.
+	if err := pc.SetMulticastHopLimit(255); err != nil {
+		return err
+	}
+
+	if err := pc.SetHopLimit(255); err != nil {
+		return err
+	}
+
+	if err := pc.SetControlMessage(ipv6.FlagInterface|ipv6.FlagHopLimit, true); err != nil {
+		return err
+	}
+
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterSolicitation)
+
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		return err
+	}
+
+	if err := pc.JoinGroup(iface, &net.IPAddr{IP: net.IPv6linklocalallrouters}); err != nil {
+		return fmt.Errorf("could not join all-routers group on %s: %w", ifname, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(server.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := server.advertise(pc, iface); err != nil && ctx.Err() == nil {
+				log.G(ctx).Debugf("ra: could not advertise on %s: %v", ifname, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	buf := make([]byte, 1500)
+
+	for {
+		n, cm, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		if n < 1 || buf[0] != TypeRouterSolicitation || cm == nil || cm.IfIndex != iface.Index || cm.HopLimit != 255 {
+			continue
+		}
+
+		// Reply to all nodes, as soliciting machines may not have an address
+		// yet.
+		if err := server.advertise(pc, iface); err != nil {
+			log.G(ctx).Debugf("ra: could not respond to solicitation on %s: %v", ifname, err)
+		}
+	}
+}
+
+// advertise sends the advertisement of the network to all nodes on the
+// provided interface.
+func (server *Server) advertise(pc *ipv6.PacketConn, iface *net.Interface) error {
+	b, err := server.Advertisement(iface).Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = pc.WriteTo(b, &ipv6.ControlMessage{
+		IfIndex:  iface.Index,
+		HopLimit: 255,
+	}, &net.IPAddr{
+		IP: net.IPv6linklocalallnodes,
+	})
+
+	return err
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ra
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestAdvertisement(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal("NewServer:", err)
+	}
+
+	if err := server.SetNetwork(&networkv1alpha1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+		Spec: networkv1alpha1.NetworkSpec{
+			IfName: "kraft0",
+			IPv6:   "fd00:1:2:3::1/64",
+		},
+	}); err != nil {
+		t.Fatal("SetNetwork:", err)
+	}
+
+	mac, _ := net.ParseMAC("02:00:00:00:00:01")
+
+	b, err := server.Advertisement(&net.Interface{
+		MTU:          1500,
+		HardwareAddr: mac,
+	}).Marshal()
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+
+	if b[0] != TypeRouterAdvertisement || b[4] != 64 || b[6] != 0 || b[7] != 90 {
+		t.Fatalf("Unexpected header: % x", b[:16])
+	}
+
+	options := map[byte][]byte{}
+	for i := 16; i < len(b); {
+		length := int(b[i+1]) * 8
+		if length == 0 || i+length > len(b) {
+			t.Fatalf("Malformed option at offset %d", i)
+		}
+
+		options[b[i]] = b[i+2 : i+length]
+		i += length
+	}
+
+	if lladdr := options[OptionSourceLinkLayerAddress]; !bytes.Equal(lladdr[:6], mac) {
+		t.Errorf("Expected link-layer address %s, got % x", mac, lladdr)
+	}
+
+	if mtu := options[OptionMTU]; !bytes.Equal(mtu, []byte{0, 0, 0, 0, 0x05, 0xdc}) {
+		t.Errorf("Unexpected MTU option: % x", mtu)
+	}
+
+	prefix := options[OptionPrefixInformation]
+	if len(prefix) != 30 || prefix[0] != 64 || prefix[1] != prefixFlagOnLink|prefixFlagAutonomous {
+		t.Fatalf("Unexpected prefix option: % x", prefix)
+	}
+	if !net.IP(prefix[14:30]).Equal(net.ParseIP("fd00:1:2:3::")) {
+		t.Errorf("Expected prefix fd00:1:2:3::, got %s", net.IP(prefix[14:30]))
+	}
+
+	if rdnss := options[OptionRecursiveDNSServer]; len(rdnss) != 22 || !net.IP(rdnss[6:22]).Equal(net.ParseIP("fd00:1:2:3::1")) {
+		t.Errorf("Unexpected name server option: % x", rdnss)
+	}
+
+	if err := server.SetNetwork(&networkv1alpha1.Network{
+		Spec: networkv1alpha1.NetworkSpec{
+			Gateway: "172.44.0.1",
+			Netmask: "255.255.255.0",
+		},
+	}); err == nil {
+		t.Error("Expected error for IPv4-only network")
+	}
+}
@@ -6,13 +6,12 @@ package network
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
-	"kraftkit.sh/config"
 	"kraftkit.sh/machine/network/bridge"
+	"kraftkit.sh/machine/network/macvtap"
 	"kraftkit.sh/store"
 )
 
@@ -29,12 +28,26 @@ func hostSupportedStrategies() map[string]*Strategy {
 					return nil, err
 				}
 
-				embeddedStore, err := store.NewEmbeddedStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](
-					filepath.Join(
-						config.G[config.KraftKit](ctx).RuntimeDir,
-						"networkv1alpha1",
-					),
+				runtimeStore, err := store.NewRuntimeStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](ctx, "networkv1alpha1")
+				if err != nil {
+					return nil, err
+				}
+
+				return networkv1alpha1.NewNetworkServiceHandler(
+					ctx,
+					service,
+					zip.WithStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 				)
+			},
+		},
+		macvtap.DriverName: {
+			NewNetworkV1alpha1: func(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
+				service, err := macvtap.NewNetworkServiceV1alpha1(ctx, opts...)
+				if err != nil {
+					return nil, err
+				}
+
+				runtimeStore, err := store.NewRuntimeStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](ctx, "networkv1alpha1")
 				if err != nil {
 					return nil, err
 				}
@@ -42,7 +55,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 				return networkv1alpha1.NewNetworkServiceHandler(
 					ctx,
 					service,
-					zip.WithStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+					zip.WithStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 				)
 			},
 		},
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package network
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// HostRoutes returns the destinations of the routes of the host, excluding
+// default routes.  Allocating a network which intersects with any of them,
+// e.g. the routes of a VPN or of Docker, would render parts of the host's
+// network unreachable.
+func HostRoutes() ([]net.IPNet, error) {
+	routes, err := netlink.RouteList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("could not list routes: %w", err)
+	}
+
+	var dsts []net.IPNet
+
+	for _, route := range routes {
+		if route.Dst == nil {
+			continue
+		}
+
+		if ones, _ := route.Dst.Mask.Size(); ones == 0 {
+			continue
+		}
+
+		// Every interface has a route to the IPv6 link-local prefix.
+		if route.Dst.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		dsts = append(dsts, *route.Dst)
+	}
+
+	return dsts, nil
+}
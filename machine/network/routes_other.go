@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package network
+
+import "net"
+
+// HostRoutes returns the destinations of the routes of the host, excluding
+// default routes.  It is only supported on Linux.
+func HostRoutes() ([]net.IPNet, error) {
+	return nil, nil
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/daemon/client"
 )
 
 // NewStrategyConstructor is a prototype for the instantiation function of a
@@ -38,9 +39,29 @@ func Strategies() map[string]*Strategy {
 		base[name] = driverInfo
 	}
 
+	for name, driverInfo := range base {
+		base[name] = viaDaemon(name, driverInfo)
+	}
+
 	return base
 }
 
+// viaDaemon returns a copy of the strategy which forwards all calls to the
+// local KraftKit daemon if it is running instead of instantiating the driver
+// in-process.
+func viaDaemon(driver string, strategy *Strategy) *Strategy {
+	ret := *strategy
+	ret.NewNetworkV1alpha1 = func(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
+		if client.Available(ctx) {
+			return client.NewNetworkV1alpha1Service(ctx, driver)
+		}
+
+		return strategy.NewNetworkV1alpha1(ctx, opts...)
+	}
+
+	return &ret
+}
+
 // DriverNames returns the list of registered platform driver implementation
 // names.
 func DriverNames() []string {
@@ -12,6 +12,7 @@ import (
 	"github.com/acorn-io/baaah/pkg/merr"
 
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/daemon/client"
 )
 
 type machineV1alpha1ServiceIterator struct {
@@ -24,6 +25,11 @@ type machineV1alpha1ServiceIterator struct {
 // useful in circumstances where the platform is not supplied.  The first
 // platform strategy to succeed is returned in all circumstances.
 func NewMachineV1alpha1ServiceIterator(ctx context.Context) (machinev1alpha1.MachineService, error) {
+	// The daemon iterates over its own supported strategies.
+	if client.Available(ctx) {
+		return client.NewMachineV1alpha1Service(ctx, "")
+	}
+
 	var err error
 	iterator := machineV1alpha1ServiceIterator{
 		strategies: map[Platform]machinev1alpha1.MachineService{},
@@ -6,7 +6,6 @@ package platform
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
@@ -25,12 +24,7 @@ var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev
 		return nil, err
 	}
 
-	embeddedStore, err := store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
-		filepath.Join(
-			config.G[config.KraftKit](ctx).RuntimeDir,
-			"machinev1alpha1",
-		),
-	)
+	runtimeStore, err := store.NewRuntimeStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](ctx, "machinev1alpha1")
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +32,7 @@ var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev
 	return machinev1alpha1.NewMachineServiceHandler(
 		ctx,
 		service,
-		zip.WithStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+		zip.WithStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 		zip.WithBefore(storePlatformFilter(PlatformFirecracker)),
 	)
 }
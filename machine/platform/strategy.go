@@ -9,6 +9,7 @@ import (
 
 	_ "kraftkit.sh/api"
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/daemon/client"
 )
 
 // strategies contains the map of registered strategies, whether provided as
@@ -35,9 +36,29 @@ func Strategies() map[Platform]*Strategy {
 		base[name] = driverInfo
 	}
 
+	for name, driverInfo := range base {
+		base[name] = viaDaemon(name, driverInfo)
+	}
+
 	return base
 }
 
+// viaDaemon returns a copy of the strategy which forwards all calls to the
+// local KraftKit daemon if it is running instead of instantiating the driver
+// in-process.
+func viaDaemon(platform Platform, strategy *Strategy) *Strategy {
+	ret := *strategy
+	ret.NewMachineV1alpha1 = func(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
+		if client.Available(ctx) {
+			return client.NewMachineV1alpha1Service(ctx, platform.String())
+		}
+
+		return strategy.NewMachineV1alpha1(ctx, opts...)
+	}
+
+	return &ret
+}
+
 // DriverNames returns the list of registered platform driver implementation
 // names.
 func DriverNames() []string {
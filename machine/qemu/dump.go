@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+// DumpNetDevice makes the provided running QEMU machine dump the traffic of
+// its first network device backend, e.g. a user-mode network device which has
+// no interface on the host which could be captured instead, to the pcap file
+// at the provided path.  The returned function stops the dump again.
+func DumpNetDevice(ctx context.Context, machine *machinev1alpha1.Machine, path string, snaplen int) (func() error, error) {
+	if machine.Status.State != machinev1alpha1.MachineStateRunning && machine.Status.State != machinev1alpha1.MachineStatePaused {
+		return nil, fmt.Errorf("machine %s is not running", machine.Name)
+	}
+
+	qcfg, err := getQEMUConfigFromPlatformConfig(machine.Status.PlatformConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var netdev string
+	for _, nd := range qcfg.NetDevs {
+		switch nd := nd.(type) {
+		case QemuNetDevUser:
+			netdev = nd.Id
+		case QemuNetDevTap:
+			netdev = nd.Id
+		}
+
+		if netdev != "" {
+			break
+		}
+	}
+
+	if netdev == "" {
+		return nil, fmt.Errorf("machine %s has no network device", machine.Name)
+	}
+
+	conn, err := qcfg.QMP[0].Connection()
+	if err != nil {
+		return nil, err
+	}
+
+	qmpClient, err := qmpClientHandshake(&conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not connect to QEMU instance: %v", err)
+	}
+
+	id := "dump-" + netdev
+
+	res, err := qmpClient.ObjectAddFilterDump(qmpapi.ObjectAddFilterDumpRequest{
+		Arguments: qmpapi.ObjectAddFilterDumpRequestArguments{
+			QomType: "filter-dump",
+			Id:      id,
+			Netdev:  netdev,
+			File:    path,
+			Maxlen:  int64(snaplen),
+		},
+	})
+	if err := errors.Join(err, qmpResponseError(res)); err != nil {
+		qmpClient.Close()
+		return nil, fmt.Errorf("could not dump network device %s: %w", netdev, err)
+	}
+
+	return func() error {
+		defer qmpClient.Close()
+
+		res, err := qmpClient.ObjectDel(qmpapi.ObjectDelRequest{
+			Arguments: qmpapi.ObjectDelRequestArguments{
+				Id: id,
+			},
+		})
+
+		return errors.Join(err, qmpResponseError(res))
+	}, nil
+}
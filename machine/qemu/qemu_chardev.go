@@ -242,15 +242,13 @@ func (cd QemuCharDevSocketUnix) String() string {
 			ret.WriteString(",logappend=off")
 		}
 	}
+	// The abstract and tight options are only supported since QEMU 5.1 and are
+	// therefore only set when enabled.
 	if cd.Abstract {
 		ret.WriteString(",abstract=on")
-	} else {
-		ret.WriteString(",abstract=off")
 	}
 	if cd.Tight {
 		ret.WriteString(",tight=on")
-	} else {
-		ret.WriteString(",tight=off")
 	}
 
 	return ret.String()
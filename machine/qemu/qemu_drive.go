@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"strings"
+)
+
+type QemuDriveInterface string
+
+const (
+	QemuDriveInterfaceIde    = QemuDriveInterface("ide")
+	QemuDriveInterfaceNone   = QemuDriveInterface("none")
+	QemuDriveInterfaceScsi   = QemuDriveInterface("scsi")
+	QemuDriveInterfaceVirtio = QemuDriveInterface("virtio")
+)
+
+type QemuDriveFormat string
+
+const (
+	QemuDriveFormatQcow2 = QemuDriveFormat("qcow2")
+	QemuDriveFormatRaw   = QemuDriveFormat("raw")
+)
+
+type QemuDriveCache string
+
+const (
+	QemuDriveCacheDirectsync   = QemuDriveCache("directsync")
+	QemuDriveCacheNone         = QemuDriveCache("none")
+	QemuDriveCacheUnsafe       = QemuDriveCache("unsafe")
+	QemuDriveCacheWriteback    = QemuDriveCache("writeback")
+	QemuDriveCacheWritethrough = QemuDriveCache("writethrough")
+)
+
+type QemuDrive struct {
+	Id       string             `json:"id,omitempty"`
+	File     string             `json:"file,omitempty"`
+	If       QemuDriveInterface `json:"if,omitempty"`
+	Format   QemuDriveFormat    `json:"format,omitempty"`
+	Cache    QemuDriveCache     `json:"cache,omitempty"`
+	Readonly bool               `json:"readonly,omitempty"`
+}
+
+// String returns a QEMU command-line compatible drive string with the format:
+// file=file,id=id[,if=if][,format=format][,cache=cache][,readonly=on]
+func (d QemuDrive) String() string {
+	var ret strings.Builder
+
+	// Commas are escaped by doubling them, as they otherwise separate options.
+	ret.WriteString("file=")
+	ret.WriteString(strings.ReplaceAll(d.File, ",", ",,"))
+	ret.WriteString(",id=")
+	ret.WriteString(d.Id)
+
+	if len(d.If) > 0 {
+		ret.WriteString(",if=")
+		ret.WriteString(string(d.If))
+	}
+	if len(d.Format) > 0 {
+		ret.WriteString(",format=")
+		ret.WriteString(string(d.Format))
+	}
+	if len(d.Cache) > 0 {
+		ret.WriteString(",cache=")
+		ret.WriteString(string(d.Cache))
+	}
+	if d.Readonly {
+		ret.WriteString(",readonly=on")
+	}
+
+	return ret.String()
+}
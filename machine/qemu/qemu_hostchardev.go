@@ -7,9 +7,12 @@ package qemu
 import (
 	"fmt"
 	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"golang.org/x/net/websocket"
 )
 
 type QemuHostCharDev interface {
@@ -51,10 +54,14 @@ func (cd QemuHostCharDevVirtualConsole) Resource() string {
 }
 
 func (cd QemuHostCharDevVirtualConsole) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevVirtualConsole.Connection")
+	return nil, fmt.Errorf("cannot connect to a virtual console which is rendered by the graphical display")
 }
 
-type QemuHostCharDevPty struct{}
+type QemuHostCharDevPty struct {
+	// Path of the pseudo-terminal which is allocated by QEMU and is therefore
+	// only known once the machine has been started.
+	Path string `json:"path,omitempty"`
+}
 
 func (cd QemuHostCharDevPty) String() string {
 	return string(QemuCharDevTypePty)
@@ -65,7 +72,11 @@ func (cd QemuHostCharDevPty) Resource() string {
 }
 
 func (cd QemuHostCharDevPty) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevPty.Connection")
+	if len(cd.Path) == 0 {
+		return nil, fmt.Errorf("cannot connect to pseudo-terminal whose path is not known")
+	}
+
+	return openFileConn(cd.Path, cd.Path)
 }
 
 type QemuHostCharDevNone struct{}
@@ -79,7 +90,7 @@ func (cd QemuHostCharDevNone) Resource() string {
 }
 
 func (cd QemuHostCharDevNone) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNone.Connection")
+	return nil, fmt.Errorf("cannot connect to a non-existent character device")
 }
 
 type QemuHostCharDevNull struct{}
@@ -94,7 +105,7 @@ func (cd QemuHostCharDevNull) Resource() string {
 }
 
 func (cd QemuHostCharDevNull) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNull.Connection")
+	return nil, fmt.Errorf("cannot connect to a null character device")
 }
 
 type QemuHostCharDevNamed struct {
@@ -125,7 +136,7 @@ func (cd QemuHostCharDevNamed) Resource() string {
 }
 
 func (cd QemuHostCharDevNamed) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNamed.Connection")
+	return nil, fmt.Errorf("cannot connect to named character device '%s' directly, connect to the backend with this id instead", cd.Id)
 }
 
 type QemuHostCharDevTty struct {
@@ -158,7 +169,11 @@ func (cd QemuHostCharDevTty) Resource() string {
 }
 
 func (cd QemuHostCharDevTty) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTty.Connection")
+	if len(cd.Path) == 0 {
+		return nil, fmt.Errorf("cannot connect to TTY character device without path")
+	}
+
+	return openFileConn(cd.Path, cd.Path)
 }
 
 type QemuHostCharDevFile struct {
@@ -188,8 +203,14 @@ func (cd QemuHostCharDevFile) Resource() string {
 	return cd.Filename
 }
 
+// Connection returns a read-only connection to the file which the character
+// device writes to, since QEMU does not read input from it.
 func (cd QemuHostCharDevFile) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevFile.Connection")
+	if len(cd.Filename) == 0 {
+		return nil, fmt.Errorf("cannot connect to file character device without filename")
+	}
+
+	return openFileConn(cd.Filename, "")
 }
 
 type QemuHostCharDevStdio struct {
@@ -213,7 +234,7 @@ func (cd QemuHostCharDevStdio) Resource() string {
 }
 
 func (cd QemuHostCharDevStdio) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevStdio.Connection")
+	return nil, fmt.Errorf("cannot connect to the standard I/O of the QEMU process")
 }
 
 type QemuHostCharDevPipe struct {
@@ -243,8 +264,23 @@ func (cd QemuHostCharDevPipe) Resource() string {
 	return cd.Filename
 }
 
+// Connection opens the pipe which QEMU uses for the character device.  If the
+// pair of FIFOs "<filename>.in" and "<filename>.out" exists, QEMU reads from
+// the former and writes to the latter; otherwise, it uses the single
+// bidirectional pipe at the filename.
 func (cd QemuHostCharDevPipe) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevPipe.Connection")
+	if len(cd.Filename) == 0 {
+		return nil, fmt.Errorf("cannot connect to pipe character device without filename")
+	}
+
+	in, out := cd.Filename+".in", cd.Filename+".out"
+	if _, err := os.Stat(in); err != nil {
+		return openFileConn(cd.Filename, cd.Filename)
+	} else if _, err := os.Stat(out); err != nil {
+		return openFileConn(cd.Filename, cd.Filename)
+	}
+
+	return openFileConn(out, in)
 }
 
 type QemuHostCharDevUDP struct {
@@ -295,8 +331,30 @@ func (cd QemuHostCharDevUDP) Resource() string {
 	return ret.String()
 }
 
+// Connection returns a connection which receives the datagrams QEMU sends to
+// the remote address and sends datagrams to the source address QEMU receives
+// from.  The remote address must therefore be local to the host.
 func (cd QemuHostCharDevUDP) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevUDP.Connection")
+	if cd.SourcePort <= 0 {
+		return nil, fmt.Errorf("cannot connect to UDP character device without source port")
+	}
+
+	laddr, err := net.ResolveUDPAddr("udp", cd.Resource())
+	if err != nil {
+		return nil, err
+	}
+
+	sourceHost := cd.SourceHost
+	if len(sourceHost) == 0 {
+		sourceHost = "127.0.0.1"
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(sourceHost, strconv.Itoa(cd.SourcePort)))
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialUDP("udp", laddr, raddr)
 }
 
 const (
@@ -359,7 +417,11 @@ func (cd QemuHostCharDevTCP) Resource() string {
 }
 
 func (cd QemuHostCharDevTCP) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTCP.Connection")
+	if !cd.Server {
+		return nil, fmt.Errorf("cannot connect to TCP character device which is not in server mode")
+	}
+
+	return net.Dial("tcp", dialableAddress(cd.Resource()))
 }
 
 type QemuHostCharDevTelnet struct {
@@ -412,7 +474,18 @@ func (cd QemuHostCharDevTelnet) Resource() string {
 }
 
 func (cd QemuHostCharDevTelnet) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTelnet.Connection")
+	if !cd.Server {
+		return nil, fmt.Errorf("cannot connect to telnet character device which is not in server mode")
+	} else if len(cd.Resource()) == 0 {
+		return nil, fmt.Errorf("cannot connect to telnet character device without host and port")
+	}
+
+	conn, err := net.Dial("tcp", dialableAddress(cd.Resource()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &telnetConn{Conn: conn}, nil
 }
 
 type QemuHostCharDevWebsocket struct {
@@ -464,7 +537,20 @@ func (cd QemuHostCharDevWebsocket) Resource() string {
 }
 
 func (cd QemuHostCharDevWebsocket) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevWebsocket.Connection")
+	if len(cd.Resource()) == 0 {
+		return nil, fmt.Errorf("cannot connect to websocket character device without host and port")
+	}
+
+	addr := dialableAddress(cd.Resource())
+
+	conn, err := websocket.Dial("ws://"+addr, "binary", "http://"+addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.PayloadType = websocket.BinaryFrame
+
+	return conn, nil
 }
 
 type QemuHostCharDevUnix struct {
@@ -519,3 +605,18 @@ func (cd QemuHostCharDevUnix) Resource() string {
 func (cd QemuHostCharDevUnix) Connection() (net.Conn, error) {
 	return net.Dial("unix", cd.Resource())
 }
+
+// dialableAddress returns the provided address on which a server listens with
+// unspecified hosts replaced by the loopback address.
+func dialableAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
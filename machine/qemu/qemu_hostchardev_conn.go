@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// fileAddr is the net.Addr of a host character device which is backed by a
+// file, such as a TTY, a pipe or a regular file.
+type fileAddr string
+
+// Network implements net.Addr
+func (addr fileAddr) Network() string {
+	return "file"
+}
+
+// String implements net.Addr
+func (addr fileAddr) String() string {
+	return string(addr)
+}
+
+// fileConn is a net.Conn which reads from and writes to files.  The reader
+// and writer may be the same file, e.g. for TTYs, or different files, e.g.
+// for pipes which consist of an input and an output FIFO.  A nil writer
+// results in a read-only connection.
+type fileConn struct {
+	name   string
+	reader *os.File
+	writer *os.File
+}
+
+// openFileConn opens the provided files as a connection.  If out is empty,
+// the connection is read-only and if it is the same as in, the file is only
+// opened once.
+func openFileConn(in, out string) (net.Conn, error) {
+	conn := fileConn{
+		name: in,
+	}
+
+	switch out {
+	case "":
+		reader, err := os.Open(in)
+		if err != nil {
+			return nil, err
+		}
+
+		conn.reader = reader
+
+	case in:
+		file, err := os.OpenFile(in, os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		conn.reader = file
+		conn.writer = file
+
+	default:
+		reader, err := os.OpenFile(in, os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		writer, err := os.OpenFile(out, os.O_RDWR, 0)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+
+		conn.reader = reader
+		conn.writer = writer
+	}
+
+	return &conn, nil
+}
+
+// Read implements net.Conn
+func (conn *fileConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+// Write implements net.Conn
+func (conn *fileConn) Write(b []byte) (int, error) {
+	if conn.writer == nil {
+		return 0, fmt.Errorf("%s is read-only", conn.name)
+	}
+
+	return conn.writer.Write(b)
+}
+
+// Close implements net.Conn
+func (conn *fileConn) Close() error {
+	err := conn.reader.Close()
+	if conn.writer != nil && conn.writer != conn.reader {
+		err = errors.Join(err, conn.writer.Close())
+	}
+
+	return err
+}
+
+// LocalAddr implements net.Conn
+func (conn *fileConn) LocalAddr() net.Addr {
+	return fileAddr(conn.name)
+}
+
+// RemoteAddr implements net.Conn
+func (conn *fileConn) RemoteAddr() net.Addr {
+	return fileAddr(conn.name)
+}
+
+// SetDeadline implements net.Conn
+func (conn *fileConn) SetDeadline(t time.Time) error {
+	return errors.Join(conn.SetReadDeadline(t), conn.SetWriteDeadline(t))
+}
+
+// SetReadDeadline implements net.Conn
+func (conn *fileConn) SetReadDeadline(t time.Time) error {
+	return conn.reader.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn
+func (conn *fileConn) SetWriteDeadline(t time.Time) error {
+	if conn.writer == nil {
+		return nil
+	}
+
+	return conn.writer.SetWriteDeadline(t)
+}
+
+// Telnet commands which are relevant for stripping option negotiation from
+// the data stream of a telnet server.
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetDONT = 254
+	telnetIAC  = 255
+)
+
+// telnetConn is a net.Conn to a telnet server which strips the option
+// negotiation from the received data and escapes sent data.  Options which
+// are requested by the server are left unanswered, which telnet servers
+// treat as a refusal.
+type telnetConn struct {
+	net.Conn
+
+	// pending holds the bytes of an incomplete command which was split across
+	// consecutive reads.
+	pending []byte
+}
+
+// Read implements net.Conn
+func (conn *telnetConn) Read(b []byte) (int, error) {
+	for {
+		buf := make([]byte, len(b))
+		n, err := conn.Conn.Read(buf)
+
+		data := append(conn.pending, buf[:n]...)
+		conn.pending = nil
+
+		out := 0
+		for i := 0; i < len(data); i++ {
+			if data[i] != telnetIAC {
+				b[out] = data[i]
+				out++
+				continue
+			}
+
+			if i+1 >= len(data) {
+				conn.pending = data[i:]
+				break
+			}
+
+			switch cmd := data[i+1]; {
+			case cmd == telnetIAC:
+				// Escaped data byte.
+				b[out] = telnetIAC
+				out++
+				i++
+
+			case cmd == telnetSB:
+				// Sub-negotiation lasts until IAC SE.
+				end := bytes.Index(data[i:], []byte{telnetIAC, telnetSE})
+				if end < 0 {
+					conn.pending = data[i:]
+					i = len(data)
+					break
+				}
+
+				i += end + 1
+
+			case cmd >= telnetWILL && cmd <= telnetDONT:
+				// Option negotiation consists of three bytes.
+				if i+2 >= len(data) {
+					conn.pending = data[i:]
+					i = len(data)
+					break
+				}
+
+				i += 2
+
+			default:
+				// Other commands consist of two bytes.
+				i++
+			}
+		}
+
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+// Write implements net.Conn
+func (conn *telnetConn) Write(b []byte) (int, error) {
+	if _, err := conn.Conn.Write(bytes.ReplaceAll(b, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
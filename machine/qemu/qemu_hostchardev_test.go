@@ -0,0 +1,365 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"golang.org/x/sys/unix"
+)
+
+// listenTCP returns a listener on an ephemeral port of the loopback address
+// and the port it listens on.
+func listenTCP(t *testing.T) (net.Listener, int) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen:", err)
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	return ln, ln.Addr().(*net.TCPAddr).Port
+}
+
+// mkfifo creates a FIFO at the provided path.
+func mkfifo(t *testing.T, path string) {
+	t.Helper()
+
+	if err := unix.Mkfifo(path, 0o600); err != nil {
+		t.Fatal("Mkfifo:", err)
+	}
+}
+
+// expectRead reads exactly len(expect) bytes from the provided reader and
+// compares them to expect.
+func expectRead(t *testing.T, r io.Reader, expect []byte) {
+	t.Helper()
+
+	if conn, ok := r.(net.Conn); ok {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	got := make([]byte, len(expect))
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatal("ReadFull:", err)
+	}
+
+	if !bytes.Equal(got, expect) {
+		t.Fatalf("expected to read %q, got %q", expect, got)
+	}
+}
+
+func TestQemuHostCharDevTCPConnection(t *testing.T) {
+	ln, port := listenTCP(t)
+
+	if _, err := (QemuHostCharDevTCP{Host: "0.0.0.0", Port: port}).Connection(); err == nil {
+		t.Error("expected connecting to a client mode device to fail")
+	}
+
+	conn, err := QemuHostCharDevTCP{Host: "0.0.0.0", Port: port, Server: true}.Connection()
+	if err != nil {
+		t.Fatal("Connection:", err)
+	}
+
+	defer conn.Close()
+
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal("Accept:", err)
+	}
+
+	defer server.Close()
+
+	if _, err := server.Write([]byte("from guest")); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRead(t, conn, []byte("from guest"))
+
+	if _, err := conn.Write([]byte("to guest")); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRead(t, server, []byte("to guest"))
+}
+
+func TestQemuHostCharDevTelnetConnection(t *testing.T) {
+	ln, port := listenTCP(t)
+
+	conn, err := QemuHostCharDevTelnet{Host: "127.0.0.1", Port: port, Server: true}.Connection()
+	if err != nil {
+		t.Fatal("Connection:", err)
+	}
+
+	defer conn.Close()
+
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal("Accept:", err)
+	}
+
+	defer server.Close()
+
+	// QEMU negotiates options before any data, which may be split across
+	// writes and contains escaped data bytes.
+	for _, chunk := range [][]byte{
+		{telnetIAC, telnetWILL, 1, telnetIAC},
+		{telnetWILL, 3, telnetIAC, telnetSB, 24, 1, telnetIAC},
+		{telnetSE, 'h', 'i', telnetIAC},
+		{telnetIAC, '!'},
+	} {
+		if _, err := server.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expectRead(t, conn, []byte{'h', 'i', telnetIAC, '!'})
+
+	if _, err := conn.Write([]byte{'o', 'k', telnetIAC}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRead(t, server, []byte{'o', 'k', telnetIAC, telnetIAC})
+}
+
+func TestQemuHostCharDevWebsocketConnection(t *testing.T) {
+	ln, port := listenTCP(t)
+
+	go func() {
+		_ = http.Serve(ln, websocket.Handler(func(ws *websocket.Conn) {
+			ws.PayloadType = websocket.BinaryFrame
+			_, _ = io.Copy(ws, ws)
+		}))
+	}()
+
+	conn, err := QemuHostCharDevWebsocket{Host: "0.0.0.0", Port: port}.Connection()
+	if err != nil {
+		t.Fatal("Connection:", err)
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("echo")); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRead(t, conn, []byte("echo"))
+}
+
+func TestQemuHostCharDevUDPConnection(t *testing.T) {
+	// The socket of QEMU receives on the source address.
+	qemu, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("ListenUDP:", err)
+	}
+
+	defer qemu.Close()
+
+	// Determine a free port for the remote address QEMU sends to.
+	remote, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("ListenUDP:", err)
+	}
+
+	remotePort := remote.LocalAddr().(*net.UDPAddr).Port
+	remote.Close()
+
+	cd := QemuHostCharDevUDP{
+		RemoteHost: "127.0.0.1",
+		RemotePort: remotePort,
+		SourcePort: qemu.LocalAddr().(*net.UDPAddr).Port,
+	}
+
+	conn, err := cd.Connection()
+	if err != nil {
+		t.Fatal("Connection:", err)
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("to guest")); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = qemu.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 64)
+	n, addr, err := qemu.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatal("ReadFromUDP:", err)
+	} else if string(buf[:n]) != "to guest" {
+		t.Fatalf("expected to read %q, got %q", "to guest", buf[:n])
+	} else if addr.Port != remotePort {
+		t.Fatalf("expected datagram from port %d, got %d", remotePort, addr.Port)
+	}
+
+	if _, err := qemu.WriteToUDP([]byte("from guest"), addr); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRead(t, conn, []byte("from guest"))
+
+	if _, err := (QemuHostCharDevUDP{RemotePort: remotePort}).Connection(); err == nil {
+		t.Error("expected connecting without source port to fail")
+	}
+}
+
+func TestQemuHostCharDevPipeConnection(t *testing.T) {
+	t.Run("pair", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "serial")
+		mkfifo(t, path+".in")
+		mkfifo(t, path+".out")
+
+		conn, err := QemuHostCharDevPipe{Filename: path}.Connection()
+		if err != nil {
+			t.Fatal("Connection:", err)
+		}
+
+		defer conn.Close()
+
+		// QEMU writes to the output and reads from the input FIFO.
+		out, err := os.OpenFile(path+".out", os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer out.Close()
+
+		in, err := os.OpenFile(path+".in", os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer in.Close()
+
+		if _, err := out.Write([]byte("from guest")); err != nil {
+			t.Fatal(err)
+		}
+
+		expectRead(t, conn, []byte("from guest"))
+
+		if _, err := conn.Write([]byte("to guest")); err != nil {
+			t.Fatal(err)
+		}
+
+		expectRead(t, in, []byte("to guest"))
+	})
+
+	t.Run("single", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "serial")
+		mkfifo(t, path)
+
+		conn, err := QemuHostCharDevPipe{Filename: path}.Connection()
+		if err != nil {
+			t.Fatal("Connection:", err)
+		}
+
+		defer conn.Close()
+
+		if _, err := conn.Write([]byte("loop")); err != nil {
+			t.Fatal(err)
+		}
+
+		expectRead(t, conn, []byte("loop"))
+	})
+}
+
+func TestQemuHostCharDevTtyConnection(t *testing.T) {
+	// A FIFO stands in for the terminal device, which is opened for reading
+	// and writing alike.
+	path := filepath.Join(t.TempDir(), "tty")
+	mkfifo(t, path)
+
+	for _, cd := range []QemuHostCharDev{
+		QemuHostCharDevTty{Path: path},
+		QemuHostCharDevPty{Path: path},
+	} {
+		conn, err := cd.Connection()
+		if err != nil {
+			t.Fatalf("%T.Connection: %v", cd, err)
+		}
+
+		if _, err := conn.Write([]byte("tty")); err != nil {
+			t.Fatal(err)
+		}
+
+		expectRead(t, conn, []byte("tty"))
+
+		conn.Close()
+	}
+
+	if _, err := (QemuHostCharDevPty{}).Connection(); err == nil {
+		t.Error("expected connecting to a pseudo-terminal without path to fail")
+	}
+}
+
+func TestQemuHostCharDevFileConnection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial.log")
+	if err := os.WriteFile(path, []byte("booted\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := QemuHostCharDevFile{Filename: path}.Connection()
+	if err != nil {
+		t.Fatal("Connection:", err)
+	}
+
+	defer conn.Close()
+
+	expectRead(t, conn, []byte("booted\n"))
+
+	if _, err := conn.Write([]byte("input")); err == nil {
+		t.Error("expected writing to a file character device to fail")
+	}
+}
+
+func TestQemuHostCharDevConnectionUnsupported(t *testing.T) {
+	for _, cd := range []QemuHostCharDev{
+		QemuHostCharDevVirtualConsole{},
+		QemuHostCharDevNone{},
+		QemuHostCharDevNull{},
+		QemuHostCharDevNamed{Id: "console"},
+		QemuHostCharDevStdio{},
+		QemuHostCharDevFile{},
+		QemuHostCharDevTCP{Port: 1},
+		QemuHostCharDevTelnet{Server: true},
+		QemuHostCharDevWebsocket{},
+	} {
+		if conn, err := cd.Connection(); err == nil {
+			conn.Close()
+			t.Errorf("expected connecting to %T to fail", cd)
+		}
+	}
+}
+
+func TestDialableAddress(t *testing.T) {
+	for addr, expect := range map[string]string{
+		"0.0.0.0:4444":   "127.0.0.1:4444",
+		"[::]:4444":      "127.0.0.1:4444",
+		":4444":          "127.0.0.1:4444",
+		"10.0.0.1:4444":  "10.0.0.1:4444",
+		"localhost:4444": "localhost:4444",
+		"invalid":        "invalid",
+	} {
+		if got := dialableAddress(addr); got != expect {
+			t.Errorf("dialableAddress(%q) = %q, expected %q", addr, got, expect)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"strings"
+)
+
+type QemuNumaNode struct {
+	Memdev string `json:"memdev,omitempty"`
+}
+
+// String returns a QEMU command-line compatible numa string with the format:
+// node[,memdev=id]
+func (n QemuNumaNode) String() string {
+	var ret strings.Builder
+
+	ret.WriteString("node")
+
+	if len(n.Memdev) > 0 {
+		ret.WriteString(",memdev=")
+		ret.WriteString(n.Memdev)
+	}
+
+	return ret.String()
+}
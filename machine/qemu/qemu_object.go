@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"fmt"
+	"strings"
+)
+
+type QemuObject interface {
+	fmt.Stringer
+}
+
+type QemuObjectType string
+
+const (
+	QemuObjectTypeMemoryBackendMemfd = QemuObjectType("memory-backend-memfd")
+)
+
+type QemuObjectMemoryBackendMemfd struct {
+	Id    string     `json:"id,omitempty"`
+	Size  QemuMemory `json:"size,omitempty"`
+	Share bool       `json:"share,omitempty"`
+}
+
+// String returns a QEMU command-line compatible object string with the format:
+// memory-backend-memfd,id=id,size=size[,share=on]
+func (o QemuObjectMemoryBackendMemfd) String() string {
+	var ret strings.Builder
+
+	ret.WriteString(string(QemuObjectTypeMemoryBackendMemfd))
+	ret.WriteString(",id=")
+	ret.WriteString(o.Id)
+	ret.WriteString(",")
+	ret.WriteString(o.Size.String())
+
+	if o.Share {
+		ret.WriteString(",share=on")
+	}
+
+	return ret.String()
+}
@@ -27,3 +27,28 @@ type SystemPowerdownRequest struct {
 type SystemWakeupRequest struct {
 	Execute string `json:"execute" default:"system_Wakeup"`
 }
+
+type BalloonRequest struct {
+	Execute string `json:"execute" default:"balloon"`
+
+	Arguments BalloonRequestArguments `json:"arguments"`
+}
+
+type BalloonRequestArguments struct {
+	// the target logical size of the VM in bytes.
+	Value int64 `json:"value"`
+}
+
+type QueryBalloonRequest struct {
+	Execute string `json:"execute" default:"query-balloon"`
+}
+
+// Information about the guest balloon device.
+type BalloonInfo struct {
+	// the logical size of the VM in bytes.
+	Actual int64 `json:"actual"`
+}
+
+type QueryBalloonResponse struct {
+	Return BalloonInfo `json:"return"`
+}
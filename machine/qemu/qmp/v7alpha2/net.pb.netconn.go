@@ -170,36 +170,36 @@ type NetdevTapOptions struct {
 	// Specify the driver used for interpreting remaining arguments.
 	Type NetClientDriver `json:"type"`
 	// interface name
-	Ifname string `json:"ifname"`
+	Ifname string `json:"ifname,omitempty"`
 	// file descriptor of an already opened tap
-	Fd string `json:"fd"`
+	Fd string `json:"fd,omitempty"`
 	// multiple file descriptors of already opened multiqueue capable tap
-	Fds string `json:"fds"`
+	Fds string `json:"fds,omitempty"`
 	// script to initialize the interface
-	Script string `json:"script"`
+	Script string `json:"script,omitempty"`
 	// script to shut down the interface
-	Downscript string `json:"downscript"`
+	Downscript string `json:"downscript,omitempty"`
 	// bridge name (since 2.8)
-	Br string `json:"br"`
+	Br string `json:"br,omitempty"`
 	// command to execute to configure bridge
-	Helper string `json:"helper"`
+	Helper string `json:"helper,omitempty"`
 	// send buffer limit. Understands [TGMKkb] suffixes.
-	Sndbuf uint64 `json:"sndbuf"`
+	Sndbuf uint64 `json:"sndbuf,omitempty"`
 	// enable the IFF_VNET_HDR flag on the tap interface
-	VnetHdr bool `json:"vnet_hdr"`
+	VnetHdr bool `json:"vnet_hdr,omitempty"`
 	// enable vhost-net network accelerator
-	Vhost bool `json:"vhost"`
+	Vhost bool `json:"vhost,omitempty"`
 	// file descriptor of an already opened vhost net device
-	Vhostfd string `json:"vhostfd"`
+	Vhostfd string `json:"vhostfd,omitempty"`
 	// file descriptors of multiple already opened vhost net devices
-	Vhostfds string `json:"vhostfds"`
+	Vhostfds string `json:"vhostfds,omitempty"`
 	// vhost on for non-MSIX virtio guests
-	Vhostforce bool `json:"vhostforce"`
+	Vhostforce bool `json:"vhostforce,omitempty"`
 	// number of queues to be created for multiqueue capable tap
-	Queues uint32 `json:"queues"`
+	Queues uint32 `json:"queues,omitempty"`
 	// maximum number of microseconds that could be spent on busy polling for tap
 	// (since 2.7)
-	PollUs uint32 `json:"poll-us"`
+	PollUs uint32 `json:"poll-us,omitempty"`
 }
 
 // Configure an Ethernet over L2TPv3 tunnel.
@@ -10,6 +10,7 @@ import (
 	"io"
 	"reflect"
 	"sync"
+	"time"
 )
 
 type QEMUMachineProtocolClient struct {
@@ -17,6 +18,14 @@ type QEMUMachineProtocolClient struct {
 	lock sync.RWMutex
 	recv *bufio.Reader
 	send *bufio.Writer
+
+	// events are the asynchronous events which were received whilst awaiting
+	// the reply to a request and which are yet to be consumed by NextEvent.
+	events [][]byte
+
+	// partial is the incomplete message which was received before reading was
+	// interrupted, e.g. by a deadline.
+	partial []byte
 }
 
 func NewQEMUMachineProtocolClient(conn io.ReadWriteCloser) *QEMUMachineProtocolClient {
@@ -31,6 +40,86 @@ func (c *QEMUMachineProtocolClient) Close() error {
 	return c.conn.Close()
 }
 
+// SetReadDeadline sets the deadline for receiving replies and events if it is
+// supported by the underlying connection.
+func (c *QEMUMachineProtocolClient) SetReadDeadline(t time.Time) error {
+	conn, ok := c.conn.(interface{ SetReadDeadline(time.Time) error })
+	if !ok {
+		return fmt.Errorf("connection does not support deadlines")
+	}
+
+	return conn.SetReadDeadline(t)
+}
+
+// readMessage returns the next message received from the connection.  The
+// message is retained if reading it is interrupted so that it can be resumed.
+func (c *QEMUMachineProtocolClient) readMessage() ([]byte, error) {
+	b, err := c.recv.ReadBytes('\n')
+	if err != nil {
+		c.partial = append(c.partial, b...)
+		return nil, err
+	}
+
+	if len(c.partial) > 0 {
+		b = append(c.partial, b...)
+		c.partial = nil
+	}
+
+	return b, nil
+}
+
+// isEvent returns whether the provided message is an asynchronous event rather
+// than the reply to a request.
+func (c *QEMUMachineProtocolClient) isEvent(b []byte) bool {
+	var msg struct {
+		Event *string `json:"event"`
+	}
+
+	return json.Unmarshal(b, &msg) == nil && msg.Event != nil
+}
+
+// readReply returns the next message received from the connection which is not
+// an asynchronous event.  Events are retained for NextEvent.
+func (c *QEMUMachineProtocolClient) readReply() ([]byte, error) {
+	for {
+		b, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.isEvent(b) {
+			return b, nil
+		}
+
+		c.events = append(c.events, b)
+	}
+}
+
+// NextEvent returns the next asynchronous event, including those which were
+// received whilst awaiting the reply to an earlier request.  It blocks until an
+// event is received or the read deadline is exceeded.
+func (c *QEMUMachineProtocolClient) NextEvent() ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.events) > 0 {
+		b := c.events[0]
+		c.events = c.events[1:]
+		return b, nil
+	}
+
+	for {
+		b, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.isEvent(b) {
+			return b, nil
+		}
+	}
+}
+
 func (c *QEMUMachineProtocolClient) setRpcRequestSetDefaults(face any) error {
 	v := reflect.ValueOf(face)
 
@@ -73,7 +162,7 @@ func (c *QEMUMachineProtocolClient) Greeting() (*GreetingResponse, error) {
 	defer c.lock.Unlock()
 
 	var res GreetingResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +196,7 @@ func (c *QEMUMachineProtocolClient) Quit(req QuitRequest) (*QuitResponse, error)
 	}
 
 	var res QuitResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +230,7 @@ func (c *QEMUMachineProtocolClient) Stop(req StopRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +264,7 @@ func (c *QEMUMachineProtocolClient) Cont(req ContRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +298,7 @@ func (c *QEMUMachineProtocolClient) SystemReset(req SystemResetRequest) (*any, e
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +332,7 @@ func (c *QEMUMachineProtocolClient) SystemPowerdown(req SystemPowerdownRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +366,7 @@ func (c *QEMUMachineProtocolClient) SystemWakeup(req SystemWakeupRequest) (*any,
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +400,7 @@ func (c *QEMUMachineProtocolClient) Capabilities(req CapabilitiesRequest) (*any,
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +434,7 @@ func (c *QEMUMachineProtocolClient) QueryKvm(req QueryKvmRequest) (*QueryKvmResp
 	}
 
 	var res QueryKvmResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -379,7 +468,7 @@ func (c *QEMUMachineProtocolClient) QueryStatus(req QueryStatusRequest) (*QueryS
 	}
 
 	var res QueryStatusResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +502,7 @@ func (c *QEMUMachineProtocolClient) SetLink(req SetLinkRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -447,7 +536,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddLegacyNic(req NetdevAddLegacyNicReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -481,7 +570,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevUser(req NetdevAddDevUserRequest
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -515,7 +604,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevTap(req NetdevAddDevTapRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -549,7 +638,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevL2TPv3(req NetdevAddDevL2TPv3Req
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -583,7 +672,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevSocket(req NetdevAddDevSocketReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -617,7 +706,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevStream(req NetdevAddDevStreamReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -651,7 +740,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevDgram(req NetdevAddDevDgramReque
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -685,7 +774,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVde(req NetdevAddDevVdeRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -719,7 +808,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevBridge(req NetdevAddDevBridgeReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -753,7 +842,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevHubPort(req NetdevAddDevHubPortR
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -787,7 +876,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevNetmap(req NetdevAddDevNetmapReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -821,7 +910,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVhostUser(req NetdevAddDevVhostU
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -855,7 +944,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVhostVDPA(req NetdevAddDevVhostV
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -889,7 +978,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetHost(req NetdevAddDevVmnetH
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -923,7 +1012,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetShared(req NetdevAddDevVmne
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -957,7 +1046,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetBridged(req NetdevAddDevVmn
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -991,7 +1080,7 @@ func (c *QEMUMachineProtocolClient) NetdevDel(req NetdevDelRequest) (*any, error
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -1025,7 +1114,245 @@ func (c *QEMUMachineProtocolClient) QueryRxFilter(req QueryRxFilterRequest) (*Qu
 	}
 
 	var res QueryRxFilterResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) DeviceAdd(req DeviceAddRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) DeviceDel(req DeviceDelRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) Balloon(req BalloonRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryBalloon(req QueryBalloonRequest) (*QueryBalloonResponse, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res QueryBalloonResponse
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryBlockstats(req QueryBlockstatsRequest) (*QueryBlockstatsResponse, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res QueryBlockstatsResponse
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) ObjectAddFilterDump(req ObjectAddFilterDumpRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) ObjectDel(req ObjectDelRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmpv7alpha2_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+// fakeQMP serves the provided messages for each request received on the
+// connection, in order, and returns the connection of the client.
+func fakeQMP(t *testing.T, replies ...[]string) net.Conn {
+	t.Helper()
+
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		recv := bufio.NewReader(server)
+		for _, messages := range replies {
+			if _, err := recv.ReadBytes('\n'); err != nil {
+				return
+			}
+
+			for _, message := range messages {
+				if _, err := server.Write([]byte(message + "\n")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return client
+}
+
+func TestClientSkipsEvents(t *testing.T) {
+	client := qmpapi.NewQEMUMachineProtocolClient(fakeQMP(t,
+		[]string{
+			`{"event": "DEVICE_DELETED", "data": {"path": "/machine/peripheral/nic1/virtio-backend"}, "timestamp": {"seconds": 1, "microseconds": 0}}`,
+			`{"return": {}}`,
+		},
+		[]string{
+			`{"event": "DEVICE_DELETED", "data": {"device": "nic1", "path": "/machine/peripheral/nic1"}, "timestamp": {"seconds": 1, "microseconds": 1}}`,
+			`{"error": {"class": "GenericError", "desc": "Device 'hostnet1' not found"}}`,
+		},
+	))
+
+	res, err := client.DeviceDel(qmpapi.DeviceDelRequest{
+		Arguments: qmpapi.DeviceDelRequestArguments{Id: "nic1"},
+	})
+	if err != nil {
+		t.Fatal("DeviceDel:", err)
+	}
+
+	if ret, ok := (*res).(map[string]any); !ok || ret["return"] == nil {
+		t.Errorf("expected the reply to device_del, got %v", *res)
+	}
+
+	res, err = client.NetdevDel(qmpapi.NetdevDelRequest{
+		Arguments: qmpapi.NetdevDelRequestArguments{Id: "hostnet1"},
+	})
+	if err != nil {
+		t.Fatal("NetdevDel:", err)
+	}
+
+	if ret, ok := (*res).(map[string]any); !ok || ret["error"] == nil {
+		t.Errorf("expected the error reply to netdev_del, got %v", *res)
+	}
+
+	// Both events are retained in the order in which they were received.
+	for _, expect := range []string{"/machine/peripheral/nic1/virtio-backend", "/machine/peripheral/nic1"} {
+		b, err := client.NextEvent()
+		if err != nil {
+			t.Fatal("NextEvent:", err)
+		}
+
+		var event struct {
+			Event string `json:"event"`
+			Data  struct {
+				Path string `json:"path"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(b, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		if event.Event != "DEVICE_DELETED" || event.Data.Path != expect {
+			t.Errorf("expected DEVICE_DELETED of %s, got %s", expect, b)
+		}
+	}
+}
+
+func TestClientNextEventDeadline(t *testing.T) {
+	conn := fakeQMP(t, []string{
+		`{"return": {}}`,
+	})
+
+	client := qmpapi.NewQEMUMachineProtocolClient(conn)
+
+	if _, err := client.DeviceDel(qmpapi.DeviceDelRequest{
+		Arguments: qmpapi.DeviceDelRequestArguments{Id: "nic1"},
+	}); err != nil {
+		t.Fatal("DeviceDel:", err)
+	}
+
+	if err := client.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal("SetReadDeadline:", err)
+	}
+
+	if _, err := client.NextEvent(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestClientResumesPartialMessage(t *testing.T) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	qmpClient := qmpapi.NewQEMUMachineProtocolClient(client)
+
+	event := `{"event": "DEVICE_DELETED", "data": {"device": "nic1"}, "timestamp": {"seconds": 1, "microseconds": 0}}`
+
+	go func() {
+		_, _ = server.Write([]byte(event[:20]))
+	}()
+
+	if err := qmpClient.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal("SetReadDeadline:", err)
+	}
+
+	if _, err := qmpClient.NextEvent(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+
+	if err := qmpClient.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal("SetReadDeadline:", err)
+	}
+
+	go func() {
+		_, _ = server.Write([]byte(event[20:] + "\n"))
+	}()
+
+	b, err := qmpClient.NextEvent()
+	if err != nil {
+		t.Fatal("NextEvent:", err)
+	}
+
+	if string(b) != event+"\n" {
+		t.Errorf("expected event %s, got %s", event, b)
+	}
+}
@@ -6,6 +6,7 @@ package qemu
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,8 +15,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	zip "api.zip"
@@ -32,16 +36,43 @@ import (
 	"kraftkit.sh/exec"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/retrytimeout"
+	"kraftkit.sh/internal/run"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network/macaddr"
+	"kraftkit.sh/machine/network/macvtap"
+	"kraftkit.sh/machine/network/portforward"
 	"kraftkit.sh/machine/qemu/qmp"
 	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+	"kraftkit.sh/machine/stats"
+	ninepfs "kraftkit.sh/machine/volume/9pfs"
+	"kraftkit.sh/machine/volume/blk"
+	"kraftkit.sh/machine/volume/virtiofs"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
 	"kraftkit.sh/unikraft/export/v0/vfscore"
 )
 
+// qemuConsoleCharDevId is the id of the character device which backs the
+// serial console of the machine.
+const qemuConsoleCharDevId = "console"
+
+// qemuSharedMemoryId is the id of the memory backend which backs the memory of
+// the machine when it is shared with vhost-user device backends.
+const qemuSharedMemoryId = "mem"
+
+// qmpDeviceDeletedTimeout is the duration for which the guest is awaited to
+// release a device which is unplugged.
+const qmpDeviceDeletedTimeout = 5 * time.Second
+
+// virtiofsDaemon describes the virtiofsd process which serves a virtio-fs
+// volume of a machine.
+type virtiofsDaemon struct {
+	id       string
+	source   string
+	readOnly bool
+}
+
 // machineV1alpha1Service ...
 type machineV1alpha1Service struct {
 	eopts []exec.ExecOption
@@ -154,6 +185,11 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		machine.Status.LogFile = filepath.Join(machine.Status.StateDir, "machine.log")
 	}
 
+	machine.Status.ConsoleSocket = QemuHostCharDevUnix{
+		SocketDir: machine.Status.StateDir,
+		Name:      "qemu_console",
+	}.Resource()
+
 	if machine.Spec.Resources.Requests == nil {
 		machine.Spec.Resources.Requests = make(corev1.ResourceList, 2)
 	}
@@ -206,9 +242,17 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			NoWait:    true,
 			Server:    true,
 		}),
-		WithSerial(QemuHostCharDevFile{
-			Monitor:  false,
-			Filename: machine.Status.LogFile,
+		// Connect the serial console to a socket such that it can be attached to
+		// interactively whilst its output is also written to the log file.
+		WithCharDevice(QemuCharDevSocketUnix{
+			Id:      qemuConsoleCharDevId,
+			Path:    machine.Status.ConsoleSocket,
+			Server:  true,
+			NoWait:  true,
+			LogFile: machine.Status.LogFile,
+		}),
+		WithSerial(QemuHostCharDevNamed{
+			Id: qemuConsoleCharDevId,
 		}),
 		WithMonitor(QemuHostCharDevUnix{
 			SocketDir: machine.Status.StateDir,
@@ -227,6 +271,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		}),
 		WithDisplay(QemuDisplayNone{}),
 		WithParallel(QemuHostCharDevNone{}),
+		// Attach a memory balloon such that the amount of memory made available to
+		// the guest can be adjusted at runtime.
+		WithDevice(QemuDeviceVirtioBalloonPci{}),
 	}
 
 	// TODO: Parse Rootfs types
@@ -251,6 +298,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, err
 	}
 
+	// Open devices which are handed to the QEMU process.
+	var extraFiles []*os.File
+
 	if len(machine.Spec.Networks) > 0 {
 		// Iterate over each interface of each network interface associated with
 		// this machine and attach it as a device.
@@ -267,6 +317,31 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 				hostnetid := fmt.Sprintf("hostnet%d", hostnetCounter)
 				hostnetCounter++
 
+				netdev := QemuNetDevTap{
+					Id:         hostnetid,
+					Ifname:     iface.Spec.IfName,
+					Br:         network.IfName,
+					Script:     "no", // Disable execution
+					Downscript: "no", // Disable execution
+				}
+
+				if network.Driver == macvtap.DriverName {
+					file, err := macvtap.OpenDevice(iface.Spec.IfName)
+					if err != nil {
+						return machine, fmt.Errorf("could not open macvtap device of %s: %w", iface.Spec.IfName, err)
+					}
+
+					defer file.Close()
+
+					// Extra files are passed to the process starting with file
+					// descriptor 3.
+					extraFiles = append(extraFiles, file)
+					netdev = QemuNetDevTap{
+						Id: hostnetid,
+						Fd: 2 + len(extraFiles),
+					}
+				}
+
 				qopts = append(qopts,
 					// TODO(nderjung): The network device should be customizable based on
 					// the network spec or machine spec.  Additional insight can be provided
@@ -277,15 +352,15 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 						Netdev: hostnetid,
 						Mac:    mac,
 					}),
-					WithNetDevice(QemuNetDevTap{
-						Id:         hostnetid,
-						Ifname:     iface.Spec.IfName,
-						Br:         network.IfName,
-						Script:     "no", // Disable execution
-						Downscript: "no", // Disable execution
-					}),
+					WithNetDevice(netdev),
 				)
 
+				// Interfaces without an address, e.g. those of a macvtap network,
+				// acquire one from their network instead.
+				if iface.Spec.CIDR == "" {
+					continue
+				}
+
 				kernelArgs = append(kernelArgs,
 					uknetdev.NewParamIp().WithValue(uknetdev.NetdevIp{
 						CIDR:     iface.Spec.CIDR,
@@ -300,7 +375,13 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		}
 	}
 
-	if len(machine.Spec.Ports) > 0 {
+	// Ports of machines which are attached to a bridge network are forwarded by
+	// the host to the machine's address on that network.  Otherwise, fall back
+	// to a user-mode network device for each published port.
+	_, _, targetErr := portforward.Target(machine.Spec.Networks)
+	forwardOnHost := targetErr == nil
+
+	if len(machine.Spec.Ports) > 0 && !forwardOnHost {
 		for _, port := range machine.Spec.Ports {
 			mac := port.MacAddress
 			if mac == "" {
@@ -323,75 +404,102 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		}
 	}
 
-	var fstab []string
+	blkCounter := 0
+
+	// The virtiofsd processes serving virtio-fs volumes are only started right
+	// before QEMU, which connects to them when it starts.
+	var virtiofsDaemons []virtiofsDaemon
 
 	for i, vol := range machine.Spec.Volumes {
+		if len(vol.Spec.Options) > 0 && vol.Spec.Driver != "9pfs" {
+			return machine, fmt.Errorf("mount options are not supported by %s volumes", vol.Spec.Driver)
+		}
+
 		switch vol.Spec.Driver {
 		case "9pfs":
+			options, err := ninepfs.ParseOptions(vol.Spec.Options)
+			if err != nil {
+				return machine, err
+			}
+
+			securityModel := QemuFsDevLocalSecurityModelPassthrough
+			if len(options.SecurityModel) > 0 {
+				securityModel = QemuFsDevLocalSecurityModel(options.SecurityModel)
+			}
+
 			hvirtioid := fmt.Sprintf("hvirtio%d", i+1)
-			mounttag := fmt.Sprintf("fs%d", i+1)
 			qopts = append(qopts,
 				WithFsDevice(QemuFsDevLocal{
-					SecurityModel: QemuFsDevLocalSecurityModelPassthrough,
+					SecurityModel: securityModel,
 					Id:            hvirtioid,
 					Path:          vol.Spec.Source,
+					Readonly:      vol.Spec.ReadOnly,
 				}),
 				WithDevice(QemuDeviceVirtio9pPci{
 					Fsdev:    hvirtioid,
-					MountTag: mounttag,
+					MountTag: fmt.Sprintf("fs%d", i+1),
 				}),
 			)
 
-			fstab = append(fstab, vfscore.NewFstabEntry(
-				mounttag,
-				vol.Spec.Destination,
-				vol.Spec.Driver,
-				// TODO(nderjung): Options (such as ro/rw) are yet supported by
-				// Unikraft:
-				"",
-				"",
-				// By default, create the directory if it does not exist when mounting.
-				"mkmp",
-			).String())
+		case virtiofs.DriverName:
+			hvhostid := fmt.Sprintf("hvhost%d", i+1)
+			virtiofsDaemons = append(virtiofsDaemons, virtiofsDaemon{
+				id:       hvhostid,
+				source:   vol.Spec.Source,
+				readOnly: vol.Spec.ReadOnly,
+			})
+			qopts = append(qopts,
+				WithCharDevice(QemuCharDevSocketUnix{
+					Id:   hvhostid,
+					Path: virtiofs.SocketPath(machine.Status.StateDir, hvhostid),
+				}),
+				WithDevice(QemuDeviceVhostUserFsPci{
+					Chardev: hvhostid,
+					Tag:     fmt.Sprintf("fs%d", i+1),
+				}),
+			)
+
+		case blk.DriverName:
+			hblkid := fmt.Sprintf("hblk%d", blkCounter)
+			blkCounter++
+			qopts = append(qopts,
+				WithDrive(QemuDrive{
+					Id:       hblkid,
+					File:     vol.Spec.Source,
+					If:       QemuDriveInterfaceNone,
+					Format:   QemuDriveFormatRaw,
+					Readonly: vol.Spec.ReadOnly,
+				}),
+				WithDevice(QemuDeviceVirtioBlkPci{
+					Drive: hblkid,
+				}),
+			)
 
 		case "initrd":
-			fstab = append(fstab, vfscore.NewFstabEntry(
-				"initrd0",
-				vol.Spec.Destination,
-				"extract",
-				"",
-				"",
-				"",
-			).String())
 		default:
 			return machine, fmt.Errorf("unsupported QEMU volume driver: %v", vol.Spec.Driver)
 		}
 	}
 
-	if len(fstab) > 0 {
-		kernelArgs = append(kernelArgs,
-			vfscore.ParamVfsFstab.WithValue(fstab),
-		)
-	}
-
-	var environ []string
-	for k, v := range machine.Spec.Env {
-		environ = append(environ, fmt.Sprintf("%s=%s", k, v))
-	}
-
-	if len(environ) > 0 {
-		kernelArgs = append(kernelArgs,
-			posixenviron.ParamEnvVars.WithValue(environ),
+	// vhost-user devices access the guest's memory directly, which therefore has
+	// to be shared with the processes serving them.
+	if len(virtiofsDaemons) > 0 {
+		qopts = append(qopts,
+			WithObject(QemuObjectMemoryBackendMemfd{
+				Id: qemuSharedMemoryId,
+				Size: QemuMemory{
+					Size: uint64(machine.Spec.Resources.Requests.Memory().Value() / QemuMemoryScale),
+					Unit: QemuMemoryUnitMB,
+				},
+				Share: true,
+			}),
+			WithNumaNode(QemuNumaNode{
+				Memdev: qemuSharedMemoryId,
+			}),
 		)
 	}
 
-	// TODO(nderjung): This is standard "Unikraft" positional argument syntax
-	// (kernel args and application arguments separated with "--").  The resulting
-	// string should be standardized through a central function.
-	args := kernelArgs.Strings()
-	if len(args) > 0 {
-		args = append(args, "--")
-	}
+	args := bootArgs(machine.Spec, kernelArgs)
 
 	// We do not need to append the kernel path since it is already provided
 	// by default by QEMU. QEMU sets arg[0] to the absolute path of the kernel
@@ -399,7 +507,6 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	// 'qemu-binfmt-conf.sh' when installing QEMU.
 	// args = append(args, filepath.Base(machine.Status.KernelPath))
 
-	args = append(args, machine.Spec.ApplicationArgs...)
 	qopts = append(qopts, WithAppend(args...))
 
 	switch machine.Spec.Architecture {
@@ -487,7 +594,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("could not prepare QEMU executable: %v", err)
 	}
 
-	process, err := exec.NewProcessFromExecutable(e, service.eopts...)
+	process, err := exec.NewProcessFromExecutable(e,
+		append(service.eopts, exec.WithExtraFiles(extraFiles...))...,
+	)
 	if err != nil {
 		machine.Status.State = machinev1alpha1.MachineStateFailed
 		return machine, fmt.Errorf("could not prepare QEMU process: %v", err)
@@ -495,11 +604,22 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	machine.CreationTimestamp = metav1.Now()
 
+	for _, daemon := range virtiofsDaemons {
+		if err := virtiofs.Spawn(ctx, machine.Status.StateDir, daemon.id, daemon.source, daemon.readOnly); err != nil {
+			machine.Status.State = machinev1alpha1.MachineStateFailed
+			_ = virtiofs.Stop(ctx, machine.Status.StateDir)
+			return machine, err
+		}
+	}
+
 	// Start and also wait for the process to be released, this ensures the
 	// program is actively being executed.
 	if err := process.StartAndWait(ctx); err != nil {
 		machine.Status.State = machinev1alpha1.MachineStateFailed
 
+		// The virtiofsd processes would otherwise wait for QEMU indefinitely.
+		_ = virtiofs.Stop(ctx, machine.Status.StateDir)
+
 		// Propagate the contents of the QEMU log file as an error
 		if errLog, err2 := os.ReadFile(qemuLogFile); err2 == nil {
 			err = errors.Join(fmt.Errorf(strings.TrimSpace(string(errLog))), err)
@@ -510,12 +630,626 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	machine.Status.State = machinev1alpha1.MachineStateCreated
 
+	// Interfaces which have been requested to be down can only be set so once
+	// the VMM is available.
+	if err := service.setLinks(ctx, machine, *qcfg); err != nil {
+		return machine, err
+	}
+
+	if len(machine.Spec.Ports) > 0 && forwardOnHost {
+		if err := service.publishPorts(ctx, machine); err != nil {
+			return machine, err
+		}
+	}
+
 	return machine, nil
 }
 
-// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
+// bootArgs returns the command-line which is passed to the unikernel based on
+// the machine's specification and the provided kernel parameters.
+func bootArgs(spec machinev1alpha1.MachineSpec, kernelArgs ukargparse.Params) []string {
+	var fstab []string
+	blkCounter := 0
+
+	for i, vol := range spec.Volumes {
+		var flags vfscore.MountFlags
+		if vol.Spec.ReadOnly {
+			flags |= vfscore.MountFlagReadOnly
+		}
+
+		switch vol.Spec.Driver {
+		case "9pfs":
+			// The options have been validated when creating the machine.
+			options, _ := ninepfs.ParseOptions(vol.Spec.Options)
+
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				fmt.Sprintf("fs%d", i+1),
+				vol.Spec.Destination,
+				vol.Spec.Driver,
+				flags.String(),
+				strings.Join(options.Mount, ","),
+				// By default, create the directory if it does not exist when mounting.
+				"mkmp",
+			).String())
+
+		case virtiofs.DriverName:
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				fmt.Sprintf("fs%d", i+1),
+				vol.Spec.Destination,
+				vol.Spec.Driver,
+				flags.String(),
+				"",
+				"mkmp",
+			).String())
+
+		case blk.DriverName:
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				blk.DeviceName(blkCounter),
+				vol.Spec.Destination,
+				vol.Spec.Filesystem,
+				flags.String(),
+				"",
+				"mkmp",
+			).String())
+			blkCounter++
+
+		case "initrd":
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				"initrd0",
+				vol.Spec.Destination,
+				"extract",
+				"",
+				"",
+				"",
+			).String())
+		}
+	}
+
+	if len(fstab) > 0 {
+		kernelArgs = append(kernelArgs,
+			vfscore.ParamVfsFstab.WithValue(fstab),
+		)
+	}
+
+	// Sort the environment variables such that the resulting command-line is
+	// deterministic for the same specification.
+	var environ []string
+	for k, v := range spec.Env {
+		environ = append(environ, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	sort.Strings(environ)
+
+	if len(environ) > 0 {
+		kernelArgs = append(kernelArgs,
+			posixenviron.ParamEnvVars.WithValue(environ),
+		)
+	}
+
+	// TODO(nderjung): This is standard "Unikraft" positional argument syntax
+	// (kernel args and application arguments separated with "--").  The resulting
+	// string should be standardized through a central function.
+	args := kernelArgs.Strings()
+	if len(args) > 0 {
+		args = append(args, "--")
+	}
+
+	return append(args, spec.ApplicationArgs...)
+}
+
+// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService.Update
+//
+// The provided specification is compared against the configuration of the
+// running QEMU process and only the differences which can be applied live via
+// QMP are performed, namely: attaching and detaching network interfaces,
+// toggling the link state of network interfaces and adjusting the memory
+// balloon.  Any other change to the specification results in an error.
 func (service *machineV1alpha1Service) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/qemu.machineV1alpha1Service.Update")
+	qcfg, ok := machine.Status.PlatformConfig.(QemuConfig)
+	if !ok {
+		return machine, fmt.Errorf("cannot read QEMU platform configuration from machine status")
+	}
+
+	process, err := processFromPidFile(qcfg.PidFile)
+	if err != nil {
+		return machine, fmt.Errorf("cannot update machine: %w", err)
+	}
+
+	if running, err := process.IsRunning(); err != nil || !running {
+		return machine, fmt.Errorf("cannot update machine: QEMU process is not running")
+	}
+
+	if err := checkImmutableSpec(machine, qcfg); err != nil {
+		return machine, fmt.Errorf("cannot update machine: %w", err)
+	}
+
+	memory, err := memoryBytes(qcfg.Memory)
+	if err != nil {
+		return machine, err
+	}
+
+	requestedMemory := machine.Spec.Resources.Requests.Memory().Value()
+	if requestedMemory > memory {
+		return machine, fmt.Errorf("cannot update machine: memory cannot exceed the boot size of %d bytes", memory)
+	} else if requestedMemory > 0 && requestedMemory != memory && !hasBalloon(qcfg) {
+		return machine, fmt.Errorf("cannot update machine: memory balloon device is not attached")
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		return machine, fmt.Errorf("could not update qemu instance: %v", err)
+	}
+
+	defer qmpClient.Close()
+
+	// Collect the list of tap interfaces which are attached to the running
+	// machine, such that they can be compared against the requested
+	// interfaces.
+	attached := map[string]QemuNetDevTap{}
+	handedOver := 0
+	hostnetCounter := 0
+	for _, netdev := range qcfg.NetDevs {
+		var id string
+
+		switch nd := netdev.(type) {
+		case QemuNetDevTap:
+			// Devices which have been handed over by file descriptor cannot be
+			// detached by name.
+			if nd.Fd == 0 {
+				attached[nd.Ifname] = nd
+			} else {
+				handedOver++
+			}
+			id = nd.Id
+		case QemuNetDevUser:
+			id = nd.Id
+		}
+
+		var i int
+		if _, err := fmt.Sscanf(id, "hostnet%d", &i); err == nil && i >= hostnetCounter {
+			hostnetCounter = i + 1
+		}
+	}
+
+	requested := map[string]bool{}
+
+	for _, network := range machine.Spec.Networks {
+		// The devices of macvtap interfaces can only be handed to QEMU when it
+		// is started.
+		if network.Driver == macvtap.DriverName {
+			handedOver -= len(network.Interfaces)
+			if handedOver < 0 {
+				return machine, fmt.Errorf("cannot update machine: macvtap interfaces cannot be attached to a running machine")
+			}
+
+			continue
+		}
+
+		for _, iface := range network.Interfaces {
+			requested[iface.Spec.IfName] = true
+
+			if _, ok := attached[iface.Spec.IfName]; ok {
+				continue
+			}
+
+			mac := iface.Spec.MacAddress
+			if mac == "" {
+				hwaddr, err := macaddr.GenerateMacAddress(false)
+				if err != nil {
+					return machine, err
+				}
+
+				mac = hwaddr.String()
+			}
+
+			hostnetid := fmt.Sprintf("hostnet%d", hostnetCounter)
+			hostnetCounter++
+
+			log.G(ctx).
+				WithField("ifname", iface.Spec.IfName).
+				WithField("bridge", network.IfName).
+				Debug("attaching network interface")
+
+			netdev := QemuNetDevTap{
+				Id:         hostnetid,
+				Ifname:     iface.Spec.IfName,
+				Br:         network.IfName,
+				Script:     "no", // Disable execution
+				Downscript: "no", // Disable execution
+			}
+
+			res, err := qmpClient.NetdevAddDevTap(qmpapi.NetdevAddDevTapRequest{
+				Arguments: qmpapi.NetdevTapOptions{
+					Id:         netdev.Id,
+					Type:       qmpapi.NET_CLIENT_DRIVER_TAP,
+					Ifname:     netdev.Ifname,
+					Script:     netdev.Script,
+					Downscript: netdev.Downscript,
+				},
+			})
+			if err := errors.Join(err, qmpResponseError(res)); err != nil {
+				return machine, fmt.Errorf("could not add netdev for interface %s: %w", iface.Spec.IfName, err)
+			}
+
+			res, err = qmpClient.DeviceAdd(qmpapi.DeviceAddRequest{
+				Arguments: qmpapi.DeviceAddRequestArguments{
+					Driver: string(QemuDeviceTypeVirtioNetPci),
+					Id:     nicID(hostnetid),
+					Netdev: hostnetid,
+					Mac:    mac,
+				},
+			})
+			if err := errors.Join(err, qmpResponseError(res)); err != nil {
+				return machine, fmt.Errorf("could not add device for interface %s: %w", iface.Spec.IfName, err)
+			}
+
+			qcfg.NetDevs = append(qcfg.NetDevs, netdev)
+			qcfg.Devices = append(qcfg.Devices, QemuDeviceVirtioNetPci{
+				Netdev: hostnetid,
+				Mac:    mac,
+			})
+			attached[netdev.Ifname] = netdev
+		}
+	}
+
+	// Detach all interfaces which are no longer requested.
+	for ifname, netdev := range attached {
+		if requested[ifname] {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("ifname", ifname).
+			Debug("detaching network interface")
+
+		// Interfaces which were supplied on the command-line cannot be unplugged
+		// since they do not carry an ID.  Instead, their link is brought down
+		// before the backend is removed.
+		// The backend is only removed once the guest has released the device,
+		// which QEMU signals asynchronously.  Devices which are not released in
+		// time are treated like those which cannot be unplugged.
+		res, err := qmpClient.DeviceDel(qmpapi.DeviceDelRequest{
+			Arguments: qmpapi.DeviceDelRequestArguments{
+				Id: nicID(netdev.Id),
+			},
+		})
+		if err = errors.Join(err, qmpResponseError(res)); err == nil {
+			if err = waitDeviceDeleted(qmpClient, nicID(netdev.Id), qmpDeviceDeletedTimeout); err != nil {
+				log.G(ctx).
+					WithField("ifname", ifname).
+					Warnf("guest did not release network interface: %v", err)
+			}
+		}
+		if err != nil {
+			res, err = qmpClient.SetLink(qmpapi.SetLinkRequest{
+				Arguments: qmpapi.SetLinkRequestArguments{
+					Name: netdev.Id,
+					Up:   false,
+				},
+			})
+			if err := errors.Join(err, qmpResponseError(res)); err != nil {
+				return machine, fmt.Errorf("could not detach interface %s: %w", ifname, err)
+			}
+		}
+
+		res, err = qmpClient.NetdevDel(qmpapi.NetdevDelRequest{
+			Arguments: qmpapi.NetdevDelRequestArguments{
+				Id: netdev.Id,
+			},
+		})
+		if err := errors.Join(err, qmpResponseError(res)); err != nil {
+			return machine, fmt.Errorf("could not remove netdev of interface %s: %w", ifname, err)
+		}
+
+		qcfg.NetDevs = slices.DeleteFunc(qcfg.NetDevs, func(nd QemuNetDev) bool {
+			tap, ok := nd.(QemuNetDevTap)
+			return ok && tap.Id == netdev.Id
+		})
+		qcfg.Devices = slices.DeleteFunc(qcfg.Devices, func(dev QemuDevice) bool {
+			nic, ok := dev.(QemuDeviceVirtioNetPci)
+			return ok && nic.Netdev == netdev.Id
+		})
+	}
+
+	machine.Status.PlatformConfig = qcfg
+
+	if err := service.setLinks(ctx, machine, qcfg); err != nil {
+		return machine, err
+	}
+
+	// Ports which are forwarded by the host can be re-published at any time.
+	if publishesPortsOnHost(machine, qcfg) {
+		if err := service.publishPorts(ctx, machine); err != nil {
+			return machine, err
+		}
+	}
+
+	if requestedMemory > 0 && hasBalloon(qcfg) {
+		res, err := qmpClient.Balloon(qmpapi.BalloonRequest{
+			Arguments: qmpapi.BalloonRequestArguments{
+				Value: requestedMemory,
+			},
+		})
+		if err := errors.Join(err, qmpResponseError(res)); err != nil {
+			return machine, fmt.Errorf("could not adjust memory balloon: %w", err)
+		}
+	}
+
+	return machine, nil
+}
+
+// checkImmutableSpec compares the attributes of the machine's specification
+// which cannot be changed whilst the machine is running against the provided
+// QEMU configuration and returns an error if they differ.
+func checkImmutableSpec(machine *machinev1alpha1.Machine, qcfg QemuConfig) error {
+	if machine.Status.KernelPath != qcfg.Kernel {
+		return fmt.Errorf("kernel cannot be changed on a running machine")
+	}
+
+	if machine.Status.InitrdPath != qcfg.InitRd {
+		return fmt.Errorf("initramfs cannot be changed on a running machine")
+	}
+
+	if cpus := machine.Spec.Resources.Requests.Cpu().Value(); cpus > 0 && uint64(cpus) != qcfg.SMP.CPUs {
+		return fmt.Errorf("number of CPUs cannot be changed on a running machine")
+	}
+
+	// Ports published through user-mode network devices are fixed for the
+	// lifetime of the machine, unlike those which are forwarded by the host.
+	if !publishesPortsOnHost(machine, qcfg) {
+		var hostfwds []string
+		for _, netdev := range qcfg.NetDevs {
+			if user, ok := netdev.(QemuNetDevUser); ok && user.Hostfwd != "" {
+				hostfwds = append(hostfwds, user.Hostfwd)
+			}
+		}
+
+		var ports []string
+		for _, port := range machine.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%s::%d-:%d", port.Protocol, port.HostPort, port.MachinePort))
+		}
+
+		slices.Sort(hostfwds)
+		slices.Sort(ports)
+
+		if !slices.Equal(hostfwds, ports) {
+			return fmt.Errorf("published ports cannot be changed on a running machine")
+		}
+	}
+
+	var sources []string
+	for _, fsdev := range qcfg.FsDevs {
+		if local, ok := fsdev.(QemuFsDevLocal); ok {
+			sources = append(sources, local.Path)
+		}
+	}
+
+	var files []string
+	for _, drive := range qcfg.Drives {
+		files = append(files, drive.File)
+	}
+
+	var tags []string
+	for _, device := range qcfg.Devices {
+		if fs, ok := device.(QemuDeviceVhostUserFsPci); ok {
+			tags = append(tags, fs.Tag)
+		}
+	}
+
+	var volumes, images, shared []string
+	for i, vol := range machine.Spec.Volumes {
+		switch vol.Spec.Driver {
+		case "9pfs":
+			volumes = append(volumes, vol.Spec.Source)
+		case virtiofs.DriverName:
+			shared = append(shared, fmt.Sprintf("fs%d", i+1))
+		case blk.DriverName:
+			images = append(images, vol.Spec.Source)
+		}
+	}
+
+	if !slices.Equal(sources, volumes) || !slices.Equal(files, images) || !slices.Equal(tags, shared) {
+		return fmt.Errorf("volumes cannot be changed on a running machine")
+	}
+
+	kernelArgs, err := ukargparse.Parse(machine.Spec.KernelArgs...)
+	if err != nil {
+		return err
+	}
+
+	// The network interface parameters are omitted from the comparison since
+	// they are only consumed by the guest at boot and are otherwise managed via
+	// the attached interfaces.
+	if withoutNetdevIp(run.BootArgsPrepare(bootArgs(machine.Spec, kernelArgs)...)) != withoutNetdevIp(qcfg.Append) {
+		return fmt.Errorf("arguments and environment variables cannot be changed on a running machine")
+	}
+
+	return nil
+}
+
+// withoutNetdevIp removes all uknetdev IP address parameters from the
+// provided command-line.
+func withoutNetdevIp(cmdline string) string {
+	prefix := uknetdev.NewParamIp().Name() + "="
+
+	var args []string
+	for _, arg := range strings.Split(cmdline, " ") {
+		if strings.HasPrefix(arg, prefix) {
+			continue
+		}
+
+		args = append(args, arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// publishesPortsOnHost returns whether the published ports of the machine are
+// forwarded by the host to the machine's address on its bridge network rather
+// than through user-mode network devices of QEMU.
+func publishesPortsOnHost(machine *machinev1alpha1.Machine, qcfg QemuConfig) bool {
+	for _, netdev := range qcfg.NetDevs {
+		if user, ok := netdev.(QemuNetDevUser); ok && user.Hostfwd != "" {
+			return false
+		}
+	}
+
+	_, _, err := portforward.Target(machine.Spec.Networks)
+	return err == nil
+}
+
+// publishPorts forwards the published ports of the machine from the host to
+// the machine's address on its bridge network, replacing any previously
+// published ports.
+func (service *machineV1alpha1Service) publishPorts(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	ifname, addr, err := portforward.Target(machine.Spec.Networks)
+	if err != nil {
+		return err
+	}
+
+	if err := portforward.Add(ctx, string(machine.UID), ifname, addr, machine.Spec.Ports); err != nil {
+		return fmt.Errorf("could not publish ports: %w", err)
+	}
+
+	return nil
+}
+
+// setLinks sets the link state of each tap interface attached to the machine
+// according to the requested state of the interface in the machine's
+// specification.
+func (service *machineV1alpha1Service) setLinks(ctx context.Context, machine *machinev1alpha1.Machine, qcfg QemuConfig) error {
+	linkDown := map[string]bool{}
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			linkDown[iface.Spec.IfName] = iface.Spec.LinkDown
+		}
+	}
+
+	var taps []QemuNetDevTap
+	for _, netdev := range qcfg.NetDevs {
+		if tap, ok := netdev.(QemuNetDevTap); ok {
+			taps = append(taps, tap)
+		}
+	}
+
+	if len(taps) == 0 {
+		return nil
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		return fmt.Errorf("could not set link state: %v", err)
+	}
+
+	defer qmpClient.Close()
+
+	for _, tap := range taps {
+		res, err := qmpClient.SetLink(qmpapi.SetLinkRequest{
+			Arguments: qmpapi.SetLinkRequestArguments{
+				Name: tap.Id,
+				Up:   !linkDown[tap.Ifname],
+			},
+		})
+		if err := errors.Join(err, qmpResponseError(res)); err != nil {
+			return fmt.Errorf("could not set link state of %s: %w", tap.Ifname, err)
+		}
+	}
+
+	return nil
+}
+
+// nicID returns the ID of the network device which is hot-plugged and
+// connected to the network backend with the provided ID.
+func nicID(hostnetid string) string {
+	return strings.Replace(hostnetid, "hostnet", "nic", 1)
+}
+
+// waitDeviceDeleted blocks until QEMU reports that the guest has released the
+// device with the provided ID after it was unplugged, or until the timeout is
+// exceeded.
+func waitDeviceDeleted(qmpClient *qmpapi.QEMUMachineProtocolClient, id string, timeout time.Duration) error {
+	if err := qmpClient.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = qmpClient.SetReadDeadline(time.Time{})
+	}()
+
+	for {
+		b, err := qmpClient.NextEvent()
+		if err != nil {
+			return fmt.Errorf("could not receive event: %w", err)
+		}
+
+		var event struct {
+			Event qmpapi.EventType `json:"event"`
+			Data  struct {
+				Device string `json:"device"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(b, &event); err != nil {
+			return fmt.Errorf("could not parse event: %w", err)
+		}
+
+		if event.Data.Device != id {
+			continue
+		}
+
+		switch event.Event {
+		case qmpapi.EVENT_DEVICE_DELETED:
+			return nil
+		case qmpapi.EVENT_DEVICE_UNPLUG_GUEST_ERROR:
+			return fmt.Errorf("guest refused to unplug %s", id)
+		}
+	}
+}
+
+// hasBalloon returns whether the provided QEMU configuration has a memory
+// balloon device attached.
+func hasBalloon(qcfg QemuConfig) bool {
+	for _, device := range qcfg.Devices {
+		if _, ok := device.(QemuDeviceVirtioBalloonPci); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memoryBytes returns the number of bytes represented by the provided QEMU
+// memory configuration.
+func memoryBytes(memory QemuMemory) (int64, error) {
+	if memory.String() == "" {
+		return 0, fmt.Errorf("memory of QEMU configuration is not set")
+	}
+
+	quantity, err := resource.ParseQuantity(strings.SplitN(memory.String(), "=", 2)[1] + "i")
+	if err != nil {
+		return 0, err
+	}
+
+	return quantity.Value(), nil
+}
+
+// qmpResponseError returns the error embedded in a generic QMP response, if
+// any.
+func qmpResponseError(res *any) error {
+	if res == nil {
+		return nil
+	}
+
+	ret, ok := (*res).(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	qerr, ok := ret["error"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	return fmt.Errorf("%v: %v", qerr["class"], qerr["desc"])
 }
 
 // getQEMUConfigFromPlatformConfig converts the provided platformConfig
@@ -648,7 +1382,35 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 	// machine, so that it can be immediately acted upon.
 	firstCall := true
 
+	// send the provided machine to the consumer, unless the context has been
+	// cancelled.
+	send := func(machine *machinev1alpha1.Machine) bool {
+		select {
+		case events <- machine:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// fail sends the provided error to the consumer, unless the context has been
+	// cancelled.
+	fail := func(err error) bool {
+		select {
+		case errs <- err:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
 	go func() {
+		// Signal the end of the stream to the consumer, e.g. once the machine has
+		// exited or errored.
+		defer close(events)
+		defer close(errs)
+		defer conn.Close()
+
 	accept:
 		for {
 			// First check if the context has been cancelled
@@ -661,20 +1423,26 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 			// Check the current state
 			machine, err := service.Get(ctx, machine)
 			if err != nil {
-				errs <- err
+				if !fail(err) {
+					break accept
+				}
 				continue
 			}
 
 			// Initialize with the current state
 			if firstCall {
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 				firstCall = false
 			}
 
 			// Listen for changes in state
 			event, err := monitor.Accept()
 			if err != nil {
-				errs <- err
+				if !fail(err) {
+					break accept
+				}
 				continue
 			}
 
@@ -682,32 +1450,44 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 			switch event.Event {
 			case qmpapi.EVENT_STOP, qmpapi.EVENT_SUSPEND, qmpapi.EVENT_POWERDOWN:
 				machine.Status.State = machinev1alpha1.MachineStatePaused
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 
 			case qmpapi.EVENT_RESUME:
 				machine.Status.State = machinev1alpha1.MachineStateRunning
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 
 			case qmpapi.EVENT_RESET, qmpapi.EVENT_WAKEUP:
 				machine.Status.State = machinev1alpha1.MachineStateRestarting
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 
 			case qmpapi.EVENT_SHUTDOWN:
 				machine.Status.State = machinev1alpha1.MachineStateExited
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 
 				if !qcfg.NoShutdown {
 					break accept
 				}
 			case qmpapi.EVENT_GUEST_PANICKED:
 				machine.Status.State = machinev1alpha1.MachineStateErrored
-				events <- machine
+				if !send(machine) {
+					break accept
+				}
 
 				if !qcfg.NoShutdown {
 					break accept
 				}
 			default:
-				errs <- fmt.Errorf("unsupported event: %s", event.Event)
+				if !fail(fmt.Errorf("unsupported event: %s", event.Event)) {
+					break accept
+				}
 			}
 		}
 	}()
@@ -718,7 +1498,27 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 // Start implements kraftkit.sh/api/machine/v1alpha1.MachineService
 func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
 	qmpClient, err := service.QMPClient(ctx, machine)
-	if err != nil && strings.HasSuffix(err.Error(), "connect: no such file or directory") {
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+		// The QEMU process is no longer running, e.g. because the machine has
+		// exited and is being restarted, in which case its QMP sockets may have
+		// been left behind and must be removed before it is re-created.
+		var qcfg *QemuConfig
+		qcfg, err = getQEMUConfigFromPlatformConfig(machine.Status.PlatformConfig)
+		if err != nil {
+			return machine, err
+		}
+
+		for _, chardev := range qcfg.QMP {
+			unix, ok := chardev.(QemuHostCharDevUnix)
+			if !ok {
+				continue
+			}
+
+			if err := os.Remove(unix.Resource()); err != nil && !os.IsNotExist(err) {
+				return machine, fmt.Errorf("could not remove stale QMP socket: %w", err)
+			}
+		}
+
 		machine, err = service.Create(ctx, machine)
 		if err != nil {
 			return machine, err
@@ -821,15 +1621,16 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	// Set the cpu and memory resources
 	// TODO(craciunouc): This is a temporary solution until we have proper
 	// un/marshalling of the resources (and all structures).
-	machine.Spec.Resources.Requests[corev1.ResourceCPU] = resource.MustParse("1")
-
-	// Backwards compatibility with older runs
-	memory := "0Mi"
-	if qcfg.Memory.String() != "" {
-		memory = strings.SplitN(qcfg.Memory.String(), "=", 2)[1]
+	cpus := qcfg.SMP.CPUs
+	if cpus == 0 {
+		cpus = 1
 	}
 
-	machine.Spec.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(memory)
+	machine.Spec.Resources.Requests[corev1.ResourceCPU] = *resource.NewQuantity(int64(cpus), resource.DecimalSI)
+
+	// Backwards compatibility with older runs
+	memory, _ := memoryBytes(qcfg.Memory)
+	machine.Spec.Resources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(memory, resource.BinarySI)
 
 	// Check if the process is alive, which ultimately indicates to us whether we
 	// able to speak to the exposed QMP socket
@@ -841,6 +1642,9 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		}
 	}
 
+	// Resource usage is only sampled whilst the machine is running.
+	machine.Status.Stats = nil
+
 	exitedAt := machine.Status.ExitedAt
 	exitCode := machine.Status.ExitCode
 
@@ -869,10 +1673,26 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	}()
 
 	if !activeProcess {
+		// A machine which is awaiting its restart by its supervisor has no
+		// process until it has been started again.
+		if savedState == machinev1alpha1.MachineStateRestarting {
+			state = savedState
+			return machine, nil
+		}
+
 		state = machinev1alpha1.MachineStateExited
 		if savedState == machinev1alpha1.MachineStateRunning {
 			exitCode = 1
 		}
+
+		// Release the published ports of a machine which has exited on its own
+		// such that they do not shadow those of other machines.
+		if savedState != machinev1alpha1.MachineStateExited && len(machine.Spec.Ports) > 0 && publishesPortsOnHost(machine, qcfg) {
+			if err := portforward.Remove(ctx, string(machine.UID)); err != nil {
+				log.G(ctx).Debugf("could not remove published ports: %v", err)
+			}
+		}
+
 		return machine, nil
 	}
 
@@ -896,6 +1716,13 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		return machine, fmt.Errorf("could not query machine status via QMP: %v", err)
 	}
 
+	// The memory available to the guest may have been reduced by the balloon.
+	if hasBalloon(qcfg) {
+		if balloon, err := qmpClient.QueryBalloon(qmpapi.QueryBalloonRequest{}); err == nil && balloon.Return.Actual > 0 {
+			machine.Spec.Resources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(balloon.Return.Actual, resource.BinarySI)
+		}
+	}
+
 	// Map the QMP status to supported machine states
 	switch status.Return.Status {
 	case qmpapi.RUN_STATE_GUEST_PANICKED:
@@ -931,9 +1758,61 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		exitCode = -1
 	}
 
+	if state == machinev1alpha1.MachineStateRunning || state == machinev1alpha1.MachineStatePaused {
+		if err := virtiofs.Check(ctx, machine.Status.StateDir); err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Warnf("volumes are no longer accessible: %v", err)
+		}
+
+		if stats.Sampling(ctx) {
+			machine.Status.Stats = sampleStats(ctx, machine, qmpClient)
+		}
+	}
+
 	return machine, nil
 }
 
+// sampleStats samples the resource usage of the provided running machine.
+// Block device statistics are queried via QMP and take precedence over the
+// I/O accounting of the process.  Failures are only logged since the
+// statistics are informational.
+func sampleStats(ctx context.Context, machine *machinev1alpha1.Machine, qmpClient *qmpapi.QEMUMachineProtocolClient) *machinev1alpha1.MachineStats {
+	sample := machinev1alpha1.MachineStats{
+		CollectedAt: time.Now(),
+	}
+
+	if memory, ok := machine.Spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		sample.MemoryLimit = uint64(memory.Value())
+	}
+
+	if err := stats.Process(ctx, machine.Status.Pid, &sample); err != nil {
+		log.G(ctx).Debugf("could not sample process statistics: %v", err)
+	}
+
+	if blockstats, err := qmpClient.QueryBlockstats(qmpapi.QueryBlockstatsRequest{}); err != nil {
+		log.G(ctx).Debugf("could not query block statistics via QMP: %v", err)
+	} else if len(blockstats.Return) > 0 {
+		sample.BlockReadBytes = 0
+		sample.BlockWriteBytes = 0
+		sample.BlockReadOps = 0
+		sample.BlockWriteOps = 0
+
+		for _, device := range blockstats.Return {
+			sample.BlockReadBytes += uint64(device.Stats.RdBytes)
+			sample.BlockWriteBytes += uint64(device.Stats.WrBytes)
+			sample.BlockReadOps += uint64(device.Stats.RdOperations)
+			sample.BlockWriteOps += uint64(device.Stats.WrOperations)
+		}
+	}
+
+	if err := stats.Interfaces(ctx, machine, &sample); err != nil {
+		log.G(ctx).Debugf("could not sample network statistics: %v", err)
+	}
+
+	return &sample
+}
+
 // List implements kraftkit.sh/api/machine/v1alpha1.MachineService.List
 func (service *machineV1alpha1Service) List(ctx context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
 	cached := machines.Items
@@ -955,6 +1834,13 @@ func (service *machineV1alpha1Service) List(ctx context.Context, machines *machi
 
 // Stop implements kraftkit.sh/api/machine/v1alpha1.MachineService.Stop
 func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	// A machine which is awaiting its restart has no process to stop.
+	if machine.Status.State == machinev1alpha1.MachineStateRestarting {
+		machine.Status.State = machinev1alpha1.MachineStateExited
+		machine.Status.ExitedAt = time.Now()
+		return machine, nil
+	}
+
 	qmpClient, err := service.QMPClient(ctx, machine)
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "connect: no such file or directory") {
@@ -979,6 +1865,12 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 
 	machine.Status.State = machinev1alpha1.MachineStateExited
 
+	if len(machine.Spec.Ports) > 0 && publishesPortsOnHost(machine, qcfg) {
+		if err := portforward.Remove(ctx, string(machine.UID)); err != nil {
+			return machine, fmt.Errorf("could not remove published ports: %w", err)
+		}
+	}
+
 	if err := retrytimeout.RetryTimeout(5*time.Second, func() error {
 		if _, err := os.ReadFile(qcfg.PidFile); !os.IsNotExist(err) {
 			return fmt.Errorf("process still active")
@@ -989,6 +1881,12 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, err
 	}
 
+	// The virtiofsd processes are only stopped once QEMU has exited, since the
+	// guest would otherwise lose access to its volumes whilst shutting down.
+	if err := virtiofs.Stop(ctx, machine.Status.StateDir); err != nil {
+		return machine, err
+	}
+
 	return machine, nil
 }
 
@@ -1001,6 +1899,12 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 
 	var errs merr.Errors
 
+	if len(machine.Spec.Ports) > 0 && publishesPortsOnHost(machine, qcfg) {
+		errs = append(errs, portforward.Remove(ctx, string(machine.UID)))
+	}
+
+	errs = append(errs, virtiofs.Stop(ctx, machine.Status.StateDir))
+
 	err := os.RemoveAll(machine.Status.StateDir)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("error deleting QEMU's state directory %s: %w", machine.Status.StateDir, err))
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+func TestWaitDeviceDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string
+		wantErr error
+	}{
+		{
+			name: "deleted",
+			events: []string{
+				`{"event": "DEVICE_DELETED", "data": {"path": "/machine/peripheral/nic1/virtio-backend"}}`,
+				`{"event": "DEVICE_DELETED", "data": {"device": "nic2", "path": "/machine/peripheral/nic2"}}`,
+				`{"event": "DEVICE_DELETED", "data": {"device": "nic1", "path": "/machine/peripheral/nic1"}}`,
+			},
+		},
+		{
+			name: "refused",
+			events: []string{
+				`{"event": "DEVICE_UNPLUG_GUEST_ERROR", "data": {"device": "nic1", "path": "/machine/peripheral/nic1"}}`,
+			},
+			wantErr: errors.New("guest refused to unplug nic1"),
+		},
+		{
+			name: "timeout",
+			events: []string{
+				`{"event": "DEVICE_DELETED", "data": {"device": "nic2", "path": "/machine/peripheral/nic2"}}`,
+			},
+			wantErr: os.ErrDeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			t.Cleanup(func() {
+				client.Close()
+				server.Close()
+			})
+
+			go func() {
+				for _, event := range tt.events {
+					if _, err := server.Write([]byte(event + "\n")); err != nil {
+						return
+					}
+				}
+			}()
+
+			err := waitDeviceDeleted(qmpapi.NewQEMUMachineProtocolClient(client), "nic1", 100*time.Millisecond)
+
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr == os.ErrDeadlineExceeded && !errors.Is(err, os.ErrDeadlineExceeded):
+				t.Errorf("expected the deadline to be exceeded, got %v", err)
+			case tt.wantErr != nil && tt.wantErr != os.ErrDeadlineExceeded && (err == nil || err.Error() != tt.wantErr.Error()):
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package stats samples the resource usage of running machines from the
+// accounting of the host, which is shared between machine drivers.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goprocess "github.com/shirou/gopsutil/v3/process"
+	"github.com/vishvananda/netlink"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// samplingKey is the key of the context value which enables sampling.
+type samplingKey struct{}
+
+// WithSampling returns a context which requests machine drivers to sample the
+// resource usage of running machines whenever they are retrieved.  Sampling
+// is comparatively expensive such that it is disabled by default, e.g. to keep
+// listing machines fast.
+func WithSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, samplingKey{}, true)
+}
+
+// Sampling returns whether the resource usage of running machines should be
+// sampled whenever they are retrieved with the provided context.
+func Sampling(ctx context.Context) bool {
+	enabled, _ := ctx.Value(samplingKey{}).(bool)
+	return enabled
+}
+
+// Process populates the CPU, memory and I/O usage of the provided stats from
+// the accounting of the process of the virtual machine monitor with the
+// provided PID.  Block I/O is approximated by the storage I/O of the process
+// and should be overridden by drivers which can query the guest's devices.
+func Process(ctx context.Context, pid int32, stats *machinev1alpha1.MachineStats) error {
+	process, err := goprocess.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("could not look up process %d: %w", pid, err)
+	}
+
+	times, err := process.TimesWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read cpu times of process %d: %w", pid, err)
+	}
+
+	stats.CPUTime = time.Duration((times.User + times.System) * float64(time.Second))
+
+	memory, err := process.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read memory usage of process %d: %w", pid, err)
+	}
+
+	stats.MemoryUsage = memory.RSS
+
+	// I/O accounting may be unavailable to unprivileged users.
+	if io, err := process.IOCountersWithContext(ctx); err == nil {
+		stats.BlockReadBytes = io.ReadBytes
+		stats.BlockWriteBytes = io.WriteBytes
+		stats.BlockReadOps = io.ReadCount
+		stats.BlockWriteOps = io.WriteCount
+	}
+
+	return nil
+}
+
+// Interfaces populates the network usage of the provided stats from the
+// statistics of the host-side links of the machine's network interfaces.  What
+// the host transmits on such a link is received by the guest and vice versa.
+func Interfaces(ctx context.Context, machine *machinev1alpha1.Machine, stats *machinev1alpha1.MachineStats) error {
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			if iface.Spec.IfName == "" {
+				continue
+			}
+
+			link, err := netlink.LinkByName(iface.Spec.IfName)
+			if err != nil {
+				return fmt.Errorf("could not get link %s: %w", iface.Spec.IfName, err)
+			}
+
+			statistics := link.Attrs().Statistics
+			if statistics == nil {
+				continue
+			}
+
+			stats.NetRxBytes += statistics.TxBytes
+			stats.NetRxPackets += statistics.TxPackets
+			stats.NetRxDropped += statistics.TxDropped
+			stats.NetTxBytes += statistics.RxBytes
+			stats.NetTxPackets += statistics.RxPackets
+			stats.NetTxDropped += statistics.RxDropped
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stats_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/machine/stats"
+)
+
+func TestSampling(t *testing.T) {
+	ctx := context.Background()
+
+	if stats.Sampling(ctx) {
+		t.Error("expected sampling to be disabled by default")
+	}
+
+	if !stats.Sampling(stats.WithSampling(ctx)) {
+		t.Error("expected sampling to be enabled")
+	}
+}
+
+func TestProcess(t *testing.T) {
+	ctx := context.Background()
+
+	var sample machinev1alpha1.MachineStats
+	if err := stats.Process(ctx, int32(os.Getpid()), &sample); err != nil {
+		t.Fatal("Process:", err)
+	}
+
+	if sample.MemoryUsage == 0 {
+		t.Error("expected memory usage of the test process")
+	}
+
+	if sample.CPUTime < 0 {
+		t.Errorf("expected non-negative cpu time, got %s", sample.CPUTime)
+	}
+
+	if err := stats.Process(ctx, -1, &sample); err == nil {
+		t.Error("expected sampling a non-existent process to fail")
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	machineWith := func(ifnames ...string) *machinev1alpha1.Machine {
+		network := networkv1alpha1.NetworkSpec{}
+		for _, ifname := range ifnames {
+			network.Interfaces = append(network.Interfaces, networkv1alpha1.NetworkInterfaceTemplateSpec{
+				Spec: networkv1alpha1.NetworkInterfaceSpec{
+					IfName: ifname,
+				},
+			})
+		}
+
+		machine := &machinev1alpha1.Machine{}
+		machine.Spec.Networks = []networkv1alpha1.NetworkSpec{network}
+
+		return machine
+	}
+
+	tests := []struct {
+		name    string
+		machine *machinev1alpha1.Machine
+		wantErr bool
+	}{
+		{
+			name:    "no networks",
+			machine: &machinev1alpha1.Machine{},
+		},
+		{
+			name:    "interface without link",
+			machine: machineWith(""),
+		},
+		{
+			name:    "missing link",
+			machine: machineWith("kraftstats0"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sample machinev1alpha1.MachineStats
+
+			err := stats.Interfaces(context.Background(), tt.machine, &sample)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Interfaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && sample != (machinev1alpha1.MachineStats{}) {
+				t.Errorf("expected no network statistics, got %+v", sample)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package supervisor
+
+import (
+	"context"
+	"errors"
+
+	zip "api.zip"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/health"
+	"kraftkit.sh/store"
+)
+
+// monitorHealth probes the provided machine according to its health check in
+// the background and records its health in the store whenever it changes.
+// Monitoring ends once the returned function is called.
+func monitorHealth(ctx context.Context, machineStore zip.Store, machine *machineapi.Machine) func() {
+	if machine.Spec.HealthCheck == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		previous := machine.Status.Health
+
+		err := health.Monitor(ctx, machine, func(current machineapi.MachineHealth) error {
+			if current.Status == previous.Status && current.FailingStreak == previous.FailingStreak {
+				return nil
+			}
+
+			if current.Status != previous.Status {
+				log.G(ctx).Infof("%s : %s", machine.Name, current.Status)
+			}
+
+			previous = current
+
+			_, err := store.Update[machineapi.MachineSpec, machineapi.MachineStatus](ctx, machineStore, machine.UID, func(existing *machineapi.Machine) error {
+				existing.Status.Health = current
+				return nil
+			})
+
+			return err
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.G(ctx).Warnf("%s : could not monitor health: %v", machine.Name, err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// clearHealth removes the recorded health of the provided machine, which is no
+// longer running, from the store.
+func clearHealth(ctx context.Context, machineStore zip.Store, machine *machineapi.Machine) {
+	if machine.Spec.HealthCheck == nil {
+		return
+	}
+
+	if _, err := store.Update[machineapi.MachineSpec, machineapi.MachineStatus](ctx, machineStore, machine.UID, func(existing *machineapi.Machine) error {
+		existing.Status.Health = machineapi.MachineHealth{}
+		return nil
+	}); err != nil {
+		log.G(ctx).Debugf("could not clear health of %s: %v", machine.Name, err)
+	}
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package supervisor
+
+// SupervisorOption is an option which configures the supervisor.
+type SupervisorOption func(*supervisor) error
+
+// WithMachine restricts supervision to the machine with the provided name or
+// UID.
+func WithMachine(machine string) SupervisorOption {
+	return func(sv *supervisor) error {
+		sv.machine = machine
+		return nil
+	}
+}
+
+// WithQuitTogether ends supervision once there is nothing left to supervise.
+func WithQuitTogether() SupervisorOption {
+	return func(sv *supervisor) error {
+		sv.quitTogether = true
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zip "api.zip"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/health"
+	"kraftkit.sh/store"
+)
+
+const (
+	// restartBackoffInitial is the delay before the first restart of a machine.
+	restartBackoffInitial = 100 * time.Millisecond
+
+	// restartBackoffMax is the upper bound of the delay between restarts.
+	restartBackoffMax = time.Minute
+
+	// restartBackoffReset is the duration a machine must have been running for
+	// before the delay between restarts is reset.
+	restartBackoffReset = 10 * time.Second
+)
+
+// restartBackoff returns the delay before restarting a machine which has been
+// running for the provided duration, given the delay used for its previous
+// restart.  The delay doubles with each consecutive restart and is reset once
+// the machine has been running for long enough.
+func restartBackoff(previous, uptime time.Duration) time.Duration {
+	if previous == 0 || uptime >= restartBackoffReset {
+		return restartBackoffInitial
+	}
+
+	if next := previous * 2; next < restartBackoffMax {
+		return next
+	}
+
+	return restartBackoffMax
+}
+
+// restart the provided machine after the provided delay.  The machine is
+// recorded as restarting for the duration of the delay and is left alone if it
+// is stopped in the meantime.  The started machine is returned.
+func restart(ctx context.Context, controller machineapi.MachineService, machineStore zip.Store, machine *machineapi.Machine, delay time.Duration) (*machineapi.Machine, error) {
+	restarting, err := store.Update[machineapi.MachineSpec, machineapi.MachineStatus](ctx, machineStore, machine.UID, func(existing *machineapi.Machine) error {
+		existing.Status.State = machineapi.MachineStateRestarting
+		existing.Status.RestartCount++
+		existing.Status.Health = health.Initial(existing)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not record restart: %w", err)
+	}
+
+	log.G(ctx).Infof("%s : restarting in %s (restart count: %d)", restarting.Name, delay, restarting.Status.RestartCount)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(delay):
+	}
+
+	// Determine whether the machine has been stopped or removed whilst waiting.
+	restarting, err = controller.Get(ctx, restarting)
+	if err != nil {
+		return nil, err
+	}
+
+	if restarting.Status.State != machineapi.MachineStateRestarting || restarting.Status.StoppedByUser {
+		return nil, fmt.Errorf("restart of %s cancelled", restarting.Name)
+	}
+
+	return controller.Start(ctx, restarting)
+}
+
+// shouldResume returns whether a machine which is discovered by the supervisor
+// without being observed should be restarted.  A supervisor which has just
+// started restarts machines with the "always" policy even if they were stopped
+// explicitly, as well as those whose restart was interrupted.
+func shouldResume(machine *machineapi.Machine, starting bool) bool {
+	switch machine.Status.State {
+	case machineapi.MachineStateRestarting:
+		return !machine.Status.StoppedByUser
+
+	case machineapi.MachineStateExited,
+		machineapi.MachineStateFailed,
+		machineapi.MachineStateErrored:
+		if starting && machine.Spec.RestartPolicy.Name == machineapi.RestartPolicyAlways {
+			return true
+		}
+
+		return machine.Spec.RestartPolicy.ShouldRestart(machine.Status)
+	}
+
+	return false
+}
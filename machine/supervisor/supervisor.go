@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package supervisor follows the events of the machines of the machine store,
+// restarts them according to their restart policy whenever they exit and
+// monitors their health according to their health check.  It is hosted by
+// either `kraft events` or the daemon.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/waitgroup"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/qemu/qmp"
+)
+
+// supervisor holds the services used to supervise machines and its options.
+type supervisor struct {
+	controller   machineapi.MachineService
+	machineStore zip.Store
+	machine      string
+	quitTogether bool
+}
+
+// Supervise watches the provided machine store and supervises each of its
+// machines via the provided controller until the context is cancelled or, if
+// requested, there is nothing left to supervise.  It returns once all
+// machines are no longer supervised.
+func Supervise(ctx context.Context, controller machineapi.MachineService, machineStore zip.Store, opts ...SupervisorOption) error {
+	sv := supervisor{
+		controller:   controller,
+		machineStore: machineStore,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&sv); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The store can be updated elsewhere and acts as the source-of-truth for
+	// VMs which are being instantiated by KraftKit.
+	watcher, err := machineStore.Watch(ctx, "", storage.ListOptions{
+		Recursive: true,
+	})
+	if err != nil {
+		return fmt.Errorf("could not watch machine store: %v", err)
+	}
+
+	defer watcher.Stop()
+
+	supervise(ctx, cancel, watcher, sv.machine, sv.quitTogether, func(machine *machineapi.Machine, restartNow bool) {
+		sv.observe(ctx, machine, restartNow)
+	})
+
+	return nil
+}
+
+// follow the events of the provided machine until it is no longer running.
+// Returns whether the machine has ended on its own, rather than the
+// observation having been cancelled.
+func (sv *supervisor) follow(ctx context.Context, machine *machineapi.Machine) bool {
+	events, errs, err := sv.controller.Watch(ctx, machine)
+	if err != nil {
+		log.G(ctx).Debugf("could not listen for status updates for %s: %v", machine.Name, err)
+		return false
+	}
+
+	for {
+		// Wait on either channel
+		select {
+		case event, ok := <-events:
+			// The stream has ended, e.g. once the machine has errored.
+			if !ok {
+				return ctx.Err() == nil
+			}
+
+			log.G(ctx).Infof("%s : %s", event.Name, event.Status.State.String())
+			switch event.Status.State {
+			case machineapi.MachineStateExited,
+				machineapi.MachineStateFailed,
+				machineapi.MachineStateErrored:
+				return true
+			}
+
+		case err, ok := <-errs:
+			if !ok {
+				return ctx.Err() == nil
+			}
+
+			if !errors.Is(err, qmp.ErrAcceptedNonEvent) {
+				log.G(ctx).Errorf("%v", err)
+			}
+			return ctx.Err() == nil
+
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// observe the provided machine and restart it according to its restart policy
+// whenever it exits, until it should no longer be restarted.
+func (sv *supervisor) observe(ctx context.Context, machine *machineapi.Machine, restartNow bool) {
+	current := machine
+	var delay time.Duration
+
+	for {
+		if restartNow {
+			delay = restartBackoff(delay, current.Status.ExitedAt.Sub(current.Status.StartedAt))
+
+			started, err := restart(ctx, sv.controller, sv.machineStore, current, delay)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.G(ctx).Warnf("%s : %v", current.Name, err)
+				}
+				return
+			}
+
+			current = started
+		}
+
+		stopMonitor := monitorHealth(ctx, sv.machineStore, current)
+		ended := sv.follow(ctx, current)
+		stopMonitor()
+
+		if !ended {
+			return
+		}
+
+		clearHealth(ctx, sv.machineStore, current)
+
+		uptime := time.Since(current.Status.StartedAt)
+
+		latest, err := sv.controller.Get(ctx, current)
+		if err != nil {
+			log.G(ctx).Debugf("could not get status of %s: %v", current.Name, err)
+			return
+		}
+
+		switch latest.Status.State {
+		case machineapi.MachineStateExited,
+			machineapi.MachineStateFailed,
+			machineapi.MachineStateErrored:
+		default:
+			return
+		}
+
+		if !latest.Spec.RestartPolicy.ShouldRestart(latest.Status) {
+			return
+		}
+
+		// Record the uptime of the machine for determining the backoff.
+		latest.Status.ExitedAt = latest.Status.StartedAt.Add(uptime)
+
+		current = latest
+		restartNow = true
+	}
+}
+
+// supervise observes the machines delivered by the watcher, each in its own
+// goroutine, until the context is cancelled, the watcher is closed or, if
+// quitTogether is set, there is nothing left to observe.  Machines can be
+// filtered by their name or UID.  It returns once all observations have
+// ended.
+func supervise(ctx context.Context, cancel context.CancelFunc, watcher watch.Interface, filter string, quitTogether bool, observe func(*machineapi.Machine, bool)) {
+	// The machines which are being observed, by UID, and a channel which is
+	// signalled whenever an observation ends.
+	observed := map[types.UID]*machineapi.Machine{}
+	observations := &waitgroup.WaitGroup[*machineapi.Machine]{}
+	done := make(chan *machineapi.Machine)
+
+	// The initial set of machines is delivered as "ADDED" events.  Use a timer
+	// to determine when the initial set has been received, after which
+	// quitTogether takes effect.
+	initial := time.After(time.Second)
+
+seek:
+	for {
+		select {
+		case <-ctx.Done():
+			break seek
+
+		case <-initial:
+			initial = nil
+
+		case machine := <-done:
+			delete(observed, machine.UID)
+			observations.Done(machine)
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				break seek
+			}
+
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+
+			machine, ok := event.Object.(*machineapi.Machine)
+			if !ok {
+				continue
+			}
+
+			if filter != "" && filter != string(machine.UID) && filter != machine.Name {
+				continue
+			}
+
+			if _, ok := observed[machine.UID]; ok {
+				continue
+			}
+
+			// Machines which have exited whilst not being observed are restarted
+			// according to their restart policy.
+			restartNow := shouldResume(machine, initial != nil)
+
+			switch machine.Status.State {
+			case machineapi.MachineStateFailed,
+				machineapi.MachineStateExited,
+				machineapi.MachineStateUnknown:
+				if quitTogether && !restartNow {
+					continue
+				}
+			default:
+			}
+
+			observed[machine.UID] = machine
+			observations.Add(machine)
+
+			go func(machine *machineapi.Machine, restartNow bool) {
+				defer func() {
+					done <- machine
+				}()
+
+				observe(machine, restartNow)
+			}(machine, restartNow)
+		}
+
+		if initial == nil && len(observed) == 0 && quitTogether {
+			cancel()
+			break seek
+		}
+	}
+
+	// Observations which are still active end on their own, or once the context
+	// is cancelled, and must continue to be received until they all have.
+	waited := make(chan struct{})
+	go func() {
+		observations.Wait()
+		close(waited)
+	}()
+
+	for {
+		select {
+		case machine := <-done:
+			observations.Done(machine)
+		case <-waited:
+			return
+		}
+	}
+}
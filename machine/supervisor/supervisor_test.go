@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/store"
+)
+
+func TestSuperviseEndsActiveObservations(t *testing.T) {
+	tests := []struct {
+		name string
+		// end stops the supervisor whilst its observations are active, given
+		// its cancel function, its watcher and a function which releases the
+		// observations.
+		end func(cancel context.CancelFunc, watcher *watch.FakeWatcher, release func())
+	}{
+		{
+			name: "cancelled",
+			end: func(cancel context.CancelFunc, _ *watch.FakeWatcher, _ func()) {
+				cancel()
+			},
+		},
+		{
+			name: "watcher closed",
+			end: func(_ context.CancelFunc, watcher *watch.FakeWatcher, release func()) {
+				watcher.Stop()
+				time.AfterFunc(50*time.Millisecond, release)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			watcher := watch.NewFake()
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+
+			// observe blocks until the context is cancelled or the observation
+			// is released, as following the events of a machine does.
+			observe := func(*machineapi.Machine, bool) {
+				started <- struct{}{}
+
+				select {
+				case <-ctx.Done():
+				case <-release:
+				}
+			}
+
+			returned := make(chan struct{})
+			go func() {
+				supervise(ctx, cancel, watcher, "", false, observe)
+				close(returned)
+			}()
+
+			for _, name := range []string{"a", "b"} {
+				watcher.Add(&machineapi.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: name,
+						UID:  types.UID("uid-" + name),
+					},
+					Status: machineapi.MachineStatus{
+						State: machineapi.MachineStateRunning,
+					},
+				})
+				<-started
+			}
+
+			tt.end(cancel, watcher, func() {
+				close(release)
+			})
+
+			select {
+			case <-returned:
+			case <-time.After(5 * time.Second):
+				t.Fatal("supervise did not return after its observations ended")
+			}
+		})
+	}
+}
+
+// exitingMachineService reports each watched machine to have exited.
+type exitingMachineService struct {
+	machineapi.MachineService
+}
+
+func (service *exitingMachineService) Watch(_ context.Context, machine *machineapi.Machine) (chan *machineapi.Machine, chan error, error) {
+	events := make(chan *machineapi.Machine, 1)
+
+	exited := *machine
+	exited.Status.State = machineapi.MachineStateExited
+	events <- &exited
+
+	return events, make(chan error), nil
+}
+
+func (service *exitingMachineService) Get(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	exited := *machine
+	exited.Status.State = machineapi.MachineStateExited
+	return &exited, nil
+}
+
+func TestObserveClearsHealthOfExitedMachine(t *testing.T) {
+	ctx := context.Background()
+
+	machineStore, err := store.NewEmbeddedStore[machineapi.MachineSpec, machineapi.MachineStatus](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	machine := &machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "uid-test",
+		},
+		Spec: machineapi.MachineSpec{
+			HealthCheck: &machineapi.MachineHealthCheck{
+				Type: machineapi.HealthCheckTCP,
+				Port: 8080,
+			},
+		},
+		Status: machineapi.MachineStatus{
+			State: machineapi.MachineStateRunning,
+			Health: machineapi.MachineHealth{
+				Status: machineapi.HealthStatusHealthy,
+			},
+		},
+	}
+
+	if err := machineStore.Create(ctx, machine.Name, machine, machine, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	sv := &supervisor{
+		controller:   &exitingMachineService{},
+		machineStore: machineStore,
+	}
+
+	// The machine exits before it is probed and is not restarted.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sv.observe(ctx, machine, false)
+
+	var got machineapi.Machine
+	if err := machineStore.Get(ctx, machine.Name, storage.GetOptions{}, &got); err != nil {
+		t.Fatal("Get:", err)
+	}
+
+	if got.Status.Health.Status != "" {
+		t.Errorf("expected health of exited machine to be cleared, got %s", got.Status.Health.Status)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ninepfs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OptionSecurityModel sets how the host maps the credentials and
+	// permissions of files created by the machine onto the shared directory.
+	OptionSecurityModel = "security_model"
+
+	// OptionCache sets the caching mode of the unikernel's 9P client.
+	OptionCache = "cache"
+
+	// OptionUid sets the owner of files in the unikernel.
+	OptionUid = "uid"
+
+	// OptionGid sets the group of files in the unikernel.
+	OptionGid = "gid"
+)
+
+// SecurityModels returns the security models with which the host can share a
+// directory.
+func SecurityModels() []string {
+	return []string{
+		"mapped-file",
+		"mapped-xattr",
+		"none",
+		"passthrough",
+	}
+}
+
+// Options are the mount options of a 9pfs volume.
+type Options struct {
+	// SecurityModel is the security model with which the host shares the
+	// directory, or empty for the default.
+	SecurityModel string
+
+	// Mount are the options in the form key=value which are passed to the
+	// unikernel when mounting the volume.
+	Mount []string
+}
+
+// ParseOptions validates the provided mount options of a 9pfs volume and
+// separates those applied by the host from those passed to the unikernel.
+func ParseOptions(options []string) (Options, error) {
+	var ret Options
+
+	for _, option := range options {
+		key, value, _ := strings.Cut(option, "=")
+		if len(value) == 0 {
+			return Options{}, fmt.Errorf("9pfs volume option %s requires a value", key)
+		}
+
+		switch key {
+		case OptionSecurityModel:
+			if !slices.Contains(SecurityModels(), value) {
+				return Options{}, fmt.Errorf("unsupported 9pfs security model: %s (expected one of %s)", value, strings.Join(SecurityModels(), ", "))
+			}
+
+			ret.SecurityModel = value
+
+		case OptionUid, OptionGid:
+			if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+				return Options{}, fmt.Errorf("invalid 9pfs volume option %s: %s", key, value)
+			}
+
+			ret.Mount = append(ret.Mount, option)
+
+		case OptionCache:
+			ret.Mount = append(ret.Mount, option)
+
+		default:
+			return Options{}, fmt.Errorf("unsupported 9pfs volume option: %s", key)
+		}
+	}
+
+	return ret, nil
+}
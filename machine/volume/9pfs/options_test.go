@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ninepfs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	for _, tc := range []struct {
+		options       []string
+		securityModel string
+		mount         []string
+		err           bool
+	}{
+		{
+			options: nil,
+		},
+		{
+			options:       []string{"cache=loose", "security_model=mapped-xattr", "uid=1000", "gid=1000"},
+			securityModel: "mapped-xattr",
+			mount:         []string{"cache=loose", "uid=1000", "gid=1000"},
+		},
+		{
+			options: []string{"security_model=mapped"},
+			err:     true,
+		},
+		{
+			options: []string{"uid=root"},
+			err:     true,
+		},
+		{
+			options: []string{"cache"},
+			err:     true,
+		},
+		{
+			options: []string{"trans=virtio"},
+			err:     true,
+		},
+	} {
+		options, err := ParseOptions(tc.options)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%v: expected an error", tc.options)
+			}
+
+			continue
+		} else if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.options, err)
+			continue
+		}
+
+		if options.SecurityModel != tc.securityModel {
+			t.Errorf("%v: expected security model %q, got %q", tc.options, tc.securityModel, options.SecurityModel)
+		}
+
+		if !slices.Equal(options.Mount, tc.mount) {
+			t.Errorf("%v: expected mount options %v, got %v", tc.options, tc.mount, options.Mount)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume/sourcewatch"
 )
 
 type v1alpha1Volume struct{}
@@ -33,6 +34,10 @@ func (*v1alpha1Volume) Create(ctx context.Context, volume *volumev1alpha1.Volume
 		return volume, fmt.Errorf("cannot use 9pfs driver when driver set to %s", volume.Spec.Driver)
 	}
 
+	if _, err := ParseOptions(volume.Spec.Options); err != nil {
+		return volume, err
+	}
+
 	if volume.ObjectMeta.UID == "" {
 		volume.ObjectMeta.UID = uuid.NewUUID()
 	}
@@ -107,6 +112,14 @@ func (*v1alpha1Volume) Get(_ context.Context, volume *volumev1alpha1.Volume) (*v
 		return nil, nil
 	}
 
+	// The contents of a volume whose source has been removed are gone, until
+	// the source is created anew.
+	if _, err := os.Stat(volume.Spec.Source); os.IsNotExist(err) {
+		volume.Status.State = volumev1alpha1.VolumeStateLost
+	} else if volume.Status.State == volumev1alpha1.VolumeStateLost {
+		volume.Status.State = volumev1alpha1.VolumeStatePending
+	}
+
 	return volume, nil
 }
 
@@ -121,6 +134,10 @@ func (*v1alpha1Volume) Update(_ context.Context, volume *volumev1alpha1.Volume)
 }
 
 // Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
-func (*v1alpha1Volume) Watch(context.Context, *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
-	panic("not implemented: kraftkit.sh/machine/volume/9pfs.v1alpha1Volume.Watch")
+func (service *v1alpha1Volume) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	if volume.Spec.Driver != "9pfs" {
+		return nil, nil, fmt.Errorf("volume %s is not a 9pfs volume", volume.Name)
+	}
+
+	return sourcewatch.Watch(ctx, volume, service.Get)
 }
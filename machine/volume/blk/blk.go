@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package blk implements a volume driver whose volumes are backed by raw disk
+// images on the host, which are exposed to machines as block devices.
+package blk
+
+import (
+	"fmt"
+	"io"
+)
+
+// DriverName is the name of the block-device volume driver.
+const DriverName = "blk"
+
+// DefaultSize is the size of newly created volumes whose size is unspecified.
+const DefaultSize = 1024 * 1024 * 1024
+
+// Filesystem is a filesystem with which volumes can be formatted.
+type Filesystem string
+
+const (
+	FilesystemExt2 = Filesystem("ext2")
+	FilesystemFat  = Filesystem("fat")
+
+	// FilesystemErofs and FilesystemSquashfs are read-only filesystems, whose
+	// images are created ahead of time, e.g. as the root filesystem of an
+	// application, rather than formatted by the driver.
+	FilesystemErofs    = Filesystem("erofs")
+	FilesystemSquashfs = Filesystem("squashfs")
+)
+
+// DefaultFilesystem is the filesystem newly created volumes are formatted with
+// when unspecified.
+const DefaultFilesystem = FilesystemExt2
+
+// Filesystems returns the list of supported filesystems.
+func Filesystems() []Filesystem {
+	return []Filesystem{
+		FilesystemExt2,
+		FilesystemFat,
+		FilesystemErofs,
+		FilesystemSquashfs,
+	}
+}
+
+// String implements fmt.Stringer
+func (fs Filesystem) String() string {
+	return string(fs)
+}
+
+// IsReadOnly returns whether the filesystem can only be mounted read-only.
+func (fs Filesystem) IsReadOnly() bool {
+	return fs == FilesystemErofs || fs == FilesystemSquashfs
+}
+
+// Format writes an empty filesystem of the provided type and size to w, which
+// is expected to be zero-filled.
+func Format(w io.WriterAt, fs Filesystem, size int64, label string) error {
+	switch fs {
+	case FilesystemExt2:
+		return FormatExt2(w, size, label)
+	case FilesystemFat:
+		return FormatFat(w, size, label)
+	case FilesystemErofs, FilesystemSquashfs:
+		return fmt.Errorf("cannot format read-only filesystem: %s", fs)
+	default:
+		return fmt.Errorf("unsupported filesystem: %s", fs)
+	}
+}
+
+// DeviceName returns the name with which the block device at the provided
+// position, amongst the block-device volumes of a machine, is referred to in
+// the unikernel's fstab.
+func DeviceName(index int) string {
+	return fmt.Sprintf("blk%d", index)
+}
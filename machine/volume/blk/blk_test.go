@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newImage returns a new sparse image of the provided size.
+func newImage(t *testing.T, size int64) *os.File {
+	t.Helper()
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "volume.img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { f.Close() })
+
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestFormatExt2(t *testing.T) {
+	e2fsck, err := exec.LookPath("e2fsck")
+	if err != nil {
+		t.Skip("e2fsck is not installed")
+	}
+
+	for _, size := range []int64{
+		1 << 20,       // Single group with 1KiB blocks
+		100<<20 + 512, // Multiple groups with 1KiB blocks and a partial last group
+		2 << 30,       // 4KiB blocks
+	} {
+		f := newImage(t, size)
+
+		if err := FormatExt2(f, size, "test"); err != nil {
+			t.Fatalf("FormatExt2(%d): %v", size, err)
+		}
+
+		if out, err := exec.Command(e2fsck, "-fn", f.Name()).CombinedOutput(); err != nil {
+			t.Errorf("e2fsck on image of %d bytes: %v\n%s", size, err, out)
+		}
+	}
+}
+
+func TestFormatFat(t *testing.T) {
+	for _, tc := range []struct {
+		size   int64
+		fsType string
+	}{
+		{fatMinSize, "FAT16"},
+		{1 << 30, "FAT16"},
+		{4 << 30, "FAT32"},
+	} {
+		f := newImage(t, tc.size)
+
+		if err := FormatFat(f, tc.size, "test"); err != nil {
+			t.Fatalf("FormatFat(%d): %v", tc.size, err)
+		}
+
+		bs := make([]byte, fatSectorSize)
+		if _, err := f.ReadAt(bs, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if bs[510] != 0x55 || bs[511] != 0xaa {
+			t.Errorf("missing boot sector signature on image of %d bytes", tc.size)
+		}
+
+		// Determine the type from the number of clusters, as FAT drivers do.
+		reserved := uint32(binary.LittleEndian.Uint16(bs[14:]))
+		rootSectors := uint32(binary.LittleEndian.Uint16(bs[17:])) * 32 / fatSectorSize
+		fatSize := uint32(binary.LittleEndian.Uint16(bs[22:]))
+		labelOff := 43
+		if fatSize == 0 {
+			fatSize = binary.LittleEndian.Uint32(bs[36:])
+			labelOff = 71
+		}
+
+		sectors := uint32(binary.LittleEndian.Uint16(bs[19:]))
+		if sectors == 0 {
+			sectors = binary.LittleEndian.Uint32(bs[32:])
+		}
+
+		if int64(sectors)*fatSectorSize != tc.size {
+			t.Errorf("expected %d sectors, got %d", tc.size/fatSectorSize, sectors)
+		}
+
+		clusters := (sectors - reserved - uint32(bs[16])*fatSize - rootSectors) / uint32(bs[13])
+
+		fsType := "FAT16"
+		if clusters >= fat32MinClusters {
+			fsType = "FAT32"
+		} else if clusters < fat16MinClusters {
+			fsType = "FAT12"
+		}
+
+		if fsType != tc.fsType {
+			t.Errorf("expected %s on image of %d bytes, got %s with %d clusters", tc.fsType, tc.size, fsType, clusters)
+		}
+
+		if label := string(bs[labelOff : labelOff+11]); label != "TEST       " {
+			t.Errorf("expected label %q, got %q", "TEST       ", label)
+		}
+	}
+}
+
+func TestCopyImage(t *testing.T) {
+	src := newImage(t, 8<<20)
+	if err := Format(src, FilesystemFat, 8<<20, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := src.WriteAt([]byte("data"), 6<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "clone.img")
+	if err := CopyImage(src.Name(), dst); err != nil {
+		t.Fatal("CopyImage:", err)
+	}
+
+	want, err := os.ReadFile(src.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatal("expected the clone to be identical to the original")
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if fs, err := DetectFilesystem(f); err != nil || fs != FilesystemFat {
+		t.Errorf("expected the clone to be detected as %s, got: %s (%v)", FilesystemFat, fs, err)
+	}
+}
@@ -0,0 +1,346 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	ext2Magic             = 0xef53
+	ext2SuperblockOffset  = 1024
+	ext2InodeSize         = 128
+	ext2GroupDescSize     = 32
+	ext2FirstIno          = 11
+	ext2RootIno           = 2
+	ext2LostAndFoundIno   = 11
+	ext2BytesPerInode     = 16384
+	ext2ReservedPercent   = 5
+	ext2FeatureFiletype   = 0x0002 // Incompatible: directory entries record the file type.
+	ext2FeatureSparseSupr = 0x0001 // Read-only compatible: superblock backups only in some groups.
+	ext2FileTypeDir       = 2
+	ext2ModeDir           = 0o040000
+
+	// ext2MinSize is the smallest size of a volume which is formatted with ext2.
+	ext2MinSize = 1024 * 1024
+)
+
+// ext2Layout describes the geometry of an ext2 filesystem.
+type ext2Layout struct {
+	blockSize       uint32
+	blocksCount     uint32
+	firstDataBlock  uint32
+	blocksPerGroup  uint32
+	inodesPerGroup  uint32
+	groupCount      uint32
+	gdtBlocks       uint32
+	inodeTableBlock uint32 // Number of blocks of the inode table of each group.
+}
+
+// newExt2Layout returns the geometry of an ext2 filesystem of the provided
+// size, following the defaults of mke2fs.
+func newExt2Layout(size int64) (*ext2Layout, error) {
+	if size < ext2MinSize {
+		return nil, fmt.Errorf("ext2 volumes must be at least %d bytes large", ext2MinSize)
+	}
+
+	l := &ext2Layout{blockSize: 4096}
+	if size < 512*1024*1024 {
+		l.blockSize = 1024
+		l.firstDataBlock = 1
+	}
+
+	if size/int64(l.blockSize) > 1<<32-1 {
+		return nil, fmt.Errorf("ext2 volumes must be smaller than %d bytes", int64(l.blockSize)<<32)
+	}
+
+	l.blocksCount = uint32(size / int64(l.blockSize))
+	l.blocksPerGroup = 8 * l.blockSize
+
+	inodesPerBlock := l.blockSize / ext2InodeSize
+
+	for {
+		l.groupCount = (l.blocksCount - l.firstDataBlock + l.blocksPerGroup - 1) / l.blocksPerGroup
+		l.gdtBlocks = (l.groupCount*ext2GroupDescSize + l.blockSize - 1) / l.blockSize
+
+		inodes := uint32(size / ext2BytesPerInode)
+		if inodes < ext2FirstIno+1 {
+			inodes = ext2FirstIno + 1
+		}
+
+		// Fill whole blocks of the inode table and at most one bitmap block.
+		l.inodesPerGroup = (inodes + l.groupCount - 1) / l.groupCount
+		l.inodesPerGroup = (l.inodesPerGroup + inodesPerBlock - 1) / inodesPerBlock * inodesPerBlock
+		if l.inodesPerGroup > 8*l.blockSize {
+			l.inodesPerGroup = 8 * l.blockSize
+		}
+
+		l.inodeTableBlock = l.inodesPerGroup / inodesPerBlock
+
+		// Drop a trailing group which is too small to hold its own metadata.
+		last := l.groupCount - 1
+		if l.groupCount > 1 && l.groupBlocks(last) <= l.overhead(last)+1 {
+			l.blocksCount -= l.groupBlocks(last)
+			continue
+		}
+
+		return l, nil
+	}
+}
+
+// hasSuper indicates whether the provided group holds a copy of the
+// superblock and the group descriptor table, which is the case for the groups
+// 0, 1 and the powers of 3, 5 and 7 with the sparse superblock feature.
+func (l *ext2Layout) hasSuper(group uint32) bool {
+	if group <= 1 {
+		return true
+	}
+
+	for _, base := range []uint32{3, 5, 7} {
+		n := base
+		for n < group {
+			n *= base
+		}
+
+		if n == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupStart returns the first block of the provided group.
+func (l *ext2Layout) groupStart(group uint32) uint32 {
+	return l.firstDataBlock + group*l.blocksPerGroup
+}
+
+// groupBlocks returns the number of blocks of the provided group, which is
+// less than blocksPerGroup for the last group.
+func (l *ext2Layout) groupBlocks(group uint32) uint32 {
+	return min(l.blocksPerGroup, l.blocksCount-l.groupStart(group))
+}
+
+// overhead returns the number of metadata blocks at the start of the
+// provided group.
+func (l *ext2Layout) overhead(group uint32) uint32 {
+	n := 2 + l.inodeTableBlock // Block and inode bitmaps.
+	if l.hasSuper(group) {
+		n += 1 + l.gdtBlocks
+	}
+
+	return n
+}
+
+// FormatExt2 writes an empty ext2 filesystem of the provided size with the
+// provided label, containing only the root and lost+found directories, to w.
+// Only the metadata is written, so w is expected to be zero-filled, e.g. a
+// newly created sparse file.
+func FormatExt2(w io.WriterAt, size int64, label string) error {
+	l, err := newExt2Layout(size)
+	if err != nil {
+		return err
+	}
+
+	now := uint32(time.Now().Unix())
+
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return err
+	}
+
+	uuid[6] = uuid[6]&0x0f | 0x40 // Version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // Variant RFC 4122
+
+	// The root and lost+found directories each occupy the first data block of
+	// the first group.
+	rootBlock := l.groupStart(0) + l.overhead(0)
+	lostAndFoundBlock := rootBlock + 1
+
+	gdt := make([]byte, l.gdtBlocks*l.blockSize)
+	var freeBlocks, freeInodes uint32
+
+	for group := uint32(0); group < l.groupCount; group++ {
+		start := l.groupStart(group)
+		blocks := l.groupBlocks(group)
+		used := l.overhead(group)
+		usedInodes := uint32(0)
+		usedDirs := uint16(0)
+
+		if group == 0 {
+			used += 2 // Root and lost+found directories.
+			usedInodes = ext2FirstIno
+			usedDirs = 2
+		}
+
+		blockBitmap := start
+		if l.hasSuper(group) {
+			blockBitmap += 1 + l.gdtBlocks
+		}
+
+		// Mark the used blocks, as well as the bits beyond the end of the last
+		// group, in the block bitmap.
+		bitmap := make([]byte, l.blockSize)
+		setBits(bitmap, 0, used)
+		setBits(bitmap, blocks, 8*l.blockSize)
+
+		if err := writeBlock(w, l.blockSize, blockBitmap, bitmap); err != nil {
+			return err
+		}
+
+		// Mark the reserved inodes, as well as the bits beyond the number of
+		// inodes per group, in the inode bitmap.
+		bitmap = make([]byte, l.blockSize)
+		setBits(bitmap, 0, usedInodes)
+		setBits(bitmap, l.inodesPerGroup, 8*l.blockSize)
+
+		if err := writeBlock(w, l.blockSize, blockBitmap+1, bitmap); err != nil {
+			return err
+		}
+
+		desc := gdt[group*ext2GroupDescSize:]
+		binary.LittleEndian.PutUint32(desc[0:], blockBitmap)
+		binary.LittleEndian.PutUint32(desc[4:], blockBitmap+1)
+		binary.LittleEndian.PutUint32(desc[8:], blockBitmap+2)
+		binary.LittleEndian.PutUint16(desc[12:], uint16(blocks-used))
+		binary.LittleEndian.PutUint16(desc[14:], uint16(l.inodesPerGroup-usedInodes))
+		binary.LittleEndian.PutUint16(desc[16:], usedDirs)
+
+		freeBlocks += blocks - used
+		freeInodes += l.inodesPerGroup - usedInodes
+	}
+
+	sb := make([]byte, 1024)
+	binary.LittleEndian.PutUint32(sb[0:], l.inodesPerGroup*l.groupCount)
+	binary.LittleEndian.PutUint32(sb[4:], l.blocksCount)
+	binary.LittleEndian.PutUint32(sb[8:], l.blocksCount/100*ext2ReservedPercent)
+	binary.LittleEndian.PutUint32(sb[12:], freeBlocks)
+	binary.LittleEndian.PutUint32(sb[16:], freeInodes)
+	binary.LittleEndian.PutUint32(sb[20:], l.firstDataBlock)
+	binary.LittleEndian.PutUint32(sb[24:], log2(l.blockSize/1024))
+	binary.LittleEndian.PutUint32(sb[28:], log2(l.blockSize/1024))
+	binary.LittleEndian.PutUint32(sb[32:], l.blocksPerGroup)
+	binary.LittleEndian.PutUint32(sb[36:], l.blocksPerGroup)
+	binary.LittleEndian.PutUint32(sb[40:], l.inodesPerGroup)
+	binary.LittleEndian.PutUint32(sb[48:], now)    // Write time
+	binary.LittleEndian.PutUint16(sb[54:], 0xffff) // Maximum mount count (disabled)
+	binary.LittleEndian.PutUint16(sb[56:], ext2Magic)
+	binary.LittleEndian.PutUint16(sb[58:], 1)   // State: cleanly unmounted
+	binary.LittleEndian.PutUint16(sb[60:], 1)   // Errors: continue
+	binary.LittleEndian.PutUint32(sb[64:], now) // Last check
+	binary.LittleEndian.PutUint32(sb[76:], 1)   // Revision: dynamic
+	binary.LittleEndian.PutUint32(sb[84:], ext2FirstIno)
+	binary.LittleEndian.PutUint16(sb[88:], ext2InodeSize)
+	binary.LittleEndian.PutUint32(sb[96:], ext2FeatureFiletype)
+	binary.LittleEndian.PutUint32(sb[100:], ext2FeatureSparseSupr)
+	copy(sb[104:120], uuid[:])
+	copy(sb[120:136], label)
+
+	for group := uint32(0); group < l.groupCount; group++ {
+		if !l.hasSuper(group) {
+			continue
+		}
+
+		binary.LittleEndian.PutUint16(sb[90:], uint16(group))
+
+		// The primary superblock is always located at an offset of 1024 bytes,
+		// which is within the first block for block sizes larger than 1024.
+		off := int64(l.groupStart(group)) * int64(l.blockSize)
+		if group == 0 {
+			off = ext2SuperblockOffset
+		}
+
+		if _, err := w.WriteAt(sb, off); err != nil {
+			return err
+		}
+
+		if err := writeBlock(w, l.blockSize, l.groupStart(group)+1, gdt); err != nil {
+			return err
+		}
+	}
+
+	// Write the inodes of the root and lost+found directories, which are both
+	// located in the inode table of the first group.
+	inodeTable := int64(l.groupStart(0)+l.overhead(0)-l.inodeTableBlock) * int64(l.blockSize)
+
+	for _, dir := range []struct {
+		ino   uint32
+		mode  uint16
+		links uint16
+		block uint32
+	}{
+		{ext2RootIno, ext2ModeDir | 0o755, 3, rootBlock},
+		{ext2LostAndFoundIno, ext2ModeDir | 0o700, 2, lostAndFoundBlock},
+	} {
+		inode := make([]byte, ext2InodeSize)
+		binary.LittleEndian.PutUint16(inode[0:], dir.mode)
+		binary.LittleEndian.PutUint32(inode[4:], l.blockSize)
+		binary.LittleEndian.PutUint32(inode[8:], now)
+		binary.LittleEndian.PutUint32(inode[12:], now)
+		binary.LittleEndian.PutUint32(inode[16:], now)
+		binary.LittleEndian.PutUint16(inode[26:], dir.links)
+		binary.LittleEndian.PutUint32(inode[28:], l.blockSize/512)
+		binary.LittleEndian.PutUint32(inode[40:], dir.block)
+
+		if _, err := w.WriteAt(inode, inodeTable+int64(dir.ino-1)*ext2InodeSize); err != nil {
+			return err
+		}
+	}
+
+	root := make([]byte, l.blockSize)
+	off := putDirEntry(root, 0, ext2RootIno, ".", 12)
+	off = putDirEntry(root, off, ext2RootIno, "..", 12)
+	putDirEntry(root, off, ext2LostAndFoundIno, "lost+found", l.blockSize-off)
+
+	if err := writeBlock(w, l.blockSize, rootBlock, root); err != nil {
+		return err
+	}
+
+	lostAndFound := make([]byte, l.blockSize)
+	off = putDirEntry(lostAndFound, 0, ext2LostAndFoundIno, ".", 12)
+	putDirEntry(lostAndFound, off, ext2RootIno, "..", l.blockSize-off)
+
+	return writeBlock(w, l.blockSize, lostAndFoundBlock, lostAndFound)
+}
+
+// putDirEntry writes a directory entry of the provided length at the provided
+// offset of the directory block and returns the offset of the next entry.
+func putDirEntry(block []byte, off, ino uint32, name string, length uint32) uint32 {
+	binary.LittleEndian.PutUint32(block[off:], ino)
+	binary.LittleEndian.PutUint16(block[off+4:], uint16(length))
+	block[off+6] = byte(len(name))
+	block[off+7] = ext2FileTypeDir
+	copy(block[off+8:], name)
+
+	return off + length
+}
+
+// writeBlock writes the provided data at the provided block.
+func writeBlock(w io.WriterAt, blockSize, block uint32, data []byte) error {
+	_, err := w.WriteAt(data, int64(block)*int64(blockSize))
+	return err
+}
+
+// setBits sets the bits in the range [from, to) of the provided bitmap.
+func setBits(bitmap []byte, from, to uint32) {
+	for i := from; i < to; i++ {
+		bitmap[i/8] |= 1 << (i % 8)
+	}
+}
+
+// log2 returns the binary logarithm of the provided power of two.
+func log2(n uint32) uint32 {
+	var ret uint32
+	for n > 1 {
+		n >>= 1
+		ret++
+	}
+
+	return ret
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	fatSectorSize     = 512
+	fatCount          = 2
+	fatMediaFixedDisk = 0xf8
+	fat16RootEntries  = 512
+	fat16MinClusters  = 4085
+	fat16MaxClusters  = 65524
+	fat32MinClusters  = 65525
+	fat32ReservedSecs = 32
+	fat32RootCluster  = 2
+	fat32FSInfoSector = 1
+	fat32BackupSector = 6
+
+	// fatMinSize is the smallest size of a volume which is formatted with FAT.
+	fatMinSize = 4 * 1024 * 1024
+)
+
+// FormatFat writes an empty FAT filesystem of the provided size with the
+// provided label to w, using FAT16 for volumes which are small enough and
+// FAT32 otherwise.  Only the metadata is written, so w is expected to be
+// zero-filled, e.g. a newly created sparse file.
+func FormatFat(w io.WriterAt, size int64, label string) error {
+	if size < fatMinSize {
+		return fmt.Errorf("FAT volumes must be at least %d bytes large", fatMinSize)
+	}
+
+	sectors := size / fatSectorSize
+	if sectors > 1<<32-1 {
+		return fmt.Errorf("FAT volumes must be smaller than %d bytes", int64(fatSectorSize)<<32)
+	}
+
+	if err := formatFat16(w, uint32(sectors), label); !errors.Is(err, errFatTooLarge) {
+		return err
+	}
+
+	return formatFat32(w, uint32(sectors), label)
+}
+
+var errFatTooLarge = errors.New("too many clusters for FAT16")
+
+// fatLabel returns the provided label as a space-padded 11 byte volume label.
+func fatLabel(label string) []byte {
+	if label == "" {
+		label = "NO NAME"
+	}
+
+	ret := []byte(strings.ToUpper(label) + strings.Repeat(" ", 11))
+	return ret[:11]
+}
+
+// fatClusterSize returns the number of sectors per cluster, following the
+// defaults of the Microsoft FAT specification, for a volume of the provided
+// number of sectors.
+func fatClusterSize(sectors uint32, fat32 bool) uint8 {
+	mib := sectors / (1024 * 1024 / fatSectorSize)
+
+	if !fat32 {
+		switch {
+		case mib < 16:
+			return 1
+		case mib < 128:
+			return 4
+		case mib < 256:
+			return 8
+		case mib < 512:
+			return 16
+		case mib < 1024:
+			return 32
+		default:
+			return 64
+		}
+	}
+
+	switch {
+	case mib < 8*1024:
+		return 8
+	case mib < 16*1024:
+		return 16
+	case mib < 32*1024:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// fatBootSector returns the fields of the boot sector which are common to FAT16
+// and FAT32.
+func fatBootSector(sectors uint32, clusterSize uint8, reserved uint16, rootEntries uint16) []byte {
+	bs := make([]byte, fatSectorSize)
+
+	copy(bs[0:], []byte{0xeb, 0x58, 0x90}) // Jump to the (absent) boot code
+	copy(bs[3:11], "KRAFTKIT")
+	binary.LittleEndian.PutUint16(bs[11:], fatSectorSize)
+	bs[13] = clusterSize
+	binary.LittleEndian.PutUint16(bs[14:], reserved)
+	bs[16] = fatCount
+	binary.LittleEndian.PutUint16(bs[17:], rootEntries)
+	if sectors < 1<<16 {
+		binary.LittleEndian.PutUint16(bs[19:], uint16(sectors))
+	} else {
+		binary.LittleEndian.PutUint32(bs[32:], sectors)
+	}
+	bs[21] = fatMediaFixedDisk
+	binary.LittleEndian.PutUint16(bs[24:], 32) // Sectors per track
+	binary.LittleEndian.PutUint16(bs[26:], 64) // Number of heads
+	bs[510] = 0x55
+	bs[511] = 0xaa
+
+	return bs
+}
+
+// fatExtendedBootRecord writes the extended boot record, which is located at
+// the provided offset of the boot sector.
+func fatExtendedBootRecord(bs []byte, off int, label []byte, fsType string) error {
+	bs[off] = 0x80   // Drive number
+	bs[off+2] = 0x29 // Extended boot signature
+
+	if _, err := rand.Read(bs[off+3 : off+7]); err != nil {
+		return err
+	}
+
+	copy(bs[off+7:off+18], label)
+	copy(bs[off+18:off+26], fsType+"        ")
+
+	return nil
+}
+
+// fatRootDir returns the first sector of the root directory, which holds the
+// volume label entry.
+func fatRootDir(label []byte) []byte {
+	dir := make([]byte, fatSectorSize)
+	copy(dir[0:11], label)
+	dir[11] = 0x08 // Volume label attribute
+
+	return dir
+}
+
+func formatFat16(w io.WriterAt, sectors uint32, label string) error {
+	clusterSize := fatClusterSize(sectors, false)
+	reserved := uint32(1)
+	rootSectors := uint32(fat16RootEntries * 32 / fatSectorSize)
+
+	// Compute the size of a single FAT, as described by the Microsoft FAT
+	// specification, which slightly overestimates it.
+	tmp1 := sectors - (reserved + rootSectors)
+	tmp2 := 256*uint32(clusterSize) + fatCount
+	fatSize := (tmp1 + tmp2 - 1) / tmp2
+
+	dataStart := reserved + fatCount*fatSize + rootSectors
+	clusters := (sectors - dataStart) / uint32(clusterSize)
+	if clusters > fat16MaxClusters {
+		return errFatTooLarge
+	} else if clusters < fat16MinClusters {
+		return fmt.Errorf("too few clusters for FAT16: %d", clusters)
+	}
+
+	bs := fatBootSector(sectors, clusterSize, uint16(reserved), fat16RootEntries)
+	binary.LittleEndian.PutUint16(bs[22:], uint16(fatSize))
+
+	if err := fatExtendedBootRecord(bs, 36, fatLabel(label), "FAT16"); err != nil {
+		return err
+	}
+
+	if _, err := w.WriteAt(bs, 0); err != nil {
+		return err
+	}
+
+	// The first two entries of the FAT hold the media descriptor and the
+	// end-of-chain marker.
+	fat := []byte{fatMediaFixedDisk, 0xff, 0xff, 0xff}
+
+	for i := uint32(0); i < fatCount; i++ {
+		if _, err := w.WriteAt(fat, int64(reserved+i*fatSize)*fatSectorSize); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.WriteAt(fatRootDir(fatLabel(label)), int64(reserved+fatCount*fatSize)*fatSectorSize)
+	return err
+}
+
+func formatFat32(w io.WriterAt, sectors uint32, label string) error {
+	clusterSize := fatClusterSize(sectors, true)
+	reserved := uint32(fat32ReservedSecs)
+
+	tmp1 := sectors - reserved
+	tmp2 := (256*uint32(clusterSize) + fatCount) / 2
+	fatSize := (tmp1 + tmp2 - 1) / tmp2
+
+	dataStart := reserved + fatCount*fatSize
+	clusters := (sectors - dataStart) / uint32(clusterSize)
+	if clusters < fat32MinClusters {
+		return fmt.Errorf("too few clusters for FAT32: %d", clusters)
+	}
+
+	bs := fatBootSector(sectors, clusterSize, uint16(reserved), 0)
+	binary.LittleEndian.PutUint32(bs[36:], fatSize)
+	binary.LittleEndian.PutUint32(bs[44:], fat32RootCluster)
+	binary.LittleEndian.PutUint16(bs[48:], fat32FSInfoSector)
+	binary.LittleEndian.PutUint16(bs[50:], fat32BackupSector)
+
+	if err := fatExtendedBootRecord(bs, 64, fatLabel(label), "FAT32"); err != nil {
+		return err
+	}
+
+	// The root directory occupies the first cluster.
+	fsinfo := make([]byte, fatSectorSize)
+	binary.LittleEndian.PutUint32(fsinfo[0:], 0x41615252)
+	binary.LittleEndian.PutUint32(fsinfo[484:], 0x61417272)
+	binary.LittleEndian.PutUint32(fsinfo[488:], clusters-1)         // Free clusters
+	binary.LittleEndian.PutUint32(fsinfo[492:], fat32RootCluster+1) // Next free cluster
+	binary.LittleEndian.PutUint32(fsinfo[508:], 0xaa550000)
+
+	for _, base := range []uint32{0, fat32BackupSector} {
+		if _, err := w.WriteAt(bs, int64(base)*fatSectorSize); err != nil {
+			return err
+		}
+
+		if _, err := w.WriteAt(fsinfo, int64(base+fat32FSInfoSector)*fatSectorSize); err != nil {
+			return err
+		}
+	}
+
+	// The first two entries of the FAT hold the media descriptor and the
+	// end-of-chain marker, followed by the end of the root directory's chain.
+	fat := make([]byte, 12)
+	binary.LittleEndian.PutUint32(fat[0:], 0x0fffff00|fatMediaFixedDisk)
+	binary.LittleEndian.PutUint32(fat[4:], 0x0fffffff)
+	binary.LittleEndian.PutUint32(fat[8:], 0x0fffffff)
+
+	for i := uint32(0); i < fatCount; i++ {
+		if _, err := w.WriteAt(fat, int64(reserved+i*fatSize)*fatSectorSize); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.WriteAt(fatRootDir(fatLabel(label)), int64(dataStart)*fatSectorSize)
+	return err
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sparseBlockSize is the granularity at which runs of zeros are skipped when
+// writing disk images, such that they are left unallocated on the host.
+const sparseBlockSize = 4096
+
+// The magic numbers of read-only filesystems which are detected but never
+// formatted by the driver.
+const (
+	squashfsMagic         = 0x73717368
+	erofsMagic            = 0xe0f5e1e2
+	erofsSuperblockOffset = 1024
+)
+
+// WriteImage replaces the disk image at dst with the contents read from r and
+// returns its size.  Blocks which are entirely zero are not written, such that
+// the image remains sparse.  The image at dst is left untouched should reading
+// from r fail.
+func WriteImage(dst string, r io.Reader) (int64, error) {
+	var size int64
+
+	err := replaceImage(dst, 0o644, func(f *os.File) error {
+		var err error
+		size, err = writeSparse(f, r)
+		return err
+	})
+
+	return size, err
+}
+
+// CopyImage replaces the disk image at dst with a copy of the disk image at
+// src.  The copy shares its blocks with the original when the host filesystem
+// supports reflinks, which makes it instantaneous and leaves both images
+// independent of each other.  Otherwise, only the blocks of the original which
+// are not zero are copied.
+func CopyImage(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("could not open disk image: %w", err)
+	}
+
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat disk image: %w", err)
+	}
+
+	return replaceImage(dst, fi.Mode().Perm(), func(f *os.File) error {
+		if err := reflink(f, in); err == nil {
+			return nil
+		}
+
+		_, err := writeSparse(f, in)
+		return err
+	})
+}
+
+// replaceImage atomically replaces the file at dst with a file of the provided
+// permissions whose contents are written by the provided function.
+func replaceImage(dst string, perm os.FileMode, write func(*os.File) error) error {
+	f, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create disk image: %w", err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("could not write disk image: %w", err)
+	}
+
+	if err := errors.Join(f.Chmod(perm), f.Close()); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("could not write disk image: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), dst); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("could not replace disk image: %w", err)
+	}
+
+	return nil
+}
+
+// writeSparse copies r to f, seeking over blocks which are entirely zero
+// rather than writing them, and returns the number of bytes copied.
+func writeSparse(f *os.File, r io.Reader) (int64, error) {
+	buf := make([]byte, 256*sparseBlockSize)
+	zeros := make([]byte, sparseBlockSize)
+
+	var size int64
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		for off := 0; off < n; off += sparseBlockSize {
+			block := buf[off:min(off+sparseBlockSize, n)]
+
+			if bytes.Equal(block, zeros[:len(block)]) {
+				if _, err := f.Seek(int64(len(block)), io.SeekCurrent); err != nil {
+					return size, err
+				}
+			} else if _, err := f.Write(block); err != nil {
+				return size, err
+			}
+
+			size += int64(len(block))
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return size, err
+		}
+	}
+
+	// Trailing zeros were skipped, so the file must be extended to its size.
+	return size, f.Truncate(size)
+}
+
+// DetectFilesystem returns the filesystem with which the provided disk image
+// is formatted.
+func DetectFilesystem(r io.ReaderAt) (Filesystem, error) {
+	magic := make([]byte, 2)
+	if _, err := r.ReadAt(magic, ext2SuperblockOffset+56); err == nil && binary.LittleEndian.Uint16(magic) == ext2Magic {
+		return FilesystemExt2, nil
+	}
+
+	magic = make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err == nil && binary.LittleEndian.Uint32(magic) == squashfsMagic {
+		return FilesystemSquashfs, nil
+	}
+
+	if _, err := r.ReadAt(magic, erofsSuperblockOffset); err == nil && binary.LittleEndian.Uint32(magic) == erofsMagic {
+		return FilesystemErofs, nil
+	}
+
+	// The boot sector of FAT holds the type of the filesystem as an informative
+	// string, whose location differs between FAT12/16 and FAT32.
+	fsType := make([]byte, 8)
+	for _, off := range []int64{54, 82} {
+		if _, err := r.ReadAt(fsType, off); err == nil && bytes.HasPrefix(fsType, []byte("FAT")) {
+			return FilesystemFat, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not detect the filesystem of the disk image")
+}
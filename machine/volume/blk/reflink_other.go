@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is unsupported on this host, such that copies are always made in
+// full.
+func reflink(dst, src *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+)
+
+// snapshotNameRegex restricts the names of snapshots, which are used as file
+// names.
+var snapshotNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// snapshotDir returns the directory in which the snapshots of the provided
+// volume are stored.
+func snapshotDir(ctx context.Context, volume *volumev1alpha1.Volume) string {
+	return filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "volumes", string(volume.ObjectMeta.UID)+".snapshots")
+}
+
+// snapshotIndex returns the position of the snapshot with the provided name
+// amongst the snapshots of the volume, or -1 if it does not exist.
+func snapshotIndex(volume *volumev1alpha1.Volume, name string) int {
+	return slices.IndexFunc(volume.Status.Snapshots, func(snapshot volumev1alpha1.VolumeSnapshot) bool {
+		return snapshot.Name == name
+	})
+}
+
+// Snapshot takes a point-in-time copy of the disk image of the provided volume
+// and records it in the status of the volume under the provided name, which
+// defaults to the current time.  The volume may be in use by a machine, in
+// which case the snapshot is as consistent as after a power loss.  The caller
+// is responsible for persisting the updated volume.
+func Snapshot(ctx context.Context, volume *volumev1alpha1.Volume, name string) (*volumev1alpha1.VolumeSnapshot, error) {
+	if volume.Spec.Driver != DriverName {
+		return nil, fmt.Errorf("snapshots are only supported by the %s volume driver", DriverName)
+	}
+
+	now := time.Now().UTC()
+	if len(name) == 0 {
+		name = now.Format("20060102T150405Z")
+	}
+
+	if !snapshotNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name: %s", name)
+	}
+
+	if snapshotIndex(volume, name) >= 0 {
+		return nil, fmt.Errorf("snapshot %s of volume %s already exists", name, volume.Name)
+	}
+
+	snapshot := volumev1alpha1.VolumeSnapshot{
+		Name:      name,
+		Source:    filepath.Join(snapshotDir(ctx, volume), name+".img"),
+		CreatedAt: metav1.NewTime(now),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(snapshot.Source), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create snapshot directory: %w", err)
+	}
+
+	if err := CopyImage(volume.Spec.Source, snapshot.Source); err != nil {
+		return nil, fmt.Errorf("cannot snapshot volume %s: %w", volume.Name, err)
+	}
+
+	volume.Status.Snapshots = append(volume.Status.Snapshots, snapshot)
+
+	return &snapshot, nil
+}
+
+// Restore replaces the disk image of the provided volume with the snapshot of
+// the provided name.  The snapshot itself is kept, such that it can be
+// restored again.
+func Restore(_ context.Context, volume *volumev1alpha1.Volume, name string) error {
+	idx := snapshotIndex(volume, name)
+	if idx < 0 {
+		return fmt.Errorf("snapshot %s of volume %s does not exist", name, volume.Name)
+	}
+
+	if volume.Status.State == volumev1alpha1.VolumeStateBound {
+		return fmt.Errorf("cannot restore volume in state %s", volume.Status.State)
+	}
+
+	if err := CopyImage(volume.Status.Snapshots[idx].Source, volume.Spec.Source); err != nil {
+		return fmt.Errorf("cannot restore snapshot %s of volume %s: %w", name, volume.Name, err)
+	}
+
+	return nil
+}
+
+// RemoveSnapshot removes the snapshot of the provided name from the provided
+// volume.  The caller is responsible for persisting the updated volume.
+func RemoveSnapshot(_ context.Context, volume *volumev1alpha1.Volume, name string) error {
+	idx := snapshotIndex(volume, name)
+	if idx < 0 {
+		return fmt.Errorf("snapshot %s of volume %s does not exist", name, volume.Name)
+	}
+
+	if err := os.Remove(volume.Status.Snapshots[idx].Source); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove snapshot: %w", err)
+	}
+
+	volume.Status.Snapshots = slices.Delete(volume.Status.Snapshots, idx, idx+1)
+
+	return nil
+}
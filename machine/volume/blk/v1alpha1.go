@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package blk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume/sourcewatch"
+)
+
+type v1alpha1Volume struct{}
+
+func NewVolumeServiceV1alpha1(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+	return &v1alpha1Volume{}, nil
+}
+
+// Create implements kraftkit.sh/api/volume/v1alpha1.Create
+func (*v1alpha1Volume) Create(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	var err error
+
+	if len(volume.Spec.Driver) == 0 {
+		volume.Spec.Driver = DriverName
+	} else if volume.Spec.Driver != DriverName {
+		return volume, fmt.Errorf("cannot use %s driver when driver set to %s", DriverName, volume.Spec.Driver)
+	}
+
+	if volume.ObjectMeta.UID == "" {
+		volume.ObjectMeta.UID = uuid.NewUUID()
+	}
+
+	if volume.ObjectMeta.Name == "" {
+		volume.ObjectMeta.Name = string(volume.ObjectMeta.UID)
+	}
+
+	if len(volume.Spec.Filesystem) > 0 && !slices.Contains(Filesystems(), Filesystem(volume.Spec.Filesystem)) {
+		return volume, fmt.Errorf("unsupported filesystem: %s", volume.Spec.Filesystem)
+	}
+
+	// An existing disk image is used as-is, since it may already hold data.
+	if len(volume.Spec.Source) > 0 {
+		volume.Spec.Managed = false
+		volume.Spec.Source, err = filepath.Abs(volume.Spec.Source)
+		if err != nil {
+			return volume, fmt.Errorf("cannot get absolute path for volume source: %w", err)
+		}
+
+		fileInfo, err := os.Stat(volume.Spec.Source)
+		if err != nil {
+			return volume, fmt.Errorf("cannot stat volume image: %w", err)
+		}
+
+		if !fileInfo.Mode().IsRegular() {
+			return volume, fmt.Errorf("volume source is not a regular file: %s", volume.Spec.Source)
+		}
+
+		if len(volume.Spec.Filesystem) == 0 {
+			f, err := os.Open(volume.Spec.Source)
+			if err != nil {
+				return volume, fmt.Errorf("cannot open volume image: %w", err)
+			}
+
+			fs, err := DetectFilesystem(f)
+			f.Close()
+			if err != nil {
+				log.G(ctx).Warnf("%v %s, assuming %s", err, volume.Spec.Source, DefaultFilesystem)
+				fs = DefaultFilesystem
+			}
+
+			volume.Spec.Filesystem = fs.String()
+		}
+
+		// Images of read-only filesystems cannot be mounted otherwise.
+		if Filesystem(volume.Spec.Filesystem).IsReadOnly() {
+			volume.Spec.ReadOnly = true
+		}
+
+		volume.Spec.Size = fileInfo.Size()
+		volume.Status.State = volumev1alpha1.VolumeStatePending
+
+		return volume, nil
+	}
+
+	if volume.Spec.Size == 0 {
+		volume.Spec.Size = DefaultSize
+	}
+
+	if len(volume.Spec.Filesystem) == 0 {
+		volume.Spec.Filesystem = DefaultFilesystem.String()
+	}
+
+	log.G(ctx).Debugf("creating new volume image in the runtime store %s", volume.ObjectMeta.UID)
+
+	volume.Spec.Source = filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "volumes", string(volume.ObjectMeta.UID)+".img")
+	volume.Spec.Managed = true
+
+	if err := os.MkdirAll(filepath.Dir(volume.Spec.Source), 0o755); err != nil {
+		return volume, fmt.Errorf("cannot create volume directory: %w", err)
+	}
+
+	f, err := os.OpenFile(volume.Spec.Source, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return volume, fmt.Errorf("cannot create volume image: %w", err)
+	}
+
+	defer f.Close()
+
+	// Truncating the new file allocates it sparsely, such that only the blocks
+	// which are written to occupy space on the host.
+	if err := f.Truncate(volume.Spec.Size); err != nil {
+		os.Remove(volume.Spec.Source)
+		return volume, fmt.Errorf("cannot allocate volume image: %w", err)
+	}
+
+	if err := Format(f, Filesystem(volume.Spec.Filesystem), volume.Spec.Size, volume.ObjectMeta.Name); err != nil {
+		os.Remove(volume.Spec.Source)
+		return volume, fmt.Errorf("cannot format volume image: %w", err)
+	}
+
+	volume.Status.State = volumev1alpha1.VolumeStatePending
+
+	return volume, nil
+}
+
+// Delete implements kraftkit.sh/api/volume/v1alpha1.Delete
+func (*v1alpha1Volume) Delete(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != DriverName {
+		return nil, nil
+	}
+
+	if len(volume.Spec.Source) == 0 {
+		return nil, nil
+	}
+
+	if volume.Status.State == volumev1alpha1.VolumeStateBound {
+		return volume, fmt.Errorf("cannot delete volume in state %s", volume.Status.State)
+	}
+
+	if volume.Spec.Managed {
+		if err := os.Remove(volume.Spec.Source); err != nil && !os.IsNotExist(err) {
+			return volume, fmt.Errorf("cannot remove volume image: %w", err)
+		}
+	}
+
+	// Snapshots are always stored in the runtime store, even when the volume
+	// itself is not managed.
+	if err := os.RemoveAll(snapshotDir(ctx, volume)); err != nil {
+		return volume, fmt.Errorf("cannot remove volume snapshots: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Get implements kraftkit.sh/api/volume/v1alpha1.Get
+func (*v1alpha1Volume) Get(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != DriverName {
+		return nil, nil
+	}
+
+	if len(volume.Spec.Source) == 0 {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(volume.Spec.Source); os.IsNotExist(err) {
+		volume.Status.State = volumev1alpha1.VolumeStateLost
+	} else if volume.Status.State == volumev1alpha1.VolumeStateLost {
+		volume.Status.State = volumev1alpha1.VolumeStatePending
+	}
+
+	return volume, nil
+}
+
+// List implements kraftkit.sh/api/volume/v1alpha1.List
+func (*v1alpha1Volume) List(_ context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	return volumes, nil
+}
+
+// Update implements kraftkit.sh/api/volume/v1alpha1.Update
+func (*v1alpha1Volume) Update(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return volume, nil
+}
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
+func (service *v1alpha1Volume) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	if volume.Spec.Driver != DriverName {
+		return nil, nil, fmt.Errorf("volume %s is not a %s volume", volume.Name, DriverName)
+	}
+
+	return sourcewatch.Watch(ctx, volume, service.Get)
+}
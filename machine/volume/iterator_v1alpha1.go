@@ -11,6 +11,7 @@ import (
 	zip "api.zip"
 	"github.com/acorn-io/baaah/pkg/merr"
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/daemon/client"
 )
 
 type volumeV1alpha1ServiceIterator struct {
@@ -23,6 +24,11 @@ type volumeV1alpha1ServiceIterator struct {
 // useful in circumstances where the driver is not supplied.  The first volume
 // driver to succeed is returned in all circumstances.
 func NewVolumeV1alpha1ServiceIterator(ctx context.Context) (volumev1alpha1.VolumeService, error) {
+	// The daemon iterates over its own supported strategies.
+	if client.Available(ctx) {
+		return client.NewVolumeV1alpha1Service(ctx, "")
+	}
+
 	var err error
 	iterator := volumeV1alpha1ServiceIterator{
 		strategies: map[string]volumev1alpha1.VolumeService{},
@@ -123,3 +129,20 @@ func (iterator *volumeV1alpha1ServiceIterator) List(ctx context.Context, cached
 
 	return cached, nil
 }
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
+func (iterator *volumeV1alpha1ServiceIterator) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	var errs []error
+
+	for _, strategy := range iterator.strategies {
+		eventChan, errChan, err := strategy.Watch(ctx, volume)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return eventChan, errChan, nil
+	}
+
+	return nil, nil, fmt.Errorf("all iterated drivers failed: %w", merr.NewErrors(errs...))
+}
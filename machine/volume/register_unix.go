@@ -6,14 +6,15 @@ package volume
 
 import (
 	"context"
-	"path/filepath"
+	"os"
 
 	zip "api.zip"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
-	"kraftkit.sh/config"
 	"kraftkit.sh/kconfig"
 	ninepfs "kraftkit.sh/machine/volume/9pfs"
+	"kraftkit.sh/machine/volume/blk"
+	"kraftkit.sh/machine/volume/virtiofs"
 	"kraftkit.sh/store"
 )
 
@@ -25,12 +26,12 @@ func hostSupportedStrategies() map[string]*Strategy {
 	return map[string]*Strategy{
 		"9pfs": {
 			IsCompatible: func(source string, _ kconfig.KeyValueMap) (bool, error) {
-				// TODO(nderjung): For now, it is OK to return true because this is the
-				// only supported driver.  In the future, we should a). check if the
-				// provided source is a readable directory and b). check if the supplied
-				// KConfig of the machine indicates that 9pfs is indeed part of the
-				// build configuration.
-				return true, nil
+				// TODO(nderjung): In the future, we should a). check if the provided
+				// source is a readable directory and b). check if the supplied KConfig
+				// of the machine indicates that 9pfs is indeed part of the build
+				// configuration.  Until then, any source which is not a disk image is
+				// shared with 9pfs.
+				return !isRegularFile(source), nil
 			},
 			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
 				service, err := ninepfs.NewVolumeServiceV1alpha1(ctx, opts...)
@@ -38,12 +39,29 @@ func hostSupportedStrategies() map[string]*Strategy {
 					return nil, err
 				}
 
-				embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](
-					filepath.Join(
-						config.G[config.KraftKit](ctx).RuntimeDir,
-						"volumev1alpha1",
-					),
+				runtimeStore, err := store.NewRuntimeStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](ctx, "volumev1alpha1")
+				if err != nil {
+					return nil, err
+				}
+
+				return volumev1alpha1.NewVolumeServiceHandler(
+					ctx,
+					service,
+					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 				)
+			},
+		},
+		blk.DriverName: {
+			IsCompatible: func(source string, _ kconfig.KeyValueMap) (bool, error) {
+				return isRegularFile(source), nil
+			},
+			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+				service, err := blk.NewVolumeServiceV1alpha1(ctx, opts...)
+				if err != nil {
+					return nil, err
+				}
+
+				runtimeStore, err := store.NewRuntimeStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](ctx, "volumev1alpha1")
 				if err != nil {
 					return nil, err
 				}
@@ -51,9 +69,38 @@ func hostSupportedStrategies() map[string]*Strategy {
 				return volumev1alpha1.NewVolumeServiceHandler(
 					ctx,
 					service,
-					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](runtimeStore, zip.StoreRehydrationSpecNil),
+				)
+			},
+		},
+		virtiofs.DriverName: {
+			IsCompatible: func(source string, _ kconfig.KeyValueMap) (bool, error) {
+				return !isRegularFile(source), nil
+			},
+			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+				service, err := virtiofs.NewVolumeServiceV1alpha1(ctx, opts...)
+				if err != nil {
+					return nil, err
+				}
+
+				runtimeStore, err := store.NewRuntimeStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](ctx, "volumev1alpha1")
+				if err != nil {
+					return nil, err
+				}
+
+				return volumev1alpha1.NewVolumeServiceHandler(
+					ctx,
+					service,
+					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](runtimeStore, zip.StoreRehydrationSpecNil),
 				)
 			},
 		},
 	}
 }
+
+// isRegularFile indicates whether the provided source is an existing regular
+// file, such as a disk image, as opposed to a directory.
+func isRegularFile(source string) bool {
+	fi, err := os.Stat(source)
+	return err == nil && fi.Mode().IsRegular()
+}
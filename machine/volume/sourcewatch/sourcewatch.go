@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package sourcewatch implements the watching of volumes for the volume
+// drivers whose volumes are backed by a path on the host, such that the
+// removal and re-creation of their source is observed as it happens.
+package sourcewatch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// GetFunc returns the current representation of the provided volume.
+type GetFunc func(context.Context, *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error)
+
+// Watch emits the provided volume as returned by get, starting with its
+// current state and subsequently whenever its state changes due to the
+// creation or removal of its source.  The stream ends once the context is
+// cancelled or with an error if the source can no longer be watched.
+func Watch(ctx context.Context, volume *volumev1alpha1.Volume, get GetFunc) (chan *volumev1alpha1.Volume, chan error, error) {
+	if len(volume.Spec.Source) == 0 {
+		return nil, nil, fmt.Errorf("volume %s has no source", volume.Name)
+	}
+
+	// Watch the parent directory of the source, as the removal of the source
+	// itself is otherwise not observed reliably across platforms.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, fmt.Errorf("setting up file watcher: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(volume.Spec.Source)); err != nil {
+		watcher.Close()
+		return nil, nil, fmt.Errorf("adding volume source to watcher: %w", err)
+	}
+
+	// Get populates the volume it is provided with, so each call receives a
+	// copy of the original volume.
+	copied := *volume
+
+	current, err := get(ctx, &copied)
+	if err != nil {
+		watcher.Close()
+		return nil, nil, err
+	}
+
+	events := make(chan *volumev1alpha1.Volume)
+	errs := make(chan error)
+
+	go func() {
+		defer watcher.Close()
+
+		// Initialize the channel with the current state of the volume, so that it
+		// can be immediately acted upon.
+		select {
+		case events <- current:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+				}
+
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != filepath.Clean(volume.Spec.Source) {
+					continue
+				}
+
+				if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+
+				copied := *volume
+
+				next, err := get(ctx, &copied)
+				if err != nil {
+					select {
+					case errs <- err:
+					case <-ctx.Done():
+					}
+
+					return
+				}
+
+				if next.Status.State == current.Status.State {
+					continue
+				}
+
+				current = next
+
+				select {
+				case events <- current:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
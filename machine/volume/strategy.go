@@ -6,8 +6,12 @@ package volume
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/daemon/client"
 	"kraftkit.sh/kconfig"
 )
 
@@ -39,9 +43,29 @@ func Strategies() map[string]*Strategy {
 		base[name] = driverInfo
 	}
 
+	for name, driverInfo := range base {
+		base[name] = viaDaemon(name, driverInfo)
+	}
+
 	return base
 }
 
+// viaDaemon returns a copy of the strategy which forwards all calls to the
+// local KraftKit daemon if it is running instead of instantiating the driver
+// in-process.
+func viaDaemon(driver string, strategy *Strategy) *Strategy {
+	ret := *strategy
+	ret.NewVolumeV1alpha1 = func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+		if client.Available(ctx) {
+			return client.NewVolumeV1alpha1Service(ctx, driver)
+		}
+
+		return strategy.NewVolumeV1alpha1(ctx, opts...)
+	}
+
+	return &ret
+}
+
 // DriverNames returns the list of registered platform driver implementation
 // names.
 func DriverNames() []string {
@@ -52,3 +76,36 @@ func DriverNames() []string {
 
 	return ret
 }
+
+// CompatibleDriverName returns the name of the driver with which a volume of
+// the provided source is created when no driver is requested.  When several
+// drivers are compatible with the source, the driver set in the configuration
+// is preferred, followed by the default driver.
+func CompatibleDriverName(ctx context.Context, source string) (string, error) {
+	var compatible []string
+
+	for name, strategy := range Strategies() {
+		if ok, err := strategy.IsCompatible(source, nil); err != nil || !ok {
+			continue
+		}
+
+		compatible = append(compatible, name)
+	}
+
+	if len(compatible) == 0 {
+		return "", fmt.Errorf("could not find compatible volume driver for %s", source)
+	}
+
+	for _, preferred := range []string{
+		config.G[config.KraftKit](ctx).VolumeDriver,
+		DefaultStrategyName(),
+	} {
+		if slices.Contains(compatible, preferred) {
+			return preferred, nil
+		}
+	}
+
+	slices.Sort(compatible)
+
+	return compatible[0], nil
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/archive"
+	"kraftkit.sh/machine/volume/blk"
+)
+
+// gzipMagic are the first bytes of gzip-compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Export writes the contents of the provided volume as a tarball to w.  The
+// directory of volumes which are shared with the machine is archived as-is,
+// whereas block-device volumes are archived as a single disk image.
+func Export(ctx context.Context, volume *volumev1alpha1.Volume, w io.Writer, opts ...archive.ArchiveOption) error {
+	if len(volume.Spec.Source) == 0 {
+		return fmt.Errorf("volume %s has no source", volume.Name)
+	}
+
+	tw := tar.NewWriter(w)
+
+	var err error
+	if volume.Spec.Driver == blk.DriverName {
+		err = archive.TarFileWriter(ctx, volume.Spec.Source, volume.Name+".img", tw, opts...)
+	} else {
+		err = archive.TarDirWriter(ctx, volume.Spec.Source, "", tw, opts...)
+	}
+	if err != nil {
+		return fmt.Errorf("could not export volume %s: %w", volume.Name, err)
+	}
+
+	return tw.Close()
+}
+
+// Import extracts the tarball read from r, which may be gzip-compressed, into
+// the provided volume.  Files which already exist in the volume are
+// overwritten.  For block-device volumes, the first file of the tarball is
+// expected to be a disk image which replaces that of the volume.  The caller is
+// responsible for persisting the updated volume.
+func Import(ctx context.Context, volume *volumev1alpha1.Volume, r io.Reader) error {
+	if len(volume.Spec.Source) == 0 {
+		return fmt.Errorf("volume %s has no source", volume.Name)
+	}
+
+	if volume.Status.State == volumev1alpha1.VolumeStateBound {
+		return fmt.Errorf("cannot import into volume in state %s", volume.Status.State)
+	}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("could not open gzip reader: %w", err)
+		}
+
+		defer gzr.Close()
+
+		r = gzr
+	} else {
+		r = br
+	}
+
+	if volume.Spec.Driver != blk.DriverName {
+		if err := archive.Untar(r, volume.Spec.Source); err != nil {
+			return fmt.Errorf("could not import into volume %s: %w", volume.Name, err)
+		}
+
+		return nil
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("could not import into volume %s: no disk image in tarball", volume.Name)
+		} else if err != nil {
+			return fmt.Errorf("could not import into volume %s: %w", volume.Name, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		size, err := blk.WriteImage(volume.Spec.Source, tr)
+		if err != nil {
+			return fmt.Errorf("could not import into volume %s: %w", volume.Name, err)
+		}
+
+		volume.Spec.Size = size
+
+		// The filesystem of the volume is kept should that of the new disk image
+		// be unrecognized.
+		if f, err := os.Open(volume.Spec.Source); err == nil {
+			if fs, err := blk.DetectFilesystem(f); err == nil {
+				volume.Spec.Filesystem = fs.String()
+				volume.Spec.ReadOnly = volume.Spec.ReadOnly || fs.IsReadOnly()
+			}
+
+			f.Close()
+		}
+
+		return nil
+	}
+}
+
+// Copy replaces the contents of the dst volume with those of the src volume.
+// Volumes which are shared with the machine can only be copied amongst each
+// other, and likewise for block-device volumes.  The caller is responsible for
+// persisting the updated dst volume.
+func Copy(ctx context.Context, src, dst *volumev1alpha1.Volume) error {
+	if dst.Status.State == volumev1alpha1.VolumeStateBound {
+		return fmt.Errorf("cannot copy into volume in state %s", dst.Status.State)
+	}
+
+	if (src.Spec.Driver == blk.DriverName) != (dst.Spec.Driver == blk.DriverName) {
+		return fmt.Errorf("cannot copy %s volume %s into %s volume %s", src.Spec.Driver, src.Name, dst.Spec.Driver, dst.Name)
+	}
+
+	if src.Spec.Driver == blk.DriverName {
+		if err := blk.CopyImage(src.Spec.Source, dst.Spec.Source); err != nil {
+			return fmt.Errorf("could not copy volume %s: %w", src.Name, err)
+		}
+
+		dst.Spec.Size = src.Spec.Size
+		dst.Spec.Filesystem = src.Spec.Filesystem
+
+		return nil
+	}
+
+	// Stream the contents of src into dst without an intermediate tarball.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	go func() {
+		pw.CloseWithError(Export(ctx, src, pw))
+	}()
+
+	if err := archive.Untar(pr, dst.Spec.Source); err != nil {
+		return fmt.Errorf("could not copy volume %s: %w", src.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package virtiofs implements a volume driver which shares host directories
+// with machines through virtio-fs.  Each volume of a machine is served by its
+// own virtiofsd process, which the VMM connects to over a vhost-user socket.
+package virtiofs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	goprocess "github.com/shirou/gopsutil/v3/process"
+
+	"kraftkit.sh/config"
+	kexec "kraftkit.sh/exec"
+	"kraftkit.sh/log"
+)
+
+// DriverName is the name of the virtio-fs volume driver.
+const DriverName = "virtiofs"
+
+// daemonPrefix is the prefix of the files of each virtiofsd process in the
+// state directory of a machine.
+const daemonPrefix = "virtiofsd"
+
+// binaryPaths are the locations at which distributions install virtiofsd
+// outside of the PATH.
+var binaryPaths = []string{
+	"/usr/libexec/virtiofsd",
+	"/usr/lib/qemu/virtiofsd",
+	"/usr/lib/virtiofsd",
+}
+
+// Binary returns the path to the virtiofsd executable, which is either set in
+// the configuration or looked up on the host.
+func Binary(ctx context.Context) (string, error) {
+	if bin := config.G[config.KraftKit](ctx).Virtiofsd; bin != "" {
+		return bin, nil
+	}
+
+	if bin, err := exec.LookPath("virtiofsd"); err == nil {
+		return bin, nil
+	}
+
+	for _, bin := range binaryPaths {
+		if _, err := os.Stat(bin); err == nil {
+			return bin, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find virtiofsd: install it or set its path with --virtiofsd")
+}
+
+// SocketPath returns the path of the vhost-user socket of the virtiofsd
+// process with the provided ID in the provided state directory.
+func SocketPath(stateDir, id string) string {
+	return filepath.Join(stateDir, daemonPrefix+"-"+id+".sock")
+}
+
+// pidFile returns the path of the pid file of the virtiofsd process with the
+// provided ID in the provided state directory.
+func pidFile(stateDir, id string) string {
+	return filepath.Join(stateDir, daemonPrefix+"-"+id+".pid")
+}
+
+// Spawn starts a virtiofsd process in the background which shares the
+// provided source directory over the vhost-user socket identified by the
+// provided ID in the provided state directory.  It returns once the socket is
+// ready to accept the VMM's connection.  The process exits by itself once the
+// VMM disconnects, or is otherwise ended with Stop.
+func Spawn(ctx context.Context, stateDir, id, source string, readOnly bool) error {
+	bin, err := Binary(ctx)
+	if err != nil {
+		return err
+	}
+
+	socket := SocketPath(stateDir, id)
+
+	// A stale socket would otherwise be mistaken for a ready one.
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove stale virtiofsd socket: %w", err)
+	}
+
+	logPath := filepath.Join(stateDir, daemonPrefix+"-"+id+".log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open virtiofsd log: %w", err)
+	}
+
+	defer logFile.Close()
+
+	args := []string{
+		"--socket-path=" + socket,
+		"--shared-dir=" + source,
+		"--cache=auto",
+	}
+	if readOnly {
+		args = append(args, "--readonly")
+	}
+
+	process, err := kexec.NewProcess(bin, args,
+		kexec.WithStdout(logFile),
+		kexec.WithStderr(logFile),
+		kexec.WithDetach(true),
+	)
+	if err != nil {
+		return fmt.Errorf("could not prepare virtiofsd: %w", err)
+	}
+
+	if err := process.Start(ctx); err != nil {
+		return fmt.Errorf("could not start virtiofsd: %w", err)
+	}
+
+	pid, err := process.Pid()
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+
+	// Reap the process once it exits, such that it does not linger when the
+	// caller is long-lived.
+	go func() {
+		if err := process.Wait(); err != nil {
+			log.G(ctx).WithField("volume", source).Debugf("virtiofsd exited: %v", err)
+		}
+
+		close(exited)
+	}()
+
+	if err := os.WriteFile(pidFile(stateDir, id), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		_ = process.Kill()
+		return fmt.Errorf("could not write virtiofsd pid file: %w", err)
+	}
+
+	if err := waitForSocket(socket, exited); err != nil {
+		_ = process.Kill()
+
+		// Propagate the contents of the log file as the reason.
+		if errLog, err2 := os.ReadFile(logPath); err2 == nil && len(errLog) > 0 {
+			err = errors.Join(errors.New(strings.TrimSpace(string(errLog))), err)
+		}
+
+		return fmt.Errorf("could not start virtiofsd for %s: %w", source, err)
+	}
+
+	return nil
+}
+
+// waitForSocket waits until the provided socket exists, unless the process
+// which is expected to create it exits beforehand or the timeout is exceeded.
+func waitForSocket(socket string, exited <-chan struct{}) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			return nil
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("virtiofsd exited prematurely")
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for virtiofsd socket")
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop ends all virtiofsd processes in the provided state directory and
+// removes their sockets and pid files.  It is to be called once the VMM has
+// exited, since the guest would otherwise lose access to its volumes.
+func Stop(ctx context.Context, stateDir string) error {
+	ids, err := daemons(stateDir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, id := range ids {
+		process, err := daemonProcess(ctx, stateDir, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not stop virtiofsd %s: %w", id, err))
+			continue
+		}
+
+		if process != nil {
+			if err := process.TerminateWithContext(ctx); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				errs = append(errs, fmt.Errorf("could not stop virtiofsd %s: %w", id, err))
+				continue
+			}
+		}
+
+		_ = os.Remove(SocketPath(stateDir, id))
+		_ = os.Remove(pidFile(stateDir, id))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check returns an error for each virtiofsd process in the provided state
+// directory which has exited without being stopped, in which case the guest
+// has lost access to the volume it served.  The process is not restarted
+// since QEMU does not reconnect to the vhost-user socket of a virtio-fs
+// device.  The pid file and socket of each such process are removed, such
+// that its exit is only reported once, whilst its log is kept for inspection.
+func Check(ctx context.Context, stateDir string) error {
+	ids, err := daemons(stateDir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, id := range ids {
+		if process, err := daemonProcess(ctx, stateDir, id); err != nil || process != nil {
+			continue
+		}
+
+		logPath := filepath.Join(stateDir, daemonPrefix+"-"+id+".log")
+		err := fmt.Errorf("virtiofsd %s has exited, see %s", id, logPath)
+
+		// Propagate the last line of the log file as the reason.
+		if errLog, err2 := os.ReadFile(logPath); err2 == nil && len(bytes.TrimSpace(errLog)) > 0 {
+			lines := strings.Split(strings.TrimSpace(string(errLog)), "\n")
+			err = fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+		}
+
+		errs = append(errs, err)
+
+		_ = os.Remove(SocketPath(stateDir, id))
+		_ = os.Remove(pidFile(stateDir, id))
+	}
+
+	return errors.Join(errs...)
+}
+
+// daemons returns the IDs of the virtiofsd processes in the provided state
+// directory which have been spawned and not yet stopped.
+func daemons(stateDir string) ([]string, error) {
+	pidFiles, err := filepath.Glob(filepath.Join(stateDir, daemonPrefix+"-*.pid"))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(pidFiles))
+	for _, file := range pidFiles {
+		ids = append(ids, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), daemonPrefix+"-"), ".pid"))
+	}
+
+	return ids, nil
+}
+
+// daemonProcess returns the virtiofsd process with the provided ID in the
+// provided state directory, or nil if it is no longer running.  Since the pid
+// recorded in its pid file may have been reused by an unrelated process once
+// it has exited, the process is only returned if it serves the socket of the
+// ID.
+func daemonProcess(ctx context.Context, stateDir, id string) (*goprocess.Process, error) {
+	b, err := os.ReadFile(pidFile(stateDir, id))
+	if err != nil {
+		return nil, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid file: %w", err)
+	}
+
+	if exists, err := goprocess.PidExistsWithContext(ctx, int32(pid)); err != nil || !exists {
+		return nil, nil
+	}
+
+	process, err := goprocess.NewProcessWithContext(ctx, int32(pid))
+	if err != nil {
+		return nil, nil
+	}
+
+	// The command line of a process which has exited but is yet to be reaped is
+	// empty, and that of a process which belongs to another user cannot be read.
+	cmdline, err := process.CmdlineSliceWithContext(ctx)
+	if err != nil {
+		return nil, nil
+	}
+
+	if !slices.Contains(cmdline, "--socket-path="+SocketPath(stateDir, id)) {
+		return nil, nil
+	}
+
+	return process, nil
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package virtiofs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	goprocess "github.com/shirou/gopsutil/v3/process"
+
+	"kraftkit.sh/config"
+)
+
+// withVirtiofsd returns a context whose configuration uses the provided shell
+// script as virtiofsd.
+func withVirtiofsd(t *testing.T, script string) context.Context {
+	t.Helper()
+
+	bin := filepath.Join(t.TempDir(), "virtiofsd")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfgm, err := config.NewConfigManager(&config.KraftKit{
+		Virtiofsd: bin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return config.WithConfigManager(context.Background(), cfgm)
+}
+
+// fakeVirtiofsd mimics virtiofsd by creating the socket and waiting to be
+// terminated, whilst retaining its command line.
+const fakeVirtiofsd = `
+for arg in "$@"; do
+	case "$arg" in --socket-path=*) touch "${arg#--socket-path=}";; esac
+done
+trap 'kill $!; exit 0' TERM
+sleep 60 &
+wait
+`
+
+func TestSpawnAndStop(t *testing.T) {
+	ctx := withVirtiofsd(t, fakeVirtiofsd)
+
+	stateDir := t.TempDir()
+
+	if err := Spawn(ctx, stateDir, "fs1", t.TempDir(), false); err != nil {
+		t.Fatal("Spawn:", err)
+	}
+
+	if _, err := os.Stat(SocketPath(stateDir, "fs1")); err != nil {
+		t.Fatal("expected socket to exist:", err)
+	}
+
+	b, err := os.ReadFile(pidFile(stateDir, "fs1"))
+	if err != nil {
+		t.Fatal("expected pid file to exist:", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop(ctx, stateDir); err != nil {
+		t.Fatal("Stop:", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		exists, err := goprocess.PidExists(int32(pid))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !exists {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected virtiofsd to be terminated")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := os.Stat(SocketPath(stateDir, "fs1")); !os.IsNotExist(err) {
+		t.Error("expected socket to be removed")
+	}
+}
+
+func TestSpawnFailure(t *testing.T) {
+	ctx := withVirtiofsd(t, `
+echo "shared directory does not exist" >&2
+exit 1
+`)
+
+	err := Spawn(ctx, t.TempDir(), "fs1", "/nonexistent", false)
+	if err == nil {
+		t.Fatal("expected Spawn to fail")
+	}
+
+	if !strings.Contains(err.Error(), "shared directory does not exist") {
+		t.Errorf("expected the log of virtiofsd in the error, got: %v", err)
+	}
+}
+
+func TestStopForeignProcess(t *testing.T) {
+	// The pid of an exited virtiofsd process may have been reused by another.
+	foreign := exec.Command("sleep", "60")
+	if err := foreign.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = foreign.Process.Kill()
+		_ = foreign.Wait()
+	}()
+
+	stateDir := t.TempDir()
+
+	if err := os.WriteFile(pidFile(stateDir, "fs1"), []byte(strconv.Itoa(foreign.Process.Pid)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop(context.Background(), stateDir); err != nil {
+		t.Fatal("Stop:", err)
+	}
+
+	if exists, err := goprocess.PidExists(int32(foreign.Process.Pid)); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Fatal("expected foreign process not to be terminated")
+	}
+
+	if _, err := os.Stat(pidFile(stateDir, "fs1")); !os.IsNotExist(err) {
+		t.Error("expected pid file to be removed")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := withVirtiofsd(t, fakeVirtiofsd)
+
+	stateDir := t.TempDir()
+
+	if err := Spawn(ctx, stateDir, "fs1", t.TempDir(), false); err != nil {
+		t.Fatal("Spawn:", err)
+	}
+
+	if err := Check(ctx, stateDir); err != nil {
+		t.Fatal("expected running virtiofsd to pass the check:", err)
+	}
+
+	process, err := daemonProcess(ctx, stateDir, "fs1")
+	if err != nil || process == nil {
+		t.Fatal("expected virtiofsd process:", err)
+	}
+
+	if err := process.Terminate(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := Check(ctx, stateDir)
+		if err != nil {
+			if !strings.Contains(err.Error(), "virtiofsd fs1 has exited") {
+				t.Errorf("expected the exit of virtiofsd to be reported, got: %v", err)
+			}
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected the exit of virtiofsd to be reported")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := Check(ctx, stateDir); err != nil {
+		t.Errorf("expected the exit of virtiofsd to be reported once, got: %v", err)
+	}
+}
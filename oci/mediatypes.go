@@ -4,14 +4,37 @@
 // You may not use this file except in compliance with the License.
 package oci
 
+import "kraftkit.sh/initrd"
+
 const (
 	MediaTypeLayer       = "application/vnd.unikraft.rootfs.diff"
 	MediaTypeImageKernel = "application/vnd.unikraft.image.v1"
 	MediaTypeInitrdCpio  = "application/vnd.unikraft.initrd.v1"
 	MediaTypeConfig      = "application/vnd.unikraft.config.v1"
 
+	// MediaTypeInitrdErofs and MediaTypeInitrdSquashfs are root filesystems
+	// which are attached to the unikernel as read-only block devices rather
+	// than extracted into its memory.  Like MediaTypeInitrdCpio, they are
+	// recorded by the AnnotationMediaType of the layer which holds the root
+	// filesystem.
+	MediaTypeInitrdErofs    = "application/vnd.unikraft.initrd.erofs.v1"
+	MediaTypeInitrdSquashfs = "application/vnd.unikraft.initrd.squashfs.v1"
+
 	MediaTypeLayerGzip       = MediaTypeLayer + "+gzip"
 	MediaTypeImageKernelGzip = MediaTypeImageKernel + "+gzip"
 	MediaTypeInitrdCpioGzip  = MediaTypeInitrdCpio + "+gzip"
 	MediaTypeConfigGzip      = MediaTypeConfig + "+gzip"
 )
+
+// initrdMediaType returns the media type of a root filesystem in the provided
+// format.
+func initrdMediaType(format initrd.Format) string {
+	switch format {
+	case initrd.FormatErofs:
+		return MediaTypeInitrdErofs
+	case initrd.FormatSquashfs:
+		return MediaTypeInitrdSquashfs
+	default:
+		return MediaTypeInitrdCpio
+	}
+}
@@ -220,12 +220,7 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 			WithField("dest", WellKnownInitrdPath).
 			Debug("including initrd")
 
-		layer, err := NewLayerFromFile(ctx,
-			ocispec.MediaTypeImageLayer,
-			popts.Initrd(),
-			WellKnownInitrdPath,
-			WithLayerAnnotation(AnnotationKernelInitrdPath, WellKnownInitrdPath),
-		)
+		layer, err := newInitrdLayer(ctx, popts.Initrd())
 		if err != nil {
 			return nil, fmt.Errorf("could build layer from file: %w", err)
 		}
@@ -1036,3 +1031,21 @@ func (ocipack *ociPackage) MarshalYAML() (interface{}, error) {
 		"platform":     ocipack.plat.Name(),
 	}, nil
 }
+
+// newInitrdLayer returns the layer which holds the provided root filesystem at
+// its well-known path.  The layer is a tar archive such that it is unpacked as
+// any other, whilst the format of the root filesystem is recorded by its media
+// type in the annotations of the layer.
+func newInitrdLayer(ctx context.Context, path string) (*Layer, error) {
+	// Archives which are not recognized are assumed to be CPIO archives as
+	// they always have been.
+	format, _ := initrd.DetectFormat(path)
+
+	return NewLayerFromFile(ctx,
+		ocispec.MediaTypeImageLayer,
+		path,
+		WellKnownInitrdPath,
+		WithLayerAnnotation(AnnotationKernelInitrdPath, WellKnownInitrdPath),
+		WithLayerAnnotation(AnnotationMediaType, initrdMediaType(format)),
+	)
+}
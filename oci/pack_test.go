@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/initrd"
+)
+
+func TestNewInitrdLayer(t *testing.T) {
+	ctx := context.Background()
+
+	for format, mediaType := range map[initrd.Format]string{
+		initrd.FormatCpio:     MediaTypeInitrdCpio,
+		initrd.FormatErofs:    MediaTypeInitrdErofs,
+		initrd.FormatSquashfs: MediaTypeInitrdSquashfs,
+	} {
+		t.Run(format.String(), func(t *testing.T) {
+			ird, err := initrd.NewFromDirectory(ctx, "../initrd/testdata/rootfs",
+				initrd.WithOutput(filepath.Join(t.TempDir(), initrd.ArchFileName(format, "x86_64"))),
+				initrd.WithOutputFormat(format),
+			)
+			if err != nil {
+				t.Fatal("NewFromDirectory:", err)
+			}
+
+			path, err := ird.Build(ctx)
+			if err != nil {
+				t.Fatal("Build:", err)
+			}
+
+			layer, err := newInitrdLayer(ctx, path)
+			if err != nil {
+				t.Fatal("newInitrdLayer:", err)
+			}
+
+			defer os.Remove(layer.tmp)
+
+			desc := layer.blob.desc
+
+			// The layer is unpacked as any other.
+			if desc.MediaType != ocispec.MediaTypeImageLayer {
+				t.Errorf("expected layer media type %s, got %s", ocispec.MediaTypeImageLayer, desc.MediaType)
+			}
+
+			if got := desc.Annotations[AnnotationMediaType]; got != mediaType {
+				t.Errorf("expected annotation %s to be %s, got %s", AnnotationMediaType, mediaType, got)
+			}
+
+			if got := desc.Annotations[AnnotationKernelInitrdPath]; got != WellKnownInitrdPath {
+				t.Errorf("expected annotation %s to be %s, got %s", AnnotationKernelInitrdPath, WellKnownInitrdPath, got)
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ package store
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -16,36 +18,47 @@ import (
 	zip "api.zip"
 	"github.com/dgraph-io/badger/v3"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage"
 	"kraftkit.sh/internal/retrytimeout"
 )
 
+const (
+	// internalKeyPrefix is the prefix of keys which are used for the store's
+	// own book-keeping and which never hold objects.
+	internalKeyPrefix = "\x00"
+
+	// revisionKey holds the resource version of the most recent write to the
+	// store.
+	revisionKey = internalKeyPrefix + "revision"
+)
+
+// embeddedVersioner stores the resource version of an object in its metadata
+// as a decimal string, identically to the API server's etcd storage backend.
 type embeddedVersioner struct{}
 
 // UpdateObject implements storage.Versioner
 func (version *embeddedVersioner) UpdateObject(obj runtime.Object, resourceVersion uint64) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.UpdateObject")
+	return storage.APIObjectVersioner{}.UpdateObject(obj, resourceVersion)
 }
 
 // UpdateList implements storage.Versioner
 func (version *embeddedVersioner) UpdateList(obj runtime.Object, resourceVersion uint64, continueValue string, remainingItemCount *int64) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.UpdateList")
+	return storage.APIObjectVersioner{}.UpdateList(obj, resourceVersion, continueValue, remainingItemCount)
 }
 
 // PrepareObjectForStorage implements storage.Versioner
 func (version *embeddedVersioner) PrepareObjectForStorage(obj runtime.Object) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.PrepareObjectForStorage")
+	return storage.APIObjectVersioner{}.PrepareObjectForStorage(obj)
 }
 
 // ObjectResourceVersion implements storage.Versioner
 func (version *embeddedVersioner) ObjectResourceVersion(obj runtime.Object) (uint64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.ObjectResourceVersion")
+	return storage.APIObjectVersioner{}.ObjectResourceVersion(obj)
 }
 
 // ParseResourceVersion implements storage.Versioner
 func (version *embeddedVersioner) ParseResourceVersion(resourceVersion string) (uint64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.ParseResourceVersion")
+	return storage.APIObjectVersioner{}.ParseResourceVersion(resourceVersion)
 }
 
 // embedded is KraftKit's default internal storage mechanism which is based on
@@ -91,30 +104,41 @@ func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 
 // open the embedded key-value store
 func (store *embedded[_, _]) open() error {
+	db, err := store.openWithOptions(store.bopts)
+	if err != nil {
+		return err
+	}
+
+	store.db = db
+
+	return nil
+}
+
+// openWithOptions opens the embedded key-value store with the provided options
+// and returns the handle without retaining it.
+func (store *embedded[_, _]) openWithOptions(bopts badger.Options) (*badger.DB, error) {
 	var db *badger.DB
 
-	db, err := badger.Open(store.bopts)
+	db, err := badger.Open(bopts)
 	if err != nil && strings.Contains(err.Error(), "permission denied") {
-		return fmt.Errorf("could not open machine store: %v", err)
+		return nil, fmt.Errorf("could not open machine store: %v", err)
 	} else if err != nil {
 		// Perform a continuous re-try to check for the dir lock on the badger
 		// database which may become free during a specified timeout period
 		if err := retrytimeout.RetryTimeout(store.timeout, func() error {
 			var err error
-			db, err = badger.Open(store.bopts)
+			db, err = badger.Open(bopts)
 			if err != nil {
 				return fmt.Errorf("could not open machine store: %v", err)
 			}
 
 			return nil
 		}); err != nil {
-			return fmt.Errorf("could not open machine store: %v", err)
+			return nil, fmt.Errorf("could not open machine store: %v", err)
 		}
 	}
 
-	store.db = db
-
-	return nil
+	return db, nil
 }
 
 // close the embedded key-value store
@@ -122,6 +146,120 @@ func (store *embedded[_, _]) close() error {
 	return store.db.Close()
 }
 
+// isInternalKey returns whether the key is used for the store's own
+// book-keeping rather than to hold an object.
+func isInternalKey(key []byte) bool {
+	return bytes.HasPrefix(key, []byte(internalKeyPrefix))
+}
+
+// currentResourceVersion returns the resource version of the most recent write
+// to the store.
+func currentResourceVersion(txn *badger.Txn) (uint64, error) {
+	item, err := txn.Get([]byte(revisionKey))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var rv uint64
+	if err := item.Value(func(val []byte) error {
+		if len(val) != 8 {
+			return fmt.Errorf("malformed store revision")
+		}
+
+		rv = binary.BigEndian.Uint64(val)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return rv, nil
+}
+
+// nextResourceVersion increments and returns the resource version of the
+// store.  Since the revision is read and written within the same transaction,
+// concurrent writers conflict and only one of them succeeds in committing.
+func nextResourceVersion(txn *badger.Txn) (uint64, error) {
+	rv, err := currentResourceVersion(txn)
+	if err != nil {
+		return 0, err
+	}
+
+	rv++
+
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, rv)
+
+	if err := txn.Set([]byte(revisionKey), val); err != nil {
+		return 0, err
+	}
+
+	return rv, nil
+}
+
+// decode the value of the provided item into the object.
+func decode(item *badger.Item, obj runtime.Object) error {
+	return item.Value(func(val []byte) error {
+		return gob.NewDecoder(bytes.NewReader(val)).Decode(obj)
+	})
+}
+
+// put encodes and saves the object at the given key with a new resource
+// version, which is also set on the object.
+func (store *embedded[_, _]) put(txn *badger.Txn, key string, obj runtime.Object, ttl uint64) error {
+	rv, err := nextResourceVersion(txn)
+	if err != nil {
+		return fmt.Errorf("could not determine resource version for %s: %v", key, err)
+	}
+
+	if err := store.versioner.UpdateObject(obj, rv); err != nil {
+		return fmt.Errorf("could not set resource version for %s: %v", key, err)
+	}
+
+	b := bytes.Buffer{}
+	if err := gob.NewEncoder(&b).Encode(obj); err != nil {
+		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	}
+
+	entry := badger.NewEntry([]byte(key), b.Bytes())
+	if ttl > 0 {
+		entry = entry.WithTTL(time.Duration(ttl) * time.Second)
+	}
+
+	if err := txn.SetEntry(entry); err != nil {
+		return fmt.Errorf("could not save machine driver to store for %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// update performs the provided function within a read-write transaction and
+// retries it with an increasing backoff if it conflicted with a concurrent
+// transaction.  The conflict is returned if the transaction still conflicts
+// once the timeout of the store has elapsed.
+func (store *embedded[_, _]) update(fn func(txn *badger.Txn) error) error {
+	deadline := time.Now().Add(store.timeout)
+	backoff := time.Millisecond
+
+	for {
+		err := store.db.Update(fn)
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("transaction kept conflicting for %s: %w", store.timeout, err)
+		}
+
+		time.Sleep(backoff)
+
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
+
 // Versioner implements storage.Interface
 func (store *embedded[_, _]) Versioner() storage.Versioner {
 	return store.versioner
@@ -133,48 +271,96 @@ func (store *embedded[_, _]) RequestWatchProgress(ctx context.Context) error {
 }
 
 // Create implements storage.Interface
-func (store *embedded[_, _]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
+//
+// Objects which already exist at the given key are overwritten only if the
+// provided object either carries no resource version or the resource version
+// of the stored object.  Otherwise, the provided object is stale and a
+// conflict error is returned.
+func (store *embedded[Spec, Status]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
 	if err := store.open(); err != nil {
 		return err
 	}
 
 	defer store.close()
 
-	b := bytes.Buffer{}
-	if err := gob.NewEncoder(&b).Encode(out); err != nil {
-		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	expected, err := store.versioner.ObjectResourceVersion(out)
+	if err != nil {
+		return fmt.Errorf("could not read resource version for %s: %v", key, err)
 	}
 
-	txn := store.db.NewTransaction(true)
-	if err := txn.SetEntry(badger.NewEntry([]byte(key), b.Bytes())); err != nil {
-		return fmt.Errorf("could not save machine driver to store for %s: %v", key, err)
-	}
+	return store.update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not access store for %s: %v", key, err)
+		}
+
+		if err == nil && expected > 0 {
+			var existing zip.Object[Spec, Status]
+			if err := decode(item, &existing); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+
+			current, err := store.versioner.ObjectResourceVersion(&existing)
+			if err != nil {
+				return fmt.Errorf("could not read resource version for %s: %v", key, err)
+			}
 
-	return txn.Commit()
+			if current != expected {
+				return storage.NewResourceVersionConflictsError(key, int64(expected))
+			}
+		}
+
+		return store.put(txn, key, out, ttl)
+	})
 }
 
 // Delete implements storage.Interface
-func (store *embedded[_, _]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
+func (store *embedded[Spec, Status]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
 	if err := store.open(); err != nil {
 		return err
 	}
 
 	defer store.close()
 
-	txn := store.db.NewTransaction(true)
+	return store.update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not access store for %s: %v", key, err)
+		}
 
-	if err := txn.Delete([]byte(key)); err != nil {
-		return err
-	}
+		var existing zip.Object[Spec, Status]
+		if err := decode(item, &existing); err != nil {
+			return fmt.Errorf("could not decode from store for %s: %v", key, err)
+		}
 
-	// TODO(nderjung): preconditions, validateDelete, cachedExistingObject
+		if preconditions != nil {
+			if err := preconditions.Check(key, &existing); err != nil {
+				return err
+			}
+		}
 
-	return txn.Commit()
-}
+		if validateDeletion != nil {
+			if err := validateDeletion(ctx, &existing); err != nil {
+				return err
+			}
+		}
+
+		if out != nil {
+			if err := decode(item, out); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+		}
+
+		// Bump the revision such that the deletion is ordered with respect to
+		// other writes.
+		if _, err := nextResourceVersion(txn); err != nil {
+			return err
+		}
 
-// Watch implements storage.Interface
-func (store *embedded[_, _]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.Watch")
+		return txn.Delete([]byte(key))
+	})
 }
 
 // Get implements storage.Interface
@@ -187,7 +373,9 @@ func (store *embedded[_, _]) Get(ctx context.Context, key string, opts storage.G
 
 	if err := store.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
-		if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) && opts.IgnoreNotFound {
+			return nil
+		} else if err != nil {
 			return fmt.Errorf("could not access store for %s: %v", key, err)
 		}
 
@@ -230,6 +418,10 @@ func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, op
 		defer itr.Close()
 
 		for itr.Rewind(); itr.Valid(); itr.Next() {
+			if isInternalKey(itr.Item().Key()) {
+				continue
+			}
+
 			val, err := itr.Item().ValueCopy(nil)
 			if err != nil {
 				return err
@@ -247,6 +439,16 @@ func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, op
 			list.Items = append(list.Items, obj)
 		}
 
+		rv, err := currentResourceVersion(txn)
+		if err != nil {
+			return err
+		}
+
+		// Older stores may not yet have recorded any resource version.
+		if rv > 0 {
+			return store.versioner.UpdateList(list, rv, "", nil)
+		}
+
 		return nil
 	}); err != nil {
 		return fmt.Errorf("could not list from store at %s: %v", key, err)
@@ -256,11 +458,183 @@ func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, op
 }
 
 // GuaranteedUpdate implements storage.Interface
-func (store *embedded[_, _]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.GuaranteedUpdate")
+func (store *embedded[Spec, Status]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
+	if err := store.open(); err != nil {
+		return err
+	}
+
+	defer store.close()
+
+	return store.update(func(txn *badger.Txn) error {
+		var existing zip.Object[Spec, Status]
+		var rv uint64
+
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			if !ignoreNotFound {
+				return storage.NewKeyNotFoundError(key, 0)
+			}
+		} else if err != nil {
+			return fmt.Errorf("could not access store for %s: %v", key, err)
+		} else {
+			if err := decode(item, &existing); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+
+			rv, err = store.versioner.ObjectResourceVersion(&existing)
+			if err != nil {
+				return fmt.Errorf("could not read resource version for %s: %v", key, err)
+			}
+		}
+
+		if preconditions != nil {
+			if err := preconditions.Check(key, &existing); err != nil {
+				return err
+			}
+		}
+
+		before := bytes.Buffer{}
+		if err := gob.NewEncoder(&before).Encode(&existing); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		updated, ttl, err := tryUpdate(existing.DeepCopyObject(), storage.ResponseMeta{
+			ResourceVersion: rv,
+		})
+		if err != nil {
+			return err
+		}
+
+		after := bytes.Buffer{}
+		if err := gob.NewEncoder(&after).Encode(updated); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		// Avoid writing, and therefore bumping the resource version, if nothing
+		// has changed.
+		if item != nil && bytes.Equal(before.Bytes(), after.Bytes()) {
+			return decode(item, destination)
+		}
+
+		var expiry uint64
+		if ttl != nil {
+			expiry = *ttl
+		}
+
+		if err := store.put(txn, key, updated, expiry); err != nil {
+			return err
+		}
+
+		b := bytes.Buffer{}
+		if err := gob.NewEncoder(&b).Encode(updated); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		return gob.NewDecoder(&b).Decode(destination)
+	})
 }
 
 // Count implements storage.Interface
 func (store *embedded[_, _]) Count(key string) (int64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.Count")
+	if err := store.open(); err != nil {
+		return 0, err
+	}
+
+	defer store.close()
+
+	var count int64
+
+	if err := store.db.View(func(txn *badger.Txn) error {
+		itr := txn.NewIterator(badger.IteratorOptions{
+			Prefix: []byte(key),
+		})
+
+		defer itr.Close()
+
+		for itr.Rewind(); itr.Valid(); itr.Next() {
+			if !isInternalKey(itr.Item().Key()) {
+				count++
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("could not count from store at %s: %v", key, err)
+	}
+
+	return count, nil
+}
+
+// createAll implements batcher
+func (store *embedded[_, _]) createAll(objs map[string]runtime.Object) error {
+	if err := store.open(); err != nil {
+		return err
+	}
+
+	defer store.close()
+
+	return store.update(func(txn *badger.Txn) error {
+		for key, obj := range objs {
+			if err := store.put(txn, key, obj, 0); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteAll implements batcher
+func (store *embedded[_, _]) deleteAll(keys []string) error {
+	if err := store.open(); err != nil {
+		return err
+	}
+
+	defer store.close()
+
+	return store.update(func(txn *badger.Txn) error {
+		if _, err := nextResourceVersion(txn); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := txn.Delete([]byte(key)); err != nil {
+				return fmt.Errorf("could not delete %s from store: %v", key, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// keys implements keyLister
+func (store *embedded[_, _]) keys(prefix string) ([]string, error) {
+	if err := store.open(); err != nil {
+		return nil, err
+	}
+
+	defer store.close()
+
+	var keys []string
+
+	if err := store.db.View(func(txn *badger.Txn) error {
+		itr := txn.NewIterator(badger.IteratorOptions{
+			Prefix:         []byte(prefix),
+			PrefetchValues: false,
+		})
+
+		defer itr.Close()
+
+		for itr.Rewind(); itr.Valid(); itr.Next() {
+			if !isInternalKey(itr.Item().Key()) {
+				keys = append(keys, string(itr.Item().KeyCopy(nil)))
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not list keys from store at %s: %v", prefix, err)
+	}
+
+	return keys, nil
 }
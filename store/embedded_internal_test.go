@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+func TestUpdateConflictTimeout(t *testing.T) {
+	s, err := NewEmbeddedStore[struct{}, struct{}](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	store := s.(*embedded[struct{}, struct{}])
+	store.timeout = 50 * time.Millisecond
+
+	if err := store.open(); err != nil {
+		t.Fatal("open:", err)
+	}
+
+	defer store.db.Close()
+
+	attempts := 0
+	start := time.Now()
+
+	err = store.update(func(txn *badger.Txn) error {
+		attempts++
+		return badger.ErrConflict
+	})
+	if !errors.Is(err, badger.ErrConflict) {
+		t.Fatalf("Expected conflict error, got %v", err)
+	}
+
+	if attempts < 2 {
+		t.Errorf("Expected the transaction to be retried, got %d attempts", attempts)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected retries to stop after the timeout, took %s", elapsed)
+	}
+
+	// Transactions which resolve their conflict are not reported.
+	attempts = 0
+	if err := store.update(func(txn *badger.Txn) error {
+		attempts++
+		if attempts < 3 {
+			return badger.ErrConflict
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Expected update to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/store"
+)
+
+type testSpec struct {
+	Value string
+}
+
+type testStatus struct {
+	Count int
+}
+
+type testObject = zip.Object[testSpec, testStatus]
+
+func newTestObject(name, value string) *testObject {
+	return &testObject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: testSpec{
+			Value: value,
+		},
+	}
+}
+
+func TestCreateResourceVersion(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := store.NewEmbeddedStore[testSpec, testStatus](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	obj := newTestObject("a", "one")
+	if err := s.Create(ctx, "a", obj, obj, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	first, err := s.Versioner().ObjectResourceVersion(obj)
+	if err != nil {
+		t.Fatal("ObjectResourceVersion:", err)
+	}
+	if first == 0 {
+		t.Fatal("Expected a resource version to be assigned")
+	}
+
+	// A concurrent writer which read the same version updates the object.
+	stale := obj.DeepCopyObject().(*testObject)
+
+	obj.Spec.Value = "two"
+	if err := s.Create(ctx, "a", obj, obj, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	second, err := s.Versioner().ObjectResourceVersion(obj)
+	if err != nil {
+		t.Fatal("ObjectResourceVersion:", err)
+	}
+	if second <= first {
+		t.Errorf("Expected resource version to increase from %d, got %d", first, second)
+	}
+
+	// The stale object must be rejected.
+	stale.Spec.Value = "three"
+	err = s.Create(ctx, "a", stale, stale, 0)
+	if !storage.IsConflict(err) {
+		t.Fatalf("Expected conflict error, got %v", err)
+	}
+
+	var got testObject
+	if err := s.Get(ctx, "a", storage.GetOptions{}, &got); err != nil {
+		t.Fatal("Get:", err)
+	}
+	if got.Spec.Value != "two" {
+		t.Errorf("Expected stored value 'two', got '%s'", got.Spec.Value)
+	}
+
+	// Objects without a resource version are written unconditionally.
+	fresh := newTestObject("a", "four")
+	if err := s.Create(ctx, "a", fresh, fresh, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+}
+
+func TestGuaranteedUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := store.NewEmbeddedStore[testSpec, testStatus](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	obj := newTestObject("a", "one")
+	if err := s.Create(ctx, "a", obj, obj, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		var dest testObject
+		if err := s.GuaranteedUpdate(ctx, "a", &dest, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+			current := input.(*testObject)
+			current.Status.Count++
+			return current, nil, nil
+		}, nil); err != nil {
+			t.Fatal("GuaranteedUpdate:", err)
+		}
+	}
+
+	var got testObject
+	if err := s.Get(ctx, "a", storage.GetOptions{}, &got); err != nil {
+		t.Fatal("Get:", err)
+	}
+	if got.Status.Count != 3 {
+		t.Errorf("Expected count 3, got %d", got.Status.Count)
+	}
+
+	var dest testObject
+	err = s.GuaranteedUpdate(ctx, "missing", &dest, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		return input, nil, nil
+	}, nil)
+	if !storage.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	count, err := s.Count("")
+	if err != nil {
+		t.Fatal("Count:", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 object, got %d", count)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := store.NewEmbeddedStore[testSpec, testStatus](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	existing := newTestObject("a", "one")
+	if err := s.Create(ctx, "a", existing, existing, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	w, err := s.Watch(ctx, "", storage.ListOptions{Recursive: true})
+	if err != nil {
+		t.Fatal("Watch:", err)
+	}
+
+	defer w.Stop()
+
+	expect := func(typ watch.EventType, value string) {
+		t.Helper()
+
+		select {
+		case event := <-w.ResultChan():
+			if event.Type != typ {
+				t.Fatalf("Expected %s event, got %s", typ, event.Type)
+			}
+			if got := event.Object.(*testObject).Spec.Value; got != value {
+				t.Fatalf("Expected value '%s', got '%s'", value, got)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for %s event", typ)
+		}
+	}
+
+	expect(watch.Added, "one")
+
+	added := newTestObject("b", "two")
+	if err := s.Create(ctx, "b", added, added, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	expect(watch.Added, "two")
+
+	existing.Spec.Value = "three"
+	if err := s.Create(ctx, "a", existing, existing, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	expect(watch.Modified, "three")
+
+	if err := s.Delete(ctx, "b", nil, nil, nil, nil); err != nil {
+		t.Fatal("Delete:", err)
+	}
+
+	expect(watch.Deleted, "two")
+}
+
+func TestUpdateByUID(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := store.NewEmbeddedStore[testSpec, testStatus](t.TempDir())
+	if err != nil {
+		t.Fatal("NewEmbeddedStore:", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		obj := newTestObject(name, name)
+		obj.UID = types.UID("uid-" + name)
+		if err := s.Create(ctx, name, obj, obj, 0); err != nil {
+			t.Fatal("Create:", err)
+		}
+	}
+
+	updated, err := store.Update[testSpec, testStatus](ctx, s, "uid-b", func(obj *testObject) error {
+		obj.Status.Count++
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Update:", err)
+	}
+	if updated.Status.Count != 1 {
+		t.Errorf("Expected count 1, got %d", updated.Status.Count)
+	}
+
+	var got testObject
+	if err := s.Get(ctx, "b", storage.GetOptions{}, &got); err != nil {
+		t.Fatal("Get:", err)
+	}
+	if got.Status.Count != 1 {
+		t.Errorf("Expected persisted count 1, got %d", got.Status.Count)
+	}
+
+	if _, err := store.Update[testSpec, testStatus](ctx, s, "uid-c", func(*testObject) error { return nil }); err == nil {
+		t.Error("Expected error updating unknown UID")
+	}
+}
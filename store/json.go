@@ -0,0 +1,470 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/internal/lockedfile"
+)
+
+// jsonDocument is the on-disk representation of the JSON store.
+type jsonDocument struct {
+	// Revision is the resource version of the most recent write to the store.
+	Revision uint64 `json:"revision"`
+
+	// Objects are the entries of the store by their key.
+	Objects map[string]jsonEntry `json:"objects"`
+}
+
+// jsonEntry is a single object held in the JSON store.
+type jsonEntry struct {
+	// Object is the human-readable representation of the object.  It is
+	// provided for inspection only and is not read back by the store.
+	Object json.RawMessage `json:"object"`
+
+	// Data is the serialized object.  Objects may hold driver-specific
+	// configuration of interface types which cannot be restored from their
+	// JSON representation, such that the gob encoding remains authoritative.
+	Data []byte `json:"data"`
+
+	// Expires is the time after which the entry is no longer valid.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// expired returns whether the entry is no longer valid.
+func (entry jsonEntry) expired() bool {
+	return entry.Expires != nil && time.Now().After(*entry.Expires)
+}
+
+// decode the entry into the provided object.
+func (entry jsonEntry) decode(obj runtime.Object) error {
+	return gob.NewDecoder(bytes.NewReader(entry.Data)).Decode(obj)
+}
+
+// jsonStore is a store which persists all objects in a single JSON file.  All
+// access to the file is guarded by a file lock such that the store can be
+// shared by several concurrent processes.
+type jsonStore[Spec, Status any] struct {
+	path      string
+	versioner *embeddedVersioner
+}
+
+// NewJSONStore returns a api.zip.Store-compatible storage interface which
+// persists objects in a human-readable JSON file at the provided path.
+func NewJSONStore[Spec, Status any](path string) (zip.Store, error) {
+	if len(path) == 0 {
+		dir, err := os.MkdirTemp("", "")
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(dir, "store.json")
+	}
+
+	return &jsonStore[Spec, Status]{
+		path:      path,
+		versioner: &embeddedVersioner{},
+	}, nil
+}
+
+// parse the contents of the store's file, dropping any expired entries.
+func (store *jsonStore[_, _]) parse(b []byte) (*jsonDocument, error) {
+	doc := jsonDocument{
+		Objects: map[string]jsonEntry{},
+	}
+
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("could not parse store at %s: %v", store.path, err)
+		}
+
+		if doc.Objects == nil {
+			doc.Objects = map[string]jsonEntry{}
+		}
+	}
+
+	for key, entry := range doc.Objects {
+		if entry.expired() {
+			delete(doc.Objects, key)
+		}
+	}
+
+	return &doc, nil
+}
+
+// read the store's file with a shared lock held.
+func (store *jsonStore[_, _]) read() (*jsonDocument, error) {
+	b, err := lockedfile.Read(store.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not open store at %s: %v", store.path, err)
+	}
+
+	return store.parse(b)
+}
+
+// transform reads the store's file, applies the provided function and writes
+// the result back, all with an exclusive lock held.  The file is left
+// untouched if the function returns an error.
+func (store *jsonStore[_, _]) transform(fn func(*jsonDocument) error) error {
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return fmt.Errorf("could not prepare store directory: %v", err)
+	}
+
+	return lockedfile.Transform(store.path, func(b []byte) ([]byte, error) {
+		doc, err := store.parse(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fn(doc); err != nil {
+			return nil, err
+		}
+
+		return json.MarshalIndent(doc, "", "  ")
+	})
+}
+
+// put encodes and saves the object at the given key with a new resource
+// version, which is also set on the object.
+func (store *jsonStore[_, _]) put(doc *jsonDocument, key string, obj runtime.Object, ttl uint64) error {
+	doc.Revision++
+
+	if err := store.versioner.UpdateObject(obj, doc.Revision); err != nil {
+		return fmt.Errorf("could not set resource version for %s: %v", key, err)
+	}
+
+	data := bytes.Buffer{}
+	if err := gob.NewEncoder(&data).Encode(obj); err != nil {
+		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	}
+
+	readable, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	}
+
+	entry := jsonEntry{
+		Object: readable,
+		Data:   data.Bytes(),
+	}
+
+	if ttl > 0 {
+		expires := time.Now().Add(time.Duration(ttl) * time.Second)
+		entry.Expires = &expires
+	}
+
+	doc.Objects[key] = entry
+
+	return nil
+}
+
+// createAll implements batcher
+func (store *jsonStore[_, _]) createAll(objs map[string]runtime.Object) error {
+	return store.transform(func(doc *jsonDocument) error {
+		for key, obj := range objs {
+			if err := store.put(doc, key, obj, 0); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteAll implements batcher
+func (store *jsonStore[_, _]) deleteAll(keys []string) error {
+	return store.transform(func(doc *jsonDocument) error {
+		doc.Revision++
+
+		for _, key := range keys {
+			delete(doc.Objects, key)
+		}
+
+		return nil
+	})
+}
+
+// keys implements keyLister
+func (store *jsonStore[_, _]) keys(prefix string) ([]string, error) {
+	doc, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range doc.Objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Versioner implements storage.Interface
+func (store *jsonStore[_, _]) Versioner() storage.Versioner {
+	return store.versioner
+}
+
+// RequestWatchProgress implements storage.Interface
+func (store *jsonStore[_, _]) RequestWatchProgress(ctx context.Context) error {
+	return fmt.Errorf("not implemented: zip.store.RequestWatchProgress")
+}
+
+// Create implements storage.Interface
+//
+// Objects which already exist at the given key are overwritten only if the
+// provided object either carries no resource version or the resource version
+// of the stored object.  Otherwise, the provided object is stale and a
+// conflict error is returned.
+func (store *jsonStore[Spec, Status]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
+	expected, err := store.versioner.ObjectResourceVersion(out)
+	if err != nil {
+		return fmt.Errorf("could not read resource version for %s: %v", key, err)
+	}
+
+	return store.transform(func(doc *jsonDocument) error {
+		if entry, ok := doc.Objects[key]; ok && expected > 0 {
+			var existing zip.Object[Spec, Status]
+			if err := entry.decode(&existing); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+
+			current, err := store.versioner.ObjectResourceVersion(&existing)
+			if err != nil {
+				return fmt.Errorf("could not read resource version for %s: %v", key, err)
+			}
+
+			if current != expected {
+				return storage.NewResourceVersionConflictsError(key, int64(expected))
+			}
+		}
+
+		return store.put(doc, key, out, ttl)
+	})
+}
+
+// Delete implements storage.Interface
+func (store *jsonStore[Spec, Status]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
+	return store.transform(func(doc *jsonDocument) error {
+		entry, ok := doc.Objects[key]
+		if !ok {
+			return nil
+		}
+
+		var existing zip.Object[Spec, Status]
+		if err := entry.decode(&existing); err != nil {
+			return fmt.Errorf("could not decode from store for %s: %v", key, err)
+		}
+
+		if preconditions != nil {
+			if err := preconditions.Check(key, &existing); err != nil {
+				return err
+			}
+		}
+
+		if validateDeletion != nil {
+			if err := validateDeletion(ctx, &existing); err != nil {
+				return err
+			}
+		}
+
+		if out != nil {
+			if err := entry.decode(out); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+		}
+
+		doc.Revision++
+		delete(doc.Objects, key)
+
+		return nil
+	})
+}
+
+// Watch implements storage.Interface
+//
+// Changes are detected by observing the store's file for modifications and
+// comparing the stored objects against the previously observed set.
+func (store *jsonStore[Spec, Status]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return watchDir(ctx, filepath.Dir(store.path), func(name string) bool {
+		return filepath.Clean(name) == filepath.Clean(store.path)
+	}, store.versioner, opts, func() (map[string]watchedObject[Spec, Status], error) {
+		doc, err := store.read()
+		if err != nil {
+			return nil, err
+		}
+
+		objects := map[string]watchedObject[Spec, Status]{}
+
+		for k, entry := range doc.Objects {
+			if (opts.Recursive && !strings.HasPrefix(k, key)) || (!opts.Recursive && k != key) {
+				continue
+			}
+
+			var obj zip.Object[Spec, Status]
+			if err := entry.decode(&obj); err != nil {
+				return nil, fmt.Errorf("could not decode from store for %s: %v", k, err)
+			}
+
+			objects[k] = watchedObject[Spec, Status]{
+				raw:    entry.Data,
+				object: &obj,
+			}
+		}
+
+		return objects, nil
+	})
+}
+
+// Get implements storage.Interface
+func (store *jsonStore[_, _]) Get(ctx context.Context, key string, opts storage.GetOptions, objPtr runtime.Object) error {
+	doc, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	entry, ok := doc.Objects[key]
+	if !ok {
+		if opts.IgnoreNotFound {
+			return nil
+		}
+
+		return fmt.Errorf("could not read from store for %s: %v", key, storage.NewKeyNotFoundError(key, 0))
+	}
+
+	if err := entry.decode(objPtr); err != nil {
+		return fmt.Errorf("could not read from store for %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// GetList implements storage.Interface
+func (store *jsonStore[Spec, Status]) GetList(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
+	// Re-cast the list
+	list := listObj.(*zip.ObjectList[Spec, Status])
+
+	// Truncate the list of results as we are about to re-populate
+	list.Items = make([]zip.Object[Spec, Status], 0)
+
+	doc, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(doc.Objects))
+	for k := range doc.Objects {
+		if strings.HasPrefix(k, key) {
+			keys = append(keys, k)
+		}
+	}
+
+	// Match the lexicographical ordering of the embedded store.
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var obj zip.Object[Spec, Status]
+		if err := doc.Objects[k].decode(&obj); err != nil {
+			return fmt.Errorf("could not list from store at %s: %v", key, err)
+		}
+
+		list.Items = append(list.Items, obj)
+	}
+
+	if doc.Revision > 0 {
+		return store.versioner.UpdateList(list, doc.Revision, "", nil)
+	}
+
+	return nil
+}
+
+// GuaranteedUpdate implements storage.Interface
+func (store *jsonStore[Spec, Status]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
+	return store.transform(func(doc *jsonDocument) error {
+		var existing zip.Object[Spec, Status]
+		var rv uint64
+
+		entry, ok := doc.Objects[key]
+		if !ok {
+			if !ignoreNotFound {
+				return storage.NewKeyNotFoundError(key, 0)
+			}
+		} else {
+			if err := entry.decode(&existing); err != nil {
+				return fmt.Errorf("could not decode from store for %s: %v", key, err)
+			}
+
+			var err error
+			rv, err = store.versioner.ObjectResourceVersion(&existing)
+			if err != nil {
+				return fmt.Errorf("could not read resource version for %s: %v", key, err)
+			}
+		}
+
+		if preconditions != nil {
+			if err := preconditions.Check(key, &existing); err != nil {
+				return err
+			}
+		}
+
+		updated, ttl, err := tryUpdate(existing.DeepCopyObject(), storage.ResponseMeta{
+			ResourceVersion: rv,
+		})
+		if err != nil {
+			return err
+		}
+
+		after := bytes.Buffer{}
+		if err := gob.NewEncoder(&after).Encode(updated); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		// Avoid bumping the resource version if nothing has changed.
+		if ok && bytes.Equal(entry.Data, after.Bytes()) {
+			return entry.decode(destination)
+		}
+
+		var expiry uint64
+		if ttl != nil {
+			expiry = *ttl
+		}
+
+		if err := store.put(doc, key, updated, expiry); err != nil {
+			return err
+		}
+
+		return doc.Objects[key].decode(destination)
+	})
+}
+
+// Count implements storage.Interface
+func (store *jsonStore[_, _]) Count(key string) (int64, error) {
+	keys, err := store.keys(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(keys)), nil
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/store"
+)
+
+func TestJSONStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "objects.json")
+
+	s, err := store.NewJSONStore[testSpec, testStatus](path)
+	if err != nil {
+		t.Fatal("NewJSONStore:", err)
+	}
+
+	obj := newTestObject("a", "one")
+	if err := s.Create(ctx, "a", obj, obj, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	stale := obj.DeepCopyObject().(*testObject)
+
+	if err := s.GuaranteedUpdate(ctx, "a", &testObject{}, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		current := input.(*testObject)
+		current.Status.Count++
+		return current, nil, nil
+	}, nil); err != nil {
+		t.Fatal("GuaranteedUpdate:", err)
+	}
+
+	stale.Spec.Value = "two"
+	if err := s.Create(ctx, "a", stale, stale, 0); !storage.IsConflict(err) {
+		t.Fatalf("Expected conflict error, got %v", err)
+	}
+
+	// The file must be readable by humans and other tools.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("ReadFile:", err)
+	}
+
+	var doc struct {
+		Objects map[string]struct {
+			Object testObject `json:"object"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	if got := doc.Objects["a"].Object.Status.Count; got != 1 {
+		t.Errorf("Expected count 1 in file, got %d", got)
+	}
+
+	var list zip.ObjectList[testSpec, testStatus]
+	if err := s.GetList(ctx, "", storage.ListOptions{}, &list); err != nil {
+		t.Fatal("GetList:", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Spec.Value != "one" {
+		t.Errorf("Expected single object with value 'one', got %v", list.Items)
+	}
+
+	if err := s.Delete(ctx, "a", nil, nil, nil, nil); err != nil {
+		t.Fatal("Delete:", err)
+	}
+
+	count, err := s.Count("")
+	if err != nil {
+		t.Fatal("Count:", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 objects, got %d", count)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	from, err := store.NewStore[testSpec, testStatus](store.BackendBadger, dir, "objects")
+	if err != nil {
+		t.Fatal("NewStore:", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		obj := newTestObject(name, name)
+		if err := from.Create(ctx, name, obj, obj, 0); err != nil {
+			t.Fatal("Create:", err)
+		}
+	}
+
+	to, err := store.NewStore[testSpec, testStatus](store.BackendJSON, dir, "objects")
+	if err != nil {
+		t.Fatal("NewStore:", err)
+	}
+
+	migrated, err := store.Migrate[testSpec, testStatus](ctx, from, to)
+	if err != nil {
+		t.Fatal("Migrate:", err)
+	}
+	if migrated != 2 {
+		t.Errorf("Expected 2 migrated objects, got %d", migrated)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		var got testObject
+		if err := to.Get(ctx, name, storage.GetOptions{}, &got); err != nil {
+			t.Fatal("Get:", err)
+		}
+		if got.Spec.Value != name {
+			t.Errorf("Expected value '%s', got '%s'", name, got.Spec.Value)
+		}
+	}
+
+	remaining, err := from.Count("")
+	if err != nil {
+		t.Fatal("Count:", err)
+	}
+	if remaining != 0 {
+		t.Errorf("Expected source store to be empty, got %d objects", remaining)
+	}
+}
+
+func TestMigrateExisting(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	from, err := store.NewStore[testSpec, testStatus](store.BackendBadger, dir, "objects")
+	if err != nil {
+		t.Fatal("NewStore:", err)
+	}
+
+	to, err := store.NewStore[testSpec, testStatus](store.BackendJSON, dir, "objects")
+	if err != nil {
+		t.Fatal("NewStore:", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		obj := newTestObject(name, "source")
+		if err := from.Create(ctx, name, obj, obj, 0); err != nil {
+			t.Fatal("Create:", err)
+		}
+	}
+
+	obj := newTestObject("b", "destination")
+	if err := to.Create(ctx, "b", obj, obj, 0); err != nil {
+		t.Fatal("Create:", err)
+	}
+
+	if _, err := store.Migrate[testSpec, testStatus](ctx, from, to); err == nil {
+		t.Fatal("Expected migration onto existing object to fail")
+	}
+
+	// Neither store is expected to have been modified.
+	for s, expect := range map[zip.Store]map[string]string{
+		from: {"a": "source", "b": "source"},
+		to:   {"b": "destination"},
+	} {
+		count, err := s.Count("")
+		if err != nil {
+			t.Fatal("Count:", err)
+		}
+		if count != int64(len(expect)) {
+			t.Errorf("Expected %d objects, got %d", len(expect), count)
+		}
+
+		for name, value := range expect {
+			var got testObject
+			if err := s.Get(ctx, name, storage.GetOptions{}, &got); err != nil {
+				t.Fatal("Get:", err)
+			}
+			if got.Spec.Value != value {
+				t.Errorf("Expected value '%s' of %s, got '%s'", value, name, got.Spec.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/config"
+)
+
+// Backend is the name of an implementation of the store which persists
+// objects on the host.
+type Backend string
+
+const (
+	// BackendBadger persists objects in the embeddable key-value database
+	// Badger.  This is the default backend.
+	BackendBadger = Backend("badger")
+
+	// BackendJSON persists objects in a single, human-readable JSON file which is
+	// protected by a file lock such that it can be safely shared by concurrent
+	// processes.  The human-readable representation of objects is read-only:
+	// the store is only ever restored from their serialized form, such that
+	// edits made to the file by hand are ignored.
+	BackendJSON = Backend("json")
+)
+
+// String implements fmt.Stringer
+func (backend Backend) String() string {
+	return string(backend)
+}
+
+// Backends returns the list of known store backends.
+func Backends() []Backend {
+	return []Backend{
+		BackendBadger,
+		BackendJSON,
+	}
+}
+
+// BackendNames returns the string representation of all known store backends.
+func BackendNames() []string {
+	ret := []string{}
+	for _, backend := range Backends() {
+		ret = append(ret, backend.String())
+	}
+
+	return ret
+}
+
+// keyLister is implemented by stores which are able to enumerate the keys of
+// all objects they hold, which is necessary to migrate between backends.
+type keyLister interface {
+	keys(prefix string) ([]string, error)
+}
+
+// batcher is implemented by stores which are able to write or remove several
+// objects within a single transaction, such that either all or none of the
+// changes are applied.
+type batcher interface {
+	createAll(objs map[string]runtime.Object) error
+	deleteAll(keys []string) error
+}
+
+// NewStore returns a api.zip.Store-compatible storage interface for the
+// provided backend.  The name identifies the set of objects, e.g.
+// "machinev1alpha1", and objects are persisted within the provided directory.
+func NewStore[Spec, Status any](backend Backend, dir, name string) (zip.Store, error) {
+	switch backend {
+	case BackendBadger, "":
+		return NewEmbeddedStore[Spec, Status](filepath.Join(dir, name))
+	case BackendJSON:
+		return NewJSONStore[Spec, Status](filepath.Join(dir, name+".json"))
+	default:
+		return nil, fmt.Errorf("unsupported store backend: %s", backend)
+	}
+}
+
+// NewRuntimeStore returns the store for the provided set of objects, e.g.
+// "machinev1alpha1", within KraftKit's runtime directory using the backend
+// selected in the configuration.
+func NewRuntimeStore[Spec, Status any](ctx context.Context, name string) (zip.Store, error) {
+	return NewStore[Spec, Status](
+		Backend(config.G[config.KraftKit](ctx).Store),
+		config.G[config.KraftKit](ctx).RuntimeDir,
+		name,
+	)
+}
+
+// Migrate moves all objects from one store into another.  The objects are
+// written to the destination within a single transaction and only then removed
+// from the source within another, such that they are held by exactly one of the
+// stores should the migration fail at any point.  Objects which already exist
+// in the destination are not overwritten and cause the migration to fail.  The
+// number of migrated objects is returned.
+func Migrate[Spec, Status any](ctx context.Context, from, to zip.Store) (int, error) {
+	src, ok := from.(interface {
+		keyLister
+		batcher
+	})
+	if !ok {
+		return 0, fmt.Errorf("source store does not support migration")
+	}
+
+	dst, ok := to.(interface {
+		keyLister
+		batcher
+	})
+	if !ok {
+		return 0, fmt.Errorf("destination store does not support migration")
+	}
+
+	keys, err := src.keys("")
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := dst.keys("")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range existing {
+		if slices.Contains(keys, key) {
+			return 0, fmt.Errorf("%s already exists in destination store", key)
+		}
+	}
+
+	objs := make(map[string]runtime.Object, len(keys))
+
+	for _, key := range keys {
+		var obj zip.Object[Spec, Status]
+		if err := from.Get(ctx, key, storage.GetOptions{}, &obj); err != nil {
+			return 0, fmt.Errorf("could not read %s: %v", key, err)
+		}
+
+		// Resource versions are specific to each store.
+		if err := to.Versioner().UpdateObject(&obj, 0); err != nil {
+			return 0, fmt.Errorf("could not reset resource version of %s: %v", key, err)
+		}
+
+		objs[key] = &obj
+	}
+
+	if len(objs) == 0 {
+		return 0, nil
+	}
+
+	if err := dst.createAll(objs); err != nil {
+		return 0, fmt.Errorf("could not write to destination store: %v", err)
+	}
+
+	if err := src.deleteAll(keys); err != nil {
+		// Revert the destination such that the objects remain solely in the
+		// source store.
+		if rerr := dst.deleteAll(keys); rerr != nil {
+			return 0, fmt.Errorf("could not remove from source store: %v: could not revert destination store: %v", err, rerr)
+		}
+
+		return 0, fmt.Errorf("could not remove from source store: %v", err)
+	}
+
+	return len(keys), nil
+}
+
+// Update applies the provided function to the object with the provided UID
+// and persists the result.  This allows amending an object outside of the
+// service which owns it, for example, to record state which cannot be derived
+// by the service itself.  The updated object is returned.
+func Update[Spec, Status any](ctx context.Context, store zip.Store, uid types.UID, fn func(*zip.Object[Spec, Status]) error) (*zip.Object[Spec, Status], error) {
+	lister, ok := store.(keyLister)
+	if !ok {
+		return nil, fmt.Errorf("store does not support updating objects by UID")
+	}
+
+	keys, err := lister.keys("")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		var obj zip.Object[Spec, Status]
+		if err := store.Get(ctx, key, storage.GetOptions{}, &obj); err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", key, err)
+		}
+
+		if obj.UID != uid {
+			continue
+		}
+
+		var updated zip.Object[Spec, Status]
+		if err := store.GuaranteedUpdate(ctx, key, &updated, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+			existing, ok := input.(*zip.Object[Spec, Status])
+			if !ok {
+				return nil, nil, fmt.Errorf("unexpected object type: %T", input)
+			}
+
+			if err := fn(existing); err != nil {
+				return nil, nil, err
+			}
+
+			return existing, nil, nil
+		}, nil); err != nil {
+			return nil, fmt.Errorf("could not update %s: %v", key, err)
+		}
+
+		return &updated, nil
+	}
+
+	return nil, fmt.Errorf("object not found: %s", uid)
+}
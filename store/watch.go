@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	zip "api.zip"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/fsnotify/fsnotify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/log"
+)
+
+// watchDebounce is the period of quiescence on the store's directory after
+// which the store is re-read to determine changes.  This coalesces the many
+// file operations which make up a single write to the store.
+const watchDebounce = 50 * time.Millisecond
+
+// Watch implements storage.Interface
+//
+// Since the store may be written to by other processes, changes are detected
+// by observing the store's directory for modifications and comparing the
+// stored objects against the previously observed set.  If the provided
+// resource version is unset or "0", the objects which currently exist are
+// first sent as "ADDED" events.  Otherwise, only objects with a newer resource
+// version are initially sent.
+func (store *embedded[Spec, Status]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return watchDir(ctx, store.path, nil, store.versioner, opts, func() (map[string]watchedObject[Spec, Status], error) {
+		return store.snapshot(key, opts.Recursive)
+	})
+}
+
+// watchedObject is an object in the store at the time of a snapshot alongside
+// its encoded form, which is used to detect modifications.
+type watchedObject[Spec, Status any] struct {
+	raw    []byte
+	object *zip.Object[Spec, Status]
+}
+
+// watchDir observes the provided directory, optionally only the files for
+// which the filter returns true, and emits events for the differences between
+// consecutive snapshots of a store.
+func watchDir[Spec, Status any](ctx context.Context, dir string, filter func(string) bool, versioner storage.Versioner, opts storage.ListOptions, snapshotter func() (map[string]watchedObject[Spec, Status], error)) (watch.Interface, error) {
+	since, err := versioner.ParseResourceVersion(opts.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not prepare store directory: %v", err)
+	}
+
+	// Start observing the directory before the initial snapshot is taken such
+	// that no writes are missed in-between.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	snapshot, err := snapshotter()
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	events := make(chan watch.Event)
+	proxy := watch.NewProxyWatcher(events)
+
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		send := func(event watch.Event) bool {
+			select {
+			case events <- event:
+				return true
+			case <-proxy.StopChan():
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, obj := range snapshot {
+			if since > 0 {
+				rv, err := versioner.ObjectResourceVersion(obj.object)
+				if err != nil || rv <= since {
+					continue
+				}
+			}
+
+			if !send(watch.Event{Type: watch.Added, Object: obj.object}) {
+				return
+			}
+		}
+
+		var debounce <-chan time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-proxy.StopChan():
+				return
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				log.G(ctx).Debugf("could not watch store at %s: %v", dir, err)
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filter != nil && !filter(event.Name) {
+					continue
+				}
+
+				debounce = time.After(watchDebounce)
+
+			case <-debounce:
+				debounce = nil
+
+				latest, err := snapshotter()
+				if err != nil {
+					send(watch.Event{
+						Type: watch.Error,
+						Object: &metav1.Status{
+							Status:  metav1.StatusFailure,
+							Message: err.Error(),
+						},
+					})
+					return
+				}
+
+				for k, obj := range latest {
+					prev, ok := snapshot[k]
+					if !ok {
+						if !send(watch.Event{Type: watch.Added, Object: obj.object}) {
+							return
+						}
+					} else if !bytes.Equal(prev.raw, obj.raw) {
+						if !send(watch.Event{Type: watch.Modified, Object: obj.object}) {
+							return
+						}
+					}
+				}
+
+				for k, obj := range snapshot {
+					if _, ok := latest[k]; !ok {
+						if !send(watch.Event{Type: watch.Deleted, Object: obj.object}) {
+							return
+						}
+					}
+				}
+
+				snapshot = latest
+			}
+		}
+	}()
+
+	return proxy, nil
+}
+
+// snapshot reads all objects at the given key, or with the given key as prefix
+// if recursive, from the store.  The store is opened read-only such that the
+// snapshot itself does not modify the store's directory.
+func (store *embedded[Spec, Status]) snapshot(key string, recursive bool) (map[string]watchedObject[Spec, Status], error) {
+	objects := map[string]watchedObject[Spec, Status]{}
+
+	// A read-only database cannot be opened before anything was ever written.
+	if _, err := os.Stat(filepath.Join(store.path, badger.ManifestFilename)); os.IsNotExist(err) {
+		return objects, nil
+	}
+
+	db, err := store.openWithOptions(store.bopts.WithReadOnly(true))
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	if err := db.View(func(txn *badger.Txn) error {
+		itr := txn.NewIterator(badger.IteratorOptions{
+			Prefix: []byte(key),
+		})
+
+		defer itr.Close()
+
+		for itr.Rewind(); itr.Valid(); itr.Next() {
+			k := string(itr.Item().KeyCopy(nil))
+			if isInternalKey([]byte(k)) || (!recursive && k != key) {
+				continue
+			}
+
+			val, err := itr.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			var obj zip.Object[Spec, Status]
+			if err := gob.NewDecoder(bytes.NewReader(val)).Decode(&obj); err != nil {
+				return err
+			}
+
+			objects[k] = watchedObject[Spec, Status]{
+				raw:    val,
+				object: &obj,
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not read from store at %s: %v", key, err)
+	}
+
+	return objects, nil
+}
@@ -186,6 +186,7 @@ import (
 	"reflect"
 {{ if .HasService -}}
 	"sync"
+	"time"
 {{ end }}
 )
 {{ end }}
@@ -198,6 +199,14 @@ type {{ .GoName }}Client struct {
 	lock sync.RWMutex
 	recv *bufio.Reader
 	send *bufio.Writer
+
+	// events are the asynchronous events which were received whilst awaiting
+	// the reply to a request and which are yet to be consumed by NextEvent.
+	events [][]byte
+
+	// partial is the incomplete message which was received before reading was
+	// interrupted, e.g. by a deadline.
+	partial []byte
 }
 
 func New{{ .GoName }}Client(conn io.ReadWriteCloser) *{{ .GoName }}Client {
@@ -212,6 +221,86 @@ func (c *{{ .GoName }}Client) Close() error {
 	return c.conn.Close()
 }
 
+// SetReadDeadline sets the deadline for receiving replies and events if it is
+// supported by the underlying connection.
+func (c *{{ .GoName }}Client) SetReadDeadline(t time.Time) error {
+	conn, ok := c.conn.(interface{ SetReadDeadline(time.Time) error })
+	if !ok {
+		return fmt.Errorf("connection does not support deadlines")
+	}
+
+	return conn.SetReadDeadline(t)
+}
+
+// readMessage returns the next message received from the connection.  The
+// message is retained if reading it is interrupted so that it can be resumed.
+func (c *{{ .GoName }}Client) readMessage() ([]byte, error) {
+	b, err := c.recv.ReadBytes('\n')
+	if err != nil {
+		c.partial = append(c.partial, b...)
+		return nil, err
+	}
+
+	if len(c.partial) > 0 {
+		b = append(c.partial, b...)
+		c.partial = nil
+	}
+
+	return b, nil
+}
+
+// isEvent returns whether the provided message is an asynchronous event rather
+// than the reply to a request.
+func (c *{{ .GoName }}Client) isEvent(b []byte) bool {
+	var msg struct {
+		Event *string ` + "`" + `json:"event"` + "`" + `
+	}
+
+	return json.Unmarshal(b, &msg) == nil && msg.Event != nil
+}
+
+// readReply returns the next message received from the connection which is not
+// an asynchronous event.  Events are retained for NextEvent.
+func (c *{{ .GoName }}Client) readReply() ([]byte, error) {
+	for {
+		b, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.isEvent(b) {
+			return b, nil
+		}
+
+		c.events = append(c.events, b)
+	}
+}
+
+// NextEvent returns the next asynchronous event, including those which were
+// received whilst awaiting the reply to an earlier request.  It blocks until an
+// event is received or the read deadline is exceeded.
+func (c *{{ .GoName }}Client) NextEvent() ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.events) > 0 {
+		b := c.events[0]
+		c.events = c.events[1:]
+		return b, nil
+	}
+
+	for {
+		b, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.isEvent(b) {
+			return b, nil
+		}
+	}
+}
+
 func (c *{{ .GoName }}Client) setRpcRequestSetDefaults(face any) error {
 	v := reflect.ValueOf(face)
 
@@ -353,7 +442,7 @@ func (c *{{ .ServiceGoName }}Client) {{ .GoName }}(
 
 	{{ if $hasRes }}
 	var res {{ if $resAsAny }}any{{ else }}{{ .Output.GoIdent.GoName }}{{ end }}
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.readReply()
 	if err != nil {
 		return nil, err
 	}
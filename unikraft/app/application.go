@@ -27,6 +27,7 @@ import (
 	"kraftkit.sh/make"
 	"kraftkit.sh/schema"
 	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app/healthcheck"
 	"kraftkit.sh/unikraft/app/volume"
 	"kraftkit.sh/unikraft/component"
 	"kraftkit.sh/unikraft/core"
@@ -144,6 +145,9 @@ type Application interface {
 	// Env variables to be used during building and runtime of application.
 	Env() map[string]string
 
+	// HealthCheck used to probe the health of instances of the application.
+	HealthCheck() *healthcheck.HealthCheckConfig
+
 	// Removes library from the project directory
 	RemoveLibrary(ctx context.Context, libraryName string) error
 
@@ -165,6 +169,7 @@ type application struct {
 	libraries     map[string]*lib.LibraryConfig
 	targets       []*target.TargetConfig
 	volumes       []*volume.VolumeConfig
+	healthcheck   *healthcheck.HealthCheckConfig
 	env           target.Env
 	command       []string
 	rootfs        string
@@ -975,6 +980,10 @@ func (app application) MarshalYAML() (interface{}, error) {
 		ret["runtime"] = app.runtime
 	}
 
+	if app.healthcheck != nil {
+		ret["healthcheck"] = app.healthcheck
+	}
+
 	return ret, nil
 }
 
@@ -1103,6 +1112,11 @@ func (app application) Env() map[string]string {
 	return app.env
 }
 
+// HealthCheck implements Application
+func (app application) HealthCheck() *healthcheck.HealthCheckConfig {
+	return app.healthcheck
+}
+
 func (app application) RemoveLibrary(ctx context.Context, libraryName string) error {
 	isLibraryExistInProject := false
 	for libKey, lib := range app.libraries {
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package healthcheck provides the representation of the health check of an
+// application project which is seeded via a Kraftfile.
+package healthcheck
+
+import "time"
+
+// HealthCheckConfig contains information about how the health of a unikernel
+// instance of the application is probed at runtime.
+type HealthCheckConfig struct {
+	typ         string
+	target      string
+	interval    time.Duration
+	timeout     time.Duration
+	retries     int
+	startPeriod time.Duration
+}
+
+// Type of the probe, i.e. "tcp", "http" or "log".
+func (hc *HealthCheckConfig) Type() string {
+	return hc.typ
+}
+
+// Target of the probe, i.e. "<port>" for TCP, "<port>[/path]" for HTTP and a
+// regular expression for log health checks.
+func (hc *HealthCheckConfig) Target() string {
+	return hc.target
+}
+
+// Interval between two consecutive probes.
+func (hc *HealthCheckConfig) Interval() time.Duration {
+	return hc.interval
+}
+
+// Timeout of a single probe.
+func (hc *HealthCheckConfig) Timeout() time.Duration {
+	return hc.timeout
+}
+
+// Retries is the number of consecutive failed probes after which the instance
+// is considered unhealthy.
+func (hc *HealthCheckConfig) Retries() int {
+	return hc.retries
+}
+
+// StartPeriod is the duration after the instance has started during which
+// failed probes are not counted.
+func (hc *HealthCheckConfig) StartPeriod() time.Duration {
+	return hc.startPeriod
+}
+
+// MarshalYAML makes HealthCheckConfig implement yaml.Marshaller
+func (hc *HealthCheckConfig) MarshalYAML() (interface{}, error) {
+	if len(hc.typ) == 0 {
+		return nil, nil
+	}
+
+	ret := map[string]interface{}{
+		hc.typ: hc.target,
+	}
+	if hc.interval > 0 {
+		ret["interval"] = hc.interval.String()
+	}
+	if hc.timeout > 0 {
+		ret["timeout"] = hc.timeout.String()
+	}
+	if hc.retries > 0 {
+		ret["retries"] = hc.retries
+	}
+	if hc.startPeriod > 0 {
+		ret["start_period"] = hc.startPeriod.String()
+	}
+
+	return ret, nil
+}
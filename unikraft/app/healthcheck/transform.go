@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TransformFromSchema parses an input schema and returns an instantiated
+// HealthCheckConfig
+func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, error) {
+	hc := HealthCheckConfig{}
+
+	entry, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected healthcheck to be a map")
+	}
+
+	duration := func(key string, prop interface{}) (time.Duration, error) {
+		str, ok := prop.(string)
+		if !ok {
+			return 0, fmt.Errorf("expected healthcheck %s to be a duration string", key)
+		}
+
+		return time.ParseDuration(str)
+	}
+
+	var err error
+
+	for key, prop := range entry {
+		switch key {
+		case "tcp", "http", "log":
+			if len(hc.typ) > 0 {
+				return nil, fmt.Errorf("healthcheck cannot specify both %s and %s", hc.typ, key)
+			}
+
+			hc.typ = key
+			hc.target = fmt.Sprintf("%v", prop)
+
+		case "interval":
+			if hc.interval, err = duration(key, prop); err != nil {
+				return nil, err
+			}
+
+		case "timeout":
+			if hc.timeout, err = duration(key, prop); err != nil {
+				return nil, err
+			}
+
+		case "start_period":
+			if hc.startPeriod, err = duration(key, prop); err != nil {
+				return nil, err
+			}
+
+		case "retries":
+			retries, ok := prop.(int)
+			if !ok {
+				return nil, fmt.Errorf("expected healthcheck retries to be a number")
+			}
+
+			hc.retries = retries
+		}
+	}
+
+	if len(hc.typ) == 0 {
+		return nil, fmt.Errorf("healthcheck must specify one of tcp, http or log")
+	}
+
+	return hc, nil
+}
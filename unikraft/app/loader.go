@@ -104,6 +104,10 @@ func NewApplicationFromInterface(ctx context.Context, iface map[string]interface
 		return nil, err
 	}
 
+	if err := Transform(ctx, getSection(iface, "healthcheck"), &app.healthcheck); err != nil {
+		return nil, err
+	}
+
 	extensions := getSectionMap(iface, "extensions")
 	if len(extensions) > 0 {
 		app.extensions = extensions
@@ -28,6 +28,7 @@ import (
 	"github.com/pkg/errors"
 
 	"kraftkit.sh/kconfig"
+	"kraftkit.sh/unikraft/app/healthcheck"
 	"kraftkit.sh/unikraft/app/volume"
 	"kraftkit.sh/unikraft/arch"
 	"kraftkit.sh/unikraft/core"
@@ -96,6 +97,7 @@ func createTransformHook(ctx context.Context, additionalTransformers ...Transfor
 		reflect.TypeOf(runtime.Runtime{}):               runtime.TransformFromSchema,
 		reflect.TypeOf(template.TemplateConfig{}):       template.TransformFromSchema,
 		reflect.TypeOf(volume.VolumeConfig{}):           volume.TransformFromSchema,
+		reflect.TypeOf(healthcheck.HealthCheckConfig{}): healthcheck.TransformFromSchema,
 		reflect.TypeOf(target.Env{}):                    transformEnv,
 	}
 
@@ -5,6 +5,7 @@
 package vfscore
 
 import (
+	"strconv"
 	"strings"
 
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
@@ -19,6 +20,24 @@ func ExportedParams() []ukargparse.Param {
 	}
 }
 
+// MountFlags are the flags with which vfscore mounts a volume.
+type MountFlags uint64
+
+const (
+	// MountFlagReadOnly mounts the volume read-only.
+	MountFlagReadOnly = MountFlags(0x1)
+)
+
+// String implements fmt.Stringer and returns the flags in the format of an
+// fstab entry, which is empty when no flags are set.
+func (flags MountFlags) String() string {
+	if flags == 0 {
+		return ""
+	}
+
+	return strconv.FormatUint(uint64(flags), 10)
+}
+
 // FstabEntry is a vfscore mount entry.
 type FstabEntry struct {
 	sourceDevice string